@@ -0,0 +1,161 @@
+package alerts
+
+import (
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// AlertmanagerSilence is a silence as returned by the Alertmanager API (GET /api/v2/silences).
+type AlertmanagerSilence struct {
+	Id        string                `json:"id"`
+	Matchers  []AlertmanagerMatcher `json:"matchers"`
+	StartsAt  time.Time             `json:"startsAt"`
+	EndsAt    time.Time             `json:"endsAt"`
+	CreatedBy string                `json:"createdBy"`
+	Comment   string                `json:"comment"`
+	Status    struct {
+		State string `json:"state"`
+	} `json:"status"`
+}
+
+type AlertmanagerMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual *bool  `json:"isEqual"` // absent in older Alertmanager versions, which means true
+}
+
+type ImportedSilence struct {
+	Id           string                `json:"id"`
+	Applications []model.ApplicationId `json:"applications"`
+	Until        timeseries.Time       `json:"until"`
+}
+
+type UnmappedSilence struct {
+	Id     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+type SilencesImport struct {
+	Imported []ImportedSilence `json:"imported"`
+	Unmapped []UnmappedSilence `json:"unmapped"`
+}
+
+// MapSilences translates the active Alertmanager silences into snoozes of the applications they match.
+// A silence matches an application if all its matchers match the application labels (see appLabel).
+// Silences that are not active or match no application are reported as unmapped.
+func MapSilences(silences []AlertmanagerSilence, apps []*model.Application, now timeseries.Time) (*SilencesImport, map[model.ApplicationId]db.ApplicationSnooze) {
+	res := &SilencesImport{Imported: []ImportedSilence{}, Unmapped: []UnmappedSilence{}}
+	snoozes := map[model.ApplicationId]db.ApplicationSnooze{}
+	for _, s := range silences {
+		until := timeseries.Time(s.EndsAt.Unix())
+		switch {
+		case s.Status.State == "expired" || !until.After(now):
+			res.Unmapped = append(res.Unmapped, UnmappedSilence{Id: s.Id, Reason: "expired"})
+			continue
+		case s.Status.State == "pending" || timeseries.Time(s.StartsAt.Unix()).After(now):
+			res.Unmapped = append(res.Unmapped, UnmappedSilence{Id: s.Id, Reason: "not active yet"})
+			continue
+		}
+		matchers, reason := compileMatchers(s.Matchers)
+		if reason != "" {
+			res.Unmapped = append(res.Unmapped, UnmappedSilence{Id: s.Id, Reason: reason})
+			continue
+		}
+		imported := ImportedSilence{Id: s.Id, Until: until}
+		for _, app := range apps {
+			if !matchers.match(app) {
+				continue
+			}
+			imported.Applications = append(imported.Applications, app.Id)
+			if until.After(snoozes[app.Id].Until) {
+				snoozes[app.Id] = db.ApplicationSnooze{Until: until, Comment: silenceComment(s)}
+			}
+		}
+		if len(imported.Applications) == 0 {
+			res.Unmapped = append(res.Unmapped, UnmappedSilence{Id: s.Id, Reason: "no application matches"})
+			continue
+		}
+		sort.Slice(imported.Applications, func(i, j int) bool {
+			return imported.Applications[i].String() < imported.Applications[j].String()
+		})
+		res.Imported = append(res.Imported, imported)
+	}
+	return res, snoozes
+}
+
+func silenceComment(s AlertmanagerSilence) string {
+	comment := "imported from Alertmanager silence " + s.Id
+	if s.CreatedBy != "" {
+		comment += " by " + s.CreatedBy
+	}
+	if s.Comment != "" {
+		comment += ": " + s.Comment
+	}
+	return comment
+}
+
+type matcher struct {
+	name  string
+	re    *regexp.Regexp
+	equal bool
+}
+
+type matchers []matcher
+
+func (ms matchers) match(app *model.Application) bool {
+	for _, m := range ms {
+		if m.re.MatchString(appLabel(app, m.name)) != m.equal {
+			return false
+		}
+	}
+	return true
+}
+
+// compileMatchers converts the matchers to anchored regular expressions as Alertmanager does.
+// Silences without a positive matcher requiring a non-empty value are rejected since they would match every application.
+func compileMatchers(src []AlertmanagerMatcher) (matchers, string) {
+	var res matchers
+	selective := false
+	for _, m := range src {
+		expr := regexp.QuoteMeta(m.Value)
+		if m.IsRegex {
+			expr = m.Value
+		}
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return nil, "invalid matcher " + m.Name + ": " + err.Error()
+		}
+		equal := m.IsEqual == nil || *m.IsEqual
+		if equal && !re.MatchString("") {
+			selective = true
+		}
+		res = append(res, matcher{name: m.Name, re: re, equal: equal})
+	}
+	if !selective {
+		return nil, "matches every application"
+	}
+	return res, ""
+}
+
+// appLabel returns the value of the application label used by Alertmanager matchers: one of the Coroot labels,
+// a Kubernetes label of the application, or the namespace and the name of the application.
+func appLabel(app *model.Application, name string) string {
+	if v := app.Labels()[name]; v != "" {
+		return v
+	}
+	if v := app.KubernetesLabel(name); v != "" {
+		return v
+	}
+	switch name {
+	case "namespace":
+		return app.Id.Namespace
+	case "application":
+		return app.Id.Name
+	}
+	return ""
+}
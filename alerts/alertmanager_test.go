@@ -0,0 +1,48 @@
+package alerts
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMapSilences(t *testing.T) {
+	now := timeseries.Time(1668000000)
+	prodApi := model.NewApplication(model.NewApplicationId("prod", model.ApplicationKindDeployment, "api"))
+	stagingApi := model.NewApplication(model.NewApplicationId("staging", model.ApplicationKindDeployment, "api"))
+	prodDb := model.NewApplication(model.NewApplicationId("prod", model.ApplicationKindStatefulSet, "db"))
+	apps := []*model.Application{prodApi, stagingApi, prodDb}
+
+	active := func(id string, matchers ...AlertmanagerMatcher) AlertmanagerSilence {
+		s := AlertmanagerSilence{Id: id, Matchers: matchers, StartsAt: now.Add(-timeseries.Hour).ToStandard(), EndsAt: now.Add(timeseries.Hour).ToStandard()}
+		s.Status.State = "active"
+		return s
+	}
+	notEqual := false
+	expired := active("expired", AlertmanagerMatcher{Name: "namespace", Value: "prod"})
+	expired.EndsAt = now.Add(-timeseries.Minute).ToStandard()
+	expired.Status.State = "expired"
+
+	res, snoozes := MapSilences([]AlertmanagerSilence{
+		active("prod-api", AlertmanagerMatcher{Name: "namespace", Value: "prod"}, AlertmanagerMatcher{Name: "application", Value: "api|web", IsRegex: true}),
+		active("not-prod", AlertmanagerMatcher{Name: "namespace", Value: "prod", IsEqual: &notEqual}),
+		active("alertname", AlertmanagerMatcher{Name: "alertname", Value: "HighLatency"}),
+		active("api", AlertmanagerMatcher{Name: "application", Value: "api"}),
+		expired,
+	}, apps, now)
+
+	assert.Equal(t, []ImportedSilence{
+		{Id: "prod-api", Applications: []model.ApplicationId{prodApi.Id}, Until: now.Add(timeseries.Hour)},
+		{Id: "api", Applications: []model.ApplicationId{prodApi.Id, stagingApi.Id}, Until: now.Add(timeseries.Hour)},
+	}, res.Imported)
+	assert.Equal(t, []UnmappedSilence{
+		{Id: "not-prod", Reason: "matches every application"},
+		{Id: "alertname", Reason: "no application matches"},
+		{Id: "expired", Reason: "expired"},
+	}, res.Unmapped)
+	assert.Len(t, snoozes, 2)
+	assert.True(t, snoozes[stagingApi.Id].Until.After(now))
+	assert.Equal(t, time.Hour, snoozes[prodApi.Id].Until.Sub(now).ToStandard())
+}
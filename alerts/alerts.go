@@ -10,6 +10,7 @@ import (
 	"github.com/coroot/coroot/model"
 	"github.com/coroot/coroot/timeseries"
 	"k8s.io/klog"
+	"sync"
 	"time"
 )
 
@@ -23,10 +24,35 @@ type Alert struct {
 type AlertManager struct {
 	db    *db.DB
 	cache *cache.Cache
+
+	damping map[db.ProjectId]map[model.ApplicationId]*model.DampingState
+	grace   map[db.ProjectId]map[model.ApplicationId]*model.OpeningGraceState
+
+	dataLossThreshold timeseries.Duration
+	missingSince      map[db.ProjectId]map[model.ApplicationId]timeseries.Time
+
+	exportLock sync.Mutex
+	exportedAt map[db.ProjectId]time.Time
+	exporting  map[db.ProjectId]bool
+
+	breakersLock sync.Mutex
+	breakers     map[breakerKey]*circuitBreaker
 }
 
-func NewAlertManager(db *db.DB, cache *cache.Cache) *AlertManager {
-	return &AlertManager{db: db, cache: cache}
+// NewAlertManager creates an AlertManager. Open incidents of applications that have been absent from the world
+// for longer than dataLossThreshold are resolved automatically; zero disables this.
+func NewAlertManager(database *db.DB, cache *cache.Cache, dataLossThreshold time.Duration) *AlertManager {
+	return &AlertManager{
+		db:                database,
+		cache:             cache,
+		damping:           map[db.ProjectId]map[model.ApplicationId]*model.DampingState{},
+		grace:             map[db.ProjectId]map[model.ApplicationId]*model.OpeningGraceState{},
+		dataLossThreshold: timeseries.Duration(int64(dataLossThreshold.Seconds())),
+		missingSince:      map[db.ProjectId]map[model.ApplicationId]timeseries.Time{},
+		exportedAt:        map[db.ProjectId]time.Time{},
+		exporting:         map[db.ProjectId]bool{},
+		breakers:          map[breakerKey]*circuitBreaker{},
+	}
 }
 
 func (mgr *AlertManager) Start(checkInterval time.Duration) {
@@ -37,6 +63,7 @@ func (mgr *AlertManager) Start(checkInterval time.Duration) {
 				klog.Errorln("failed to get projects:", err)
 				continue
 			}
+			mgr.forgetDeletedProjects(projects)
 			for _, project := range projects {
 				mgr.checkProject(project)
 			}
@@ -59,26 +86,244 @@ func (mgr *AlertManager) checkProject(project *db.Project) {
 
 	auditor.Audit(world)
 
+	mgr.exportMetrics(project, world)
+
+	var openBefore map[model.ApplicationId]*db.Incident
+	if project.Settings.Flapping.OpeningGrace().Period > 0 {
+		if openBefore, err = mgr.db.GetOpenIncidents(project.Id); err != nil {
+			klog.Errorln(err)
+			return
+		}
+	}
+
+	var notifications []*Alert
 	for _, app := range world.Applications {
-		status := app.SLOStatus()
+		status := mgr.damp(project, app.Id, app.SLOStatus())
 		if status == model.UNKNOWN {
 			continue
 		}
 		apps++
-		incident, err := mgr.db.CreateOrUpdateIncident(project.Id, app.Id, timeseries.Now(), status)
+		now := timeseries.Now()
+		status = mgr.applyGrace(project, app.Id, status, openBefore[app.Id] != nil, now)
+		incident, err := mgr.db.CreateOrUpdateIncident(project.Id, app.Id, now, status, project.Settings.Escalation, project.Settings.Flapping, project.Settings.Repeat)
 		if err != nil {
 			klog.Errorln(err)
 			continue
 		}
+		if br := app.SLOBurnRate(); status > model.OK && br > 0 {
+			if err := mgr.db.UpdateIncidentPeakBurnRate(project.Id, app.Id, br); err != nil {
+				klog.Errorln(err)
+			}
+		}
 		if incident == nil {
 			continue
 		}
-		if ok := mgr.sendAlert(project, app, incident); ok {
-			if err := mgr.db.MarkIncidentAsSent(project.Id, app.Id, incident, timeseries.Now()); err != nil {
+		if incident.ResolvedAt.IsZero() && (incident.IsSnoozed(now) || project.Settings.IsApplicationSnoozed(app.Id, now)) {
+			continue
+		}
+		notifications = append(notifications, &Alert{ProjectId: project.Id, ApplicationId: app.Id, Incident: incident, Reports: app.Reports})
+	}
+	mgr.forgetMissingApplications(project.Id, world)
+
+	open := mgr.attributeIncidents(project, world)
+	for _, n := range notifications {
+		if i := open[n.ApplicationId]; i != nil && i.Key == n.Incident.Key {
+			n.Incident.CausedBy = i.CausedBy
+		}
+		if isSuppressedByCause(n.Incident, open) {
+			klog.Infof("%s: notification for %s suppressed: caused by incident %s", project.Id, n.ApplicationId, n.Incident.CausedBy)
+			continue
+		}
+		if ok := mgr.sendAlert(project, n.ApplicationId, n.Reports, n.Incident); ok {
+			if err := mgr.db.MarkIncidentAsSent(project.Id, n.ApplicationId, n.Incident, timeseries.Now()); err != nil {
+				klog.Errorln(err)
+			}
+		}
+	}
+
+	mgr.announceRemovedSLOResolutions(project)
+
+	if mgr.dataLossThreshold > 0 && len(world.Applications) > 0 { // an empty world is more likely an outage than a data loss
+		mgr.resolveLostIncidents(project, world)
+	}
+}
+
+// attributeIncidents attributes the open incidents of the project to the incidents of the upstream applications
+// (see correlateIncidents) and returns the open incidents by application.
+func (mgr *AlertManager) attributeIncidents(project *db.Project, world *model.World) map[model.ApplicationId]*db.Incident {
+	open, err := mgr.db.GetOpenIncidents(project.Id)
+	if err != nil {
+		klog.Errorln(err)
+		return nil
+	}
+	for appId, cause := range correlateIncidents(world, open) {
+		if err := mgr.db.SetIncidentCause(project.Id, appId, open[appId], cause); err != nil {
+			klog.Errorln(err)
+			continue
+		}
+		klog.Infof("%s: incident %s of %s is caused by incident %s", project.Id, open[appId].Key, appId, cause)
+	}
+	return open
+}
+
+// resolveLostIncidents resolves the open incidents of applications that have disappeared from the world,
+// e.g., because they were deleted, since their SLOs can no longer be evaluated.
+func (mgr *AlertManager) resolveLostIncidents(project *db.Project, world *model.World) {
+	incidents, err := mgr.db.GetOpenIncidents(project.Id)
+	if err != nil {
+		klog.Errorln(err)
+		return
+	}
+	now := timeseries.Now()
+	missing := map[model.ApplicationId]timeseries.Time{}
+	for appId, incident := range incidents {
+		if world.GetApplication(appId) != nil {
+			continue
+		}
+		since, ok := mgr.missingSince[project.Id][appId]
+		if !ok {
+			since = now
+		}
+		if now.Sub(since) < mgr.dataLossThreshold {
+			missing[appId] = since
+			continue
+		}
+		if err := mgr.db.ResolveIncident(project.Id, appId, incident, now, db.IncidentResolveReasonDataLost); err != nil {
+			klog.Errorln(err)
+			missing[appId] = since
+			continue
+		}
+		klog.Infof("%s: incident %s of %s resolved: no data since %s", project.Id, incident.Key, appId, since.ToStandard())
+		if ok := mgr.sendAlert(project, appId, nil, incident); ok {
+			if err := mgr.db.MarkIncidentAsSent(project.Id, appId, incident, timeseries.Now()); err != nil {
 				klog.Errorln(err)
 			}
 		}
 	}
+	mgr.missingSince[project.Id] = missing
+}
+
+// announceRemovedSLOResolutions notifies about the incidents resolved because the SLOs of their applications
+// have been removed (see db.SaveCheckConfig). Only recent resolutions are announced, to not send stale ones
+// if the notifications start working later.
+func (mgr *AlertManager) announceRemovedSLOResolutions(project *db.Project) {
+	now := timeseries.Now()
+	incidents, err := mgr.db.GetUnannouncedResolutions(project.Id, db.IncidentResolveReasonCheckRemoved, now.Add(-timeseries.Hour))
+	if err != nil {
+		klog.Errorln(err)
+		return
+	}
+	for idx := range incidents {
+		i := &incidents[idx]
+		if ok := mgr.sendAlert(project, i.ApplicationId, nil, i); ok {
+			if err := mgr.db.MarkIncidentAsSent(project.Id, i.ApplicationId, i, timeseries.Now()); err != nil {
+				klog.Errorln(err)
+			}
+		}
+	}
+}
+
+// damp smooths out the SLO status of the application according to the project's flapping policy.
+// The state is kept in memory, so it's reset on restart.
+func (mgr *AlertManager) damp(project *db.Project, appId model.ApplicationId, status model.Status) model.Status {
+	states := mgr.damping[project.Id]
+	if states == nil {
+		states = map[model.ApplicationId]*model.DampingState{}
+		mgr.damping[project.Id] = states
+	}
+	state := states[appId]
+	if state == nil {
+		state = &model.DampingState{}
+		states[appId] = state
+	}
+	return project.Settings.Flapping.Damping().Apply(state, status)
+}
+
+// forgetMissingApplications drops the in-memory states of the project's applications that are no longer in the world.
+func (mgr *AlertManager) forgetMissingApplications(projectId db.ProjectId, world *model.World) {
+	present := make(map[model.ApplicationId]bool, len(world.Applications))
+	for _, app := range world.Applications {
+		present[app.Id] = true
+	}
+	for appId := range mgr.damping[projectId] {
+		if !present[appId] {
+			delete(mgr.damping[projectId], appId)
+		}
+	}
+	for appId := range mgr.grace[projectId] {
+		if !present[appId] {
+			delete(mgr.grace[projectId], appId)
+		}
+	}
+}
+
+// forgetDeletedProjects drops the in-memory states of the projects that no longer exist.
+func (mgr *AlertManager) forgetDeletedProjects(projects []*db.Project) {
+	exist := make(map[db.ProjectId]bool, len(projects))
+	for _, p := range projects {
+		exist[p.Id] = true
+	}
+	for projectId := range mgr.damping {
+		if !exist[projectId] {
+			delete(mgr.damping, projectId)
+		}
+	}
+	for projectId := range mgr.grace {
+		if !exist[projectId] {
+			delete(mgr.grace, projectId)
+		}
+	}
+	mgr.forgetDeletedProjectBreakers(exist)
+}
+
+// applyGrace delays opening incidents according to the grace period of the project's flapping policy.
+// The time the status became unhealthy is kept in memory, so the grace period restarts on restart.
+func (mgr *AlertManager) applyGrace(project *db.Project, appId model.ApplicationId, status model.Status, incidentIsOpen bool, now timeseries.Time) model.Status {
+	states := mgr.grace[project.Id]
+	if states == nil {
+		states = map[model.ApplicationId]*model.OpeningGraceState{}
+		mgr.grace[project.Id] = states
+	}
+	state := states[appId]
+	if state == nil {
+		state = &model.OpeningGraceState{}
+		states[appId] = state
+	}
+	return project.Settings.Flapping.OpeningGrace().Apply(state, status, incidentIsOpen, now)
+}
+
+// exportMetrics pushes the SLO metrics of the audited world to the project's OTLP collector if the export interval
+// has elapsed since the previous export. The export runs in the background so that a slow collector doesn't delay
+// the alerting, at most one export per project is in flight. A failed export isn't retried until the next interval.
+func (mgr *AlertManager) exportMetrics(project *db.Project, world *model.World) {
+	cfg := project.Settings.Integrations.OTLP
+	if cfg == nil || !cfg.Enabled {
+		return
+	}
+	now := time.Now()
+	mgr.exportLock.Lock()
+	defer mgr.exportLock.Unlock()
+	if mgr.exporting[project.Id] || now.Sub(mgr.exportedAt[project.Id]) < cfg.GetInterval().ToStandard() {
+		return
+	}
+	mgr.exportedAt[project.Id] = now
+	mgr.exporting[project.Id] = true
+	metrics := NewOTLPMetrics(project, world, timeseries.Now())
+	go func() {
+		defer func() {
+			mgr.exportLock.Lock()
+			delete(mgr.exporting, project.Id)
+			mgr.exportLock.Unlock()
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.GetTimeout().ToStandard())
+		defer cancel()
+		if err := NewOTLP(cfg).Export(ctx, metrics); err != nil {
+			klog.Errorf("%s: failed to export metrics to OTLP: %s", project.Id, err)
+			otlpExportsTotal.WithLabelValues("error").Inc()
+			return
+		}
+		otlpExportsTotal.WithLabelValues("ok").Inc()
+	}()
 }
 
 func (mgr *AlertManager) loadWorld(project *db.Project) (*model.World, error) {
@@ -97,20 +342,49 @@ func (mgr *AlertManager) loadWorld(project *db.Project) (*model.World, error) {
 	step := project.Prometheus.RefreshInterval
 	to := cacheTo.Truncate(step)
 	from := to.Add(-timeseries.Hour)
-	return constructor.New(cc, step, checkConfigs).LoadWorld(context.Background(), from, to, step, nil)
+	w, err := constructor.New(cc, step, checkConfigs, project.Prometheus.ExtraSelector, project.Prometheus.ReplicaLabel, project.Settings.ApplicationIdentity, project.Settings.ApplicationExclusions).LoadWorld(context.Background(), from, to, step, nil)
+	if err != nil {
+		return nil, err
+	}
+	project.ApplySLOObjectives(w)
+	return w, nil
 }
 
-func (mgr *AlertManager) sendAlert(project *db.Project, app *model.Application, incident *db.Incident) bool {
-	alert := Alert{ProjectId: project.Id, ApplicationId: app.Id, Incident: incident, Reports: app.Reports}
+func (mgr *AlertManager) sendAlert(project *db.Project, appId model.ApplicationId, reports []*model.AuditReport, incident *db.Incident) bool {
+	alert := Alert{ProjectId: project.Id, ApplicationId: appId, Incident: incident, Reports: reports}
+	if r := mgr.RouteIncident(project, appId, incident, time.Now()); r.Suppressed != "" {
+		klog.Infof("%s: notification for %s suppressed: %s", project.Id, appId, r.Suppressed)
+		return false
+	}
 	sent := false
 	if cfg := project.Settings.Integrations.Slack; cfg != nil && cfg.Enabled {
-		err := NewSlack(cfg.Token).SendAlert(project.Settings.Integrations.BaseUrl, cfg.DefaultChannel, alert)
-		if err != nil {
-			klog.Errorln("slack error:", err)
-		} else {
-			klog.Infoln("alert successfully sent to the slack channel")
-			sent = true
-		}
+		sent = mgr.sendWithBreaker(project.Id, IntegrationSlack, cfg.GetTimeout(), cfg.GetBreakerFailureThreshold(), cfg.GetBreakerCooldown(), func(ctx context.Context) error {
+			return NewSlack(cfg.Token).SendAlert(ctx, project.Settings.Integrations.BaseUrl, cfg.DefaultChannel, alert)
+		}) || sent
 	}
 	return sent
 }
+
+// sendWithBreaker sends a notification through the integration within the timeout unless the integration
+// is disabled by its circuit breaker due to failures: for the cooldown after the threshold of consecutive failures.
+func (mgr *AlertManager) sendWithBreaker(projectId db.ProjectId, integration string, timeout timeseries.Duration, threshold int, cooldown timeseries.Duration, send func(ctx context.Context) error) bool {
+	b := mgr.getBreaker(projectId, integration)
+	b.Configure(threshold, cooldown.ToStandard())
+	if !b.Allow(time.Now()) {
+		klog.Warningf("%s: %s integration is disabled due to failures, the notification is skipped", projectId, integration)
+		notificationsTotal.WithLabelValues(integration, "skipped").Inc()
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout.ToStandard())
+	defer cancel()
+	if err := send(ctx); err != nil {
+		klog.Errorf("%s: %s error: %s", projectId, integration, err)
+		notificationsTotal.WithLabelValues(integration, "error").Inc()
+		b.Failure(err, time.Now())
+		return false
+	}
+	klog.Infof("%s: alert successfully sent to %s", projectId, integration)
+	notificationsTotal.WithLabelValues(integration, "ok").Inc()
+	b.Success()
+	return true
+}
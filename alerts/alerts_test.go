@@ -0,0 +1,93 @@
+package alerts
+
+import (
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestResolveLostIncidents(t *testing.T) {
+	database, err := db.Open(t.TempDir(), "", "")
+	require.NoError(t, err)
+	projectId, err := database.SaveProject(db.Project{Name: "test"}, "")
+	require.NoError(t, err)
+	project, err := database.GetProject(projectId)
+	require.NoError(t, err)
+
+	present := model.NewApplicationId("default", model.ApplicationKindDeployment, "present")
+	lost := model.NewApplicationId("default", model.ApplicationKindDeployment, "lost")
+	now := timeseries.Now()
+	for _, appId := range []model.ApplicationId{present, lost} {
+		_, err := database.CreateOrUpdateIncident(projectId, appId, now.Add(-timeseries.Hour), model.CRITICAL, nil, nil, nil)
+		require.NoError(t, err)
+	}
+	world := model.NewWorld(now.Add(-timeseries.Hour), now, timeseries.Minute)
+	world.GetOrCreateApplication(present)
+
+	mgr := NewAlertManager(database, nil, 10*time.Minute)
+	openIncidents := func() []model.ApplicationId {
+		incidents, err := database.GetOpenIncidents(projectId)
+		require.NoError(t, err)
+		var res []model.ApplicationId
+		for appId := range incidents {
+			res = append(res, appId)
+		}
+		return res
+	}
+
+	// the application has just disappeared
+	mgr.resolveLostIncidents(project, world)
+	assert.ElementsMatch(t, []model.ApplicationId{present, lost}, openIncidents())
+	require.Contains(t, mgr.missingSince[projectId], lost)
+	assert.NotContains(t, mgr.missingSince[projectId], present)
+
+	// the application has been missing for longer than the threshold
+	mgr.missingSince[projectId][lost] = now.Add(-15 * timeseries.Minute)
+	mgr.resolveLostIncidents(project, world)
+	assert.ElementsMatch(t, []model.ApplicationId{present}, openIncidents())
+	assert.Empty(t, mgr.missingSince[projectId])
+
+	incidents, err := database.GetIncidentsByApp(projectId, lost, now.Add(-2*timeseries.Hour), now.Add(timeseries.Hour))
+	require.NoError(t, err)
+	require.Len(t, incidents, 1)
+	assert.Equal(t, db.IncidentResolveReasonDataLost, incidents[0].ResolveReason)
+}
+
+func TestForgetStates(t *testing.T) {
+	now := timeseries.Now()
+	present := model.NewApplicationId("default", model.ApplicationKindDeployment, "present")
+	removed := model.NewApplicationId("default", model.ApplicationKindDeployment, "removed")
+	world := model.NewWorld(now.Add(-timeseries.Hour), now, timeseries.Minute)
+	world.GetOrCreateApplication(present)
+	p1 := &db.Project{Id: "p1"}
+	p2 := &db.Project{Id: "p2"}
+
+	mgr := NewAlertManager(nil, nil, 0)
+	for _, p := range []*db.Project{p1, p2} {
+		for _, appId := range []model.ApplicationId{present, removed} {
+			mgr.damp(p, appId, model.OK)
+			mgr.applyGrace(p, appId, model.OK, false, now)
+		}
+		mgr.getBreaker(p.Id, IntegrationSlack)
+	}
+
+	mgr.forgetMissingApplications(p1.Id, world)
+	assert.Contains(t, mgr.damping[p1.Id], present)
+	assert.NotContains(t, mgr.damping[p1.Id], removed)
+	assert.Len(t, mgr.damping[p2.Id], 2)
+	assert.Contains(t, mgr.grace[p1.Id], present)
+	assert.NotContains(t, mgr.grace[p1.Id], removed)
+	assert.Len(t, mgr.grace[p2.Id], 2)
+
+	mgr.forgetDeletedProjects([]*db.Project{p1})
+	assert.Contains(t, mgr.damping, p1.Id)
+	assert.NotContains(t, mgr.damping, p2.Id)
+	assert.Contains(t, mgr.grace, p1.Id)
+	assert.NotContains(t, mgr.grace, p2.Id)
+	assert.Contains(t, mgr.breakers, breakerKey{projectId: p1.Id, integration: IntegrationSlack})
+	assert.NotContains(t, mgr.breakers, breakerKey{projectId: p2.Id, integration: IntegrationSlack})
+}
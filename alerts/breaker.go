@@ -0,0 +1,133 @@
+package alerts
+
+import (
+	"github.com/coroot/coroot/db"
+	"sync"
+	"time"
+)
+
+const (
+	IntegrationSlack = "slack"
+)
+
+type BreakerState struct {
+	Open         bool      `json:"open"`
+	Failures     int       `json:"failures"`
+	LastError    string    `json:"last_error,omitempty"`
+	DisabledTill time.Time `json:"disabled_till,omitempty"`
+}
+
+// circuitBreaker stops sending notifications to a failing integration for a cooldown, so that a hanging or broken
+// endpoint doesn't back up alerting. After the cooldown, the breaker is half-open: a single probe is admitted
+// while the other attempts are still rejected, the breaker closes if the probe succeeds and opens for another cooldown otherwise.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	lock      sync.Mutex
+	failures  int
+	lastError string
+	openedAt  time.Time
+	probing   bool
+}
+
+// Configure changes the number of consecutive failures opening the breaker and the cooldown,
+// they take effect from the next failure.
+func (b *circuitBreaker) Configure(threshold int, cooldown time.Duration) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.threshold, b.cooldown = threshold, cooldown
+}
+
+// Allow reports whether an attempt can be made. The caller must report the result of an allowed attempt
+// with Success or Failure.
+func (b *circuitBreaker) Allow(now time.Time) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	switch {
+	case b.openedAt.IsZero():
+		return true
+	case now.Before(b.openedAt.Add(b.cooldown)) || b.probing:
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *circuitBreaker) Success() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.failures, b.lastError, b.openedAt, b.probing = 0, "", time.Time{}, false
+}
+
+func (b *circuitBreaker) Failure(err error, now time.Time) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.probing = false
+	b.failures++
+	b.lastError = err.Error()
+	if b.failures >= b.threshold {
+		b.openedAt = now
+	}
+}
+
+func (b *circuitBreaker) State(now time.Time) BreakerState {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	s := BreakerState{Failures: b.failures, LastError: b.lastError}
+	if !b.openedAt.IsZero() && now.Before(b.openedAt.Add(b.cooldown)) {
+		s.Open = true
+		s.DisabledTill = b.openedAt.Add(b.cooldown)
+	}
+	return s
+}
+
+type breakerKey struct {
+	projectId   db.ProjectId
+	integration string
+}
+
+// getBreaker returns the circuit breaker of the project's integration creating it if necessary.
+func (mgr *AlertManager) getBreaker(projectId db.ProjectId, integration string) *circuitBreaker {
+	mgr.breakersLock.Lock()
+	defer mgr.breakersLock.Unlock()
+	k := breakerKey{projectId: projectId, integration: integration}
+	b := mgr.breakers[k]
+	if b == nil {
+		b = &circuitBreaker{threshold: db.DefaultBreakerFailureThreshold, cooldown: db.DefaultBreakerCooldown.ToStandard()}
+		mgr.breakers[k] = b
+	}
+	return b
+}
+
+// IntegrationState returns the state of the circuit breaker of the project's integration.
+// The state is empty if alerting is disabled (the manager is nil).
+func (mgr *AlertManager) IntegrationState(projectId db.ProjectId, integration string) BreakerState {
+	return mgr.integrationState(projectId, integration, time.Now())
+}
+
+func (mgr *AlertManager) integrationState(projectId db.ProjectId, integration string, now time.Time) BreakerState {
+	if mgr == nil {
+		return BreakerState{}
+	}
+	return mgr.getBreaker(projectId, integration).State(now)
+}
+
+// ResetIntegration closes the circuit breaker of the project's integration, e.g., once its settings have been changed.
+func (mgr *AlertManager) ResetIntegration(projectId db.ProjectId, integration string) {
+	if mgr == nil {
+		return
+	}
+	mgr.getBreaker(projectId, integration).Success()
+}
+
+// forgetDeletedProjectBreakers drops the circuit breakers of the projects missing from exist.
+func (mgr *AlertManager) forgetDeletedProjectBreakers(exist map[db.ProjectId]bool) {
+	mgr.breakersLock.Lock()
+	defer mgr.breakersLock.Unlock()
+	for k := range mgr.breakers {
+		if !exist[k.projectId] {
+			delete(mgr.breakers, k)
+		}
+	}
+}
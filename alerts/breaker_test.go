@@ -0,0 +1,71 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Unix(1668000000, 0)
+	b := &circuitBreaker{threshold: 3, cooldown: 10 * time.Minute}
+	err := errors.New("connection refused")
+
+	b.Failure(err, now)
+	b.Failure(err, now)
+	assert.True(t, b.Allow(now))
+	assert.Equal(t, BreakerState{Failures: 2, LastError: "connection refused"}, b.State(now))
+
+	b.Failure(err, now)
+	assert.False(t, b.Allow(now.Add(time.Minute)))
+	assert.Equal(t, BreakerState{Open: true, Failures: 3, LastError: "connection refused", DisabledTill: now.Add(10 * time.Minute)}, b.State(now))
+
+	// a single probe after the cooldown: a failure opens the breaker again
+	now = now.Add(10 * time.Minute)
+	assert.True(t, b.Allow(now))
+	assert.False(t, b.Allow(now), "only one probe is admitted")
+	b.Failure(err, now)
+	assert.False(t, b.Allow(now.Add(time.Minute)))
+
+	now = now.Add(10 * time.Minute)
+	assert.True(t, b.Allow(now))
+	assert.False(t, b.Allow(now))
+	b.Success()
+	assert.True(t, b.Allow(now))
+	assert.Equal(t, BreakerState{}, b.State(now))
+}
+
+func TestSendWithBreaker(t *testing.T) {
+	projectId := db.ProjectId("test-send-with-breaker")
+	mgr := NewAlertManager(nil, nil, 0)
+	calls := 0
+	hanging := func(ctx context.Context) error {
+		calls++
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	threshold, cooldown := 3, timeseries.Hour
+	for i := 0; i < threshold+2; i++ {
+		assert.False(t, mgr.sendWithBreaker(projectId, IntegrationSlack, timeseries.Duration(0), threshold, cooldown, hanging))
+	}
+	assert.Equal(t, threshold, calls)
+	s := mgr.IntegrationState(projectId, IntegrationSlack)
+	assert.True(t, s.Open)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), s.DisabledTill, time.Minute)
+	assert.Equal(t, context.DeadlineExceeded.Error(), s.LastError)
+
+	mgr.ResetIntegration(projectId, IntegrationSlack)
+	assert.Equal(t, BreakerState{}, mgr.IntegrationState(projectId, IntegrationSlack))
+	assert.False(t, mgr.sendWithBreaker(projectId, IntegrationSlack, timeseries.Duration(0), threshold, cooldown, hanging))
+	assert.Equal(t, threshold+1, calls)
+}
+
+func TestIntegrationStateWithoutAlertManager(t *testing.T) {
+	var mgr *AlertManager
+	mgr.ResetIntegration("test", IntegrationSlack)
+	assert.Equal(t, BreakerState{}, mgr.IntegrationState("test", IntegrationSlack))
+}
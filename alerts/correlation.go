@@ -0,0 +1,121 @@
+package alerts
+
+import (
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"sort"
+)
+
+const (
+	// an upstream incident opened later than this after the incident of a dependent application isn't considered its cause
+	rootCauseMaxDelay = 5 * timeseries.Minute
+)
+
+// correlateIncidents attributes the open incidents that have no cause yet to the open incidents of the applications
+// they depend on (directly or transitively) according to the dependency graph of the world.
+// The farthest upstream incident is chosen as the root cause. An incident is never attributed to an incident
+// that is itself attributed to it, so dependency cycles don't suppress all their incidents.
+// It returns the keys of the root-cause incidents by the application of the newly attributed incident.
+func correlateIncidents(w *model.World, open map[model.ApplicationId]*db.Incident) map[model.ApplicationId]string {
+	byKey := map[string]*db.Incident{}
+	appIds := make([]model.ApplicationId, 0, len(open))
+	for appId, i := range open {
+		byKey[i.Key] = i
+		appIds = append(appIds, appId)
+	}
+	sort.Slice(appIds, func(i, j int) bool {
+		ii, ij := open[appIds[i]], open[appIds[j]]
+		if ii.OpenedAt != ij.OpenedAt {
+			return ii.OpenedAt.Before(ij.OpenedAt)
+		}
+		return appIds[i].String() < appIds[j].String()
+	})
+
+	res := map[model.ApplicationId]string{}
+	for _, appId := range appIds {
+		incident := open[appId]
+		if incident.CausedBy != "" {
+			continue
+		}
+		app := w.GetApplication(appId)
+		if app == nil {
+			continue
+		}
+		var cause *db.Incident
+		depth, causeDepth := 0, 0
+		visited := map[model.ApplicationId]bool{appId: true}
+		for layer := upstreamApplications(w, app, visited); len(layer) > 0; {
+			depth++
+			var next []*model.Application
+			for _, u := range layer {
+				if i := open[u.Id]; i != nil && isCause(i, incident) && (cause == nil || depth > causeDepth) {
+					cause, causeDepth = i, depth
+				}
+				next = append(next, upstreamApplications(w, u, visited)...)
+			}
+			layer = next
+		}
+		if cause == nil {
+			continue
+		}
+		root := cause.Key
+		if r := byKey[cause.CausedBy]; r != nil {
+			root = r.Key
+		}
+		incident.CausedBy = root
+		res[appId] = root
+	}
+	return res
+}
+
+// isCause reports whether the candidate incident of an upstream application can be the cause of the incident.
+// The causes are always root causes, so checking the direct cause of the candidate is enough to prevent cycles.
+func isCause(candidate, incident *db.Incident) bool {
+	if candidate.OpenedAt.After(incident.OpenedAt.Add(rootCauseMaxDelay)) {
+		return false
+	}
+	return candidate.CausedBy != incident.Key
+}
+
+// upstreamApplications returns the applications the given one connects to that haven't been visited yet
+// ordered by id and marks them as visited.
+func upstreamApplications(w *model.World, app *model.Application, visited map[model.ApplicationId]bool) []*model.Application {
+	var res []*model.Application
+	for _, i := range app.Instances {
+		for _, c := range i.Upstreams {
+			if c.RemoteInstance == nil {
+				continue
+			}
+			id := c.RemoteInstance.OwnerId
+			if visited[id] {
+				continue
+			}
+			visited[id] = true
+			if u := w.GetApplication(id); u != nil {
+				res = append(res, u)
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Id.String() < res[j].Id.String()
+	})
+	return res
+}
+
+// isSuppressedByCause reports whether the notification about the incident should be skipped since it's attributed to
+// another incident: while the root-cause incident is open, or if the incident has been resolved without being announced.
+func isSuppressedByCause(incident *db.Incident, open map[model.ApplicationId]*db.Incident) bool {
+	if incident.CausedBy == "" {
+		return false
+	}
+	if !incident.ResolvedAt.IsZero() {
+		return incident.SentAt.IsZero()
+	}
+	for _, i := range open {
+		if i.Key == incident.CausedBy {
+			return true
+		}
+	}
+	return false
+}
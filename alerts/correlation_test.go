@@ -0,0 +1,67 @@
+package alerts
+
+import (
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCorrelateIncidents(t *testing.T) {
+	now := timeseries.Time(1668000000)
+	w := model.NewWorld(now.Add(-timeseries.Hour), now, timeseries.Minute)
+	app := func(name string) *model.Application {
+		return w.GetOrCreateApplication(model.NewApplicationId("default", model.ApplicationKindDeployment, name))
+	}
+	connect := func(from, to *model.Application) {
+		i := from.GetOrCreateInstance(from.Id.Name + "-1")
+		remote := to.GetOrCreateInstance(to.Id.Name + "-1")
+		i.Upstreams = append(i.Upstreams, &model.Connection{Instance: i, RemoteInstance: remote})
+	}
+	web, api, cache, postgres, worker, a, b := app("web"), app("api"), app("cache"), app("db"), app("worker"), app("a"), app("b")
+	connect(web, api)
+	connect(api, cache)
+	connect(cache, postgres)
+	connect(worker, postgres)
+	connect(a, b)
+	connect(b, a)
+
+	incident := func(key string, openedAt timeseries.Time) *db.Incident {
+		return &db.Incident{Key: key, OpenedAt: openedAt, Severity: model.CRITICAL}
+	}
+	open := map[model.ApplicationId]*db.Incident{
+		web.Id:      incident("web", now),
+		api.Id:      incident("api", now.Add(-timeseries.Minute)),
+		postgres.Id: incident("db", now.Add(-2*timeseries.Minute)),
+		worker.Id:   incident("worker", now.Add(-timeseries.Hour)), // long before the db incident
+		a.Id:        incident("a", now),
+		b.Id:        incident("b", now),
+	}
+
+	causes := correlateIncidents(w, open)
+	assert.Equal(t, map[model.ApplicationId]string{
+		web.Id: "db",
+		api.Id: "db",
+		a.Id:   "b",
+	}, causes)
+	assert.Equal(t, "db", open[web.Id].CausedBy)
+	assert.Equal(t, "", open[postgres.Id].CausedBy)
+	assert.Equal(t, "", open[b.Id].CausedBy)
+
+	// already attributed incidents are kept as is
+	assert.Empty(t, correlateIncidents(w, open))
+
+	assert.True(t, isSuppressedByCause(open[web.Id], open))
+	assert.False(t, isSuppressedByCause(open[postgres.Id], open))
+
+	delete(open, postgres.Id)
+	assert.False(t, isSuppressedByCause(open[web.Id], open))
+
+	resolved := incident("resolved", now.Add(-timeseries.Hour))
+	resolved.ResolvedAt = now
+	resolved.CausedBy = "db"
+	assert.True(t, isSuppressedByCause(resolved, open))
+	resolved.SentAt = now.Add(-timeseries.Minute)
+	assert.False(t, isSuppressedByCause(resolved, open))
+}
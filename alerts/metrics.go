@@ -0,0 +1,23 @@
+package alerts
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var notificationsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "coroot_notifications_total",
+	},
+	[]string{"integration", "status"},
+)
+
+var otlpExportsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "coroot_otlp_exports_total",
+	},
+	[]string{"status"},
+)
+
+func init() {
+	prometheus.MustRegister(notificationsTotal, otlpExportsTotal)
+}
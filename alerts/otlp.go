@@ -0,0 +1,190 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// OTLP pushes metrics to an OpenTelemetry collector using OTLP/HTTP with JSON encoding,
+// so no OpenTelemetry SDK is required.
+type OTLP struct {
+	endpoint string
+	headers  []db.IntegrationHeader
+	client   *http.Client
+}
+
+func NewOTLP(cfg *db.IntegrationOTLP) *OTLP {
+	return &OTLP{endpoint: cfg.Endpoint, headers: cfg.Headers, client: &http.Client{}}
+}
+
+func (o *OTLP) Export(ctx context.Context, metrics *OTLPMetrics) error {
+	body, err := json.Marshal(metrics)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for _, h := range o.headers {
+		req.Header.Set(h.Name, h.Value)
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// OTLPMetrics is an ExportMetricsServiceRequest in the JSON encoding of OTLP, only the gauges are supported.
+type OTLPMetrics struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	} `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpScopeMetrics struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Metrics []*otlpMetric `json:"metrics"`
+}
+
+type otlpMetric struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Unit        string `json:"unit,omitempty"`
+	Gauge       struct {
+		DataPoints []otlpDataPoint `json:"dataPoints"`
+	} `json:"gauge"`
+}
+
+type otlpDataPoint struct {
+	Attributes []otlpAttribute `json:"attributes"`
+	// uint64 fields are encoded as strings in the JSON encoding of OTLP
+	TimeUnixNano string  `json:"timeUnixNano"`
+	AsDouble     float64 `json:"asDouble"`
+}
+
+type otlpAttribute struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+func otlpAttributes(kv ...string) []otlpAttribute {
+	res := make([]otlpAttribute, 0, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		a := otlpAttribute{Key: kv[i]}
+		a.Value.StringValue = kv[i+1]
+		res = append(res, a)
+	}
+	return res
+}
+
+// NewOTLPMetrics builds the gauges of the SLO checks of the applications of the audited world:
+// the SLI (the percentage of good requests over the world's time window), the objective, the burn rate and the status
+// (see model.Status). The values that can't be calculated, e.g., due to the lack of data, are omitted.
+func NewOTLPMetrics(project *db.Project, w *model.World, now timeseries.Time) *OTLPMetrics {
+	sli := &otlpMetric{Name: "coroot.slo.sli", Description: "The percentage of good requests", Unit: "%"}
+	objective := &otlpMetric{Name: "coroot.slo.objective", Description: "The objective of the SLO", Unit: "%"}
+	burnRate := &otlpMetric{Name: "coroot.slo.burn_rate", Description: "The error budget burn rate", Unit: "1"}
+	status := &otlpMetric{Name: "coroot.slo.status", Description: "The status of the SLO check: 0 - unknown, 1 - ok, 2 - info, 3 - warning, 4 - critical", Unit: "1"}
+	ts := strconv.FormatInt(int64(now)*1e9, 10)
+	add := func(m *otlpMetric, attrs []otlpAttribute, v float64) {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return
+		}
+		m.Gauge.DataPoints = append(m.Gauge.DataPoints, otlpDataPoint{Attributes: attrs, TimeUnixNano: ts, AsDouble: v})
+	}
+
+	for _, app := range w.Applications {
+		if len(app.AvailabilitySLIs) == 0 && len(app.LatencySLIs) == 0 {
+			continue
+		}
+		attrs := func(checkId model.CheckId) []otlpAttribute {
+			return otlpAttributes(
+				"application.id", app.Id.String(),
+				"application.namespace", app.Id.Namespace,
+				"application.kind", string(app.Id.Kind),
+				"application.name", app.Id.Name,
+				"check", string(checkId),
+			)
+		}
+		if len(app.AvailabilitySLIs) > 0 {
+			s := app.AvailabilitySLIs[0]
+			a := attrs(model.Checks.SLOAvailability.Id)
+			good := s.TotalRequests
+			if !timeseries.IsEmpty(s.FailedRequests) {
+				good = timeseries.Aggregate(timeseries.Sub, s.TotalRequests, timeseries.Map(timeseries.NanToZero, s.FailedRequests))
+			}
+			add(sli, a, goodPercentage(s.TotalRequests, good))
+			add(objective, a, s.Config.ObjectivePercentage)
+		}
+		if len(app.LatencySLIs) > 0 {
+			s := app.LatencySLIs[0]
+			a := attrs(model.Checks.SLOLatency.Id)
+			total, fast := s.GetTotalAndFast(false)
+			add(sli, a, goodPercentage(total, fast))
+			add(objective, a, s.Config.ObjectivePercentage)
+		}
+		for _, id := range []model.CheckId{model.Checks.SLOAvailability.Id, model.Checks.SLOLatency.Id} {
+			ch := app.GetCheck(id)
+			if ch == nil {
+				continue
+			}
+			a := attrs(id)
+			if ch.Status > model.UNKNOWN {
+				add(burnRate, a, ch.BurnRate())
+			}
+			add(status, a, float64(ch.Status))
+		}
+	}
+
+	rm := otlpResourceMetrics{}
+	rm.Resource.Attributes = otlpAttributes("service.name", "coroot", "coroot.project.id", string(project.Id), "coroot.project.name", project.Name)
+	sm := otlpScopeMetrics{}
+	sm.Scope.Name = "coroot"
+	for _, m := range []*otlpMetric{sli, objective, burnRate, status} {
+		if len(m.Gauge.DataPoints) > 0 {
+			sm.Metrics = append(sm.Metrics, m)
+		}
+	}
+	rm.ScopeMetrics = append(rm.ScopeMetrics, sm)
+	return &OTLPMetrics{ResourceMetrics: []otlpResourceMetrics{rm}}
+}
+
+// goodPercentage returns the percentage of good requests in total over the whole series, NaN if there are no requests.
+func goodPercentage(total, good timeseries.TimeSeries) float64 {
+	t := timeseries.Reduce(timeseries.NanSum, total)
+	if math.IsNaN(t) || t <= 0 {
+		return timeseries.NaN
+	}
+	g := timeseries.Reduce(timeseries.NanSum, good)
+	if math.IsNaN(g) {
+		g = 0
+	}
+	return g / t * 100
+}
@@ -0,0 +1,116 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewOTLPMetrics(t *testing.T) {
+	now := timeseries.Time(1668000000)
+	w := model.NewWorld(now.Add(-timeseries.Hour), now, timeseries.Minute)
+
+	app := model.NewApplication(model.NewApplicationId("prod", model.ApplicationKindDeployment, "api"))
+	app.AvailabilitySLIs = append(app.AvailabilitySLIs, &model.AvailabilitySLI{
+		Config:         model.CheckConfigSLOAvailability{ObjectivePercentage: 99},
+		TotalRequests:  timeseries.NewWithData(0, timeseries.Minute, []float64{100, 100, timeseries.NaN, 200}),
+		FailedRequests: timeseries.NewWithData(0, timeseries.Minute, []float64{0, 4, timeseries.NaN, timeseries.NaN}),
+	})
+	r := model.NewAuditReport(app.Id, w.Ctx, nil, model.AuditReportSLO)
+	ch := r.CreateCheck(model.Checks.SLOAvailability)
+	ch.SetBurnRate(2)
+	ch.SetStatus(model.WARNING, "")
+	app.Reports = append(app.Reports, r)
+	w.Applications = append(w.Applications, app, model.NewApplication(model.NewApplicationId("prod", model.ApplicationKindDeployment, "web")))
+
+	m := NewOTLPMetrics(&db.Project{Id: "p1", Name: "production"}, w, now)
+	assert.Len(t, m.ResourceMetrics, 1)
+	rm := m.ResourceMetrics[0]
+	assert.Equal(t, otlpAttributes("service.name", "coroot", "coroot.project.id", "p1", "coroot.project.name", "production"), rm.Resource.Attributes)
+	values := map[string]float64{}
+	for _, metric := range rm.ScopeMetrics[0].Metrics {
+		assert.Len(t, metric.Gauge.DataPoints, 1)
+		dp := metric.Gauge.DataPoints[0]
+		assert.Equal(t, "1668000000000000000", dp.TimeUnixNano)
+		assert.Contains(t, dp.Attributes, otlpAttributes("check", "SLOAvailability")[0])
+		values[metric.Name] = dp.AsDouble
+	}
+	assert.Equal(t, map[string]float64{
+		"coroot.slo.sli":       99,
+		"coroot.slo.objective": 99,
+		"coroot.slo.burn_rate": 2,
+		"coroot.slo.status":    float64(model.WARNING),
+	}, values)
+}
+
+func TestOTLPExport(t *testing.T) {
+	var auth, contentType string
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, contentType = r.Header.Get("Authorization"), r.Header.Get("Content-Type")
+		data, _ := ioutil.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &body)
+		if r.URL.Path != "/v1/metrics" {
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	o := NewOTLP(&db.IntegrationOTLP{Endpoint: srv.URL + "/v1/metrics", Headers: []db.IntegrationHeader{{Name: "Authorization", Value: "Bearer secret"}}})
+	assert.NoError(t, o.Export(context.Background(), &OTLPMetrics{}))
+	assert.Equal(t, "Bearer secret", auth)
+	assert.Equal(t, "application/json", contentType)
+	assert.Contains(t, body, "resourceMetrics")
+
+	o = NewOTLP(&db.IntegrationOTLP{Endpoint: srv.URL + "/metrics"})
+	assert.EqualError(t, o.Export(context.Background(), &OTLPMetrics{}), "404 Not Found: not found")
+}
+
+func TestExportMetricsAsync(t *testing.T) {
+	release := make(chan struct{})
+	requests := make(chan struct{}, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- struct{}{}
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	mgr := NewAlertManager(nil, nil, 0)
+	project := &db.Project{Id: "test-export-async"}
+	project.Settings.Integrations.OTLP = &db.IntegrationOTLP{Endpoint: srv.URL, Enabled: true, Interval: timeseries.Second}
+	now := timeseries.Now()
+	w := model.NewWorld(now.Add(-timeseries.Hour), now, timeseries.Minute)
+
+	// a hanging collector doesn't block the caller
+	done := make(chan struct{})
+	go func() {
+		mgr.exportMetrics(project, w)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("exportMetrics blocked on the collector")
+	}
+	<-requests
+
+	// the interval has elapsed, but the previous export is still in flight
+	mgr.exportLock.Lock()
+	mgr.exportedAt[project.Id] = time.Time{}
+	mgr.exportLock.Unlock()
+	mgr.exportMetrics(project, w)
+	select {
+	case <-requests:
+		t.Fatal("a second export started while the first one was in flight")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
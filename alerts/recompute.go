@@ -0,0 +1,204 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"github.com/coroot/coroot/cache"
+	"github.com/coroot/coroot/constructor"
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/coroot/coroot/utils"
+	"k8s.io/klog"
+)
+
+type RecomputeResult struct {
+	From         timeseries.Time `json:"from"`
+	To           timeseries.Time `json:"to"`
+	Applications int             `json:"applications"`
+	Deleted      int             `json:"deleted"`
+	Created      int             `json:"created"`
+	Skipped      []string        `json:"skipped,omitempty"`
+}
+
+// RecomputeIncidents re-evaluates the SLOs of the project's applications over the given past range using the current
+// check configs and replaces the incidents stored for this range with the recomputed ones. Nothing is notified.
+// Incidents extending beyond the range (including the open ones) are kept as is: the range of the application
+// is narrowed so as not to overlap them. Incidents still firing at the end of the range are resolved there.
+func RecomputeIncidents(ctx context.Context, database *db.DB, cache *cache.Cache, project *db.Project, from, to timeseries.Time) (*RecomputeResult, error) {
+	cc := cache.GetCacheClient(project)
+	cacheTo, err := cc.GetTo()
+	if err != nil {
+		return nil, err
+	}
+	if cacheTo.IsZero() {
+		return nil, fmt.Errorf("cache is empty")
+	}
+	step := project.Prometheus.RefreshInterval
+	if cacheTo.Before(to) {
+		to = cacheTo
+	}
+	from, to = from.Truncate(step), to.Truncate(step)
+	if !from.Before(to) {
+		return nil, fmt.Errorf("the range is empty")
+	}
+	checkConfigs, err := database.GetCheckConfigs(project.Id)
+	if err != nil {
+		return nil, err
+	}
+	c := constructor.New(cc, step, checkConfigs, project.Prometheus.ExtraSelector, project.Prometheus.ReplicaLabel, project.Settings.ApplicationIdentity, project.Settings.ApplicationExclusions)
+	world, err := c.LoadWorld(ctx, to.Add(-timeseries.Hour), to, step, nil)
+	if err != nil {
+		return nil, err
+	}
+	project.ApplySLOObjectives(world)
+	c.LoadRawSLIs(ctx, world, from.Add(-model.MaxAlertRuleWindow), to)
+
+	res := &RecomputeResult{From: from, To: to}
+	now := timeseries.Now()
+	evaluationStep := step
+	if evaluationStep < timeseries.Minute {
+		evaluationStep = timeseries.Minute
+	}
+	for _, app := range world.Applications {
+		if len(app.AvailabilitySLIs) == 0 && len(app.LatencySLIs) == 0 {
+			continue
+		}
+		existing, err := database.GetIncidentsByAppForUpdate(project.Id, app.Id, from, to)
+		if err != nil {
+			return nil, err
+		}
+		appFrom, appTo := recomputableRange(existing, from, to)
+		if !appFrom.Before(appTo) {
+			res.Skipped = append(res.Skipped, app.Id.String())
+			continue
+		}
+		var times []timeseries.Time
+		for t := appFrom.Add(evaluationStep); t.Before(appTo); t = t.Add(evaluationStep) {
+			times = append(times, t)
+		}
+		statuses, burnRates := evaluateSLOs(app, times)
+		incidents := replayIncidents(project, times, statuses, burnRates, appTo)
+		deleted, err := database.ReplaceIncidents(project.Id, app.Id, appFrom, appTo, incidents, now)
+		if err != nil {
+			return nil, err
+		}
+		res.Applications++
+		res.Deleted += deleted
+		res.Created += len(incidents)
+	}
+	klog.Infof(
+		"%s: recomputed incidents of %d apps from %s to %s: %d deleted, %d created",
+		project.Id, res.Applications, from.ToStandard(), to.ToStandard(), res.Deleted, res.Created,
+	)
+	return res, nil
+}
+
+// recomputableRange narrows the range so that it doesn't overlap the incidents that aren't entirely within it.
+// The range is empty if an open incident started before it.
+func recomputableRange(incidents []db.Incident, from, to timeseries.Time) (timeseries.Time, timeseries.Time) {
+	for _, i := range incidents {
+		switch {
+		case i.OpenedAt.Before(from) && i.ResolvedAt.IsZero():
+			return from, from
+		case i.OpenedAt.Before(from):
+			if i.ResolvedAt.After(from) {
+				from = i.ResolvedAt
+			}
+		case i.ResolvedAt.IsZero() || i.ResolvedAt.After(to):
+			if i.OpenedAt.Before(to) {
+				to = i.OpenedAt
+			}
+		}
+	}
+	return from, to
+}
+
+// evaluateSLOs returns the SLO status and the highest burn rate of the application at each of the given times
+// calculated from the raw SLI series the same way the auditor does.
+func evaluateSLOs(app *model.Application, times []timeseries.Time) ([]model.Status, []float64) {
+	var checks [][]model.BurnRate
+	if len(app.AvailabilitySLIs) > 0 {
+		sli := app.AvailabilitySLIs[0]
+		failed := sli.FailedRequestsRaw
+		if timeseries.IsEmpty(failed) {
+			failed = timeseries.Replace(sli.TotalRequestsRaw, 0)
+		} else {
+			failed = timeseries.Map(timeseries.NanToZero, failed)
+		}
+		checks = append(checks, model.CheckBurnRatesAt(times, failed, sli.TotalRequestsRaw, sli.Config.ObjectivePercentage))
+	}
+	if len(app.LatencySLIs) > 0 {
+		sli := app.LatencySLIs[0]
+		total, fast := sli.GetTotalAndFast(true)
+		if timeseries.IsEmpty(fast) {
+			fast = timeseries.Replace(total, 0)
+		} else {
+			fast = timeseries.Map(timeseries.NanToZero, fast)
+		}
+		slow := timeseries.Aggregate(timeseries.Sub, total, fast)
+		checks = append(checks, model.CheckBurnRatesAt(times, slow, total, sli.Config.ObjectivePercentage))
+	}
+	statuses := make([]model.Status, len(times))
+	burnRates := make([]float64, len(times))
+	for _, brs := range checks {
+		for i, br := range brs {
+			if br.Severity > statuses[i] {
+				statuses[i] = br.Severity
+			}
+			if br.Severity > model.UNKNOWN && br.Value > burnRates[i] {
+				burnRates[i] = br.Value
+			}
+		}
+	}
+	return statuses, burnRates
+}
+
+// replayIncidents turns the SLO statuses of an application into incidents following the rules of
+// db.CreateOrUpdateIncident, including the project's damping, flapping, and escalation policies.
+// An incident that is still open at the end is resolved at that time.
+func replayIncidents(project *db.Project, times []timeseries.Time, statuses []model.Status, burnRates []float64, end timeseries.Time) []*db.Incident {
+	settings := project.Settings
+	damping := settings.Flapping.Damping()
+	state := &model.DampingState{}
+	var incidents []*db.Incident
+	var last *db.Incident
+	for idx, now := range times {
+		status := damping.Apply(state, statuses[idx])
+		if status == model.UNKNOWN {
+			continue
+		}
+		switch {
+		case status > model.OK && last != nil && settings.Flapping.IsFlapping(last, now):
+			last.ResolvedAt = 0
+			last.ResolveReason = ""
+			last.FlapCount++
+			if status > last.Severity {
+				last.Severity = status
+				last.SeverityHistory = append(last.SeverityHistory, db.SeverityChange{Time: now, Severity: status})
+			}
+		case last == nil || !last.ResolvedAt.IsZero():
+			if status > model.OK {
+				last = &db.Incident{Key: utils.NanoId(8), OpenedAt: now, Severity: status}
+				last.SeverityHistory = []db.SeverityChange{{Time: now, Severity: status}}
+				incidents = append(incidents, last)
+			}
+		case status == model.OK:
+			last.ResolvedAt = now
+		default:
+			status = settings.Escalation.Escalate(last, status, now)
+			if status != last.Severity {
+				last.Severity = status
+				last.SeverityHistory = append(last.SeverityHistory, db.SeverityChange{Time: now, Severity: status})
+			}
+		}
+		if last != nil && last.ResolvedAt.IsZero() && burnRates[idx] > last.PeakBurnRate {
+			last.PeakBurnRate = burnRates[idx]
+		}
+	}
+	if last != nil && last.ResolvedAt.IsZero() {
+		last.ResolvedAt = end
+		last.ResolveReason = db.IncidentResolveReasonRecomputedEnd
+	}
+	return incidents
+}
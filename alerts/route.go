@@ -0,0 +1,64 @@
+package alerts
+
+import (
+	"fmt"
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"time"
+)
+
+// Route is the routing decision for a notification about an incident: the integrations it's dispatched to
+// or the reason it's suppressed.
+type Route struct {
+	// whether at least one integration receives the notification
+	Delivered    bool               `json:"delivered"`
+	Suppressed   string             `json:"suppressed,omitempty"`
+	Integrations []RouteIntegration `json:"integrations"`
+}
+
+type RouteIntegration struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel,omitempty"`
+	// the reason the notification isn't sent through the integration
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// RouteIncident decides where a notification about the incident of the application is dispatched at the given time.
+func (mgr *AlertManager) RouteIncident(project *db.Project, appId model.ApplicationId, incident *db.Incident, now time.Time) *Route {
+	return mgr.RouteNotification(project, appId, incident.Severity, !incident.ResolvedAt.IsZero(), now)
+}
+
+// RouteNotification decides where a notification about an incident of the application with the given severity
+// (WARNING or CRITICAL, resolved incidents keep their last severity) is dispatched at the given time. Notifications about resolved incidents aren't affected by the snoozes
+// and the quiet hours, as they aren't retried and the channel would never learn the incident has been closed.
+// The circuit breakers of the integrations are ignored if the manager is nil.
+func (mgr *AlertManager) RouteNotification(project *db.Project, appId model.ApplicationId, severity model.Status, resolved bool, now time.Time) *Route {
+	r := &Route{Integrations: []RouteIntegration{}}
+	if !resolved {
+		if s, ok := project.Settings.ApplicationSnoozes[appId]; ok && s.Until.After(timeseries.Time(now.Unix())) {
+			r.Suppressed = fmt.Sprintf("the application is snoozed until %s", s.Until.ToStandard().UTC().Format(time.RFC3339))
+			return r
+		}
+	}
+	if qh := project.Settings.Integrations.QuietHours; !resolved && severity == model.WARNING && qh.IsActive(now) {
+		r.Suppressed = fmt.Sprintf("WARNING notifications are suppressed by the quiet hours (%s-%s %s)", qh.Start, qh.End, qh.Timezone)
+		return r
+	}
+	if cfg := project.Settings.Integrations.Slack; cfg != nil {
+		i := RouteIntegration{Type: IntegrationSlack, Channel: cfg.DefaultChannel}
+		switch s := mgr.integrationState(project.Id, IntegrationSlack, now); {
+		case !cfg.Enabled:
+			i.Skipped = "the integration is disabled"
+		case s.Open:
+			i.Skipped = fmt.Sprintf("the integration is disabled due to failures until %s: %s", s.DisabledTill.UTC().Format(time.RFC3339), s.LastError)
+		}
+		r.Integrations = append(r.Integrations, i)
+	}
+	for _, i := range r.Integrations {
+		if i.Skipped == "" {
+			r.Delivered = true
+		}
+	}
+	return r
+}
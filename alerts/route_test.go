@@ -0,0 +1,46 @@
+package alerts
+
+import (
+	"errors"
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRouteNotification(t *testing.T) {
+	now := time.Date(2022, 11, 9, 23, 30, 0, 0, time.UTC)
+	appId := model.NewApplicationId("prod", model.ApplicationKindDeployment, "api")
+	p := &db.Project{Id: "test-route-notification"}
+	mgr := NewAlertManager(nil, nil, 0)
+
+	r := mgr.RouteNotification(p, appId, model.CRITICAL, false, now)
+	assert.Equal(t, &Route{Integrations: []RouteIntegration{}}, r)
+
+	p.Settings.Integrations.Slack = &db.IntegrationSlack{DefaultChannel: "alerts", Enabled: true}
+	r = mgr.RouteNotification(p, appId, model.WARNING, false, now)
+	assert.Equal(t, &Route{Delivered: true, Integrations: []RouteIntegration{{Type: IntegrationSlack, Channel: "alerts"}}}, r)
+
+	p.Settings.Integrations.QuietHours = &db.QuietHours{Start: "22:00", End: "07:00", Timezone: "UTC"}
+	r = mgr.RouteNotification(p, appId, model.WARNING, false, now)
+	assert.False(t, r.Delivered)
+	assert.Equal(t, "WARNING notifications are suppressed by the quiet hours (22:00-07:00 UTC)", r.Suppressed)
+	assert.True(t, mgr.RouteNotification(p, appId, model.CRITICAL, false, now).Delivered)
+	// the resolution of a WARNING incident isn't suppressed
+	assert.True(t, mgr.RouteNotification(p, appId, model.WARNING, true, now).Delivered)
+
+	p.Settings.ApplicationSnoozes = map[model.ApplicationId]db.ApplicationSnooze{appId: {Until: timeseries.Time(now.Add(time.Hour).Unix())}}
+	r = mgr.RouteNotification(p, appId, model.CRITICAL, false, now)
+	assert.Equal(t, "the application is snoozed until 2022-11-10T00:30:00Z", r.Suppressed)
+	assert.True(t, mgr.RouteNotification(p, appId, model.CRITICAL, true, now).Delivered)
+
+	b := mgr.getBreaker(p.Id, IntegrationSlack)
+	for i := 0; i < db.DefaultBreakerFailureThreshold; i++ {
+		b.Failure(errors.New("channel_not_found"), now)
+	}
+	r = mgr.RouteNotification(p, appId, model.CRITICAL, true, now)
+	assert.False(t, r.Delivered)
+	assert.Equal(t, "the integration is disabled due to failures until 2022-11-09T23:40:00Z: channel_not_found", r.Integrations[0].Skipped)
+}
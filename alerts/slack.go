@@ -41,12 +41,16 @@ func (s *Slack) IsChannelAvailable(ctx context.Context, channel string) (bool, e
 	return false, nil
 }
 
-func (s *Slack) SendAlert(baseUrl, channel string, a Alert) error {
+func (s *Slack) SendAlert(ctx context.Context, baseUrl, channel string, a Alert) error {
 	appLink := fmt.Sprintf("<%s/p/%s/app/%s?incident=%s|*%s*>", baseUrl, a.ProjectId, a.ApplicationId.String(), a.Incident.Key, a.ApplicationId.Name)
 	header, snippet, color, details := "", "", "", ""
 	if a.Incident.ResolvedAt.IsZero() {
 		header = fmt.Sprintf("%s is not meeting its SLOs", appLink)
 		snippet = fmt.Sprintf("%s is not meeting its SLOs", a.ApplicationId.Name)
+		if a.Incident.Reminder {
+			header = fmt.Sprintf("%s is still not meeting its SLOs", appLink)
+			snippet = fmt.Sprintf("%s is still not meeting its SLOs", a.ApplicationId.Name)
+		}
 		if a.Incident.Severity == model.CRITICAL {
 			color = "#f44034"
 		} else {
@@ -68,6 +72,9 @@ func (s *Slack) SendAlert(baseUrl, channel string, a Alert) error {
 		header = fmt.Sprintf("%s incident resolved", appLink)
 		snippet = fmt.Sprintf("%s incident resolved", a.ApplicationId.Name)
 		color = "#23d160"
+		if a.Incident.ResolveReason != "" {
+			details += fmt.Sprintf("Reason: %s\n", a.Incident.ResolveReason)
+		}
 		for _, r := range a.Reports {
 			if r.Name != model.AuditReportSLO {
 				continue
@@ -88,6 +95,6 @@ func (s *Slack) SendAlert(baseUrl, channel string, a Alert) error {
 	attachments := slack.MsgOptionAttachments(slack.Attachment{Color: color, Blocks: slack.Blocks{BlockSet: []slack.Block{
 		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, details, false, false), nil, nil),
 	}}})
-	_, _, err := s.client.PostMessage(channel, text, blocks, attachments, slack.MsgOptionDisableLinkUnfurl())
+	_, _, err := s.client.PostMessageContext(ctx, channel, text, blocks, attachments, slack.MsgOptionDisableLinkUnfurl())
 	return err
 }
@@ -2,9 +2,13 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/coroot/coroot/alerts"
 	"github.com/coroot/coroot/api/views"
+	"github.com/coroot/coroot/api/views/capacity"
+	"github.com/coroot/coroot/api/views/overview"
 	"github.com/coroot/coroot/cache"
 	"github.com/coroot/coroot/constructor"
 	"github.com/coroot/coroot/db"
@@ -15,37 +19,146 @@ import (
 	"github.com/coroot/coroot/utils"
 	"github.com/gorilla/mux"
 	"k8s.io/klog"
+	"net"
 	"net/http"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
+const (
+	worldLoadRetryAfter = 5 // seconds
+	maxRequestIdLength  = 64
+	requestIdHeader     = "X-Request-Id"
+
+	defaultMaxBodySize             = 1 << 20
+	defaultWorldLoadQueueTimeout   = 30 * time.Second
+	defaultCheckConfigsSaveTimeout = 30 * time.Second
+
+	// the number of steps a world loaded for a point in time (the at= parameter) covers
+	pointInTimeSteps = 5
+
+	// an ad-hoc SLO evaluation queries Prometheus directly, so its range is limited by the number of points
+	sloEvaluationMaxPoints = 2000
+	sloEvaluationTimeout   = time.Minute
+)
+
+var errWorldLoadQueueTimeout = errors.New("timed out waiting for a world load slot")
+
 type Api struct {
 	cache    *cache.Cache
 	db       *db.DB
 	stats    *stats.Collector
+	alerts   *alerts.AlertManager
 	readOnly bool
+
+	maskSecrets       bool
+	trustForwardedFor bool
+
+	maxBodySize int64
+
+	statsSampleRate uint64
+	statsRequests   uint64
+
+	worldLoads            chan struct{}
+	worldLoadQueueTimeout time.Duration
+
+	maxIncidentsPageSize int
+
+	checkConfigsSaveTimeout time.Duration
+
+	searchCache    searchCache
+	promProxyShare promProxyShare
+}
+
+// Options configures an Api. A zero field means the default value.
+type Options struct {
+	// AlertManager provides the states of the notification integrations (nil if alerting is disabled).
+	AlertManager *alerts.AlertManager
+
+	// ReadOnly enables the read-only mode, in which secrets are always masked.
+	ReadOnly bool
+	// MaskSecrets enables masking of secrets in the settings responses.
+	MaskSecrets bool
+	// TrustForwardedFor makes the audit log take the client's address from X-Forwarded-For,
+	// it must be set only if the API is behind a reverse proxy setting the header.
+	TrustForwardedFor bool
+
+	// MaxBodySize limits the size of a request body (defaultMaxBodySize by default).
+	MaxBodySize int64
+
+	// Every StatsSampleRate-th request is registered in the stats collector (every request by default).
+	StatsSampleRate uint64
+
+	// At most MaxWorldLoads worlds are constructed concurrently (unlimited by default),
+	// the excess requests wait up to WorldLoadQueueTimeout for their turn (defaultWorldLoadQueueTimeout by default).
+	MaxWorldLoads         int
+	WorldLoadQueueTimeout time.Duration
+
+	// MaxIncidentsPageSize limits the number of incidents per page of the incident list (defaultIncidentsPageSize by default).
+	MaxIncidentsPageSize int
+
+	// The configs of all the levels of a check are saved in a single transaction, which is rolled back
+	// after CheckConfigsSaveTimeout (defaultCheckConfigsSaveTimeout by default).
+	CheckConfigsSaveTimeout time.Duration
 }
 
-func NewApi(cache *cache.Cache, db *db.DB, stats *stats.Collector, readOnly bool) *Api {
-	return &Api{cache: cache, db: db, stats: stats, readOnly: readOnly}
+func NewApi(cache *cache.Cache, db *db.DB, stats *stats.Collector, opts Options) *Api {
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = defaultMaxBodySize
+	}
+	if opts.StatsSampleRate < 1 {
+		opts.StatsSampleRate = 1
+	}
+	if opts.WorldLoadQueueTimeout <= 0 {
+		opts.WorldLoadQueueTimeout = defaultWorldLoadQueueTimeout
+	}
+	if opts.MaxIncidentsPageSize < 1 {
+		opts.MaxIncidentsPageSize = defaultIncidentsPageSize
+	}
+	if opts.CheckConfigsSaveTimeout <= 0 {
+		opts.CheckConfigsSaveTimeout = defaultCheckConfigsSaveTimeout
+	}
+	api := &Api{
+		cache:                   cache,
+		db:                      db,
+		stats:                   stats,
+		alerts:                  opts.AlertManager,
+		readOnly:                opts.ReadOnly,
+		maskSecrets:             opts.ReadOnly || opts.MaskSecrets,
+		trustForwardedFor:       opts.TrustForwardedFor,
+		maxBodySize:             opts.MaxBodySize,
+		statsSampleRate:         opts.StatsSampleRate,
+		worldLoadQueueTimeout:   opts.WorldLoadQueueTimeout,
+		maxIncidentsPageSize:    opts.MaxIncidentsPageSize,
+		checkConfigsSaveTimeout: opts.CheckConfigsSaveTimeout,
+	}
+	if opts.MaxWorldLoads > 0 {
+		api.worldLoads = make(chan struct{}, opts.MaxWorldLoads)
+	}
+	return api
 }
 
 func (api *Api) Projects(w http.ResponseWriter, r *http.Request) {
-	api.stats.RegisterRequest(r)
-	projects, err := api.db.GetProjectNames()
+	projects, err := api.db.GetProjects()
 	if err != nil {
 		klog.Errorln("failed to get projects:", err)
-		http.Error(w, "", http.StatusInternalServerError)
+		httpError(w, "", http.StatusInternalServerError)
 		return
 	}
+	tag := r.URL.Query().Get("tag")
 	type Project struct {
 		Id   db.ProjectId `json:"id"`
 		Name string       `json:"name"`
+		Tags db.Tags      `json:"tags"`
 	}
 	res := make([]Project, 0, len(projects))
-	for id, name := range projects {
-		res = append(res, Project{Id: id, Name: name})
+	for _, p := range projects {
+		if tag != "" && !p.Tags.Match(tag) {
+			continue
+		}
+		res = append(res, Project{Id: p.Id, Name: p.Name, Tags: p.Tags})
 	}
 	sort.Slice(res, func(i, j int) bool {
 		return res[i].Name < res[j].Name
@@ -60,7 +173,7 @@ func (api *Api) Project(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 
 	case http.MethodGet:
-		res := ProjectForm{}
+		res := &ProjectForm{}
 		res.Prometheus.RefreshInterval = db.DefaultRefreshInterval
 		if id != "" {
 			project, err := api.db.GetProject(id)
@@ -70,61 +183,66 @@ func (api *Api) Project(w http.ResponseWriter, r *http.Request) {
 					return
 				}
 				klog.Errorln("failed to get project:", err)
-				http.Error(w, "", http.StatusInternalServerError)
+				httpError(w, "", http.StatusInternalServerError)
 				return
 			}
-			res.Name = project.Name
-			res.Prometheus = project.Prometheus
-			if api.readOnly {
-				res.Prometheus.Url = "http://<hidden>"
-			}
+			res = newProjectForm(project)
 		}
-		utils.WriteJson(w, res)
+		api.writeSettings(w, res)
 
 	case http.MethodPost:
 		if api.readOnly {
 			return
 		}
+		var stored Form
+		if id != "" {
+			project, err := api.db.GetProjectForUpdate(id)
+			if err != nil && !errors.Is(err, db.ErrNotFound) {
+				klog.Errorln("failed to get project:", err)
+				httpError(w, "", http.StatusInternalServerError)
+				return
+			}
+			if project != nil {
+				stored = newProjectForm(project)
+			}
+		}
 		var form ProjectForm
-		if err := ReadAndValidate(r, &form); err != nil {
-			klog.Warningln("bad request:", err)
-			http.Error(w, "", http.StatusBadRequest)
+		if err := api.readAndValidateSettings(w, r, &form, stored); err != nil {
+			badRequest(w, err, "")
 			return
 		}
 		project := db.Project{
 			Id:         id,
 			Name:       form.Name,
+			Tags:       form.Tags,
 			Prometheus: form.Prometheus,
 		}
-		p := project.Prometheus
-		user, password := "", ""
-		if p.BasicAuth != nil {
-			user, password = p.BasicAuth.User, p.BasicAuth.Password
-		}
-		promClient, err := prom.NewApiClient(p.Url, user, password, p.TlsSkipVerify)
+		client, err := promClient(&project)
 		if err != nil {
 			klog.Errorln("failed to get api client:", err)
-			http.Error(w, "", http.StatusInternalServerError)
+			httpError(w, "", http.StatusInternalServerError)
 			return
 		}
 		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 		defer cancel()
-		if err := promClient.Ping(ctx); err != nil {
+		if err := client.Ping(ctx); err != nil {
 			klog.Warningln("failed to ping prometheus:", err)
-			http.Error(w, err.Error(), http.StatusBadGateway)
+			httpError(w, err.Error(), http.StatusBadGateway)
 			return
 		}
-		id, err := api.db.SaveProject(project)
+		id, err := api.db.SaveProject(project, api.actor(r))
 		if err != nil {
 			if errors.Is(err, db.ErrConflict) {
-				http.Error(w, "This project name is already being used.", http.StatusConflict)
+				httpError(w, "This project name is already being used.", http.StatusConflict)
 				return
 			}
 			klog.Errorln("failed to save project:", err)
-			http.Error(w, "", http.StatusInternalServerError)
+			httpError(w, "", http.StatusInternalServerError)
 			return
 		}
-		http.Error(w, string(id), http.StatusOK)
+		utils.WriteJson(w, struct {
+			Id db.ProjectId `json:"id"`
+		}{Id: id})
 
 	case http.MethodDelete:
 		if api.readOnly {
@@ -132,13 +250,12 @@ func (api *Api) Project(w http.ResponseWriter, r *http.Request) {
 		}
 		if err := api.db.DeleteProject(id); err != nil {
 			klog.Errorln("failed to delete project:", err)
-			http.Error(w, "", http.StatusInternalServerError)
+			httpError(w, "", http.StatusInternalServerError)
 			return
 		}
-		http.Error(w, "", http.StatusOK)
 
 	default:
-		http.Error(w, "", http.StatusMethodNotAllowed)
+		httpError(w, "", http.StatusMethodNotAllowed)
 	}
 }
 
@@ -149,9 +266,8 @@ func (api *Api) Status(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		var form ProjectStatusForm
-		if err := ReadAndValidate(r, &form); err != nil {
-			klog.Warningln("bad request:", err)
-			http.Error(w, "", http.StatusBadRequest)
+		if err := api.readAndValidate(w, r, &form); err != nil {
+			badRequest(w, err, "")
 			return
 		}
 		var appType model.ApplicationType
@@ -166,74 +282,262 @@ func (api *Api) Status(w http.ResponseWriter, r *http.Request) {
 		}
 		if err := api.db.ToggleConfigurationHint(projectId, appType, mute); err != nil {
 			klog.Errorln("failed to toggle:", err)
-			http.Error(w, "", http.StatusInternalServerError)
+			httpError(w, "", http.StatusInternalServerError)
 			return
 		}
 		return
 	}
+	summary := r.URL.Query().Get("summary") == "1"
 	project, err := api.db.GetProject(projectId)
 	if err != nil {
 		if errors.Is(err, db.ErrNotFound) {
 			klog.Warningln("project not found:", projectId)
-			utils.WriteJson(w, views.Status(nil, nil, nil))
+			if summary {
+				utils.WriteJson(w, views.StatusSummary(nil, nil, 0, nil))
+				return
+			}
+			utils.WriteJson(w, views.Status(nil, nil, nil, api.alerts))
 			return
 		}
 		klog.Errorln(err)
-		http.Error(w, "", http.StatusInternalServerError)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	cc := api.cache.GetCacheClient(project)
+	cacheStatus, err := cc.GetStatus()
+	if err != nil {
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	if summary {
+		cacheTo, err := cc.GetTo()
+		if err != nil {
+			klog.Errorln(err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		openIncidents, err := api.db.CountOpenIncidents(projectId)
+		if err != nil {
+			klog.Errorln(err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		utils.WriteJson(w, views.StatusSummary(project, cacheStatus, cacheTo, openIncidents))
+		return
+	}
+	now := timeseries.Now()
+	world, err := api.loadWorld(r.Context(), project, now.Add(-timeseries.Hour), now)
+	if err != nil {
+		worldLoadFailed(w, err)
 		return
 	}
-	cacheStatus, err := api.cache.GetCacheClient(project).GetStatus()
+	utils.WriteJson(w, views.Status(project, cacheStatus, world, api.alerts))
+}
+
+func (api *Api) ConfigurationHints(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+	project, err := api.db.GetProject(projectId)
 	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			klog.Warningln("project not found:", projectId)
+			httpError(w, "", http.StatusNotFound)
+			return
+		}
 		klog.Errorln(err)
-		http.Error(w, "", http.StatusInternalServerError)
+		httpError(w, "", http.StatusInternalServerError)
 		return
 	}
 	now := timeseries.Now()
 	world, err := api.loadWorld(r.Context(), project, now.Add(-timeseries.Hour), now)
+	if err != nil {
+		worldLoadFailed(w, err)
+		return
+	}
+	utils.WriteJson(w, views.ConfigurationHints(project, world))
+}
+
+func (api *Api) CacheCoverage(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+	project, err := api.db.GetProject(projectId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			klog.Warningln("project not found:", projectId)
+			httpError(w, "", http.StatusNotFound)
+			return
+		}
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	now := timeseries.Now()
+	q := r.URL.Query()
+	from := utils.ParseTimeFromUrl(now, q, "from", now.Add(-timeseries.Hour))
+	to := utils.ParseTimeFromUrl(now, q, "to", now)
+	coverage, err := api.cache.GetCacheClient(project).GetCoverage(from, to)
+	if err != nil {
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	if coverage == nil {
+		coverage = []cache.Interval{}
+	}
+	utils.WriteJson(w, coverage)
+}
+
+// Annotations returns the timeline markers (incidents and deployments) overlapping the given time range
+// for the whole project or, if the app parameter is set, for a single application.
+func (api *Api) Annotations(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+	now := timeseries.Now()
+	q := r.URL.Query()
+	from := utils.ParseTimeFromUrl(now, q, "from", now.Add(-timeseries.Hour))
+	to := utils.ParseTimeFromUrl(now, q, "to", now)
+
+	var incidents []db.Incident
+	var deployments []db.Deployment
+	var err error
+	if app := q.Get("app"); app != "" {
+		var appId model.ApplicationId
+		if appId, err = model.NewApplicationIdFromString(app); err != nil {
+			klog.Warningf("invalid application_id %s: %s ", app, err)
+			httpError(w, "invalid application_id: "+app, http.StatusBadRequest)
+			return
+		}
+		if incidents, err = api.db.GetIncidentsByApp(projectId, appId, from, to); err == nil {
+			deployments, err = api.db.GetDeploymentsByApp(projectId, appId, from, to)
+		}
+	} else {
+		if incidents, err = api.db.GetIncidents(projectId, from, to); err == nil {
+			deployments, err = api.db.GetDeployments(projectId, from, to)
+		}
+	}
 	if err != nil {
 		klog.Errorln(err)
-		http.Error(w, "", http.StatusInternalServerError)
+		httpError(w, "", http.StatusInternalServerError)
 		return
 	}
-	utils.WriteJson(w, views.Status(project, cacheStatus, world))
+	utils.WriteJson(w, views.Annotations(incidents, deployments, now))
 }
 
 func (api *Api) Overview(w http.ResponseWriter, r *http.Request) {
 	world, project, err := api.loadWorldByRequest(r)
 	if err != nil {
-		klog.Errorln(err)
-		http.Error(w, "", http.StatusInternalServerError)
+		worldLoadFailed(w, err)
 		return
 	}
 	if world == nil {
 		return
 	}
-	utils.WriteJson(w, views.Overview(world, project))
+	q := r.URL.Query()
+	world.FilterApplications(utils.ParseLabelsFromUrl(q, "labels"))
+
+	// with ?status_window=5m, the statuses reflect the last 5 minutes of the range rather than the whole range
+	var current *model.World
+	if window := utils.ParseDurationFromUrl(q, "status_window", 0); window > 0 && world.Ctx.To.Add(-window).After(world.Ctx.From) {
+		current, err = api.loadWorld(r.Context(), project, world.Ctx.To.Add(-window), world.Ctx.To)
+		if err != nil {
+			worldLoadFailed(w, err)
+			return
+		}
+	}
+	openIncidents, err := api.db.GetOpenIncidents(project.Id)
+	if err != nil {
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	tier := model.ApplicationTier(0)
+	if t := q.Get("tier"); t != "" {
+		v, err := strconv.Atoi(t)
+		if err != nil || !model.ApplicationTier(v).IsValid() {
+			httpError(w, "invalid tier", http.StatusBadRequest)
+			return
+		}
+		tier = model.ApplicationTier(v)
+	}
+	groupBy := overview.GroupBy(q.Get("group_by"))
+	if !groupBy.IsValid() {
+		httpError(w, "invalid group_by, should be one of: namespace, category", http.StatusBadRequest)
+		return
+	}
+	utils.WriteJson(w, views.Overview(world, current, project, openIncidents, tier, groupBy))
 }
 
 func (api *Api) Search(w http.ResponseWriter, r *http.Request) {
 	world, _, err := api.loadWorldByRequest(r)
 	if err != nil {
-		klog.Errorln(err)
-		http.Error(w, "", http.StatusInternalServerError)
+		worldLoadFailed(w, err)
 		return
 	}
 	if world == nil {
 		return
 	}
+	world.FilterApplications(utils.ParseLabelsFromUrl(r.URL.Query(), "labels"))
 	utils.WriteJson(w, views.Search(world))
 }
 
 func (api *Api) Configs(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	projectId := db.ProjectId(vars["project"])
+	project, err := api.db.GetProject(projectId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			httpError(w, "", http.StatusNotFound)
+			return
+		}
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	checkConfigs, err := api.db.GetCheckConfigs(projectId)
+	if err != nil {
+		klog.Errorln("failed to get check configs:", err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	objective := func(checkId model.CheckId, appId model.ApplicationId) float64 {
+		v, _ := project.Settings.SLODefaults.Objective(checkId, api.applicationCategory(r.Context(), project, appId))
+		return v
+	}
+	utils.WriteJson(w, views.Configs(checkConfigs, objective))
+}
+
+func (api *Api) LintCheckConfigs(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+	checkConfigs, err := api.db.GetCheckConfigs(projectId)
+	if err != nil {
+		klog.Errorln("failed to get check configs:", err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	issues := model.LintCheckConfigs(checkConfigs)
+	if issues == nil {
+		issues = []model.LintIssue{}
+	}
+	utils.WriteJson(w, issues)
+}
+
+func (api *Api) ListCheckConfigs(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+	checkId := model.CheckId(r.URL.Query().Get("check"))
+	scope := model.CheckConfigScope(r.URL.Query().Get("scope"))
+	if scope != "" && !scope.IsValid() {
+		httpError(w, "invalid scope", http.StatusBadRequest)
+		return
+	}
 	checkConfigs, err := api.db.GetCheckConfigs(projectId)
 	if err != nil {
 		klog.Errorln("failed to get check configs:", err)
-		http.Error(w, "", http.StatusInternalServerError)
+		httpError(w, "", http.StatusInternalServerError)
 		return
 	}
-	utils.WriteJson(w, views.Configs(checkConfigs))
+	entries := model.ListCheckConfigs(checkConfigs, checkId, scope)
+	if entries == nil {
+		entries = []model.CheckConfigEntry{}
+	}
+	utils.WriteJson(w, entries)
 }
 
 func (api *Api) Categories(w http.ResponseWriter, r *http.Request) {
@@ -245,14 +549,13 @@ func (api *Api) Categories(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		var form ApplicationCategoryForm
-		if err := ReadAndValidate(r, &form); err != nil {
-			klog.Warningln("bad request:", err)
-			http.Error(w, "Invalid name or patterns", http.StatusBadRequest)
+		if err := api.readAndValidate(w, r, &form); err != nil {
+			badRequest(w, err, "Invalid name or patterns")
 			return
 		}
-		if err := api.db.SaveApplicationCategory(projectId, form.Name, form.NewName, form.customPatterns); err != nil {
+		if err := api.db.SaveApplicationCategory(projectId, form.Name, form.NewName, form.customPatterns, api.actor(r)); err != nil {
 			klog.Errorln("failed to save:", err)
-			http.Error(w, "", http.StatusInternalServerError)
+			httpError(w, "", http.StatusInternalServerError)
 			return
 		}
 		return
@@ -261,29 +564,1332 @@ func (api *Api) Categories(w http.ResponseWriter, r *http.Request) {
 	p, err := api.db.GetProject(projectId)
 	if err != nil {
 		klog.Errorln(err)
-		http.Error(w, "", http.StatusInternalServerError)
+		httpError(w, "", http.StatusInternalServerError)
 		return
 	}
 	utils.WriteJson(w, views.Categories(p))
 }
 
-func (api *Api) Integrations(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	projectId := db.ProjectId(vars["project"])
+// CategoriesPreview shows how the applications would be categorized with the candidate patterns without saving them.
+func (api *Api) CategoriesPreview(w http.ResponseWriter, r *http.Request) {
+	var form ApplicationCategoriesPreviewForm
+	if err := api.readAndValidate(w, r, &form); err != nil {
+		badRequest(w, err, "")
+		return
+	}
+	world, project, err := api.loadWorldByRequest(r)
+	if err != nil {
+		worldLoadFailed(w, err)
+		return
+	}
+	if world == nil {
+		return
+	}
+	utils.WriteJson(w, views.CategoriesPreview(world, project, form.patterns))
+}
+
+func (api *Api) CategoryLabel(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+	if api.readOnly {
+		return
+	}
+	var form ApplicationCategoryLabelForm
+	if err := api.readAndValidate(w, r, &form); err != nil {
+		badRequest(w, err, "")
+		return
+	}
+	if err := api.db.SaveApplicationCategoryLabel(projectId, form.Label, api.actor(r)); err != nil {
+		klog.Errorln("failed to save:", err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (api *Api) GoldenSignals(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+
+	if r.Method == http.MethodPost {
+		if api.readOnly {
+			return
+		}
+		var form GoldenSignalsForm
+		if err := api.readAndValidate(w, r, &form); err != nil {
+			badRequest(w, err, "")
+			return
+		}
+		if err := api.db.SaveGoldenSignals(projectId, form.Kind, form.Signals, api.actor(r)); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				httpError(w, "", http.StatusNotFound)
+				return
+			}
+			klog.Errorln("failed to save:", err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	p, err := api.db.GetProject(projectId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			httpError(w, "", http.StatusNotFound)
+			return
+		}
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJson(w, views.GoldenSignals(p))
+}
+
+func (api *Api) ApplicationIdentity(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+	project, err := api.db.GetProject(projectId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			httpError(w, "", http.StatusNotFound)
+			return
+		}
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		if api.readOnly {
+			return
+		}
+		var form ApplicationIdentityForm
+		if err := api.readAndValidate(w, r, &form); err != nil {
+			badRequest(w, err, "")
+			return
+		}
+		if !form.IsEmpty() {
+			now := timeseries.Now()
+			world, err := api.loadWorld(r.Context(), project, now.Add(-timeseries.Hour), now)
+			if err != nil {
+				worldLoadFailed(w, err)
+				return
+			}
+			if world == nil {
+				httpError(w, "No data to check the labels against, try again later", http.StatusBadRequest)
+				return
+			}
+			if missing := form.MissingLabels(world); len(missing) > 0 {
+				var errs ValidationErrors
+				for _, l := range missing {
+					errs.Add("labels", "no pod has the label %q", l)
+				}
+				badRequest(w, errs, "")
+				return
+			}
+		}
+		if err := api.db.SaveApplicationIdentity(projectId, &form.ApplicationIdentity, api.actor(r)); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				httpError(w, "", http.StatusNotFound)
+				return
+			}
+			klog.Errorln("failed to save:", err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	identity := project.Settings.ApplicationIdentity
+	if identity == nil {
+		identity = &model.ApplicationIdentity{Labels: []string{}}
+	}
+	utils.WriteJson(w, identity)
+}
+
+func (api *Api) ApplicationExclusions(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+
+	if r.Method == http.MethodPost {
+		if api.readOnly {
+			return
+		}
+		var form ApplicationExclusionsForm
+		if err := api.readAndValidate(w, r, &form); err != nil {
+			badRequest(w, err, "")
+			return
+		}
+		if err := api.db.SaveApplicationExclusions(projectId, &form.ApplicationExclusions, api.actor(r)); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				httpError(w, "", http.StatusNotFound)
+				return
+			}
+			klog.Errorln("failed to save:", err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	project, err := api.db.GetProject(projectId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			httpError(w, "", http.StatusNotFound)
+			return
+		}
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	exclusions := project.Settings.ApplicationExclusions
+	if exclusions == nil {
+		exclusions = &model.ApplicationExclusions{Patterns: []string{}}
+	}
+	utils.WriteJson(w, exclusions)
+}
+
+func (api *Api) ApplicationTiers(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+
+	if r.Method == http.MethodPost {
+		if api.readOnly {
+			return
+		}
+		var form ApplicationTiersForm
+		if err := api.readAndValidate(w, r, &form); err != nil {
+			badRequest(w, err, "")
+			return
+		}
+		if err := api.db.SaveApplicationTiers(projectId, &form.ApplicationTiers, api.actor(r)); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				httpError(w, "", http.StatusNotFound)
+				return
+			}
+			klog.Errorln("failed to save:", err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	project, err := api.db.GetProject(projectId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			httpError(w, "", http.StatusNotFound)
+			return
+		}
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	tiers := project.Settings.ApplicationTiers
+	if tiers == nil {
+		tiers = &model.ApplicationTiers{}
+	}
+	utils.WriteJson(w, tiers)
+}
+
+func (api *Api) HealthRollup(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+
+	if r.Method == http.MethodPost {
+		if api.readOnly {
+			return
+		}
+		var form HealthRollupForm
+		if err := api.readAndValidate(w, r, &form); err != nil {
+			badRequest(w, err, "")
+			return
+		}
+		var rollup *model.HealthRollup
+		if form.Function != model.HealthRollupWorst || len(form.Weights) > 0 {
+			rollup = &form.HealthRollup
+		}
+		if err := api.db.SaveHealthRollup(projectId, rollup, api.actor(r)); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				httpError(w, "", http.StatusNotFound)
+				return
+			}
+			klog.Errorln("failed to save:", err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	project, err := api.db.GetProject(projectId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			httpError(w, "", http.StatusNotFound)
+			return
+		}
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	rollup := project.Settings.HealthRollup
+	if rollup == nil {
+		rollup = &model.HealthRollup{Function: model.HealthRollupWorst}
+	}
+	utils.WriteJson(w, rollup)
+}
+
+// SavedViews lists the saved views of the project (only the ones of the scope if the scope parameter is set),
+// saves a view replacing the one with the same scope and name, or deletes the view with the given scope and name.
+func (api *Api) SavedViews(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+	q := r.URL.Query()
+
+	switch r.Method {
+	case http.MethodPost:
+		if api.readOnly {
+			return
+		}
+		var form SavedViewForm
+		if err := api.readAndValidate(w, r, &form); err != nil {
+			badRequest(w, err, "")
+			return
+		}
+		if err := api.db.SaveView(projectId, form.SavedView, api.actor(r)); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				httpError(w, "", http.StatusNotFound)
+				return
+			}
+			klog.Errorln("failed to save:", err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		return
+
+	case http.MethodDelete:
+		if api.readOnly {
+			return
+		}
+		if err := api.db.DeleteView(projectId, q.Get("scope"), q.Get("name"), api.actor(r)); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				httpError(w, "view not found", http.StatusNotFound)
+				return
+			}
+			klog.Errorln("failed to delete:", err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	project, err := api.db.GetProject(projectId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			httpError(w, "", http.StatusNotFound)
+			return
+		}
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJson(w, project.Settings.GetSavedViews(q.Get("scope")))
+}
+
+func (api *Api) SeverityLabels(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+
+	if r.Method == http.MethodPost {
+		if api.readOnly {
+			return
+		}
+		var form SeverityLabelsForm
+		if err := api.readAndValidate(w, r, &form); err != nil {
+			badRequest(w, err, "")
+			return
+		}
+		if err := api.db.SaveSeverityLabels(projectId, form.Labels, api.actor(r)); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				httpError(w, "", http.StatusNotFound)
+				return
+			}
+			klog.Errorln("failed to save:", err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	p, err := api.db.GetProject(projectId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			httpError(w, "", http.StatusNotFound)
+			return
+		}
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	res := struct {
+		Custom  model.SeverityLabels `json:"custom"`
+		Labels  model.SeverityLabels `json:"labels"`
+		Builtin model.SeverityLabels `json:"builtin"`
+	}{
+		Custom:  p.Settings.SeverityLabels,
+		Labels:  model.GetSeverityLabels(p.Settings.SeverityLabels),
+		Builtin: model.BuiltinSeverityLabels,
+	}
+	utils.WriteJson(w, res)
+}
+
+func (api *Api) SLODefaults(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+
+	if r.Method == http.MethodPost {
+		if api.readOnly {
+			return
+		}
+		var form SLODefaultsForm
+		if err := api.readAndValidate(w, r, &form); err != nil {
+			badRequest(w, err, "")
+			return
+		}
+		if err := api.db.SaveSLODefaults(projectId, form.Get(), api.actor(r)); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				httpError(w, "", http.StatusNotFound)
+				return
+			}
+			klog.Errorln("failed to save:", err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	p, err := api.db.GetProject(projectId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			httpError(w, "", http.StatusNotFound)
+			return
+		}
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	res := struct {
+		Custom  *db.SLODefaults `json:"custom"`
+		Builtin db.SLODefaults  `json:"builtin"`
+	}{
+		Custom: p.Settings.SLODefaults,
+		Builtin: db.SLODefaults{
+			AvailabilityObjective: model.Checks.SLOAvailability.DefaultThreshold,
+			LatencyObjective:      model.Checks.SLOLatency.DefaultThreshold,
+		},
+	}
+	utils.WriteJson(w, res)
+}
+
+func (api *Api) LogsLink(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+
+	if r.Method == http.MethodPost {
+		if api.readOnly {
+			return
+		}
+		var form LogsLinkForm
+		if err := api.readAndValidate(w, r, &form); err != nil {
+			badRequest(w, err, "")
+			return
+		}
+		if err := api.db.SaveLogsLink(projectId, form.Template, api.actor(r)); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				httpError(w, "", http.StatusNotFound)
+				return
+			}
+			klog.Errorln("failed to save:", err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	p, err := api.db.GetProject(projectId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			httpError(w, "", http.StatusNotFound)
+			return
+		}
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	res := struct {
+		Template     model.LogsLinkTemplate `json:"template"`
+		Placeholders []string               `json:"placeholders"`
+	}{
+		Template:     p.Settings.LogsLink,
+		Placeholders: model.LogsLinkPlaceholders,
+	}
+	utils.WriteJson(w, res)
+}
+
+func (api *Api) MetricThresholds(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+
+	if r.Method == http.MethodPost {
+		if api.readOnly {
+			return
+		}
+		var form MetricThresholdsForm
+		if err := api.readAndValidate(w, r, &form); err != nil {
+			badRequest(w, err, "")
+			return
+		}
+		if err := api.db.SaveMetricThresholds(projectId, form.Thresholds, api.actor(r)); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				httpError(w, "", http.StatusNotFound)
+				return
+			}
+			klog.Errorln("failed to save:", err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	p, err := api.db.GetProject(projectId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			httpError(w, "", http.StatusNotFound)
+			return
+		}
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	res := struct {
+		Custom     model.MetricThresholds `json:"custom"`
+		Thresholds model.MetricThresholds `json:"thresholds"`
+		Builtin    model.MetricThresholds `json:"builtin"`
+	}{
+		Custom:     p.Settings.MetricThresholds,
+		Thresholds: model.GetMetricThresholds(p.Settings.MetricThresholds),
+		Builtin:    model.BuiltinMetricThresholds,
+	}
+	utils.WriteJson(w, res)
+}
+
+func (api *Api) Integrations(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectId := db.ProjectId(vars["project"])
+
+	if r.Method == http.MethodPost {
+		if api.readOnly {
+			return
+		}
+		var form IntegrationsForm
+		if err := api.readAndValidate(w, r, &form); err != nil {
+			badRequest(w, err, "Invalid base url")
+			return
+		}
+		if err := api.db.SaveIntegrationsBaseUrl(projectId, form.BaseUrl); err != nil {
+			klog.Errorln("failed to save:", err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	p, err := api.db.GetProject(projectId)
+	if err != nil {
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	api.writeSettings(w, views.Integrations(r.Context(), p, api.alerts))
+}
+
+func (api *Api) IntegrationsSlack(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectId := db.ProjectId(vars["project"])
+
+	var form IntegrationsSlackForm
+
+	if r.Method == http.MethodPost {
+		if api.readOnly {
+			return
+		}
+		p, err := api.db.GetProjectForUpdate(projectId)
+		if err != nil {
+			klog.Errorln(err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		if err := api.readAndValidateSettings(w, r, &form, newIntegrationsSlackForm(p.Settings.Integrations.Slack)); err != nil {
+			badRequest(w, err, "")
+			return
+		}
+		ok, err := alerts.NewSlack(form.Token).IsChannelAvailable(r.Context(), form.Channel)
+		if err != nil {
+			httpError(w, "Invalid token", http.StatusBadRequest)
+			return
+		}
+		if !ok {
+			httpError(w, "Channel is not available", http.StatusBadRequest)
+			return
+		}
+		if err := api.db.SaveIntegrationsSlack(projectId, &db.IntegrationSlack{
+			Token:                   form.Token,
+			DefaultChannel:          form.Channel,
+			Enabled:                 form.Enabled,
+			Timeout:                 form.Timeout,
+			BreakerFailureThreshold: form.BreakerFailureThreshold,
+			BreakerCooldown:         form.BreakerCooldown,
+		}); err != nil {
+			klog.Errorln("failed to save:", err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		api.alerts.ResetIntegration(projectId, alerts.IntegrationSlack)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		if api.readOnly {
+			return
+		}
+		if err := api.db.SaveIntegrationsSlack(projectId, nil); err != nil {
+			klog.Errorln("failed to delete:", err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		api.alerts.ResetIntegration(projectId, alerts.IntegrationSlack)
+		return
+	}
+
+	p, err := api.db.GetProject(projectId)
+	if err != nil {
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	api.writeSettings(w, newIntegrationsSlackForm(p.Settings.Integrations.Slack))
+}
+
+func (api *Api) IntegrationsOTLP(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectId := db.ProjectId(vars["project"])
+
+	var form IntegrationsOTLPForm
+
+	if r.Method == http.MethodPost {
+		if api.readOnly {
+			return
+		}
+		p, err := api.db.GetProjectForUpdate(projectId)
+		if err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				httpError(w, "", http.StatusNotFound)
+				return
+			}
+			klog.Errorln(err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		if err := api.readAndValidateSettings(w, r, &form, newIntegrationsOTLPForm(p.Settings.Integrations.OTLP)); err != nil {
+			badRequest(w, err, "")
+			return
+		}
+		cfg := &db.IntegrationOTLP{
+			Endpoint: form.Endpoint,
+			Headers:  form.Headers,
+			Interval: form.Interval,
+			Enabled:  form.Enabled,
+			Timeout:  form.Timeout,
+		}
+		// an empty request checks that the collector is reachable and accepts the credentials
+		ctx, cancel := context.WithTimeout(r.Context(), cfg.GetTimeout().ToStandard())
+		defer cancel()
+		if err := alerts.NewOTLP(cfg).Export(ctx, &alerts.OTLPMetrics{}); err != nil {
+			httpError(w, "Collector is not available: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := api.db.SaveIntegrationsOTLP(projectId, cfg, api.actor(r)); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				httpError(w, "", http.StatusNotFound)
+				return
+			}
+			klog.Errorln("failed to save:", err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		if api.readOnly {
+			return
+		}
+		if err := api.db.SaveIntegrationsOTLP(projectId, nil, api.actor(r)); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				httpError(w, "", http.StatusNotFound)
+				return
+			}
+			klog.Errorln("failed to delete:", err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	p, err := api.db.GetProject(projectId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			httpError(w, "", http.StatusNotFound)
+			return
+		}
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	api.writeSettings(w, newIntegrationsOTLPForm(p.Settings.Integrations.OTLP))
+}
+
+func (api *Api) IntegrationsQuietHours(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+
+	var form QuietHoursForm
+
+	if r.Method == http.MethodPost {
+		if api.readOnly {
+			return
+		}
+		if err := api.readAndValidate(w, r, &form); err != nil {
+			badRequest(w, err, "")
+			return
+		}
+		if err := api.db.SaveQuietHours(projectId, &form.QuietHours, api.actor(r)); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				httpError(w, "", http.StatusNotFound)
+				return
+			}
+			klog.Errorln("failed to save:", err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		if api.readOnly {
+			return
+		}
+		if err := api.db.SaveQuietHours(projectId, nil, api.actor(r)); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				httpError(w, "", http.StatusNotFound)
+				return
+			}
+			klog.Errorln("failed to delete:", err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	p, err := api.db.GetProject(projectId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			httpError(w, "", http.StatusNotFound)
+			return
+		}
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	if qh := p.Settings.Integrations.QuietHours; qh != nil {
+		form.QuietHours = *qh
+	} else {
+		form.Start, form.End, form.Timezone = "22:00", "07:00", "UTC"
+	}
+	api.writeSettings(w, &form)
+}
+
+// NotificationRoutePreview returns where a notification about a hypothetical incident of the application
+// with the given severity (warning or critical) would be dispatched at the given time (now by default), or why it would
+// be suppressed. If resolved is true, the notification is about the resolution of such an incident. The snoozes of individual incidents and the suppression
+// of the incidents caused by upstream ones aren't taken into account, as they depend on the actual incidents.
+func (api *Api) NotificationRoutePreview(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+	q := r.URL.Query()
+	appId, err := model.NewApplicationIdFromString(q.Get("app"))
+	if err != nil {
+		httpError(w, "invalid application_id: "+q.Get("app"), http.StatusBadRequest)
+		return
+	}
+	var severity model.Status
+	switch q.Get("severity") {
+	case "warning":
+		severity = model.WARNING
+	case "critical", "":
+		severity = model.CRITICAL
+	default:
+		httpError(w, "invalid severity, should be one of: warning, critical", http.StatusBadRequest)
+		return
+	}
+	resolved := q.Get("resolved") == "true"
+	now := timeseries.Now()
+	at := utils.ParseTimeFromUrl(now, q, "at", now)
+
+	p, err := api.db.GetProject(projectId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			httpError(w, "", http.StatusNotFound)
+			return
+		}
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJson(w, api.alerts.RouteIncident(p, appId, previewIncident(severity, resolved, at), at.ToStandard()))
+}
+
+// previewIncident returns a hypothetical incident as it's passed to the dispatch: resolved incidents keep their severity.
+func previewIncident(severity model.Status, resolved bool, at timeseries.Time) *db.Incident {
+	i := &db.Incident{Severity: severity, OpenedAt: at}
+	if resolved {
+		i.ResolvedAt = at
+	}
+	return i
+}
+
+func (api *Api) Prom(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+	project, err := api.db.GetProject(projectId)
+	if err != nil {
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	c, err := promClient(project)
+	if err != nil {
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	key, err := api.promProxyKey(w, r, projectId)
+	if err != nil {
+		badRequest(w, err, "")
+		return
+	}
+	if key == "" {
+		c.Proxy(r, w)
+		return
+	}
+	res, ok := api.promProxyShare.do(r.Context(), key, promProxyCanReuse(r), func() promProxyResult {
+		ctx, cancel := context.WithTimeout(context.Background(), promProxySharedCallTimeout)
+		defer cancel()
+		resp, body, err := c.ProxyRequest(ctx, r)
+		if err != nil {
+			return promProxyResult{err: err}
+		}
+		return promProxyResult{status: resp.StatusCode, header: resp.Header, body: body}
+	})
+	if !ok {
+		return
+	}
+	writePromProxyResult(w, res)
+}
+
+func (api *Api) PromQLInspect(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+	query := r.URL.Query().Get("query")
+	if query == "" {
+		httpError(w, "query is required", http.StatusBadRequest)
+		return
+	}
+	project, err := api.db.GetProject(projectId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			httpError(w, "", http.StatusNotFound)
+			return
+		}
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	c, err := promClient(project)
+	if err != nil {
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	now := timeseries.Now()
+	at := utils.ParseTimeFromUrl(now, r.URL.Query(), "time", now)
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	res, err := c.Inspect(ctx, query, at, project.Prometheus.RefreshInterval)
+	if err != nil {
+		klog.Warningln("failed to inspect query:", err)
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	utils.WriteJson(w, res)
+}
+
+// EvaluateSLO runs the queries of an SLO config against the project's Prometheus and returns the SLI,
+// the burn rates and the error budget over the requested range without saving the config.
+func (api *Api) EvaluateSLO(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+	var form SLOEvaluationForm
+	if err := api.readAndValidate(w, r, &form); err != nil {
+		badRequest(w, err, "")
+		return
+	}
+	project, err := api.db.GetProject(projectId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			httpError(w, "", http.StatusNotFound)
+			return
+		}
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	c, err := promClient(project)
+	if err != nil {
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	client := prom.WithReplicaDedup(prom.WithSelector(c, project.Prometheus.ExtraSelector), project.Prometheus.ReplicaLabel)
+
+	now := timeseries.Now()
+	q := r.URL.Query()
+	from := utils.ParseTimeFromUrl(now, q, "from", now.Add(-timeseries.Day))
+	to := utils.ParseTimeFromUrl(now, q, "to", now)
+	if !from.Before(to) {
+		httpError(w, "invalid time range", http.StatusBadRequest)
+		return
+	}
+	step := sloEvaluationStep(from, to, project.Prometheus.RefreshInterval)
+	from, to = from.Truncate(step), to.Truncate(step)
+
+	ctx, cancel := context.WithTimeout(r.Context(), sloEvaluationTimeout)
+	defer cancel()
+	if form.Availability != nil {
+		sli := constructor.LoadAvailabilitySLI(ctx, client, form.Availability.Configs[0], from, to, step)
+		utils.WriteJson(w, views.EvaluateAvailabilitySLO(sli, to))
+		return
+	}
+	sli := constructor.LoadLatencySLI(ctx, client, form.Latency.Configs[0], from, to, step)
+	utils.WriteJson(w, views.EvaluateLatencySLO(sli, to))
+}
+
+// AppSLOBurnRates returns the burn rates of the application's SLO over the windows of each alert rule as time series
+// (the last day by default), to see when and why the alerting thresholds were crossed.
+func (api *Api) AppSLOBurnRates(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectId := db.ProjectId(vars["project"])
+	id, err := model.NewApplicationIdFromString(vars["app"])
+	if err != nil {
+		klog.Warningf("invalid application_id %s: %s ", vars["app"], err)
+		httpError(w, "invalid application_id: "+vars["app"], http.StatusBadRequest)
+		return
+	}
+	q := r.URL.Query()
+	checkId := model.Checks.SLOAvailability.Id
+	if c := q.Get("check"); c != "" {
+		checkId = model.CheckId(c)
+	}
+	if checkId != model.Checks.SLOAvailability.Id && checkId != model.Checks.SLOLatency.Id {
+		httpError(w, "invalid check, should be one of: "+string(model.Checks.SLOAvailability.Id)+", "+string(model.Checks.SLOLatency.Id), http.StatusBadRequest)
+		return
+	}
+	project, err := api.db.GetProject(projectId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			httpError(w, "", http.StatusNotFound)
+			return
+		}
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	checkConfigs, err := api.db.GetCheckConfigs(projectId)
+	if err != nil {
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	now := timeseries.Now()
+	from := utils.ParseTimeFromUrl(now, q, "from", now.Add(-timeseries.Day))
+	to := utils.ParseTimeFromUrl(now, q, "to", now)
+	if !from.Before(to) {
+		httpError(w, "invalid time range", http.StatusBadRequest)
+		return
+	}
+	step := sloEvaluationStep(from.Add(-model.MaxAlertRuleWindow), to, project.Prometheus.RefreshInterval)
+	from, to = from.Truncate(step), to.Truncate(step)
+	pointsCount := int(to.Sub(from)/step) + 1
+	// the windows of the first points reach back beyond the range
+	loadFrom := from.Add(-model.MaxAlertRuleWindow)
+
+	client := prom.WithReplicaDedup(prom.WithSelector(api.cache.GetCacheClient(project), project.Prometheus.ExtraSelector), project.Prometheus.ReplicaLabel)
+	ctx, cancel := context.WithTimeout(r.Context(), sloEvaluationTimeout)
+	defer cancel()
+	if checkId == model.Checks.SLOAvailability.Id {
+		configs := checkConfigs.GetAvailability(id)
+		if len(configs) == 0 {
+			httpError(w, "SLO is not configured", http.StatusNotFound)
+			return
+		}
+		cfg := configs[0]
+		if cfg.ObjectivePercentage <= 0 {
+			cfg.ObjectivePercentage, _ = project.Settings.SLODefaults.Objective(checkId, api.applicationCategory(ctx, project, id))
+		}
+		sli := constructor.LoadAvailabilitySLI(ctx, client, cfg, loadFrom, to, step)
+		utils.WriteJson(w, views.AvailabilityBurnRates(sli, from, pointsCount, step))
+		return
+	}
+	configs := checkConfigs.GetLatency(id)
+	if len(configs) == 0 {
+		httpError(w, "SLO is not configured", http.StatusNotFound)
+		return
+	}
+	cfg := configs[0]
+	if cfg.ObjectivePercentage <= 0 {
+		cfg.ObjectivePercentage, _ = project.Settings.SLODefaults.Objective(checkId, api.applicationCategory(ctx, project, id))
+	}
+	sli := constructor.LoadLatencySLI(ctx, client, cfg, loadFrom, to, step)
+	utils.WriteJson(w, views.LatencyBurnRates(sli, from, pointsCount, step))
+}
+
+// sloEvaluationStep returns the step keeping the number of points of the evaluated range within sloEvaluationMaxPoints.
+func sloEvaluationStep(from, to timeseries.Time, refreshInterval timeseries.Duration) timeseries.Duration {
+	step := refreshInterval
+	if n := to.Sub(from) / step; n > sloEvaluationMaxPoints {
+		step *= (n + sloEvaluationMaxPoints - 1) / sloEvaluationMaxPoints
+	}
+	return step
+}
+
+func promClient(project *db.Project) (*prom.ApiClient, error) {
+	p := project.Prometheus
+	user, password := "", ""
+	if p.BasicAuth != nil {
+		user, password = p.BasicAuth.User, p.BasicAuth.Password
+	}
+	tlsConfig, err := p.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	return prom.NewApiClient(p.Url, user, password, tlsConfig, p.GetQueryTimeout().ToStandard())
+}
+
+func (api *Api) App(w http.ResponseWriter, r *http.Request) {
+	id, err := model.NewApplicationIdFromString(mux.Vars(r)["app"])
+	if err != nil {
+		klog.Warningf("invalid application_id %s: %s ", mux.Vars(r)["app"], err)
+		httpError(w, "invalid application_id: "+mux.Vars(r)["app"], http.StatusBadRequest)
+		return
+	}
+	world, project, err := api.loadWorldByRequest(r)
+	if err != nil {
+		worldLoadFailed(w, err)
+		return
+	}
+	if world == nil {
+		return
+	}
+	app := world.GetApplication(id)
+	if app == nil {
+		klog.Warningln("application not found:", id)
+		httpError(w, "Application not found", http.StatusNotFound)
+		return
+	}
+	incidents, err := api.db.GetIncidentsByApp(project.Id, app.Id, world.Ctx.From, world.Ctx.To)
+	if err != nil {
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	deployments, err := api.db.GetDeploymentsByApp(project.Id, app.Id, world.Ctx.From, world.Ctx.To)
+	if err != nil {
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	var shifted *model.World
+	offset := utils.ParseDurationFromUrl(r.URL.Query(), "offset", 0).Truncate(world.Ctx.Step)
+	if offset > 0 {
+		if shifted, err = api.loadShiftedWorld(r.Context(), project, world, offset); err != nil {
+			worldLoadFailed(w, err)
+			return
+		}
+	}
+	goldenSignals := model.GetGoldenSignals(app.Id.Kind, project.Settings.GoldenSignals)
+	severityLabels := model.GetSeverityLabels(project.Settings.SeverityLabels)
+	utils.WriteJson(w, views.Application(world, shifted, offset, app, incidents, deployments, goldenSignals, severityLabels, project.Settings.LogsLink))
+}
+
+func (api *Api) AppReplicas(w http.ResponseWriter, r *http.Request) {
+	id, err := model.NewApplicationIdFromString(mux.Vars(r)["app"])
+	if err != nil {
+		klog.Warningf("invalid application_id %s: %s ", mux.Vars(r)["app"], err)
+		httpError(w, "invalid application_id: "+mux.Vars(r)["app"], http.StatusBadRequest)
+		return
+	}
+	world, _, err := api.loadWorldByRequest(r)
+	if err != nil {
+		worldLoadFailed(w, err)
+		return
+	}
+	if world == nil {
+		return
+	}
+	app := world.GetApplication(id)
+	if app == nil {
+		klog.Warningln("application not found:", id)
+		httpError(w, "Application not found", http.StatusNotFound)
+		return
+	}
+	utils.WriteJson(w, views.Replicas(app))
+}
+
+func (api *Api) AppGrafanaDashboard(w http.ResponseWriter, r *http.Request) {
+	id, err := model.NewApplicationIdFromString(mux.Vars(r)["app"])
+	if err != nil {
+		klog.Warningf("invalid application_id %s: %s ", mux.Vars(r)["app"], err)
+		httpError(w, "invalid application_id: "+mux.Vars(r)["app"], http.StatusBadRequest)
+		return
+	}
+	world, project, err := api.loadWorldByRequest(r)
+	if err != nil {
+		worldLoadFailed(w, err)
+		return
+	}
+	if world == nil {
+		return
+	}
+	app := world.GetApplication(id)
+	if app == nil {
+		klog.Warningln("application not found:", id)
+		httpError(w, "Application not found", http.StatusNotFound)
+		return
+	}
+	goldenSignals := model.GetGoldenSignals(app.Id.Kind, project.Settings.GoldenSignals)
+	if api.maskSecrets {
+		utils.MaskSecretFields(project) // the dashboard mentions the Prometheus URL
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.json"`, project.Name, app.Id.Name))
+	utils.WriteJson(w, views.GrafanaDashboard(project, app, goldenSignals))
+}
+
+// AppSLI returns only the availability SLI of the application, e.g., for embedding into a status page.
+func (api *Api) AppSLI(w http.ResponseWriter, r *http.Request) {
+	id, err := model.NewApplicationIdFromString(mux.Vars(r)["app"])
+	if err != nil {
+		klog.Warningf("invalid application_id %s: %s ", mux.Vars(r)["app"], err)
+		httpError(w, "invalid application_id: "+mux.Vars(r)["app"], http.StatusBadRequest)
+		return
+	}
+	world, _, err := api.loadWorldByRequest(r)
+	if err != nil {
+		worldLoadFailed(w, err)
+		return
+	}
+	if world == nil {
+		return
+	}
+	app := world.GetApplication(id)
+	if app == nil {
+		klog.Warningln("application not found:", id)
+		httpError(w, "Application not found", http.StatusNotFound)
+		return
+	}
+	points, _ := strconv.Atoi(r.URL.Query().Get("points"))
+	utils.WriteJson(w, views.SLI(app, points))
+}
+
+func (api *Api) AppTopTalkers(w http.ResponseWriter, r *http.Request) {
+	id, err := model.NewApplicationIdFromString(mux.Vars(r)["app"])
+	if err != nil {
+		klog.Warningf("invalid application_id %s: %s ", mux.Vars(r)["app"], err)
+		httpError(w, "invalid application_id: "+mux.Vars(r)["app"], http.StatusBadRequest)
+		return
+	}
+	limit, ascending, err := parseTopTalkersParams(r)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	world, _, err := api.loadWorldByRequestWithTraffic(r, true)
+	if err != nil {
+		worldLoadFailed(w, err)
+		return
+	}
+	if world == nil {
+		return
+	}
+	app := world.GetApplication(id)
+	if app == nil {
+		klog.Warningln("application not found:", id)
+		httpError(w, "Application not found", http.StatusNotFound)
+		return
+	}
+	utils.WriteJson(w, views.AppTopTalkers(app, limit, ascending))
+}
+
+func (api *Api) Incident(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectId := db.ProjectId(vars["project"])
+	key := vars["incident"]
+	now := timeseries.Now()
+
+	if r.Method == http.MethodPost {
+		if api.readOnly {
+			return
+		}
+		var form IncidentForm
+		if err := api.readAndValidate(w, r, &form); err != nil {
+			badRequest(w, err, "")
+			return
+		}
+		var err error
+		switch form.Action {
+		case "acknowledge":
+			err = api.db.AcknowledgeIncident(projectId, key, now)
+		case "snooze":
+			err = api.db.SnoozeIncident(projectId, key, now.Add(form.Duration))
+		case "unsnooze":
+			err = api.db.SnoozeIncident(projectId, key, 0)
+		}
+		if err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				httpError(w, "Incident not found", http.StatusNotFound)
+				return
+			}
+			klog.Errorln("failed to update incident:", err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	project, err := api.db.GetProject(projectId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			klog.Warningln("project not found:", projectId)
+			httpError(w, "", http.StatusNotFound)
+			return
+		}
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	incident, err := api.db.GetIncidentByKey(projectId, key)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			httpError(w, "Incident not found", http.StatusNotFound)
+			return
+		}
+		klog.Errorln("failed to get incident:", err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	from, to := incidentWindow(incident, now)
+	world, err := api.loadWorld(r.Context(), project, from, to)
+	if err != nil {
+		worldLoadFailed(w, err)
+		return
+	}
+	if world == nil {
+		return
+	}
+	deployments, err := api.db.GetDeploymentsByApp(projectId, incident.ApplicationId, from, to)
+	if err != nil {
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	var causedBy *db.Incident
+	if incident.CausedBy != "" {
+		causedBy, err = api.db.GetIncidentByKey(projectId, incident.CausedBy)
+		if err != nil && !errors.Is(err, db.ErrNotFound) {
+			klog.Errorln(err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+	}
+	dependents, err := api.db.GetIncidentsCausedBy(projectId, incident.Key)
+	if err != nil {
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJson(w, views.Incident(world, incident, causedBy, dependents, deployments, now))
+}
+
+func (api *Api) Escalation(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+
+	if r.Method == http.MethodPost {
+		if api.readOnly {
+			return
+		}
+		var form EscalationForm
+		if err := api.readAndValidate(w, r, &form); err != nil {
+			badRequest(w, err, "")
+			return
+		}
+		var policy *db.EscalationPolicy
+		if form.WarningToCriticalAfter > 0 {
+			policy = &form.EscalationPolicy
+		}
+		if err := api.db.SaveEscalationPolicy(projectId, policy); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				httpError(w, "", http.StatusNotFound)
+				return
+			}
+			klog.Errorln("failed to save:", err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	p, err := api.db.GetProject(projectId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			httpError(w, "", http.StatusNotFound)
+			return
+		}
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	var form EscalationForm
+	if p.Settings.Escalation != nil {
+		form.EscalationPolicy = *p.Settings.Escalation
+	}
+	utils.WriteJson(w, form)
+}
+
+func (api *Api) Repeat(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
 
 	if r.Method == http.MethodPost {
 		if api.readOnly {
 			return
 		}
-		var form IntegrationsForm
-		if err := ReadAndValidate(r, &form); err != nil {
-			klog.Warningln("bad request:", err)
-			http.Error(w, "Invalid base url", http.StatusBadRequest)
+		var form RepeatForm
+		if err := api.readAndValidate(w, r, &form); err != nil {
+			badRequest(w, err, "")
 			return
 		}
-		if err := api.db.SaveIntegrationsBaseUrl(projectId, form.BaseUrl); err != nil {
+		var policy *db.RepeatPolicy
+		if form.Interval > 0 {
+			policy = &form.RepeatPolicy
+		}
+		if err := api.db.SaveRepeatPolicy(projectId, policy, api.actor(r)); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				httpError(w, "", http.StatusNotFound)
+				return
+			}
 			klog.Errorln("failed to save:", err)
-			http.Error(w, "", http.StatusInternalServerError)
+			httpError(w, "", http.StatusInternalServerError)
 			return
 		}
 		return
@@ -291,57 +1897,44 @@ func (api *Api) Integrations(w http.ResponseWriter, r *http.Request) {
 
 	p, err := api.db.GetProject(projectId)
 	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			httpError(w, "", http.StatusNotFound)
+			return
+		}
 		klog.Errorln(err)
-		http.Error(w, "", http.StatusInternalServerError)
+		httpError(w, "", http.StatusInternalServerError)
 		return
 	}
-
-	utils.WriteJson(w, views.Integrations(r.Context(), p))
+	var form RepeatForm
+	if p.Settings.Repeat != nil {
+		form.RepeatPolicy = *p.Settings.Repeat
+	}
+	utils.WriteJson(w, form)
 }
 
-func (api *Api) IntegrationsSlack(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	projectId := db.ProjectId(vars["project"])
-
-	var form IntegrationsSlackForm
+func (api *Api) Flapping(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
 
 	if r.Method == http.MethodPost {
 		if api.readOnly {
 			return
 		}
-		if err := ReadAndValidate(r, &form); err != nil {
-			klog.Warningln("bad request:", err)
-			http.Error(w, "", http.StatusBadRequest)
-			return
-		}
-		ok, err := alerts.NewSlack(form.Token).IsChannelAvailable(r.Context(), form.Channel)
-		if err != nil {
-			http.Error(w, "Invalid token", http.StatusBadRequest)
+		var form FlappingForm
+		if err := api.readAndValidate(w, r, &form); err != nil {
+			badRequest(w, err, "")
 			return
 		}
-		if !ok {
-			http.Error(w, "Channel is not available", http.StatusBadRequest)
-			return
+		var policy *db.FlappingPolicy
+		if form.Cooldown > 0 || form.FireAfter > 0 || form.ClearAfter > 0 || form.GracePeriod > 0 {
+			policy = &form.FlappingPolicy
 		}
-		if err := api.db.SaveIntegrationsSlack(projectId, &db.IntegrationSlack{
-			Token:          form.Token,
-			DefaultChannel: form.Channel,
-			Enabled:        form.Enabled,
-		}); err != nil {
+		if err := api.db.SaveFlappingPolicy(projectId, policy); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				httpError(w, "", http.StatusNotFound)
+				return
+			}
 			klog.Errorln("failed to save:", err)
-			http.Error(w, "", http.StatusInternalServerError)
-			return
-		}
-		return
-	}
-
-	if r.Method == http.MethodDelete {
-		if api.readOnly {
-			return
-		}
-		if err := api.db.SaveIntegrationsSlack(projectId, nil); err != nil {
-			klog.Errorln("failed to delete:", err)
-			http.Error(w, "", http.StatusInternalServerError)
+			httpError(w, "", http.StatusInternalServerError)
 			return
 		}
 		return
@@ -349,56 +1942,73 @@ func (api *Api) IntegrationsSlack(w http.ResponseWriter, r *http.Request) {
 
 	p, err := api.db.GetProject(projectId)
 	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			httpError(w, "", http.StatusNotFound)
+			return
+		}
 		klog.Errorln(err)
-		http.Error(w, "", http.StatusInternalServerError)
+		httpError(w, "", http.StatusInternalServerError)
 		return
 	}
-	if cfg := p.Settings.Integrations.Slack; cfg != nil {
-		form.Token = cfg.Token
-		if api.readOnly {
-			form.Token = "<token>"
-		}
-		form.Channel = cfg.DefaultChannel
-		form.Enabled = cfg.Enabled
-	} else {
-		form.Enabled = true
+	var form FlappingForm
+	if p.Settings.Flapping != nil {
+		form.FlappingPolicy = *p.Settings.Flapping
 	}
 	utils.WriteJson(w, form)
 }
 
-func (api *Api) Prom(w http.ResponseWriter, r *http.Request) {
+func (api *Api) AuditLog(w http.ResponseWriter, r *http.Request) {
 	projectId := db.ProjectId(mux.Vars(r)["project"])
-	project, err := api.db.GetProject(projectId)
+	limit := 100
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	entries, err := api.db.GetAuditLog(projectId, limit)
 	if err != nil {
-		klog.Errorln(err)
-		http.Error(w, "", http.StatusInternalServerError)
+		klog.Errorln("failed to get audit log:", err)
+		httpError(w, "", http.StatusInternalServerError)
 		return
 	}
-	p := project.Prometheus
-	user, password := "", ""
-	if p.BasicAuth != nil {
-		user, password = p.BasicAuth.User, p.BasicAuth.Password
+	if entries == nil {
+		entries = []db.AuditLogEntry{}
 	}
-	c, err := prom.NewApiClient(p.Url, user, password, p.TlsSkipVerify)
-	if err != nil {
-		klog.Errorln(err)
-		http.Error(w, "", http.StatusInternalServerError)
+	if api.maskSecrets {
+		for i := range entries {
+			maskAuditLogEntry(&entries[i])
+		}
+	}
+	utils.WriteJson(w, entries)
+}
+
+// maskAuditLogEntry replaces the secrets of the project configuration recorded in the entry with placeholders
+// (the entries recorded by earlier versions may contain them).
+func maskAuditLogEntry(e *db.AuditLogEntry) {
+	if e.Object != "project" {
 		return
 	}
-	c.Proxy(r, w)
+	for _, raw := range []*json.RawMessage{&e.Old, &e.New} {
+		var v *db.ProjectAuditView
+		if err := json.Unmarshal(*raw, &v); err != nil || v == nil {
+			continue
+		}
+		utils.MaskSecretFields(v)
+		if data, err := json.Marshal(v); err == nil {
+			*raw = data
+		}
+	}
 }
 
-func (api *Api) App(w http.ResponseWriter, r *http.Request) {
-	id, err := model.NewApplicationIdFromString(mux.Vars(r)["app"])
+func (api *Api) Instance(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := model.NewApplicationIdFromString(vars["app"])
 	if err != nil {
-		klog.Warningf("invalid application_id %s: %s ", mux.Vars(r)["app"], err)
-		http.Error(w, "invalid application_id: "+mux.Vars(r)["app"], http.StatusBadRequest)
+		klog.Warningf("invalid application_id %s: %s ", vars["app"], err)
+		httpError(w, "invalid application_id: "+vars["app"], http.StatusBadRequest)
 		return
 	}
-	world, project, err := api.loadWorldByRequest(r)
+	world, _, err := api.loadWorldByRequest(r)
 	if err != nil {
-		klog.Errorln(err)
-		http.Error(w, "", http.StatusInternalServerError)
+		worldLoadFailed(w, err)
 		return
 	}
 	if world == nil {
@@ -407,16 +2017,61 @@ func (api *Api) App(w http.ResponseWriter, r *http.Request) {
 	app := world.GetApplication(id)
 	if app == nil {
 		klog.Warningln("application not found:", id)
-		http.Error(w, "Application not found", http.StatusNotFound)
+		httpError(w, "Application not found", http.StatusNotFound)
 		return
 	}
-	incidents, err := api.db.GetIncidentsByApp(project.Id, app.Id, world.Ctx.From, world.Ctx.To)
-	if err != nil {
-		klog.Errorln(err)
-		http.Error(w, "", http.StatusInternalServerError)
+	instance := app.GetInstance(vars["instance"])
+	if instance == nil {
+		klog.Warningf("instance not found: %s/%s", id, vars["instance"])
+		httpError(w, "Instance not found", http.StatusNotFound)
+		return
+	}
+	utils.WriteJson(w, views.Instance(world, app, instance))
+}
+
+func (api *Api) Deployments(w http.ResponseWriter, r *http.Request) {
+	if api.readOnly {
+		return
+	}
+	vars := mux.Vars(r)
+	projectId := db.ProjectId(vars["project"])
+
+	var deployments []db.Deployment
+	if appIdStr, ok := vars["app"]; ok {
+		appId, err := model.NewApplicationIdFromString(appIdStr)
+		if err != nil {
+			klog.Warningf("invalid application_id %s: %s ", appIdStr, err)
+			httpError(w, "invalid application_id: "+appIdStr, http.StatusBadRequest)
+			return
+		}
+		var form DeploymentForm
+		if err := api.readAndValidate(w, r, &form); err != nil {
+			badRequest(w, err, "")
+			return
+		}
+		deployments = append(deployments, db.Deployment{ApplicationId: appId, Version: form.Version, Timestamp: timeseries.Time(form.Timestamp)})
+	} else {
+		var form DeploymentsForm
+		if err := api.readAndValidate(w, r, &form); err != nil {
+			badRequest(w, err, "")
+			return
+		}
+		for _, d := range form.Deployments {
+			deployments = append(deployments, db.Deployment{ApplicationId: d.ApplicationId, Version: d.Version, Timestamp: timeseries.Time(d.Timestamp)})
+		}
+	}
+	if err := api.db.SaveDeployments(projectId, deployments); err != nil {
+		klog.Errorln("failed to save deployments:", err)
+		httpError(w, "", http.StatusInternalServerError)
 		return
 	}
-	utils.WriteJson(w, views.Application(world, app, incidents))
+}
+
+// InheritedObjective is the objective of an SLO check of an application which doesn't define its own.
+type InheritedObjective struct {
+	Objective float64                   `json:"objective"`
+	Source    db.SLOObjectiveSource     `json:"source"`
+	Category  model.ApplicationCategory `json:"category"`
 }
 
 func (api *Api) Check(w http.ResponseWriter, r *http.Request) {
@@ -425,7 +2080,7 @@ func (api *Api) Check(w http.ResponseWriter, r *http.Request) {
 	appId, err := model.NewApplicationIdFromString(vars["app"])
 	if err != nil {
 		klog.Warningf("invalid application_id %s: %s ", vars["app"], err)
-		http.Error(w, "invalid application_id: "+vars["app"], http.StatusBadRequest)
+		httpError(w, "invalid application_id: "+vars["app"], http.StatusBadRequest)
 		return
 	}
 	checkId := model.CheckId(vars["check"])
@@ -436,47 +2091,69 @@ func (api *Api) Check(w http.ResponseWriter, r *http.Request) {
 		project, err := api.db.GetProject(projectId)
 		if err != nil {
 			klog.Errorln("failed to get project:", err)
-			http.Error(w, "", http.StatusInternalServerError)
+			httpError(w, "", http.StatusInternalServerError)
 			return
 		}
 		checkConfigs, err := api.db.GetCheckConfigs(projectId)
 		if err != nil {
 			klog.Errorln("failed to get check configs:", err)
-			http.Error(w, "", http.StatusInternalServerError)
+			httpError(w, "", http.StatusInternalServerError)
 			return
 		}
 		res := struct {
 			Form         any               `json:"form"`
 			Integrations map[string]string `json:"integrations"`
+			// the objective applied if the config has no objective of its own (SLO checks only)
+			InheritedObjective *InheritedObjective `json:"inherited_objective,omitempty"`
 		}{
 			Integrations: map[string]string{},
 		}
 		if cfg := project.Settings.Integrations.Slack; cfg != nil && cfg.Enabled {
 			res.Integrations["slack"] = cfg.DefaultChannel
 		}
+		if checkId == model.Checks.SLOAvailability.Id || checkId == model.Checks.SLOLatency.Id {
+			category := api.applicationCategory(r.Context(), project, appId)
+			o := &InheritedObjective{Category: category}
+			o.Objective, o.Source = project.Settings.SLODefaults.Objective(checkId, category)
+			res.InheritedObjective = o
+		}
+		level := r.URL.Query().Get("level")
+		if level == "" {
+			level = CheckConfigLevelApplication
+		}
+		var errs ValidationErrors
+		validateCheckConfigLevel(&errs, level)
+		if len(errs) > 0 {
+			badRequest(w, errs, "")
+			return
+		}
+		id := checkConfigLevelId(appId, level)
 		switch checkId {
 		case model.Checks.SLOAvailability.Id:
 			form := CheckConfigSLOAvailabilityForm{
-				Configs: checkConfigs.GetAvailability(appId),
+				Level:   level,
+				Configs: checkConfigs.GetOwnAvailability(id),
+			}
+			if level == CheckConfigLevelApplication {
+				form.Inherited = checkConfigs.GetOwnAvailability(appId.NamespaceId())
 			}
 			if len(form.Configs) == 0 {
-				form.Configs = append(form.Configs, model.CheckConfigSLOAvailability{
-					TotalRequestsQuery:  "",
-					FailedRequestsQuery: "",
-					ObjectivePercentage: model.Checks.SLOAvailability.DefaultThreshold,
-				})
+				form.Configs = append(form.Configs, model.CheckConfigSLOAvailability{})
 				form.Empty = true
 			}
 			res.Form = form
 		case model.Checks.SLOLatency.Id:
 			form := CheckConfigSLOLatencyForm{
-				Configs: checkConfigs.GetLatency(appId),
+				Level:   level,
+				Configs: checkConfigs.GetOwnLatency(id),
+			}
+			if level == CheckConfigLevelApplication {
+				form.Inherited = checkConfigs.GetOwnLatency(appId.NamespaceId())
 			}
 			if len(form.Configs) == 0 {
 				form.Configs = append(form.Configs, model.CheckConfigSLOLatency{
-					HistogramQuery:      "",
-					ObjectiveBucket:     0.1,
-					ObjectivePercentage: model.Checks.SLOLatency.DefaultThreshold,
+					HistogramQuery:  "",
+					ObjectiveBucket: 0.1,
 				})
 				form.Empty = true
 			}
@@ -486,7 +2163,7 @@ func (api *Api) Check(w http.ResponseWriter, r *http.Request) {
 				Configs: checkConfigs.GetSimpleAll(checkId, appId),
 			}
 			if len(form.Configs) == 0 {
-				http.Error(w, "", http.StatusNotFound)
+				httpError(w, "", http.StatusNotFound)
 				return
 			}
 			res.Form = form
@@ -501,35 +2178,33 @@ func (api *Api) Check(w http.ResponseWriter, r *http.Request) {
 		switch checkId {
 		case model.Checks.SLOAvailability.Id:
 			var form CheckConfigSLOAvailabilityForm
-			if err := ReadAndValidate(r, &form); err != nil {
-				klog.Warningln("bad request:", err)
-				http.Error(w, "", http.StatusBadRequest)
+			if err := api.readAndValidate(w, r, &form); err != nil {
+				badRequest(w, err, "")
 				return
 			}
-			if err := api.db.SaveCheckConfig(projectId, appId, checkId, form.Configs); err != nil {
+			if err := api.db.SaveCheckConfig(projectId, checkConfigLevelId(appId, form.Level), checkId, form.Configs, api.actor(r)); err != nil {
 				klog.Errorln("failed to save check config:", err)
-				http.Error(w, "", http.StatusInternalServerError)
+				httpError(w, "", http.StatusInternalServerError)
 				return
 			}
 		case model.Checks.SLOLatency.Id:
 			var form CheckConfigSLOLatencyForm
-			if err := ReadAndValidate(r, &form); err != nil {
-				klog.Warningln("bad request:", err)
-				http.Error(w, "", http.StatusBadRequest)
+			if err := api.readAndValidate(w, r, &form); err != nil {
+				badRequest(w, err, "")
 				return
 			}
-			if err := api.db.SaveCheckConfig(projectId, appId, checkId, form.Configs); err != nil {
+			if err := api.db.SaveCheckConfig(projectId, checkConfigLevelId(appId, form.Level), checkId, form.Configs, api.actor(r)); err != nil {
 				klog.Errorln("failed to save check config:", err)
-				http.Error(w, "", http.StatusInternalServerError)
+				httpError(w, "", http.StatusInternalServerError)
 				return
 			}
 		default:
 			var form CheckConfigForm
-			if err := ReadAndValidate(r, &form); err != nil {
-				klog.Warningln("bad request:", err)
-				http.Error(w, "", http.StatusBadRequest)
+			if err := api.readAndValidate(w, r, &form); err != nil {
+				badRequest(w, err, "")
 				return
 			}
+			var updates []db.CheckConfigUpdate
 			for level, cfg := range form.Configs {
 				var id model.ApplicationId
 				switch level {
@@ -538,13 +2213,20 @@ func (api *Api) Check(w http.ResponseWriter, r *http.Request) {
 				case 1:
 					id = model.ApplicationIdZero
 				case 2:
+					id = appId.NamespaceId()
+				case 3:
 					id = appId
+				default:
+					continue
 				}
-				if err := api.db.SaveCheckConfig(projectId, id, checkId, cfg); err != nil {
-					klog.Errorln("failed to save check config:", err)
-					http.Error(w, "", http.StatusInternalServerError)
-					return
-				}
+				updates = append(updates, db.CheckConfigUpdate{ApplicationId: id, Config: cfg})
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), api.checkConfigsSaveTimeout)
+			defer cancel()
+			if err := api.db.SaveCheckConfigs(ctx, projectId, checkId, updates, api.actor(r)); err != nil {
+				klog.Errorln("failed to save check configs:", err)
+				httpError(w, "", http.StatusInternalServerError)
+				return
 			}
 			return
 		}
@@ -555,8 +2237,7 @@ func (api *Api) Node(w http.ResponseWriter, r *http.Request) {
 	nodeName := mux.Vars(r)["node"]
 	world, _, err := api.loadWorldByRequest(r)
 	if err != nil {
-		klog.Errorln(err)
-		http.Error(w, "", http.StatusInternalServerError)
+		worldLoadFailed(w, err)
 		return
 	}
 	if world == nil {
@@ -565,20 +2246,153 @@ func (api *Api) Node(w http.ResponseWriter, r *http.Request) {
 	node := world.GetNode(nodeName)
 	if node == nil {
 		klog.Warningf("node not found: %s ", nodeName)
-		http.Error(w, "Node not found", http.StatusNotFound)
+		httpError(w, "Node not found", http.StatusNotFound)
 		return
 	}
 	utils.WriteJson(w, views.Node(world, node))
 }
 
+// Cluster returns the CPU, memory and disk totals and utilization of all the nodes of the project.
+func (api *Api) Cluster(w http.ResponseWriter, r *http.Request) {
+	world, _, err := api.loadWorldByRequest(r)
+	if err != nil {
+		worldLoadFailed(w, err)
+		return
+	}
+	if world == nil {
+		return
+	}
+	utils.WriteJson(w, model.CalcClusterTotals(world.Nodes))
+}
+
+func (api *Api) NodeTopTalkers(w http.ResponseWriter, r *http.Request) {
+	nodeName := mux.Vars(r)["node"]
+	limit, ascending, err := parseTopTalkersParams(r)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	world, _, err := api.loadWorldByRequestWithTraffic(r, true)
+	if err != nil {
+		worldLoadFailed(w, err)
+		return
+	}
+	if world == nil {
+		return
+	}
+	node := world.GetNode(nodeName)
+	if node == nil {
+		klog.Warningf("node not found: %s ", nodeName)
+		httpError(w, "Node not found", http.StatusNotFound)
+		return
+	}
+	utils.WriteJson(w, views.NodeTopTalkers(node, limit, ascending))
+}
+
+// parseTopTalkersParams reads the optional limit and order (asc or desc, the default) params.
+func parseTopTalkersParams(r *http.Request) (int, bool, error) {
+	q := r.URL.Query()
+	limit := 0
+	if l := q.Get("limit"); l != "" {
+		var err error
+		if limit, err = strconv.Atoi(l); err != nil || limit <= 0 {
+			return 0, false, fmt.Errorf("invalid limit: %s", l)
+		}
+	}
+	switch order := q.Get("order"); order {
+	case "", "desc":
+		return limit, false, nil
+	case "asc":
+		return limit, true, nil
+	default:
+		return 0, false, fmt.Errorf("invalid order: %s, should be one of: asc, desc", order)
+	}
+}
+
+func (api *Api) NodeCapacity(w http.ResponseWriter, r *http.Request) {
+	nodeName := mux.Vars(r)["node"]
+	world, _, err := api.loadWorldByRequest(r)
+	if err != nil {
+		worldLoadFailed(w, err)
+		return
+	}
+	if world == nil {
+		return
+	}
+	node := world.GetNode(nodeName)
+	if node == nil {
+		klog.Warningf("node not found: %s ", nodeName)
+		httpError(w, "Node not found", http.StatusNotFound)
+		return
+	}
+	utils.WriteJson(w, struct {
+		Node     *model.AuditReport `json:"node"`
+		Capacity *capacity.View     `json:"capacity"`
+	}{
+		Node:     views.Node(world, node),
+		Capacity: views.NodeCapacity(world, node),
+	})
+}
+
+// NodeInventory lists all the nodes seen within the requested time window, including the ones that came and went.
+func (api *Api) NodeInventory(w http.ResponseWriter, r *http.Request) {
+	world, _, err := api.loadWorldByRequest(r)
+	if err != nil {
+		worldLoadFailed(w, err)
+		return
+	}
+	if world == nil {
+		return
+	}
+	utils.WriteJson(w, views.NodeInventory(world))
+}
+
+func (api *Api) CheckAcrossApplications(w http.ResponseWriter, r *http.Request) {
+	cfg := model.GetCheckConfig(model.CheckId(mux.Vars(r)["check"]))
+	if cfg == nil {
+		httpError(w, "unknown check", http.StatusNotFound)
+		return
+	}
+	world, _, err := api.loadWorldByRequest(r)
+	if err != nil {
+		worldLoadFailed(w, err)
+		return
+	}
+	if world == nil {
+		return
+	}
+	utils.WriteJson(w, views.Check(world, cfg))
+}
+
+// loadShiftedWorld loads the world for the window of the given one moved back by the offset.
+// It returns nil if the cache has no data for the shifted window, e.g., because it's beyond the retention.
+func (api *Api) loadShiftedWorld(ctx context.Context, project *db.Project, world *model.World, offset timeseries.Duration) (*model.World, error) {
+	from, to := world.Ctx.From.Add(-offset), world.Ctx.To.Add(-offset)
+	coverage, err := api.cache.GetCacheClient(project).GetCoverage(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(coverage) == 0 {
+		return nil, nil
+	}
+	return api.loadWorld(ctx, project, from, to)
+}
+
 func (api *Api) loadWorld(ctx context.Context, project *db.Project, from, to timeseries.Time) (*model.World, error) {
+	return api.loadWorldWithStep(ctx, project, from, to, 0, false)
+}
+
+// loadWorldWithStep is like loadWorld, but with the requested step (the refresh interval if zero).
+// The effective step is available as world.Ctx.Step, see minStep. The traffic of the connections is loaded
+// only if requested (see constructor.TRAFFIC_QUERIES).
+func (api *Api) loadWorldWithStep(ctx context.Context, project *db.Project, from, to timeseries.Time, step timeseries.Duration, traffic bool) (*model.World, error) {
 	cc := api.cache.GetCacheClient(project)
 	cacheTo, err := cc.GetTo()
 	if err != nil {
 		return nil, err
 	}
 
-	step := project.Prometheus.RefreshInterval
+	step = minStep(step, project.Prometheus.RefreshInterval)
 	from = from.Truncate(step)
 	to = to.Truncate(step)
 
@@ -598,11 +2412,96 @@ func (api *Api) loadWorld(ctx context.Context, project *db.Project, from, to tim
 		return nil, err
 	}
 
-	world, err := constructor.New(cc, project.Prometheus.RefreshInterval, checkConfigs).LoadWorld(ctx, from, to, step, nil)
-	return world, err
+	if gaps, err := cc.GetGaps(from, to); err != nil {
+		klog.Warningln("failed to get cache gaps:", err)
+	} else if len(gaps) > 0 {
+		klog.Warningf("%s: no cached data for %d interval(s) within %s-%s, the first one is %s-%s",
+			project.Id, len(gaps), from.ToStandard(), to.ToStandard(), gaps[0].From.ToStandard(), gaps[0].To.ToStandard())
+	}
+
+	release, err := api.acquireWorldLoad(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	c := constructor.New(cc, project.Prometheus.RefreshInterval, checkConfigs, project.Prometheus.ExtraSelector, project.Prometheus.ReplicaLabel, project.Settings.ApplicationIdentity, project.Settings.ApplicationExclusions)
+	if traffic {
+		c = c.WithTraffic()
+	}
+	world, err := c.LoadWorld(ctx, from, to, step, nil)
+	if err != nil {
+		return nil, err
+	}
+	project.ApplySLOObjectives(world)
+	if s := project.Prometheus.MaxStaleness; s > 0 && cacheTo.Before(timeseries.Now().Add(-s)) {
+		world.StaleSince = cacheTo
+	}
+	if versions, err := cc.GetPrometheusVersions(from, to); err != nil {
+		klog.Warningln("failed to get Prometheus versions:", err)
+	} else {
+		world.PrometheusVersions = versions
+		if len(versions) > 1 {
+			world.Warnings = append(world.Warnings, "the data has been fetched from different Prometheus versions: "+strings.Join(versions, ", "))
+		}
+	}
+	return world, nil
+}
+
+// applicationCategory returns the category of the application. Only the category label requires the world to be loaded,
+// the patterns are matched against the application id. The label values are taken from the cached list of the project's
+// applications (see getProjectApplications), so that resolving the categories of many applications loads the world once.
+func (api *Api) applicationCategory(ctx context.Context, project *db.Project, appId model.ApplicationId) model.ApplicationCategory {
+	if project.Settings.ApplicationCategoryLabel != "" {
+		if e, err := api.getProjectApplications(ctx, project); err != nil {
+			klog.Warningln("failed to get applications:", err)
+		} else if v := e.categoryValues[appId]; v != "" {
+			return model.ApplicationCategory(v)
+		}
+	}
+	return model.CalcApplicationCategory(model.NewApplication(appId), project.Settings.ApplicationCategories, "")
+}
+
+// acquireWorldLoad waits for a free world load slot, giving up after the queue timeout.
+func (api *Api) acquireWorldLoad(ctx context.Context) (func(), error) {
+	if api.worldLoads == nil {
+		return func() {}, nil
+	}
+	release := func() { <-api.worldLoads }
+	select {
+	case api.worldLoads <- struct{}{}:
+		return release, nil
+	default:
+	}
+	timer := time.NewTimer(api.worldLoadQueueTimeout)
+	defer timer.Stop()
+	select {
+	case api.worldLoads <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+		return nil, errWorldLoadQueueTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// worldLoadFailed responds to a failed world load, asking the client to retry later if the instance is overloaded.
+func worldLoadFailed(w http.ResponseWriter, err error) {
+	if errors.Is(err, errWorldLoadQueueTimeout) {
+		klog.Warningln(err)
+		w.Header().Set("Retry-After", strconv.Itoa(worldLoadRetryAfter))
+		httpError(w, "Too many concurrent requests, try again later", http.StatusServiceUnavailable)
+		return
+	}
+	klog.Errorln(err)
+	httpError(w, "", http.StatusInternalServerError)
 }
 
 func (api *Api) loadWorldByRequest(r *http.Request) (*model.World, *db.Project, error) {
+	return api.loadWorldByRequestWithTraffic(r, false)
+}
+
+func (api *Api) loadWorldByRequestWithTraffic(r *http.Request, traffic bool) (*model.World, *db.Project, error) {
 	projectId := db.ProjectId(mux.Vars(r)["project"])
 	project, err := api.db.GetProject(projectId)
 	if err != nil {
@@ -623,17 +2522,48 @@ func (api *Api) loadWorldByRequest(r *http.Request) (*model.World, *db.Project,
 		if incident, err := api.db.GetIncidentByKey(projectId, incidentKey); err != nil {
 			klog.Warningln("failed to get incident:", err)
 		} else {
-			from = incident.OpenedAt.Add(-timeseries.Hour)
-			if !incident.ResolvedAt.IsZero() && incident.ResolvedAt.Add(timeseries.Hour).Before(to) {
-				to = incident.ResolvedAt.Add(timeseries.Hour)
-			}
+			from, to = incidentWindow(incident, to)
 		}
 	}
 
-	world, err := api.loadWorld(r.Context(), project, from, to)
+	if at := utils.ParseTimeFromUrl(now, q, "at", 0); !at.IsZero() {
+		from, to = pointInTimeWindow(at, project.Prometheus.RefreshInterval)
+	}
+
+	step := utils.ParseDurationFromUrl(q, "step", 0)
+	world, err := api.loadWorldWithStep(r.Context(), project, from, to, step, traffic)
 	return world, project, err
 }
 
+// incidentWindow returns the time range covering the incident with an hour of context around it.
+// The range of an ongoing incident ends at the given time.
+func incidentWindow(incident *db.Incident, to timeseries.Time) (timeseries.Time, timeseries.Time) {
+	from := incident.OpenedAt.Add(-timeseries.Hour)
+	if !incident.ResolvedAt.IsZero() && incident.ResolvedAt.Add(timeseries.Hour).Before(to) {
+		to = incident.ResolvedAt.Add(timeseries.Hour)
+	}
+	return from, to
+}
+
+// pointInTimeWindow returns the minimal time range to evaluate the state at the given time:
+// a few steps ending at it, as checks like dataIsMissing look at the last points rather than the last one.
+func pointInTimeWindow(at timeseries.Time, step timeseries.Duration) (timeseries.Time, timeseries.Time) {
+	to := at.Truncate(step)
+	return to.Add(-pointInTimeSteps * step), to
+}
+
+// minStep returns the requested step with the floor of the refresh interval, as there is no data finer
+// than the scrape resolution. It's rounded up to a multiple of the refresh interval to match the cached points.
+func minStep(step, refreshInterval timeseries.Duration) timeseries.Duration {
+	if step <= refreshInterval || refreshInterval <= 0 {
+		return maxDuration(step, refreshInterval)
+	}
+	if r := step % refreshInterval; r != 0 {
+		step += refreshInterval - r
+	}
+	return step
+}
+
 func increaseStepForBigDurations(duration, step timeseries.Duration) timeseries.Duration {
 	switch {
 	case duration > 5*24*timeseries.Hour:
@@ -656,3 +2586,80 @@ func maxDuration(d1, d2 timeseries.Duration) timeseries.Duration {
 	}
 	return d2
 }
+
+// actor identifies the author of a configuration change.
+// There is no authentication yet, so it's the client's address. X-Forwarded-For is taken into account only
+// if the API is behind a trusted reverse proxy, the last address of the header is the one the proxy has added.
+func (api *Api) actor(r *http.Request) string {
+	if api.trustForwardedFor {
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			addrs := strings.Split(xff, ",")
+			if addr := strings.TrimSpace(addrs[len(addrs)-1]); addr != "" {
+				return addr
+			}
+		}
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// RequestId is a middleware assigning an id to every request, so that error responses can be matched with the logs.
+// An id set by a client or a reverse proxy is preserved.
+func (api *Api) RequestId(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIdHeader)
+		if id == "" || len(id) > maxRequestIdLength {
+			id = utils.NanoId(12)
+		}
+		w.Header().Set(requestIdHeader, id)
+		next.ServeHTTP(w, r)
+	})
+}
+
+type errorResponse struct {
+	Error     string `json:"error"`
+	RequestId string `json:"request_id,omitempty"`
+}
+
+// httpError replies with a JSON body describing the error, use it instead of http.Error.
+// An empty message is replaced with the status text.
+func httpError(w http.ResponseWriter, message string, code int) {
+	if message == "" {
+		message = http.StatusText(code)
+	}
+	w.Header().Del("Content-Length")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	utils.WriteJsonWithStatus(w, code, errorResponse{Error: message, RequestId: w.Header().Get(requestIdHeader)})
+}
+
+func badRequest(w http.ResponseWriter, err error, message string) {
+	klog.Warningln("bad request:", err)
+	switch {
+	case errors.Is(err, ErrRequestBodyTooLarge):
+		httpError(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	case errors.Is(err, ErrRequestTimeout):
+		httpError(w, err.Error(), http.StatusRequestTimeout)
+		return
+	}
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		if message == "" {
+			message = err.Error()
+		}
+		httpError(w, message, http.StatusBadRequest)
+		return
+	}
+	if message == "" {
+		message = errs.Error()
+	}
+	utils.WriteJsonWithStatus(w, http.StatusUnprocessableEntity, struct {
+		Message string           `json:"message"`
+		Errors  ValidationErrors `json:"errors"`
+	}{
+		Message: message,
+		Errors:  errs,
+	})
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/coroot/coroot/alerts"
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMinStep(t *testing.T) {
+	refreshInterval := 15 * timeseries.Second
+	assert.Equal(t, 15*timeseries.Second, minStep(0, refreshInterval))
+	assert.Equal(t, 15*timeseries.Second, minStep(timeseries.Second, refreshInterval))
+	assert.Equal(t, 30*timeseries.Second, minStep(20*timeseries.Second, refreshInterval))
+	assert.Equal(t, timeseries.Minute, minStep(timeseries.Minute, refreshInterval))
+}
+
+func TestPointInTimeWindow(t *testing.T) {
+	step := 30 * timeseries.Second
+	from, to := pointInTimeWindow(1668034815, step)
+	assert.Equal(t, timeseries.Time(1668034800), to)
+	assert.Equal(t, to.Add(-pointInTimeSteps*step), from)
+
+	from, to = pointInTimeWindow(1668034800, step)
+	assert.Equal(t, timeseries.Time(1668034800), to)
+	assert.Equal(t, timeseries.Time(1668034650), from)
+}
+
+func TestPreviewIncidentRoute(t *testing.T) {
+	at := timeseries.Time(1668034800) // 2022-11-09 23:00 UTC
+	appId := model.NewApplicationId("prod", model.ApplicationKindDeployment, "api")
+	p := &db.Project{Id: "test-preview-incident-route"}
+	p.Settings.Integrations.Slack = &db.IntegrationSlack{DefaultChannel: "alerts", Enabled: true}
+	p.Settings.Integrations.QuietHours = &db.QuietHours{Start: "22:00", End: "07:00", Timezone: "UTC"}
+	am := alerts.NewAlertManager(nil, nil, 0)
+
+	// incidents as they are dispatched: resolved ones keep their severity
+	incidents := []*db.Incident{
+		{Severity: model.WARNING, OpenedAt: at.Add(-timeseries.Hour)},
+		{Severity: model.WARNING, OpenedAt: at.Add(-timeseries.Hour), ResolvedAt: at},
+		{Severity: model.CRITICAL, OpenedAt: at.Add(-timeseries.Hour)},
+		{Severity: model.CRITICAL, OpenedAt: at.Add(-timeseries.Hour), ResolvedAt: at},
+	}
+	for _, i := range incidents {
+		resolved := !i.ResolvedAt.IsZero()
+		dispatched := am.RouteIncident(p, appId, i, at.ToStandard())
+		previewed := am.RouteIncident(p, appId, previewIncident(i.Severity, resolved, at), at.ToStandard())
+		assert.Equal(t, dispatched, previewed, "severity=%s resolved=%t", i.Severity, resolved)
+	}
+	assert.False(t, am.RouteIncident(p, appId, previewIncident(model.WARNING, false, at), at.ToStandard()).Delivered)
+	assert.True(t, am.RouteIncident(p, appId, previewIncident(model.WARNING, true, at), at.ToStandard()).Delivered)
+}
+
+func TestProjectSettingsNotFound(t *testing.T) {
+	database, err := db.Open(t.TempDir(), "", "")
+	require.NoError(t, err)
+	api := NewApi(nil, database, nil, Options{})
+
+	for _, c := range []struct {
+		handler http.HandlerFunc
+		form    string
+	}{
+		{handler: api.GoldenSignals, form: `{"kind":"Deployment"}`},
+		{handler: api.SeverityLabels, form: `{"labels":{}}`},
+		{handler: api.ApplicationTiers, form: `{}`},
+		{handler: api.HealthRollup, form: `{}`},
+		{handler: api.SavedViews, form: `{"name":"errors","scope":"overview"}`},
+		{handler: api.MetricThresholds, form: `{}`},
+		{handler: api.ApplicationIdentity, form: `{}`},
+		{handler: api.ApplicationExclusions, form: `{}`},
+		{handler: api.SLODefaults, form: `{}`},
+		{handler: api.LogsLink, form: `{}`},
+		{handler: api.IntegrationsOTLP, form: `{"endpoint":"http://127.0.0.1:1"}`},
+		{handler: api.IntegrationsQuietHours, form: `{"start":"22:00","end":"07:00","timezone":"UTC"}`},
+		{handler: api.Escalation, form: `{}`},
+		{handler: api.Repeat, form: `{}`},
+		{handler: api.Flapping, form: `{}`},
+	} {
+		w := httptest.NewRecorder()
+		c.handler(w, mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/", nil), map[string]string{"project": "unknown"}))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = httptest.NewRecorder()
+		c.handler(w, mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(c.form)), map[string]string{"project": "unknown"}))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	}
+}
+
+func TestAcquireWorldLoad(t *testing.T) {
+	unlimited := NewApi(nil, nil, nil, Options{})
+	release, err := unlimited.acquireWorldLoad(context.Background())
+	require.NoError(t, err)
+	release()
+
+	api := NewApi(nil, nil, nil, Options{MaxWorldLoads: 1, WorldLoadQueueTimeout: 50 * time.Millisecond})
+	release, err = api.acquireWorldLoad(context.Background())
+	require.NoError(t, err)
+
+	_, err = api.acquireWorldLoad(context.Background())
+	assert.Equal(t, errWorldLoadQueueTimeout, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = api.acquireWorldLoad(ctx)
+	assert.Equal(t, context.Canceled, err)
+
+	// the waiting request gets the slot as soon as it's released
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		release()
+	}()
+	release, err = api.acquireWorldLoad(context.Background())
+	require.NoError(t, err)
+	release()
+}
+
+func TestWorldLoadFailed(t *testing.T) {
+	w := httptest.NewRecorder()
+	worldLoadFailed(w, fmt.Errorf("failed to load world: %w", errWorldLoadQueueTimeout))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "5", w.Header().Get("Retry-After"))
+
+	w = httptest.NewRecorder()
+	worldLoadFailed(w, errors.New("prometheus is unavailable"))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Header().Get("Retry-After"))
+}
+
+func TestAppSLIInvalidApplicationId(t *testing.T) {
+	api := NewApi(nil, nil, nil, Options{})
+	r := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/", nil), map[string]string{"project": "test", "app": "api"})
+	w := httptest.NewRecorder()
+	api.AppSLI(w, r)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid application_id: api")
+}
+
+func TestActor(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.RemoteAddr = "10.0.0.1:53412"
+	r.Header.Set("X-Forwarded-For", "1.2.3.4, 192.168.1.10")
+
+	assert.Equal(t, "10.0.0.1", NewApi(nil, nil, nil, Options{}).actor(r))
+
+	trusted := NewApi(nil, nil, nil, Options{TrustForwardedFor: true})
+	assert.Equal(t, "192.168.1.10", trusted.actor(r))
+	r.Header.Del("X-Forwarded-For")
+	assert.Equal(t, "10.0.0.1", trusted.actor(r))
+}
@@ -1,50 +1,161 @@
 package api
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"github.com/coroot/coroot/alerts"
+	"github.com/coroot/coroot/cache"
 	"github.com/coroot/coroot/db"
 	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/prom"
+	"github.com/coroot/coroot/timeseries"
 	"github.com/coroot/coroot/utils"
+	"io/ioutil"
+	"math"
 	"net/http"
 	"net/url"
+	"os"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
 var (
-	ErrInvalidForm = errors.New("invalid form")
+	ErrInvalidForm         = errors.New("invalid form")
+	ErrRequestBodyTooLarge = errors.New("request body too large")
+	ErrRequestTimeout      = errors.New("timed out reading request body")
 
-	slugRe = regexp.MustCompile("^[-_0-9a-z]{3,}$")
+	slugRe           = regexp.MustCompile("^[-_0-9a-z]{3,}$")
+	tagKeyRe         = regexp.MustCompile("^[-_.0-9a-zA-Z]+$")
+	labelNameRe      = regexp.MustCompile("^[-_./0-9a-zA-Z]+$")
+	promLabelNameRe  = regexp.MustCompile("^[a-zA-Z_][a-zA-Z0-9_]*$")
+	savedViewScopeRe = regexp.MustCompile("^[a-z_]+$")
+	colorRe          = regexp.MustCompile(`^(#[0-9a-fA-F]{3,8}|[a-zA-Z]+|(rgb|rgba|hsl|hsla)\([-0-9.,%\s]+\))$`)
 )
 
 type Form interface {
-	Valid() bool
+	Validate() ValidationErrors
 }
 
-func ReadAndValidate(r *http.Request, f Form) error {
-	if err := utils.ReadJson(r, f); err != nil {
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type ValidationErrors []ValidationError
+
+func (errs *ValidationErrors) Add(field, format string, a ...any) {
+	*errs = append(*errs, ValidationError{Field: field, Message: fmt.Sprintf(format, a...)})
+}
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, e := range errs {
+		msgs = append(msgs, e.Field+": "+e.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (errs ValidationErrors) Is(target error) bool {
+	return target == ErrInvalidForm
+}
+
+func (api *Api) readAndValidate(w http.ResponseWriter, r *http.Request, f Form) error {
+	if err := api.readForm(w, r, f); err != nil {
 		return err
 	}
-	if !f.Valid() {
-		return ErrInvalidForm
+	if errs := f.Validate(); len(errs) > 0 {
+		return errs
 	}
 	return nil
 }
 
+func (api *Api) readForm(w http.ResponseWriter, r *http.Request, f Form) error {
+	body, err := api.readBody(w, r)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, f); err != nil {
+		return fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	return nil
+}
+
+// readBody reads the request body limiting its size to maxBodySize.
+// The time spent reading is limited by the read timeout of the server (see http.Server.ReadTimeout).
+func (api *Api) readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, api.maxBodySize))
+	if err != nil {
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return nil, ErrRequestTimeout
+		}
+		if int64(len(body)) == api.maxBodySize {
+			return nil, ErrRequestBodyTooLarge
+		}
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+	return body, nil
+}
+
 type ProjectForm struct {
-	Name string `json:"name"`
+	Name string  `json:"name"`
+	Tags db.Tags `json:"tags"`
 
 	Prometheus db.Prometheus `json:"prometheus"`
 }
 
-func (f *ProjectForm) Valid() bool {
+func newProjectForm(p *db.Project) *ProjectForm {
+	return &ProjectForm{Name: p.Name, Tags: p.Tags, Prometheus: p.Prometheus}
+}
+
+func (f *ProjectForm) Validate() ValidationErrors {
+	var errs ValidationErrors
 	if !slugRe.MatchString(f.Name) {
-		return false
+		errs.Add("name", "must be at least 3 characters long and contain only lowercase letters, digits, dashes and underscores")
+	}
+	for k, v := range f.Tags {
+		if !tagKeyRe.MatchString(k) {
+			errs.Add("tags", "invalid tag key %q: must contain only letters, digits, dashes, underscores and dots", k)
+		}
+		if strings.TrimSpace(v) == "" {
+			errs.Add("tags", "empty value of tag %q", k)
+		}
 	}
 	if _, err := url.Parse(f.Prometheus.Url); err != nil {
-		return false
+		errs.Add("prometheus.url", "invalid url: %s", err)
+	}
+	if _, err := f.Prometheus.TLSConfig(); err != nil {
+		errs.Add("prometheus.tls", "%s", err)
+	}
+	if s, err := prom.NormalizeSelector(f.Prometheus.ExtraSelector); err != nil {
+		errs.Add("prometheus.extra_selector", "%s", err)
+	} else {
+		f.Prometheus.ExtraSelector = s
+	}
+	if f.Prometheus.QueryTimeout < 0 {
+		errs.Add("prometheus.query_timeout", "must not be negative")
+	}
+	f.Prometheus.ReplicaLabel = strings.TrimSpace(f.Prometheus.ReplicaLabel)
+	if l := f.Prometheus.ReplicaLabel; l != "" && !promLabelNameRe.MatchString(l) {
+		errs.Add("prometheus.replica_label", "invalid label name")
+	}
+	if f.Prometheus.MaxStaleness < 0 {
+		errs.Add("prometheus.max_staleness", "must not be negative")
+	}
+	if s := f.Prometheus.CacheChunkSize; s != 0 {
+		valid := false
+		for _, size := range cache.ChunkSizes {
+			valid = valid || s == size
+		}
+		switch {
+		case !valid:
+			errs.Add("prometheus.cache_chunk_size", "must be one of 1h, 4h or 12h")
+		case f.Prometheus.RefreshInterval > 0 && s%f.Prometheus.RefreshInterval != 0:
+			errs.Add("prometheus.cache_chunk_size", "must be a multiple of the refresh interval")
+		}
 	}
-	return true
+	return errs
 }
 
 type ProjectStatusForm struct {
@@ -52,44 +163,298 @@ type ProjectStatusForm struct {
 	UnMute *model.ApplicationType `json:"unmute"`
 }
 
-func (f *ProjectStatusForm) Valid() bool {
-	return true
+func (f *ProjectStatusForm) Validate() ValidationErrors {
+	return nil
 }
 
 type CheckConfigForm struct {
 	Configs []*model.CheckConfigSimple `json:"configs"`
 }
 
-func (f *CheckConfigForm) Valid() bool {
-	return true
+func (f *CheckConfigForm) Validate() ValidationErrors {
+	return nil
+}
+
+// The levels an SLO config can be defined at: the application itself,
+// or its namespace, so the config applies to the applications of the namespace that have no config of their own.
+const (
+	CheckConfigLevelApplication = "application"
+	CheckConfigLevelNamespace   = "namespace"
+)
+
+// checkConfigLevelId returns the id the SLO config of the given level is stored under.
+func checkConfigLevelId(appId model.ApplicationId, level string) model.ApplicationId {
+	if level == CheckConfigLevelNamespace {
+		return appId.NamespaceId()
+	}
+	return appId
+}
+
+func validateCheckConfigLevel(errs *ValidationErrors, level string) {
+	switch level {
+	case "", CheckConfigLevelApplication, CheckConfigLevelNamespace:
+	default:
+		errs.Add("level", "unknown level %q", level)
+	}
 }
 
 type CheckConfigSLOAvailabilityForm struct {
+	Level   string                             `json:"level"`
 	Configs []model.CheckConfigSLOAvailability `json:"configs"`
 	Empty   bool                               `json:"empty"`
+
+	// the configs of the namespace the application inherits if it has none of its own (read-only)
+	Inherited []model.CheckConfigSLOAvailability `json:"inherited,omitempty"`
+}
+
+func (f *CheckConfigSLOAvailabilityForm) UnmarshalJSON(data []byte) error {
+	var form struct {
+		Level   string `json:"level"`
+		Configs []struct {
+			model.CheckConfigSLOAvailability
+			ObjectivePercentage objective `json:"objective_percentage"`
+		} `json:"configs"`
+		Empty bool `json:"empty"`
+	}
+	if err := json.Unmarshal(data, &form); err != nil {
+		return err
+	}
+	f.Configs = f.Configs[:0]
+	for _, c := range form.Configs {
+		c.CheckConfigSLOAvailability.ObjectivePercentage = float64(c.ObjectivePercentage)
+		f.Configs = append(f.Configs, c.CheckConfigSLOAvailability)
+	}
+	f.Level = form.Level
+	f.Empty = form.Empty
+	return nil
 }
 
-func (f *CheckConfigSLOAvailabilityForm) Valid() bool {
-	for _, c := range f.Configs {
-		if c.TotalRequestsQuery == "" || c.FailedRequestsQuery == "" {
-			return false
+func (f *CheckConfigSLOAvailabilityForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	validateCheckConfigLevel(&errs, f.Level)
+	for i, c := range f.Configs {
+		for j, q := range c.Queries() {
+			prefix := fmt.Sprintf("configs[%d].", i)
+			if j > 0 {
+				prefix += fmt.Sprintf("additional_queries[%d].", j-1)
+			}
+			if q.TotalRequestsQuery == "" {
+				errs.Add(prefix+"total_requests_query", "required")
+			}
+			if q.FailedRequestsQuery == "" {
+				errs.Add(prefix+"failed_requests_query", "required")
+			}
+			switch {
+			case q.Weight < 0 || math.IsNaN(q.Weight) || math.IsInf(q.Weight, 0):
+				errs.Add(prefix+"weight", "must be a positive number")
+			case c.IsWeighted() && q.Weight == 0:
+				errs.Add(prefix+"weight", "required if any query pair is weighted")
+			}
 		}
 	}
-	return true
+	return errs
+}
+
+// objective is an SLO objective that can be specified as a number (99.9), a percentage ("99.9%") or a number of nines ("3 nines", "three nines").
+// An empty value (null or "") means the objective is inherited and is unmarshalled as zero.
+type objective float64
+
+func (o *objective) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		s = string(data)
+	}
+	if s = strings.TrimSpace(s); s == "" || s == "null" {
+		*o = 0
+		return nil
+	}
+	v, err := parseObjective(s)
+	if err != nil {
+		return err
+	}
+	*o = objective(v)
+	return nil
+}
+
+var nines = map[string]int{"one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6, "seven": 7, "eight": 8, "nine": 9}
+
+func parseObjective(s string) (float64, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	var v float64
+	if n := strings.TrimSpace(strings.TrimSuffix(s, "nines")); n != s {
+		count, ok := nines[n]
+		if !ok {
+			c, err := strconv.Atoi(n)
+			if err != nil || c < 1 || c > 9 {
+				return 0, fmt.Errorf("invalid objective: %s", s)
+			}
+			count = c
+		}
+		v = 100 - math.Pow(10, float64(2-count))
+		v = math.Round(v*1e9) / 1e9
+	} else {
+		var err error
+		v, err = strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(s, "%")), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid objective: %s", s)
+		}
+	}
+	if v <= 0 || v > 100 {
+		return 0, fmt.Errorf("objective must be greater than 0 and less than or equal to 100: %s", s)
+	}
+	return v, nil
+}
+
+type SLODefaultsForm struct {
+	AvailabilityObjective objective                                       `json:"availability_objective"`
+	LatencyObjective      objective                                       `json:"latency_objective"`
+	Categories            map[model.ApplicationCategory]SLOObjectivesForm `json:"categories"`
+}
+
+type SLOObjectivesForm struct {
+	AvailabilityObjective objective `json:"availability_objective"`
+	LatencyObjective      objective `json:"latency_objective"`
+}
+
+// Validate checks only the categories: the objectives are validated while being unmarshalled, zero means the next level.
+func (f *SLODefaultsForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	for c := range f.Categories {
+		if c == "" {
+			errs.Add("categories", "empty category name")
+		}
+	}
+	return errs
+}
+
+// Get returns the defaults to be saved, nil if nothing is overridden.
+func (f *SLODefaultsForm) Get() *db.SLODefaults {
+	d := &db.SLODefaults{
+		AvailabilityObjective: float64(f.AvailabilityObjective),
+		LatencyObjective:      float64(f.LatencyObjective),
+	}
+	for c, o := range f.Categories {
+		if o.AvailabilityObjective <= 0 && o.LatencyObjective <= 0 {
+			continue
+		}
+		if d.Categories == nil {
+			d.Categories = map[model.ApplicationCategory]db.SLOObjectives{}
+		}
+		d.Categories[c] = db.SLOObjectives{
+			AvailabilityObjective: float64(o.AvailabilityObjective),
+			LatencyObjective:      float64(o.LatencyObjective),
+		}
+	}
+	if d.AvailabilityObjective <= 0 && d.LatencyObjective <= 0 && len(d.Categories) == 0 {
+		return nil
+	}
+	return d
+}
+
+type LogsLinkForm struct {
+	Template model.LogsLinkTemplate `json:"template"`
+}
+
+func (f *LogsLinkForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	f.Template = model.LogsLinkTemplate(strings.TrimSpace(string(f.Template)))
+	if f.Template == "" {
+		return nil
+	}
+	if err := f.Template.Validate(); err != nil {
+		errs.Add("template", err.Error())
+	}
+	return errs
 }
 
 type CheckConfigSLOLatencyForm struct {
+	Level   string                        `json:"level"`
 	Configs []model.CheckConfigSLOLatency `json:"configs"`
 	Empty   bool                          `json:"empty"`
+
+	// the configs of the namespace the application inherits if it has none of its own (read-only)
+	Inherited []model.CheckConfigSLOLatency `json:"inherited,omitempty"`
+}
+
+func (f *CheckConfigSLOLatencyForm) UnmarshalJSON(data []byte) error {
+	var form struct {
+		Level   string `json:"level"`
+		Configs []struct {
+			model.CheckConfigSLOLatency
+			ObjectivePercentage objective `json:"objective_percentage"`
+		} `json:"configs"`
+		Empty bool `json:"empty"`
+	}
+	if err := json.Unmarshal(data, &form); err != nil {
+		return err
+	}
+	f.Configs = f.Configs[:0]
+	for _, c := range form.Configs {
+		c.CheckConfigSLOLatency.ObjectivePercentage = float64(c.ObjectivePercentage)
+		f.Configs = append(f.Configs, c.CheckConfigSLOLatency)
+	}
+	f.Level = form.Level
+	f.Empty = form.Empty
+	return nil
+}
+
+func (f *CheckConfigSLOLatencyForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	validateCheckConfigLevel(&errs, f.Level)
+	for i, c := range f.Configs {
+		if c.HistogramQuery == "" {
+			errs.Add(fmt.Sprintf("configs[%d].histogram_query", i), "required")
+		}
+		if c.ObjectiveBucket <= 0 {
+			errs.Add(fmt.Sprintf("configs[%d].objective_bucket", i), "must be greater than 0")
+		}
+		for j, b := range c.DisplayBuckets {
+			if b <= 0 || (j > 0 && b <= c.DisplayBuckets[j-1]) {
+				errs.Add(fmt.Sprintf("configs[%d].display_buckets", i), "must be positive and in ascending order")
+				break
+			}
+		}
+	}
+	return errs
+}
+
+// SLOEvaluationForm is an availability or a latency SLO config evaluated without being saved.
+type SLOEvaluationForm struct {
+	Availability *CheckConfigSLOAvailabilityForm `json:"availability"`
+	Latency      *CheckConfigSLOLatencyForm      `json:"latency"`
 }
 
-func (f *CheckConfigSLOLatencyForm) Valid() bool {
-	for _, c := range f.Configs {
-		if c.HistogramQuery == "" || c.ObjectiveBucket <= 0 {
-			return false
+func (f *SLOEvaluationForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	switch {
+	case f.Availability != nil && f.Latency != nil:
+		errs.Add("availability", "either an availability or a latency config is expected")
+	case f.Availability != nil:
+		if len(f.Availability.Configs) != 1 {
+			errs.Add("availability.configs", "a single config is expected")
+			return errs
+		}
+		for _, e := range f.Availability.Validate() {
+			errs.Add("availability."+e.Field, "%s", e.Message)
+		}
+		if o := f.Availability.Configs[0].ObjectivePercentage; o <= 0 || o > 100 {
+			errs.Add("availability.configs[0].objective_percentage", "must be greater than 0 and less than or equal to 100")
+		}
+	case f.Latency != nil:
+		if len(f.Latency.Configs) != 1 {
+			errs.Add("latency.configs", "a single config is expected")
+			return errs
+		}
+		for _, e := range f.Latency.Validate() {
+			errs.Add("latency."+e.Field, "%s", e.Message)
 		}
+		if o := f.Latency.Configs[0].ObjectivePercentage; o <= 0 || o > 100 {
+			errs.Add("latency.configs[0].objective_percentage", "must be greater than 0 and less than or equal to 100")
+		}
+	default:
+		errs.Add("availability", "either an availability or a latency config is required")
 	}
-	return true
+	return errs
 }
 
 type ApplicationCategoryForm struct {
@@ -99,44 +464,539 @@ type ApplicationCategoryForm struct {
 	customPatterns []string
 }
 
-func (f *ApplicationCategoryForm) Valid() bool {
+func (f *ApplicationCategoryForm) Validate() ValidationErrors {
+	var errs ValidationErrors
 	if !slugRe.MatchString(string(f.NewName)) {
-		return false
+		errs.Add("new_name", "must be at least 3 characters long and contain only lowercase letters, digits, dashes and underscores")
 	}
 	f.customPatterns = strings.Fields(f.CustomPatterns)
-	if !utils.GlobValidate(f.customPatterns) {
-		return false
+	validateCategoryPatterns(&errs, "custom_patterns", f.customPatterns)
+	return errs
+}
+
+func validateCategoryPatterns(errs *ValidationErrors, field string, patterns []string) {
+	if !utils.GlobValidate(patterns) {
+		errs.Add(field, "invalid glob pattern")
+		return
 	}
-	for _, p := range f.customPatterns {
+	for _, p := range patterns {
 		if strings.Count(p, "/") != 1 || strings.Index(p, "/") < 1 {
-			return false
+			errs.Add(field, "%s: should be <namespace>/<application_name>", p)
 		}
 	}
-	return true
+}
+
+// ApplicationCategoriesPreviewForm holds the candidate custom patterns of the categories, separated by spaces.
+// An empty string removes the custom patterns of the category.
+type ApplicationCategoriesPreviewForm struct {
+	Categories map[model.ApplicationCategory]string `json:"categories"`
+	patterns   map[model.ApplicationCategory][]string
+}
+
+func (f *ApplicationCategoriesPreviewForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	f.patterns = map[model.ApplicationCategory][]string{}
+	for c, ps := range f.Categories {
+		if !slugRe.MatchString(string(c)) {
+			errs.Add("categories", "invalid category name: %s", c)
+			continue
+		}
+		f.patterns[c] = strings.Fields(ps)
+		validateCategoryPatterns(&errs, "categories", f.patterns[c])
+	}
+	return errs
+}
+
+type ApplicationCategoryLabelForm struct {
+	Label string `json:"label"`
+}
+
+func (f *ApplicationCategoryLabelForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	f.Label = strings.TrimSpace(f.Label)
+	if f.Label != "" && !labelNameRe.MatchString(f.Label) {
+		errs.Add("label", "must contain only letters, digits, dashes, underscores, dots and slashes")
+	}
+	return errs
+}
+
+type GoldenSignalsForm struct {
+	Kind    model.ApplicationKind `json:"kind"`
+	Signals []model.GoldenSignal  `json:"signals"`
+}
+
+func (f *GoldenSignalsForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	if f.Kind == "" {
+		errs.Add("kind", "required")
+	}
+	for i := range f.Signals {
+		s := &f.Signals[i]
+		s.Chart = strings.TrimSpace(s.Chart)
+		if s.Report == "" || s.Chart == "" {
+			errs.Add("signals", "report and chart are required")
+			break
+		}
+	}
+	return errs
+}
+
+type SeverityLabelsForm struct {
+	Labels model.SeverityLabels `json:"labels"`
+}
+
+func (f *SeverityLabelsForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	for status, l := range f.Labels {
+		if _, ok := model.BuiltinSeverityLabels[status]; !ok {
+			errs.Add("labels", "unknown status %q", status)
+			continue
+		}
+		l.Label = strings.TrimSpace(l.Label)
+		l.Color = strings.TrimSpace(l.Color)
+		if len(l.Label) > 32 {
+			errs.Add("labels", "label of %q is too long", status)
+		}
+		if l.Color != "" && !colorRe.MatchString(l.Color) {
+			errs.Add("labels", "invalid color of %q: %s", status, l.Color)
+		}
+		if l.Label == "" && l.Color == "" {
+			delete(f.Labels, status)
+			continue
+		}
+		f.Labels[status] = l
+	}
+	return errs
+}
+
+type MetricThresholdsForm struct {
+	Thresholds model.MetricThresholds `json:"thresholds"`
+}
+
+func (f *MetricThresholdsForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	for metric, t := range f.Thresholds {
+		if _, ok := model.BuiltinMetricThresholds[metric]; !ok {
+			errs.Add("thresholds", "unknown metric %q", metric)
+			continue
+		}
+		if t.Warning < 0 || t.Critical < 0 {
+			errs.Add("thresholds", "thresholds of %q must not be negative", metric)
+		}
+		if t.Warning > 0 && t.Critical > 0 && t.Warning >= t.Critical {
+			errs.Add("thresholds", "the warning threshold of %q must be lower than the critical one", metric)
+		}
+	}
+	return errs
+}
+
+type ApplicationExclusionsForm struct {
+	model.ApplicationExclusions
+}
+
+func (f *ApplicationExclusionsForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	for idx, p := range f.Patterns {
+		f.Patterns[idx] = strings.TrimSpace(p)
+	}
+	if err := f.ApplicationExclusions.Validate(); err != nil {
+		errs.Add("patterns", err.Error())
+	}
+	return errs
+}
+
+type ApplicationTiersForm struct {
+	model.ApplicationTiers
+}
+
+func (f *ApplicationTiersForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	for tier, ps := range f.Patterns {
+		var patterns []string
+		for _, p := range ps {
+			if p = strings.TrimSpace(p); p != "" {
+				patterns = append(patterns, p)
+			}
+		}
+		if len(patterns) == 0 {
+			delete(f.Patterns, tier)
+			continue
+		}
+		f.Patterns[tier] = patterns
+	}
+	if err := f.ApplicationTiers.Validate(); err != nil {
+		errs.Add("tiers", err.Error())
+	}
+	return errs
+}
+
+type HealthRollupForm struct {
+	model.HealthRollup
+}
+
+func (f *HealthRollupForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	if f.Function == "" {
+		f.Function = model.HealthRollupWorst
+	}
+	if err := f.HealthRollup.Validate(); err != nil {
+		errs.Add("function", err.Error())
+	}
+	return errs
+}
+
+const (
+	maxSavedViewNameLength  = 100
+	maxSavedViewQueryParams = 50
+)
+
+type SavedViewForm struct {
+	db.SavedView
+}
+
+func (f *SavedViewForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	f.Name = strings.TrimSpace(f.Name)
+	switch {
+	case f.Name == "":
+		errs.Add("name", "required")
+	case len(f.Name) > maxSavedViewNameLength:
+		errs.Add("name", "must be at most %d characters long", maxSavedViewNameLength)
+	}
+	if !savedViewScopeRe.MatchString(f.Scope) {
+		errs.Add("scope", "must contain only lowercase letters and underscores")
+	}
+	if len(f.Query) > maxSavedViewQueryParams {
+		errs.Add("query", "too many parameters")
+	}
+	for k := range f.Query {
+		if k == "" {
+			errs.Add("query", "empty parameter name")
+			break
+		}
+	}
+	return errs
+}
+
+type ApplicationIdentityForm struct {
+	model.ApplicationIdentity
+}
+
+func (f *ApplicationIdentityForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	if len(f.Labels) > 5 {
+		errs.Add("labels", "too many labels")
+	}
+	seen := map[string]bool{}
+	for _, l := range f.Labels {
+		switch {
+		case !labelNameRe.MatchString(l):
+			errs.Add("labels", "invalid label %q", l)
+		case seen[l]:
+			errs.Add("labels", "duplicate label %q", l)
+		}
+		seen[l] = true
+	}
+	return errs
 }
 
 type IntegrationsForm struct {
 	BaseUrl string `json:"base_url"`
 }
 
-func (f *IntegrationsForm) Valid() bool {
+func (f *IntegrationsForm) Validate() ValidationErrors {
+	var errs ValidationErrors
 	if _, err := url.Parse(f.BaseUrl); err != nil || f.BaseUrl == "" {
-		return false
+		errs.Add("base_url", "invalid url")
+		return errs
 	}
 	f.BaseUrl = strings.TrimRight(f.BaseUrl, "/")
-	return true
+	return nil
 }
 
 type IntegrationsSlackForm struct {
-	Token   string `json:"token"`
-	Channel string `json:"channel"`
-	Enabled bool   `json:"enabled"`
+	Token                   string              `json:"token" secret:"true"`
+	Channel                 string              `json:"channel"`
+	Enabled                 bool                `json:"enabled"`
+	Timeout                 timeseries.Duration `json:"timeout"`
+	BreakerFailureThreshold int                 `json:"breaker_failure_threshold"`
+	BreakerCooldown         timeseries.Duration `json:"breaker_cooldown"`
+}
+
+func newIntegrationsSlackForm(cfg *db.IntegrationSlack) *IntegrationsSlackForm {
+	if cfg == nil {
+		return &IntegrationsSlackForm{Enabled: true}
+	}
+	return &IntegrationsSlackForm{
+		Token:                   cfg.Token,
+		Channel:                 cfg.DefaultChannel,
+		Enabled:                 cfg.Enabled,
+		Timeout:                 cfg.Timeout,
+		BreakerFailureThreshold: cfg.BreakerFailureThreshold,
+		BreakerCooldown:         cfg.BreakerCooldown,
+	}
+}
+
+func (f *IntegrationsSlackForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	if f.Token == "" {
+		errs.Add("token", "required")
+	}
+	if f.Channel == "" {
+		errs.Add("channel", "required")
+	}
+	if f.Timeout < 0 || f.Timeout > 5*timeseries.Minute {
+		errs.Add("timeout", "must be between 0 (the default of %s) and 5 minutes", utils.FormatDuration(db.DefaultIntegrationTimeout.ToStandard(), 1))
+	}
+	if f.BreakerFailureThreshold < 0 || f.BreakerFailureThreshold > 100 {
+		errs.Add("breaker_failure_threshold", "must be between 0 (the default of %d) and 100", db.DefaultBreakerFailureThreshold)
+	}
+	if f.BreakerCooldown < 0 || f.BreakerCooldown > 24*timeseries.Hour {
+		errs.Add("breaker_cooldown", "must be between 0 (the default of %s) and 24 hours", utils.FormatDuration(db.DefaultBreakerCooldown.ToStandard(), 1))
+	}
+	return errs
+}
+
+type IntegrationsOTLPForm struct {
+	Endpoint string                 `json:"endpoint"`
+	Headers  []db.IntegrationHeader `json:"headers"`
+	Interval timeseries.Duration    `json:"interval"`
+	Enabled  bool                   `json:"enabled"`
+	Timeout  timeseries.Duration    `json:"timeout"`
+}
+
+func newIntegrationsOTLPForm(cfg *db.IntegrationOTLP) *IntegrationsOTLPForm {
+	if cfg == nil {
+		return &IntegrationsOTLPForm{Enabled: true, Headers: []db.IntegrationHeader{}}
+	}
+	return &IntegrationsOTLPForm{Endpoint: cfg.Endpoint, Headers: cfg.Headers, Interval: cfg.Interval, Enabled: cfg.Enabled, Timeout: cfg.Timeout}
+}
+
+func (f *IntegrationsOTLPForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	f.Endpoint = strings.TrimSpace(f.Endpoint)
+	if u, err := url.Parse(f.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs.Add("endpoint", "must be an http(s) URL, e.g., http://otel-collector:4318/v1/metrics")
+	}
+	for i := range f.Headers {
+		h := &f.Headers[i]
+		h.Name = strings.TrimSpace(h.Name)
+		if h.Name == "" || strings.ContainsAny(h.Name, " :\r\n") {
+			errs.Add(fmt.Sprintf("headers[%d].name", i), "invalid header name")
+		}
+	}
+	if f.Interval != 0 && (f.Interval < 10*timeseries.Second || f.Interval > timeseries.Hour) {
+		errs.Add("interval", "must be between 10 seconds and 1 hour, 0 means the default of %s", utils.FormatDuration(db.DefaultOTLPExportInterval.ToStandard(), 1))
+	}
+	if f.Timeout < 0 || f.Timeout > 5*timeseries.Minute {
+		errs.Add("timeout", "must be between 0 (the default of %s) and 5 minutes", utils.FormatDuration(db.DefaultIntegrationTimeout.ToStandard(), 1))
+	}
+	return errs
+}
+
+// DeploymentForm is a deployment of the application specified in the URL.
+type DeploymentForm struct {
+	Version   string `json:"version"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+func (f *DeploymentForm) validate(errs *ValidationErrors, prefix string) {
+	if f.Version == "" {
+		errs.Add(prefix+"version", "required")
+	}
+	if f.Timestamp <= 0 {
+		errs.Add(prefix+"timestamp", "must be a unix timestamp in seconds")
+	}
+}
+
+func (f *DeploymentForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	f.validate(&errs, "")
+	return errs
+}
+
+type ApplicationDeploymentForm struct {
+	ApplicationId model.ApplicationId `json:"application_id"`
+	DeploymentForm
+}
+
+type DeploymentsForm struct {
+	Deployments []ApplicationDeploymentForm `json:"deployments"`
+}
+
+func (f *DeploymentsForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	if len(f.Deployments) == 0 {
+		errs.Add("deployments", "required")
+	}
+	for i := range f.Deployments {
+		d := &f.Deployments[i]
+		prefix := fmt.Sprintf("deployments[%d].", i)
+		if d.ApplicationId.IsZero() {
+			errs.Add(prefix+"application_id", "required")
+		}
+		d.validate(&errs, prefix)
+	}
+	return errs
+}
+
+type IncidentForm struct {
+	Action   string              `json:"action"`
+	Duration timeseries.Duration `json:"duration"`
+}
+
+func (f *IncidentForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	switch f.Action {
+	case "acknowledge", "unsnooze":
+	case "snooze":
+		if f.Duration <= 0 {
+			errs.Add("duration", "must be greater than 0")
+		}
+	default:
+		errs.Add("action", "should be one of: acknowledge, snooze, unsnooze")
+	}
+	return errs
+}
+
+// IncidentsAcknowledgeForm acknowledges or snoozes all the open incidents at once,
+// optionally only those of the applications of the category and/or having all the labels.
+type IncidentsAcknowledgeForm struct {
+	Action   string                    `json:"action"`
+	Duration timeseries.Duration       `json:"duration"`
+	Category model.ApplicationCategory `json:"category"`
+	Labels   model.Labels              `json:"labels"`
+	Comment  string                    `json:"comment"`
+}
+
+func (f *IncidentsAcknowledgeForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	switch f.Action {
+	case "acknowledge":
+	case "snooze":
+		if f.Duration <= 0 {
+			errs.Add("duration", "must be greater than 0")
+		}
+	default:
+		errs.Add("action", "should be one of: acknowledge, snooze")
+	}
+	f.Comment = strings.TrimSpace(f.Comment)
+	if f.Comment == "" {
+		errs.Add("comment", "is required")
+	}
+	return errs
+}
+
+func (f *IncidentsAcknowledgeForm) hasFilter() bool {
+	return f.Category != "" || len(f.Labels) > 0
 }
 
-func (f *IntegrationsSlackForm) Valid() bool {
-	if f.Token == "" || f.Channel == "" {
+// match reports whether the application falls under the filter.
+func (f *IncidentsAcknowledgeForm) match(app *model.Application, category model.ApplicationCategory) bool {
+	if f.Category != "" && category != f.Category {
 		return false
 	}
+	return app.MatchLabels(f.Labels)
+}
+
+const maxIncidentsRecomputeRange = timeseries.Day
+
+type IncidentsRecomputeForm struct {
+	From timeseries.Time `json:"from"`
+	To   timeseries.Time `json:"to"`
+}
+
+func (f *IncidentsRecomputeForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	switch {
+	case f.From.IsZero():
+		errs.Add("from", "is required")
+	case f.To.IsZero():
+		errs.Add("to", "is required")
+	case !f.From.Before(f.To):
+		errs.Add("to", "must be after from")
+	case f.To.After(timeseries.Now()):
+		errs.Add("to", "must not be in the future")
+	case f.To.Sub(f.From) > maxIncidentsRecomputeRange:
+		errs.Add("to", "the range must not exceed "+maxIncidentsRecomputeRange.ToStandard().String())
+	}
+	return errs
+}
+
+// AlertmanagerSilencesForm is the list of silences as returned by the Alertmanager API.
+type AlertmanagerSilencesForm []alerts.AlertmanagerSilence
+
+func (f *AlertmanagerSilencesForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	for i, s := range *f {
+		if s.Id == "" {
+			errs.Add(fmt.Sprintf("[%d].id", i), "is required")
+		}
+		if len(s.Matchers) == 0 {
+			errs.Add(fmt.Sprintf("[%d].matchers", i), "must not be empty")
+		}
+	}
+	return errs
+}
+
+type EscalationForm struct {
+	db.EscalationPolicy
+}
+
+func (f *EscalationForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	if f.WarningToCriticalAfter < 0 {
+		errs.Add("warning_to_critical_after", "must not be negative")
+	}
+	return errs
+}
 
-	return true
+type RepeatForm struct {
+	db.RepeatPolicy
+}
+
+func (f *RepeatForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	if f.Interval < 0 {
+		errs.Add("interval", "must not be negative")
+	}
+	return errs
+}
+
+type QuietHoursForm struct {
+	db.QuietHours
+}
+
+func (f *QuietHoursForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	f.Start = strings.TrimSpace(f.Start)
+	f.End = strings.TrimSpace(f.End)
+	if f.Timezone == "" {
+		f.Timezone = "UTC"
+	}
+	if err := f.QuietHours.Validate(); err != nil {
+		errs.Add("quiet_hours", err.Error())
+	}
+	return errs
+}
+
+type FlappingForm struct {
+	db.FlappingPolicy
+}
+
+func (f *FlappingForm) Validate() ValidationErrors {
+	var errs ValidationErrors
+	if f.Cooldown < 0 {
+		errs.Add("cooldown", "must not be negative")
+	}
+	if f.FireAfter < 0 {
+		errs.Add("fire_after", "must not be negative")
+	}
+	if f.ClearAfter < 0 {
+		errs.Add("clear_after", "must not be negative")
+	}
+	if f.GracePeriod < 0 {
+		errs.Add("grace_period", "must not be negative")
+	}
+	return errs
 }
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadAndValidateLimits(t *testing.T) {
+	api := NewApi(nil, nil, nil, Options{MaxBodySize: 64})
+
+	post := func(body string) int {
+		r := httptest.NewRequest(http.MethodPost, "/api/project/test/escalation", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		api.Escalation(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post(`{"warning_to_critical_after": 1`+strings.Repeat(" ", 64)+`}`))
+	assert.Equal(t, http.StatusUnprocessableEntity, post(`{"warning_to_critical_after": -60000}`))
+	assert.Equal(t, http.StatusBadRequest, post(`{`))
+
+	body := strings.Repeat(" ", 64)
+	read, err := api.readBody(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	assert.NoError(t, err)
+	assert.Equal(t, body, string(read))
+}
+
+func TestReadBodyTimeout(t *testing.T) {
+	api := NewApi(nil, nil, nil, Options{})
+	errs := make(chan error, 1)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := api.readBody(w, r)
+		errs <- err
+	}))
+	srv.Config.ReadTimeout = 100 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	// the client sends only a part of the declared body and stalls
+	c, err := net.Dial("tcp", srv.Listener.Addr().String())
+	require.NoError(t, err)
+	defer c.Close()
+	_, err = fmt.Fprintf(c, "POST / HTTP/1.1\r\nHost: test\r\nContent-Length: 100\r\n\r\n{")
+	require.NoError(t, err)
+
+	select {
+	case err := <-errs:
+		assert.Equal(t, ErrRequestTimeout, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("readBody didn't time out")
+	}
+}
+
+func TestParseObjective(t *testing.T) {
+	for s, expected := range map[string]float64{
+		"99.9":        99.9,
+		"99.95%":      99.95,
+		" 99.5 % ":    99.5,
+		"100":         100,
+		"three nines": 99.9,
+		"3 nines":     99.9,
+		"Five Nines":  99.999,
+		"two nines":   99,
+		"0.1":         0.1,
+		"1 nines":     90,
+	} {
+		v, err := parseObjective(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, expected, v, s)
+	}
+	for _, s := range []string{"", "0", "-1", "100.1", "101%", "ten nines", "nines", "abc"} {
+		_, err := parseObjective(s)
+		assert.Error(t, err, s)
+	}
+
+	var f CheckConfigSLOAvailabilityForm
+	assert.NoError(t, json.Unmarshal([]byte(`{"configs":[{"total_requests_query":"t","failed_requests_query":"f","objective_percentage":"three nines"},{"objective_percentage":99.5}]}`), &f))
+	assert.Len(t, f.Configs, 2)
+	assert.Equal(t, 99.9, f.Configs[0].ObjectivePercentage)
+	assert.Equal(t, "t", f.Configs[0].TotalRequestsQuery)
+	assert.Equal(t, 99.5, f.Configs[1].ObjectivePercentage)
+	assert.Error(t, json.Unmarshal([]byte(`{"configs":[{"objective_percentage":"101%"}]}`), &f))
+
+	var defaults SLODefaultsForm
+	assert.NoError(t, json.Unmarshal([]byte(`{"availability_objective":"99.5%"}`), &defaults))
+	assert.Equal(t, objective(99.5), defaults.AvailabilityObjective)
+	assert.Equal(t, objective(0), defaults.LatencyObjective)
+	assert.Equal(t, &db.SLODefaults{AvailabilityObjective: 99.5}, defaults.Get())
+
+	// an empty objective is inherited
+	assert.NoError(t, json.Unmarshal([]byte(`{"configs":[{"objective_percentage":""},{"objective_percentage":null}]}`), &f))
+	assert.Equal(t, float64(0), f.Configs[0].ObjectivePercentage)
+	assert.Equal(t, float64(0), f.Configs[1].ObjectivePercentage)
+
+	defaults = SLODefaultsForm{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"categories":{"payments":{"latency_objective":"two nines"},"batch":{}}}`), &defaults))
+	assert.Equal(t, &db.SLODefaults{Categories: map[model.ApplicationCategory]db.SLOObjectives{"payments": {LatencyObjective: 99}}}, defaults.Get())
+	assert.Nil(t, (&SLODefaultsForm{}).Get())
+}
+
+func TestIncidentsAcknowledgeForm(t *testing.T) {
+	f := &IncidentsAcknowledgeForm{Action: "snooze", Comment: " "}
+	errs := f.Validate()
+	assert.Len(t, errs, 2)
+
+	f = &IncidentsAcknowledgeForm{Action: "acknowledge", Comment: "cloud provider outage"}
+	assert.Empty(t, f.Validate())
+	assert.False(t, f.hasFilter())
+
+	app := model.NewApplication(model.NewApplicationId("payments", model.ApplicationKindDeployment, "api"))
+	f.Category = "application"
+	f.Labels = model.Labels{"ns": "payments"}
+	assert.True(t, f.hasFilter())
+	assert.True(t, f.match(app, "application"))
+	assert.False(t, f.match(app, "monitoring"))
+	f.Labels = model.Labels{"ns": "default"}
+	assert.False(t, f.match(app, "application"))
+}
+
+func TestSavedViewForm(t *testing.T) {
+	f := &SavedViewForm{SavedView: db.SavedView{Name: " ", Scope: "Overview", Query: map[string]string{"": "1"}}}
+	assert.Len(t, f.Validate(), 3)
+
+	f = &SavedViewForm{SavedView: db.SavedView{Name: " tier 1, last day ", Scope: "overview", Query: map[string]string{"tier": "1", "from": "now-1d"}}}
+	assert.Empty(t, f.Validate())
+	assert.Equal(t, "tier 1, last day", f.Name)
+}
+
+func TestDeploymentsForm(t *testing.T) {
+	var f DeploymentsForm
+	require.NoError(t, json.Unmarshal([]byte(`{"deployments":[{"application_id":"default:Deployment:api","version":"v1.2","timestamp":1668000000},{"version":""}]}`), &f))
+	require.Len(t, f.Deployments, 2)
+	assert.Equal(t, model.NewApplicationId("default", model.ApplicationKindDeployment, "api"), f.Deployments[0].ApplicationId)
+	assert.Equal(t, DeploymentForm{Version: "v1.2", Timestamp: 1668000000}, f.Deployments[0].DeploymentForm)
+	assert.Len(t, f.Validate(), 3)
+
+	assert.Len(t, (&DeploymentsForm{}).Validate(), 1)
+	assert.Len(t, (&DeploymentForm{}).Validate(), 2)
+}
+
+func TestBadRequest(t *testing.T) {
+	var errs ValidationErrors
+	errs.Add("name", "required")
+	errs.Add("tags[0]", "invalid key %q", "a b")
+	assert.True(t, errors.Is(errs, ErrInvalidForm))
+	assert.Equal(t, `name: required; tags[0]: invalid key "a b"`, errs.Error())
+
+	w := httptest.NewRecorder()
+	badRequest(w, errs, "")
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.JSONEq(t, `{"message":"name: required; tags[0]: invalid key \"a b\"","errors":[{"field":"name","message":"required"},{"field":"tags[0]","message":"invalid key \"a b\""}]}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	badRequest(w, fmt.Errorf("failed to unmarshal body: %w", io.ErrUnexpectedEOF), "invalid data")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"invalid data"`)
+
+	w = httptest.NewRecorder()
+	badRequest(w, ErrRequestBodyTooLarge, "")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestCheckConfigSLOAvailabilityForm(t *testing.T) {
+	var f CheckConfigSLOAvailabilityForm
+	require.NoError(t, json.Unmarshal([]byte(`{"configs":[{"total_requests_query":"http_total","failed_requests_query":"http_failed","additional_queries":[{"total_requests_query":"grpc_total"}],"objective_percentage":99}]}`), &f))
+	require.Len(t, f.Configs, 1)
+	assert.Len(t, f.Configs[0].Queries(), 2)
+	assert.Equal(t, ValidationErrors{{Field: "configs[0].additional_queries[0].failed_requests_query", Message: "required"}}, f.Validate())
+
+	f.Configs[0].AdditionalQueries[0].FailedRequestsQuery = "grpc_failed"
+	assert.Empty(t, f.Validate())
+}
+
+func TestProjectFormTags(t *testing.T) {
+	f := &ProjectForm{Name: "prod", Tags: db.Tags{"env": "prod", "team.name": "payments"}}
+	assert.Empty(t, f.Validate())
+
+	f.Tags = db.Tags{"env type": "prod", "team": " "}
+	errs := f.Validate()
+	assert.Len(t, errs, 2)
+	for _, e := range errs {
+		assert.Equal(t, "tags", e.Field)
+	}
+}
+
+func TestSeverityLabelsForm(t *testing.T) {
+	f := &SeverityLabelsForm{Labels: model.SeverityLabels{
+		"critical": {Label: " P1 ", Color: " #f00 "},
+		"warning":  {Label: " "},
+	}}
+	assert.Empty(t, f.Validate())
+	assert.Equal(t, model.SeverityLabels{"critical": {Label: "P1", Color: "#f00"}}, f.Labels)
+
+	f = &SeverityLabelsForm{Labels: model.SeverityLabels{
+		"fatal":    {Label: "P0"},
+		"critical": {Label: strings.Repeat("x", 33), Color: "url(x)"},
+	}}
+	assert.Len(t, f.Validate(), 3)
+}
+
+func TestProjectFormMaxStaleness(t *testing.T) {
+	f := &ProjectForm{Name: "prod", Prometheus: db.Prometheus{MaxStaleness: 10 * timeseries.Minute}}
+	assert.Empty(t, f.Validate())
+
+	f.Prometheus.MaxStaleness = -timeseries.Minute
+	assert.Equal(t, ValidationErrors{{Field: "prometheus.max_staleness", Message: "must not be negative"}}, f.Validate())
+}
+
+func TestRepeatForm(t *testing.T) {
+	f := &RepeatForm{RepeatPolicy: db.RepeatPolicy{Interval: timeseries.Hour}}
+	assert.Empty(t, f.Validate())
+
+	f.Interval = -timeseries.Hour
+	assert.Equal(t, ValidationErrors{{Field: "interval", Message: "must not be negative"}}, f.Validate())
+}
+
+func TestCheckConfigLevel(t *testing.T) {
+	appId := model.NewApplicationId("default", model.ApplicationKindDeployment, "app")
+	assert.Equal(t, appId, checkConfigLevelId(appId, ""))
+	assert.Equal(t, appId, checkConfigLevelId(appId, CheckConfigLevelApplication))
+	assert.Equal(t, appId.NamespaceId(), checkConfigLevelId(appId, CheckConfigLevelNamespace))
+
+	var f CheckConfigSLOLatencyForm
+	require.NoError(t, json.Unmarshal([]byte(`{"level":"namespace","configs":[{"histogram_query":"h","objective_bucket":0.1,"objective_percentage":99}]}`), &f))
+	assert.Equal(t, CheckConfigLevelNamespace, f.Level)
+	assert.Empty(t, f.Validate())
+
+	f.Level = "cluster"
+	assert.Equal(t, ValidationErrors{{Field: "level", Message: `unknown level "cluster"`}}, f.Validate())
+}
+
+func TestIntegrationsSlackFormBreaker(t *testing.T) {
+	f := &IntegrationsSlackForm{Token: "xoxb-1", Channel: "alerts", BreakerFailureThreshold: 3, BreakerCooldown: timeseries.Hour}
+	assert.Empty(t, f.Validate())
+
+	f.BreakerFailureThreshold, f.BreakerCooldown = -1, 48*timeseries.Hour
+	assert.Equal(t, ValidationErrors{
+		{Field: "breaker_failure_threshold", Message: "must be between 0 (the default of 5) and 100"},
+		{Field: "breaker_cooldown", Message: "must be between 0 (the default of 10 minutes) and 24 hours"},
+	}, f.Validate())
+}
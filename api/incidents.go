@@ -0,0 +1,368 @@
+package api
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/coroot/coroot/alerts"
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/coroot/coroot/utils"
+	"github.com/gorilla/mux"
+	"io"
+	"k8s.io/klog"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type IncidentListItem struct {
+	Key           string              `json:"key"`
+	ApplicationId model.ApplicationId `json:"application_id"`
+	Severity      model.Status        `json:"severity"`
+	OpenedAt      timeseries.Time     `json:"opened_at"`
+	ResolvedAt    timeseries.Time     `json:"resolved_at"`
+	Duration      timeseries.Duration `json:"duration"`
+	PeakBurnRate  float64             `json:"peak_burn_rate"`
+	Recomputed    bool                `json:"recomputed"`
+	CausedBy      string              `json:"caused_by,omitempty"`
+}
+
+const defaultIncidentsPageSize = 100
+
+type IncidentsPage struct {
+	Incidents []IncidentListItem `json:"incidents"`
+	// the cursor to pass to get the next page, empty if it's the last one
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Incidents lists the incidents of the project within the given time range (the last 30 days by default)
+// ordered by the opening time, optionally only those of the application given by ?app=.
+// The list is paginated: a page holds ?limit= incidents (defaultIncidentsPageSize by default, capped by maxIncidentsPageSize),
+// and the next one is requested with the cursor returned along with the page.
+// With ?format=csv, all the incidents of the range are returned as a CSV file suitable for postmortem reviews.
+func (api *Api) Incidents(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+	now := timeseries.Now()
+	q := r.URL.Query()
+	from := utils.ParseTimeFromUrl(now, q, "from", now.Add(-30*timeseries.Day))
+	to := utils.ParseTimeFromUrl(now, q, "to", now)
+
+	if q.Get("format") == "csv" {
+		incidents, err := api.db.GetIncidents(projectId, from, to)
+		if err != nil {
+			klog.Errorln(err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="incidents-%s.csv"`, projectId))
+		if err := writeIncidentsCsv(w, incidentListItems(incidents)); err != nil {
+			klog.Errorln("failed to write csv:", err)
+		}
+		return
+	}
+
+	var appId model.ApplicationId
+	if s := q.Get("app"); s != "" {
+		var err error
+		if appId, err = model.NewApplicationIdFromString(s); err != nil {
+			httpError(w, "invalid app: "+s, http.StatusBadRequest)
+			return
+		}
+	}
+	limit := defaultIncidentsPageSize
+	if s := q.Get("limit"); s != "" {
+		var err error
+		if limit, err = strconv.Atoi(s); err != nil || limit < 1 {
+			httpError(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+	if limit > api.maxIncidentsPageSize {
+		limit = api.maxIncidentsPageSize
+	}
+	var cursor *db.IncidentCursor
+	if s := q.Get("cursor"); s != "" {
+		var err error
+		if cursor, err = db.ParseIncidentCursor(s); err != nil {
+			httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	incidents, next, err := api.db.GetIncidentsPage(projectId, appId, from, to, cursor, limit)
+	if err != nil {
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	res := IncidentsPage{Incidents: incidentListItems(incidents)}
+	if next != nil {
+		res.NextCursor = next.String()
+	}
+	utils.WriteJson(w, res)
+}
+
+func incidentListItems(incidents []db.Incident) []IncidentListItem {
+	res := make([]IncidentListItem, 0, len(incidents))
+	for _, i := range incidents {
+		item := IncidentListItem{
+			Key:           i.Key,
+			ApplicationId: i.ApplicationId,
+			Severity:      i.Severity,
+			OpenedAt:      i.OpenedAt,
+			ResolvedAt:    i.ResolvedAt,
+			PeakBurnRate:  i.PeakBurnRate,
+			Recomputed:    i.IsRecomputed(),
+			CausedBy:      i.CausedBy,
+		}
+		if !i.ResolvedAt.IsZero() {
+			item.Duration = i.ResolvedAt.Sub(i.OpenedAt)
+		}
+		res = append(res, item)
+	}
+	return res
+}
+
+type IncidentStats struct {
+	ApplicationId model.ApplicationId `json:"application_id"`
+	Incidents     int                 `json:"incidents"`
+	Open          int                 `json:"open"`
+	// the mean time to resolve of the resolved incidents, zero if there are none
+	MTTR timeseries.Duration `json:"mttr"`
+	// the mean time between the openings of consecutive incidents, zero if there are less than two
+	MTBF timeseries.Duration `json:"mtbf"`
+}
+
+// IncidentStats returns the MTTR and MTBF of the applications having incidents within the given time range
+// (the last 30 days by default), ordered by MTTR, the worst first.
+func (api *Api) IncidentStats(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+	now := timeseries.Now()
+	q := r.URL.Query()
+	from := utils.ParseTimeFromUrl(now, q, "from", now.Add(-30*timeseries.Day))
+	to := utils.ParseTimeFromUrl(now, q, "to", now)
+
+	incidents, err := api.db.GetIncidents(projectId, from, to)
+	if err != nil {
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJson(w, calcIncidentStats(incidents))
+}
+
+// calcIncidentStats calculates the stats of the applications from their incidents ordered by the opening time.
+// Open incidents don't count toward MTTR, but their openings count toward MTBF.
+func calcIncidentStats(incidents []db.Incident) []IncidentStats {
+	type acc struct {
+		stats             IncidentStats
+		resolved          int
+		resolveTime       timeseries.Duration
+		first, lastOpened timeseries.Time
+	}
+	byApp := map[model.ApplicationId]*acc{}
+	for _, i := range incidents {
+		a := byApp[i.ApplicationId]
+		if a == nil {
+			a = &acc{stats: IncidentStats{ApplicationId: i.ApplicationId}, first: i.OpenedAt}
+			byApp[i.ApplicationId] = a
+		}
+		a.stats.Incidents++
+		a.lastOpened = i.OpenedAt
+		if i.ResolvedAt.IsZero() {
+			a.stats.Open++
+			continue
+		}
+		a.resolved++
+		a.resolveTime += i.ResolvedAt.Sub(i.OpenedAt)
+	}
+	res := make([]IncidentStats, 0, len(byApp))
+	for _, a := range byApp {
+		if a.resolved > 0 {
+			a.stats.MTTR = a.resolveTime / timeseries.Duration(a.resolved)
+		}
+		if a.stats.Incidents > 1 {
+			a.stats.MTBF = a.lastOpened.Sub(a.first) / timeseries.Duration(a.stats.Incidents-1)
+		}
+		res = append(res, a.stats)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].MTTR != res[j].MTTR {
+			return res[i].MTTR > res[j].MTTR
+		}
+		if res[i].Incidents != res[j].Incidents {
+			return res[i].Incidents > res[j].Incidents
+		}
+		return res[i].ApplicationId.String() < res[j].ApplicationId.String()
+	})
+	return res
+}
+
+func writeIncidentsCsv(w io.Writer, incidents []IncidentListItem) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"app", "severity", "opened", "resolved", "duration", "peak burn rate", "recomputed"}); err != nil {
+		return err
+	}
+	for _, i := range incidents {
+		resolved, duration := "", ""
+		if !i.ResolvedAt.IsZero() {
+			resolved = i.ResolvedAt.ToStandard().UTC().Format(time.RFC3339)
+			duration = i.Duration.ToStandard().String()
+		}
+		record := []string{
+			i.ApplicationId.String(),
+			i.Severity.String(),
+			i.OpenedAt.ToStandard().UTC().Format(time.RFC3339),
+			resolved,
+			duration,
+			strconv.FormatFloat(i.PeakBurnRate, 'f', 1, 64),
+			strconv.FormatBool(i.Recomputed),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// RecomputeIncidents re-evaluates the SLOs of the project's applications over a past range using the current check configs
+// and replaces the incidents of that range with the recomputed ones, which are flagged as such. Nothing is notified.
+func (api *Api) RecomputeIncidents(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+	if api.readOnly {
+		return
+	}
+	var form IncidentsRecomputeForm
+	if err := api.readAndValidate(w, r, &form); err != nil {
+		badRequest(w, err, "")
+		return
+	}
+	project, err := api.db.GetProject(projectId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			httpError(w, "Project not found", http.StatusNotFound)
+			return
+		}
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	release, err := api.acquireWorldLoad(r.Context())
+	if err != nil {
+		worldLoadFailed(w, err)
+		return
+	}
+	defer release()
+	res, err := alerts.RecomputeIncidents(r.Context(), api.db, api.cache, project, form.From, form.To)
+	if err != nil {
+		klog.Errorln("failed to recompute incidents:", err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJson(w, res)
+}
+
+type AcknowledgedIncident struct {
+	Key           string              `json:"key"`
+	ApplicationId model.ApplicationId `json:"application_id"`
+}
+
+// AcknowledgeIncidents acknowledges or snoozes all the open incidents of the project in one call, e.g., during a widespread outage.
+// The incidents can be narrowed down to the applications of a category and/or having the given labels.
+func (api *Api) AcknowledgeIncidents(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+	if api.readOnly {
+		return
+	}
+	var form IncidentsAcknowledgeForm
+	if err := api.readAndValidate(w, r, &form); err != nil {
+		badRequest(w, err, "")
+		return
+	}
+	open, err := api.db.GetOpenIncidents(projectId)
+	if err != nil {
+		klog.Errorln(err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	var world *model.World
+	var project *db.Project
+	if form.hasFilter() && len(open) > 0 {
+		if world, project, err = api.loadWorldByRequest(r); err != nil {
+			worldLoadFailed(w, err)
+			return
+		}
+		if world == nil {
+			httpError(w, "Project not found", http.StatusNotFound)
+			return
+		}
+	}
+	var incidents []*db.Incident
+	res := make([]AcknowledgedIncident, 0, len(open))
+	for appId, i := range open {
+		if world != nil {
+			app := world.GetApplication(appId)
+			if app == nil {
+				continue
+			}
+			category := model.CalcApplicationCategory(app, project.Settings.ApplicationCategories, project.Settings.ApplicationCategoryLabel)
+			if !form.match(app, category) {
+				continue
+			}
+		}
+		incidents = append(incidents, i)
+		res = append(res, AcknowledgedIncident{Key: i.Key, ApplicationId: appId})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ApplicationId.String() < res[j].ApplicationId.String()
+	})
+	now := timeseries.Now()
+	var snoozedUntil timeseries.Time
+	if form.Action == "snooze" {
+		snoozedUntil = now.Add(form.Duration)
+	}
+	if err := api.db.AcknowledgeIncidents(projectId, incidents, now, snoozedUntil, form.Comment, api.actor(r)); err != nil {
+		klog.Errorln("failed to acknowledge incidents:", err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJson(w, res)
+}
+
+// ImportAlertmanagerSilences snoozes the applications matched by the active Alertmanager silences,
+// so that teams migrating from Alertmanager don't have to recreate them. Silences that can't be mapped are reported.
+func (api *Api) ImportAlertmanagerSilences(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+	if api.readOnly {
+		return
+	}
+	var form AlertmanagerSilencesForm
+	if err := api.readAndValidate(w, r, &form); err != nil {
+		badRequest(w, err, "")
+		return
+	}
+	world, _, err := api.loadWorldByRequest(r)
+	if err != nil {
+		worldLoadFailed(w, err)
+		return
+	}
+	if world == nil {
+		httpError(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	now := timeseries.Now()
+	res, snoozes := alerts.MapSilences(form, world.Applications, now)
+	if len(snoozes) > 0 {
+		if err := api.db.SnoozeApplications(projectId, snoozes, now, api.actor(r)); err != nil {
+			klog.Errorln("failed to save snoozes:", err)
+			httpError(w, "", http.StatusInternalServerError)
+			return
+		}
+	}
+	utils.WriteJson(w, res)
+}
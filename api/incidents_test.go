@@ -0,0 +1,58 @@
+package api
+
+import (
+	"bytes"
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestWriteIncidentsCsv(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := writeIncidentsCsv(buf, []IncidentListItem{
+		{
+			ApplicationId: model.NewApplicationId("default", model.ApplicationKindDeployment, "a,b"),
+			Severity:      model.CRITICAL,
+			OpenedAt:      1667210400,
+			ResolvedAt:    1667214000,
+			Duration:      timeseries.Hour,
+			PeakBurnRate:  14.56,
+			Recomputed:    true,
+		},
+		{
+			ApplicationId: model.NewApplicationId("default", model.ApplicationKindDeployment, "c"),
+			Severity:      model.WARNING,
+			OpenedAt:      1667210400,
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t,
+		"app,severity,opened,resolved,duration,peak burn rate,recomputed\n"+
+			"\"default:Deployment:a,b\",critical,2022-10-31T10:00:00Z,2022-10-31T11:00:00Z,1h0m0s,14.6,true\n"+
+			"default:Deployment:c,warning,2022-10-31T10:00:00Z,,,0.0,false\n",
+		buf.String())
+}
+
+func TestCalcIncidentStats(t *testing.T) {
+	a := model.NewApplicationId("default", model.ApplicationKindDeployment, "a")
+	b := model.NewApplicationId("default", model.ApplicationKindDeployment, "b")
+	c := model.NewApplicationId("default", model.ApplicationKindDeployment, "c")
+	incident := func(appId model.ApplicationId, openedAt, resolvedAt timeseries.Time) db.Incident {
+		return db.Incident{ApplicationId: appId, OpenedAt: openedAt, ResolvedAt: resolvedAt}
+	}
+	h := timeseries.Time(timeseries.Hour)
+	stats := calcIncidentStats([]db.Incident{
+		incident(a, 0, h/2),
+		incident(b, 0, 2*h),
+		incident(a, 10*h, 11*h+h/2),
+		incident(c, 12*h, 0),
+		incident(a, 20*h, 0),
+	})
+	assert.Equal(t, []IncidentStats{
+		{ApplicationId: b, Incidents: 1, MTTR: 2 * timeseries.Hour},
+		{ApplicationId: a, Incidents: 3, Open: 1, MTTR: timeseries.Hour, MTBF: 10 * timeseries.Hour},
+		{ApplicationId: c, Incidents: 1, Open: 1},
+	}, stats)
+}
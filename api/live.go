@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/coroot/coroot/auditor"
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"k8s.io/klog"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const (
+	liveHeartbeatInterval = 30 * time.Second
+	liveWriteTimeout      = 10 * time.Second
+	livePongTimeout       = 2 * liveHeartbeatInterval
+)
+
+var upgrader = websocket.Upgrader{}
+
+type LiveStatusChange struct {
+	Id       model.ApplicationId `json:"id"`
+	Status   model.Status        `json:"status"`
+	Previous model.Status        `json:"previous"`
+}
+
+type LiveMessage struct {
+	Time    timeseries.Time    `json:"time"`
+	Changes []LiveStatusChange `json:"changes"`
+}
+
+// Live streams application status changes of a project over a WebSocket connection.
+// The world is re-evaluated every time the cache is updated; the first message contains the statuses of all applications.
+func (api *Api) Live(w http.ResponseWriter, r *http.Request) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+	project, err := api.db.GetProject(projectId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			klog.Warningln("project not found:", projectId)
+			httpError(w, "", http.StatusNotFound)
+			return
+		}
+		klog.Errorln("failed to get project:", err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		klog.Warningln("failed to upgrade connection:", err)
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	conn.SetReadDeadline(time.Now().Add(livePongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(livePongTimeout))
+	})
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(liveHeartbeatInterval)
+	defer heartbeat.Stop()
+	refresh := time.NewTicker(time.Duration(project.Prometheus.RefreshInterval) * time.Second)
+	defer refresh.Stop()
+
+	var statuses map[model.ApplicationId]model.Status
+	var lastTo timeseries.Time
+	update := func() error {
+		to, err := api.cache.GetCacheClient(project).GetTo()
+		if err != nil {
+			return err
+		}
+		if to.IsZero() || !to.After(lastTo) {
+			return nil
+		}
+		world, err := api.loadWorld(ctx, project, to.Add(-timeseries.Hour), to)
+		if err != nil || world == nil {
+			return err
+		}
+		lastTo = to
+		auditor.Audit(world)
+		current := make(map[model.ApplicationId]model.Status, len(world.Applications))
+		for _, app := range world.Applications {
+			current[app.Id] = app.Status
+		}
+		first := statuses == nil
+		changes := diffStatuses(statuses, current)
+		statuses = current
+		if !first && len(changes) == 0 {
+			return nil
+		}
+		conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+		return conn.WriteJSON(LiveMessage{Time: to, Changes: changes})
+	}
+
+	if err := update(); err != nil {
+		klog.Warningln("live:", err)
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(liveWriteTimeout))
+			return
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(liveWriteTimeout)); err != nil {
+				return
+			}
+		case <-refresh.C:
+			if err := update(); err != nil {
+				klog.Warningln("live:", err)
+				return
+			}
+		}
+	}
+}
+
+func diffStatuses(prev, curr map[model.ApplicationId]model.Status) []LiveStatusChange {
+	var res []LiveStatusChange
+	for id, s := range curr {
+		p, ok := prev[id]
+		if ok && p == s {
+			continue
+		}
+		res = append(res, LiveStatusChange{Id: id, Status: s, Previous: p})
+	}
+	for id, p := range prev {
+		if _, ok := curr[id]; !ok {
+			res = append(res, LiveStatusChange{Id: id, Status: model.UNKNOWN, Previous: p})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Id.String() < res[j].Id.String()
+	})
+	return res
+}
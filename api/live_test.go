@@ -0,0 +1,44 @@
+package api
+
+import (
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDiffStatuses(t *testing.T) {
+	app := func(name string) model.ApplicationId {
+		return model.NewApplicationId("default", model.ApplicationKindDeployment, name)
+	}
+	curr := map[model.ApplicationId]model.Status{app("a"): model.OK, app("b"): model.WARNING}
+
+	// the first message contains all the applications
+	assert.Equal(t, []LiveStatusChange{
+		{Id: app("a"), Status: model.OK, Previous: model.UNKNOWN},
+		{Id: app("b"), Status: model.WARNING, Previous: model.UNKNOWN},
+	}, diffStatuses(nil, curr))
+
+	assert.Empty(t, diffStatuses(curr, curr))
+
+	next := map[model.ApplicationId]model.Status{app("a"): model.CRITICAL, app("c"): model.OK}
+	assert.Equal(t, []LiveStatusChange{
+		{Id: app("a"), Status: model.CRITICAL, Previous: model.OK},
+		{Id: app("b"), Status: model.UNKNOWN, Previous: model.WARNING},
+		{Id: app("c"), Status: model.OK, Previous: model.UNKNOWN},
+	}, diffStatuses(curr, next))
+}
+
+func TestLiveUnknownProject(t *testing.T) {
+	database, err := db.Open(t.TempDir(), "", "")
+	require.NoError(t, err)
+	api := NewApi(nil, database, nil, Options{})
+
+	w := httptest.NewRecorder()
+	api.Live(w, mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/", nil), map[string]string{"project": "unknown"}))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
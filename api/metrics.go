@@ -0,0 +1,111 @@
+package api
+
+import (
+	"bufio"
+	"errors"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/coroot/coroot/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	httpRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "coroot_http_requests_total",
+		},
+		[]string{"method", "code"},
+	)
+	httpRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "coroot_http_request_duration_seconds",
+			Buckets: []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		},
+		[]string{"method"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(httpRequestsTotal, httpRequestDuration)
+}
+
+// CollectStats is a middleware registering a sample of the API requests in the usage statistics collector.
+// All the API requests are observed in the request stats of the collector.
+func (api *Api) CollectStats(next http.Handler) http.Handler {
+	observed := observeRequests(next, func(duration time.Duration, code int) {
+		api.stats.ObserveRequest(duration, code)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if api.stats == nil || !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if n := atomic.AddUint64(&api.statsRequests, 1); (n-1)%api.statsSampleRate == 0 {
+			api.stats.RegisterRequest(r)
+		}
+		observed.ServeHTTP(w, r)
+	})
+}
+
+// observeRequests calls observe with the duration and the status code of every request handled by next.
+func observeRequests(next http.Handler, observe func(duration time.Duration, code int)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		t := time.Now()
+		next.ServeHTTP(rec, r)
+		observe(time.Since(t), rec.status)
+	})
+}
+
+// statusRecorder records the status code of a response. It passes hijacking (WebSocket upgrades)
+// and flushing through to the underlying writer.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer doesn't support hijacking")
+	}
+	r.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer to the code looking for the interfaces it implements.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// RequestStats returns the aggregated stats of the API requests handled within the window (1h by default),
+// which is limited by the retention of the request stats.
+func (api *Api) RequestStats(w http.ResponseWriter, r *http.Request) {
+	if api.stats == nil {
+		httpError(w, "usage statistics are disabled", http.StatusNotFound)
+		return
+	}
+	window := utils.ParseDurationFromUrl(r.URL.Query(), "window", timeseries.Hour)
+	utils.WriteJson(w, api.stats.RequestStats(window.ToStandard()))
+}
+
+// Instrument is a middleware counting the handled requests and measuring their duration.
+func (api *Api) Instrument(next http.Handler) http.Handler {
+	return promhttp.InstrumentHandlerDuration(httpRequestDuration, promhttp.InstrumentHandlerCounter(httpRequestsTotal, next))
+}
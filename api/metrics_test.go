@@ -0,0 +1,47 @@
+package api
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestObserveRequestsWebSocket(t *testing.T) {
+	codes := make(chan int, 1)
+	h := observeRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+		_ = conn.WriteMessage(websocket.TextMessage, []byte("hello"))
+	}), func(duration time.Duration, code int) {
+		codes <- code
+	})
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, msg, err := conn.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(msg))
+	assert.Equal(t, http.StatusSwitchingProtocols, <-codes)
+}
+
+func TestObserveRequests(t *testing.T) {
+	var code int
+	h := observeRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpError(w, "", http.StatusBadGateway)
+	}), func(duration time.Duration, c int) {
+		code = c
+	})
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/projects", nil))
+	assert.Equal(t, http.StatusBadGateway, code)
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/coroot/coroot/db"
+	"io"
+	"k8s.io/klog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// how long the result of a proxied range query can be reused by identical queries, unless the response says otherwise
+	promProxySharedResultTTL = 5 * time.Second
+	// a shared call doesn't depend on the request that started it, so it's limited on its own
+	promProxySharedCallTimeout = 2 * time.Minute
+)
+
+type promProxyResult struct {
+	status int
+	header http.Header
+	body   []byte
+	err    error
+}
+
+type promProxyCall struct {
+	done      chan struct{}
+	result    promProxyResult
+	expiresAt time.Time
+}
+
+// promProxyShare deduplicates identical proxied queries: concurrent ones share a single upstream call,
+// and its result is reused for a short time after it's completed (see promProxyResultTTL).
+type promProxyShare struct {
+	lock  sync.Mutex
+	calls map[string]*promProxyCall
+}
+
+// do returns the result of the call with the given key: the one in flight, the completed one if it hasn't expired
+// and reuse is allowed, or the result of f called otherwise. It returns false if ctx is done while waiting.
+func (s *promProxyShare) do(ctx context.Context, key string, reuse bool, f func() promProxyResult) (promProxyResult, bool) {
+	s.lock.Lock()
+	if s.calls == nil {
+		s.calls = map[string]*promProxyCall{}
+	}
+	now := time.Now()
+	for k, c := range s.calls {
+		if c.isDone() && !c.expiresAt.After(now) {
+			delete(s.calls, k)
+		}
+	}
+	if c := s.calls[key]; c != nil && (reuse || !c.isDone()) {
+		s.lock.Unlock()
+		select {
+		case <-c.done:
+			return c.result, true
+		case <-ctx.Done():
+			return promProxyResult{}, false
+		}
+	}
+	c := &promProxyCall{done: make(chan struct{})}
+	s.calls[key] = c
+	s.lock.Unlock()
+
+	// the waiters get a failed result if f panics, the panic itself is propagated to the caller
+	completed := false
+	defer func() {
+		var p any
+		if !completed {
+			p = recover()
+			c.result = promProxyResult{err: fmt.Errorf("proxied call panicked: %v", p)}
+		}
+		s.lock.Lock()
+		c.expiresAt = time.Now().Add(promProxyResultTTL(c.result))
+		close(c.done)
+		s.lock.Unlock()
+		if !completed {
+			panic(p)
+		}
+	}()
+	c.result = f()
+	completed = true
+	return c.result, true
+}
+
+func (c *promProxyCall) isDone() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// promProxyResultTTL returns how long the result can be reused: only successful responses are reused
+// and no longer than allowed by their Cache-Control header.
+func promProxyResultTTL(r promProxyResult) time.Duration {
+	if r.err != nil || r.status != http.StatusOK {
+		return 0
+	}
+	ttl := promProxySharedResultTTL
+	for _, directive := range strings.Split(r.header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(strings.ToLower(directive)), "=")
+		switch name {
+		case "no-cache", "no-store":
+			return 0
+		case "max-age":
+			if seconds, err := strconv.Atoi(value); err == nil && time.Duration(seconds)*time.Second < ttl {
+				ttl = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	return ttl
+}
+
+// promProxyKey identifies a proxied range query by the project and the query parameters passed in the URL or the body.
+// The body is read and replaced so that the request can be forwarded. It returns an empty key if the request isn't a range query.
+func (api *Api) promProxyKey(w http.ResponseWriter, r *http.Request, projectId db.ProjectId) (string, error) {
+	if !strings.HasSuffix(r.URL.Path, "/api/v1/query_range") {
+		return "", nil
+	}
+	params := r.URL.Query()
+	if r.Method == http.MethodPost {
+		body, err := api.readBody(w, r)
+		if err != nil {
+			return "", err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		bodyParams, err := url.ParseQuery(string(body))
+		if err != nil {
+			return "", err
+		}
+		for k, vv := range bodyParams {
+			params[k] = append(params[k], vv...)
+		}
+	}
+	return string(projectId) + " " + params.Encode(), nil
+}
+
+// promProxyCanReuse reports whether the client accepts a recently completed result rather than a fresh one.
+func promProxyCanReuse(r *http.Request) bool {
+	cc := strings.ToLower(r.Header.Get("Cache-Control"))
+	return !strings.Contains(cc, "no-cache") && !strings.Contains(cc, "no-store")
+}
+
+func writePromProxyResult(w http.ResponseWriter, res promProxyResult) {
+	if res.err != nil {
+		klog.Errorln(res.err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+	for k, vv := range res.header {
+		for _, v := range vv {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(res.status)
+	_, _ = w.Write(res.body)
+}
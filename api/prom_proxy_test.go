@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPromProxyShare(t *testing.T) {
+	s := &promProxyShare{}
+	var calls int32
+	release := make(chan struct{})
+	f := func() promProxyResult {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return promProxyResult{status: http.StatusOK, body: []byte("ok")}
+	}
+
+	wg := sync.WaitGroup{}
+	results := make([]promProxyResult, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = s.do(context.Background(), "q1", true, f)
+		}(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for _, r := range results {
+		assert.Equal(t, "ok", string(r.body))
+	}
+
+	// the completed result is reused unless the client asks for a fresh one
+	s.do(context.Background(), "q1", true, f)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	s.do(context.Background(), "q1", false, f)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// different queries aren't shared
+	s.do(context.Background(), "q2", true, f)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	// errors aren't reused
+	failing := func() promProxyResult {
+		atomic.AddInt32(&calls, 1)
+		return promProxyResult{status: http.StatusServiceUnavailable}
+	}
+	s.do(context.Background(), "q3", true, failing)
+	s.do(context.Background(), "q3", true, failing)
+	assert.Equal(t, int32(5), atomic.LoadInt32(&calls))
+}
+
+func TestPromProxyResultTTL(t *testing.T) {
+	ok := func(cacheControl string) promProxyResult {
+		h := http.Header{}
+		if cacheControl != "" {
+			h.Set("Cache-Control", cacheControl)
+		}
+		return promProxyResult{status: http.StatusOK, header: h}
+	}
+	assert.Equal(t, promProxySharedResultTTL, promProxyResultTTL(ok("")))
+	assert.Equal(t, 2*time.Second, promProxyResultTTL(ok("public, max-age=2")))
+	assert.Equal(t, promProxySharedResultTTL, promProxyResultTTL(ok("max-age=60")))
+	assert.Equal(t, time.Duration(0), promProxyResultTTL(ok("no-store")))
+	assert.Equal(t, time.Duration(0), promProxyResultTTL(promProxyResult{status: http.StatusBadRequest}))
+}
+
+func TestPromProxySharePanic(t *testing.T) {
+	s := &promProxyShare{}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	panicking := func() promProxyResult {
+		close(started)
+		<-release
+		panic(http.ErrAbortHandler)
+	}
+
+	waiter := make(chan promProxyResult)
+	go func() {
+		defer func() {
+			assert.Equal(t, http.ErrAbortHandler, recover())
+		}()
+		s.do(context.Background(), "q", true, panicking)
+	}()
+	<-started
+	go func() {
+		res, _ := s.do(context.Background(), "q", true, func() promProxyResult {
+			return promProxyResult{status: http.StatusOK}
+		})
+		waiter <- res
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	select {
+	case res := <-waiter:
+		assert.Error(t, res.err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the waiter hasn't got the result of the panicked call")
+	}
+
+	// the failed result isn't reused
+	res, _ := s.do(context.Background(), "q", true, func() promProxyResult {
+		return promProxyResult{status: http.StatusOK}
+	})
+	assert.Equal(t, http.StatusOK, res.status)
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/coroot/coroot/utils"
+	"k8s.io/klog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	globalSearchConcurrency = 4
+	globalSearchCacheTTL    = time.Minute
+	globalSearchCacheSize   = 100 // projects
+	globalSearchWorldRange  = 15 * timeseries.Minute
+)
+
+type SearchMatch struct {
+	ProjectId db.ProjectId        `json:"project_id"`
+	AppId     model.ApplicationId `json:"app_id"`
+	Name      string              `json:"name"`
+}
+
+type searchCache struct {
+	lock     sync.Mutex
+	projects map[db.ProjectId]searchCacheEntry
+}
+
+type searchCacheEntry struct {
+	updatedAt time.Time
+	apps      []model.ApplicationId
+	// the values of the project's category label of the applications, see applicationCategory
+	categoryLabel  string
+	categoryValues map[model.ApplicationId]string
+}
+
+// get returns the cached entry of the project unless it's expired or has been built for another category label.
+func (c *searchCache) get(id db.ProjectId, categoryLabel string) (searchCacheEntry, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	e, ok := c.projects[id]
+	if !ok || time.Since(e.updatedAt) > globalSearchCacheTTL || e.categoryLabel != categoryLabel {
+		return searchCacheEntry{}, false
+	}
+	return e, true
+}
+
+// set caches the entry evicting the expired ones and, if the cache is still full, the least recently updated one.
+func (c *searchCache) set(id db.ProjectId, e searchCacheEntry) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.projects == nil {
+		c.projects = map[db.ProjectId]searchCacheEntry{}
+	}
+	now := time.Now()
+	var oldest db.ProjectId
+	for pid, pe := range c.projects {
+		if now.Sub(pe.updatedAt) > globalSearchCacheTTL {
+			delete(c.projects, pid)
+			continue
+		}
+		if oldest == "" || pe.updatedAt.Before(c.projects[oldest].updatedAt) {
+			oldest = pid
+		}
+	}
+	if _, ok := c.projects[id]; !ok && len(c.projects) >= globalSearchCacheSize {
+		delete(c.projects, oldest)
+	}
+	e.updatedAt = now
+	c.projects[id] = e
+}
+
+// GlobalSearch looks for applications matching the query across all projects.
+// The application lists are cached for a short time since loading many worlds is expensive.
+func (api *Api) GlobalSearch(w http.ResponseWriter, r *http.Request) {
+	q := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+	projects, err := api.db.GetProjects()
+	if err != nil {
+		klog.Errorln("failed to get projects:", err)
+		httpError(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	var lock sync.Mutex
+	res := make([]SearchMatch, 0)
+	wg := sync.WaitGroup{}
+	sem := make(chan struct{}, globalSearchConcurrency)
+	for _, p := range projects {
+		wg.Add(1)
+		go func(p *db.Project) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			e, err := api.getProjectApplications(r.Context(), p)
+			if err != nil {
+				klog.Warningln("failed to get applications of project", p.Id, ":", err)
+				return
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			for _, id := range e.apps {
+				if q == "" || strings.Contains(strings.ToLower(id.Name), q) || strings.Contains(strings.ToLower(id.Namespace), q) {
+					res = append(res, SearchMatch{ProjectId: p.Id, AppId: id, Name: id.Name})
+				}
+			}
+		}(p)
+	}
+	wg.Wait()
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Name == res[j].Name {
+			return res[i].ProjectId < res[j].ProjectId
+		}
+		return res[i].Name < res[j].Name
+	})
+	utils.WriteJson(w, res)
+}
+
+// getProjectApplications returns the recently seen applications of the project along with the values of their category label.
+func (api *Api) getProjectApplications(ctx context.Context, p *db.Project) (searchCacheEntry, error) {
+	label := p.Settings.ApplicationCategoryLabel
+	if e, ok := api.searchCache.get(p.Id, label); ok {
+		return e, nil
+	}
+	now := timeseries.Now()
+	world, err := api.loadWorld(ctx, p, now.Add(-globalSearchWorldRange), now)
+	if err != nil {
+		return searchCacheEntry{}, err
+	}
+	e := searchCacheEntry{categoryLabel: label, categoryValues: map[model.ApplicationId]string{}}
+	if world != nil {
+		for _, a := range world.Applications {
+			e.apps = append(e.apps, a.Id)
+			if label != "" {
+				if v := a.KubernetesLabel(label); v != "" {
+					e.categoryValues[a.Id] = v
+				}
+			}
+		}
+	}
+	api.searchCache.set(p.Id, e)
+	return e, nil
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestApplicationCategoryFromCache(t *testing.T) {
+	api := &Api{}
+	p := &db.Project{Id: "test"}
+	p.Settings.ApplicationCategoryLabel = "team"
+	p.Settings.ApplicationCategories = map[model.ApplicationCategory][]string{"payments": {"prod/billing*"}}
+	billing := model.NewApplicationId("prod", model.ApplicationKindDeployment, "billing")
+	api.searchCache.set(p.Id, searchCacheEntry{
+		apps:           []model.ApplicationId{billing},
+		categoryLabel:  "team",
+		categoryValues: map[model.ApplicationId]string{billing: "core"},
+	})
+
+	// the label value is taken from the cache without loading the world
+	assert.Equal(t, model.ApplicationCategory("core"), api.applicationCategory(context.Background(), p, billing))
+	other := model.NewApplicationId("prod", model.ApplicationKindDeployment, "billing-worker")
+	assert.Equal(t, model.ApplicationCategory("payments"), api.applicationCategory(context.Background(), p, other))
+
+	// the entry built for another label isn't used
+	_, ok := api.searchCache.get(p.Id, "owner")
+	assert.False(t, ok)
+}
+
+func TestSearchCacheEviction(t *testing.T) {
+	c := &searchCache{}
+	c.set("expired", searchCacheEntry{})
+	c.projects["expired"] = searchCacheEntry{updatedAt: time.Now().Add(-2 * globalSearchCacheTTL)}
+	_, ok := c.get("expired", "")
+	assert.False(t, ok)
+
+	// expired entries are evicted on the next write
+	c.set("p0", searchCacheEntry{})
+	assert.NotContains(t, c.projects, db.ProjectId("expired"))
+
+	// the size is bounded, the least recently updated entry is evicted first
+	c.projects["p0"] = searchCacheEntry{updatedAt: time.Now().Add(-time.Second)}
+	for i := 1; i <= globalSearchCacheSize; i++ {
+		c.set(db.ProjectId(fmt.Sprintf("p%d", i)), searchCacheEntry{})
+	}
+	assert.Len(t, c.projects, globalSearchCacheSize)
+	_, ok = c.get("p0", "")
+	assert.False(t, ok)
+	_, ok = c.get("p1", "")
+	assert.True(t, ok)
+
+	// updating a cached entry doesn't evict others
+	c.set("p1", searchCacheEntry{apps: []model.ApplicationId{{Name: "app"}}})
+	assert.Len(t, c.projects, globalSearchCacheSize)
+	e, ok := c.get("p1", "")
+	assert.True(t, ok)
+	assert.Len(t, e.apps, 1)
+}
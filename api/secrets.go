@@ -0,0 +1,30 @@
+package api
+
+import (
+	"github.com/coroot/coroot/utils"
+	"net/http"
+)
+
+// writeSettings writes a settings-bearing response replacing the secrets with placeholders if masking is enabled.
+// v must be a pointer, it's modified in place.
+func (api *Api) writeSettings(w http.ResponseWriter, v any) {
+	if api.maskSecrets {
+		utils.MaskSecretFields(v)
+	}
+	utils.WriteJson(w, v)
+}
+
+// readAndValidateSettings is like readAndValidate, but if masking is enabled, the secrets submitted
+// as placeholders are replaced with their values from stored (a form of the same type) before validation.
+func (api *Api) readAndValidateSettings(w http.ResponseWriter, r *http.Request, f Form, stored Form) error {
+	if err := api.readForm(w, r, f); err != nil {
+		return err
+	}
+	if api.maskSecrets && stored != nil {
+		utils.RestoreSecretFields(f, stored)
+	}
+	if errs := f.Validate(); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
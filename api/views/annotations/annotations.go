@@ -0,0 +1,65 @@
+package annotations
+
+import (
+	"fmt"
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"sort"
+)
+
+type Type string
+
+const (
+	TypeIncident           Type = "incident"
+	TypeIncidentEscalation Type = "incident_escalation"
+	TypeDeployment         Type = "deployment"
+)
+
+var colors = map[Type]string{
+	TypeIncident:           "red",
+	TypeIncidentEscalation: "red",
+	TypeDeployment:         "blue",
+}
+
+type Annotation struct {
+	Type          Type                `json:"type"`
+	ApplicationId model.ApplicationId `json:"application_id"`
+	Name          string              `json:"name"`
+	X1            timeseries.Time     `json:"x1"`
+	X2            timeseries.Time     `json:"x2"`
+	Icon          string              `json:"icon"`
+	Color         string              `json:"color"`
+}
+
+// Render turns incidents and deployments into timeline markers ordered by time.
+// Open incidents are shown as lasting until now.
+func Render(incidents []db.Incident, deployments []db.Deployment, now timeseries.Time) []Annotation {
+	res := []Annotation{}
+	add := func(t Type, appId model.ApplicationId, name string, x1, x2 timeseries.Time, icon string) {
+		res = append(res, Annotation{Type: t, ApplicationId: appId, Name: name, X1: x1, X2: x2, Icon: icon, Color: colors[t]})
+	}
+	for _, i := range incidents {
+		resolvedAt := i.ResolvedAt
+		if resolvedAt.IsZero() {
+			resolvedAt = now
+		}
+		name := "incident"
+		if i.FlapCount > 0 {
+			name = fmt.Sprintf("incident (flapped %d times)", i.FlapCount)
+		}
+		add(TypeIncident, i.ApplicationId, name, i.OpenedAt, resolvedAt, "")
+		for idx, c := range i.SeverityHistory {
+			if idx > 0 && c.Severity > i.SeverityHistory[idx-1].Severity {
+				add(TypeIncidentEscalation, i.ApplicationId, "incident escalated to "+c.Severity.String(), c.Time, c.Time, "mdi-arrow-up-bold-outline")
+			}
+		}
+	}
+	for _, d := range deployments {
+		add(TypeDeployment, d.ApplicationId, "deployment "+d.Version, d.Timestamp, d.Timestamp, "mdi-rocket-launch-outline")
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].X1.Before(res[j].X1)
+	})
+	return res
+}
@@ -0,0 +1,31 @@
+package annotations
+
+import (
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	api := model.NewApplicationId("default", model.ApplicationKindDeployment, "api")
+	web := model.NewApplicationId("default", model.ApplicationKindDeployment, "web")
+	incidents := []db.Incident{
+		{ApplicationId: api, OpenedAt: 200, ResolvedAt: 500, FlapCount: 2, SeverityHistory: []db.SeverityChange{
+			{Time: 200, Severity: model.WARNING},
+			{Time: 300, Severity: model.CRITICAL},
+			{Time: 400, Severity: model.WARNING},
+		}},
+		{ApplicationId: web, OpenedAt: 600},
+	}
+	deployments := []db.Deployment{{ApplicationId: api, Version: "v1.2", Timestamp: 100}}
+
+	assert.Equal(t, []Annotation{
+		{Type: TypeDeployment, ApplicationId: api, Name: "deployment v1.2", X1: 100, X2: 100, Icon: "mdi-rocket-launch-outline", Color: "blue"},
+		{Type: TypeIncident, ApplicationId: api, Name: "incident (flapped 2 times)", X1: 200, X2: 500, Color: "red"},
+		{Type: TypeIncidentEscalation, ApplicationId: api, Name: "incident escalated to critical", X1: 300, X2: 300, Icon: "mdi-arrow-up-bold-outline", Color: "red"},
+		{Type: TypeIncident, ApplicationId: web, Name: "incident", X1: 600, X2: 1000, Color: "red"},
+	}, Render(incidents, deployments, 1000))
+
+	assert.Equal(t, []Annotation{}, Render(nil, nil, 1000))
+}
@@ -1,6 +1,7 @@
 package application
 
 import (
+	"github.com/coroot/coroot/api/views/annotations"
 	"github.com/coroot/coroot/auditor"
 	"github.com/coroot/coroot/db"
 	"github.com/coroot/coroot/model"
@@ -11,8 +12,32 @@ import (
 )
 
 type View struct {
-	AppMap  *AppMap              `json:"app_map"`
-	Reports []*model.AuditReport `json:"reports"`
+	AppMap    *AppMap              `json:"app_map"`
+	Reports   []*model.AuditReport `json:"reports"`
+	Incidents []Incident           `json:"incidents"`
+
+	GoldenSignals  []*model.Widget      `json:"golden_signals"`
+	Warnings       []string             `json:"warnings,omitempty"`
+	CheckErrors    []CheckError         `json:"check_errors,omitempty"`
+	StaleSince     timeseries.Time      `json:"stale_since,omitempty"`
+	SeverityLabels model.SeverityLabels `json:"severity_labels"`
+	Offset         timeseries.Duration  `json:"offset,omitempty"`
+}
+
+type CheckError struct {
+	Report  model.AuditReportName `json:"report"`
+	CheckId model.CheckId         `json:"check_id"`
+	Error   string                `json:"error"`
+}
+
+type Incident struct {
+	Key             string              `json:"key"`
+	OpenedAt        timeseries.Time     `json:"opened_at"`
+	ResolvedAt      timeseries.Time     `json:"resolved_at"`
+	ResolveReason   string              `json:"resolve_reason,omitempty"`
+	Severity        model.Status        `json:"severity"`
+	SeverityHistory []db.SeverityChange `json:"severity_history"`
+	Recomputed      bool                `json:"recomputed"`
 }
 
 type AppMap struct {
@@ -31,8 +56,9 @@ type Application struct {
 }
 
 type Instance struct {
-	Id     string       `json:"id"`
-	Labels model.Labels `json:"labels"`
+	Id      string       `json:"id"`
+	Labels  model.Labels `json:"labels"`
+	LogsUrl string       `json:"logs_url,omitempty"`
 
 	Clients       []*ApplicationLink `json:"clients"`
 	Dependencies  []*ApplicationLink `json:"dependencies"`
@@ -55,7 +81,7 @@ type InstanceLink struct {
 	Direction string       `json:"direction"`
 }
 
-func Render(world *model.World, app *model.Application, incidents []db.Incident) *View {
+func Render(world *model.World, shifted *model.World, offset timeseries.Duration, app *model.Application, incidents []db.Incident, deployments []db.Deployment, goldenSignals []model.GoldenSignal, severityLabels model.SeverityLabels, logsLink model.LogsLinkTemplate) *View {
 	auditor.Audit(world)
 
 	appMap := &AppMap{
@@ -73,6 +99,7 @@ func Render(world *model.World, app *model.Application, incidents []db.Incident)
 			continue
 		}
 		i := &Instance{Id: instance.Name, Labels: model.Labels{}}
+		i.LogsUrl = logsLink.Render(app.Id, instance.Name, world.Ctx.From, world.Ctx.To)
 		if instance.Postgres != nil && instance.Postgres.Version.Value() != "" {
 			i.Labels["version"] = instance.Postgres.Version.Value()
 		}
@@ -133,37 +160,92 @@ func Render(world *model.World, app *model.Application, incidents []db.Incident)
 		return appMap.Dependencies[i].Id.Name < appMap.Dependencies[j].Id.Name
 	})
 
-	if len(incidents) > 0 {
-		now := timeseries.Now()
-		for i := range incidents {
-			if incidents[i].ResolvedAt.IsZero() {
-				incidents[i].ResolvedAt = now
+	var charts []*model.Chart
+	for _, r := range app.Reports {
+		for _, w := range r.Widgets {
+			switch {
+			case w.ChartGroup != nil:
+				charts = append(charts, w.ChartGroup.Charts...)
+			case w.Chart != nil:
+				charts = append(charts, w.Chart)
+			}
+		}
+	}
+	v := &View{Reports: app.Reports, Incidents: []Incident{}, Warnings: world.Warnings, StaleSince: world.StaleSince, SeverityLabels: severityLabels}
+	for _, r := range app.Reports {
+		for _, ch := range r.Checks {
+			if ch.Error != "" {
+				v.CheckErrors = append(v.CheckErrors, CheckError{Report: r.Name, CheckId: ch.Id, Error: ch.Error})
 			}
 		}
-		for _, r := range app.Reports {
+	}
+	for _, i := range incidents {
+		v.Incidents = append(v.Incidents, Incident{
+			Key:             i.Key,
+			OpenedAt:        i.OpenedAt,
+			ResolvedAt:      i.ResolvedAt,
+			ResolveReason:   i.ResolveReason,
+			Severity:        i.Severity,
+			SeverityHistory: i.SeverityHistory,
+			Recomputed:      i.IsRecomputed(),
+		})
+	}
+
+	for _, a := range annotations.Render(incidents, deployments, timeseries.Now()) {
+		for _, ch := range charts {
+			ch.AddAnnotation(a.Name, a.X1, a.X2, a.Icon)
+		}
+	}
+
+	v.AppMap = appMap
+	v.GoldenSignals = featureGoldenSignals(app.Reports, goldenSignals)
+	if offset > 0 {
+		v.Offset = offset
+		addShiftedSeries(v.GoldenSignals, shiftedGoldenSignals(shifted, app.Id, goldenSignals), offset)
+	}
+	return v
+}
+
+// featureGoldenSignals moves the widgets matching the golden signals to the top of their reports
+// and returns them in the order the signals are defined.
+func featureGoldenSignals(reports []*model.AuditReport, goldenSignals []model.GoldenSignal) []*model.Widget {
+	res := []*model.Widget{}
+	for _, gs := range goldenSignals {
+		for _, r := range reports {
 			for _, w := range r.Widgets {
-				var charts []*model.Chart
-				switch {
-				case w.ChartGroup != nil:
-					charts = w.ChartGroup.Charts
-				case w.Chart != nil:
-					charts = []*model.Chart{w.Chart}
-				default:
-					continue
-				}
-				for _, ch := range charts {
-					for _, i := range incidents {
-						ch.AddAnnotation("incident", i.OpenedAt, i.ResolvedAt, "")
-					}
+				if gs.Match(r.Name, widgetTitle(w)) {
+					res = append(res, w)
 				}
 			}
 		}
 	}
+	rank := map[*model.Widget]int{}
+	for i, w := range res {
+		if _, ok := rank[w]; !ok {
+			rank[w] = i
+		}
+	}
+	for _, r := range reports {
+		sort.SliceStable(r.Widgets, func(i, j int) bool {
+			ri, iok := rank[r.Widgets[i]]
+			rj, jok := rank[r.Widgets[j]]
+			if iok && jok {
+				return ri < rj
+			}
+			return iok && !jok
+		})
+	}
+	return res
+}
 
-	return &View{
-		AppMap:  appMap,
-		Reports: app.Reports,
+func widgetTitle(w *model.Widget) string {
+	switch {
+	case w.Chart != nil:
+		return w.Chart.Title
+	case w.ChartGroup != nil:
+		return w.ChartGroup.Title
 	}
+	return ""
 }
 
 func (m *AppMap) addDependency(w *model.World, id model.ApplicationId) {
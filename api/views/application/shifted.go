@@ -0,0 +1,60 @@
+package application
+
+import (
+	"github.com/coroot/coroot/auditor"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/coroot/coroot/utils"
+)
+
+// shiftedGoldenSignals returns the golden signal charts of the application within the shifted world by their titles.
+// It returns nil if there's no data for the shifted window (e.g., it's beyond the cache retention).
+func shiftedGoldenSignals(shifted *model.World, appId model.ApplicationId, goldenSignals []model.GoldenSignal) map[string]*model.Chart {
+	if shifted == nil {
+		return nil
+	}
+	app := shifted.GetApplication(appId)
+	if app == nil {
+		return nil
+	}
+	auditor.Audit(shifted)
+	res := map[string]*model.Chart{}
+	for _, w := range featureGoldenSignals(app.Reports, goldenSignals) {
+		for title, ch := range widgetCharts(w) {
+			res[title] = ch
+		}
+	}
+	return res
+}
+
+// addShiftedSeries adds a shifted counterpart to every series of the golden signal charts. The counterpart of a series
+// missing in the shifted window has no data.
+func addShiftedSeries(widgets []*model.Widget, shifted map[string]*model.Chart, offset timeseries.Duration) {
+	suffix := " (" + utils.FormatDuration(offset.ToStandard(), 2) + " ago)"
+	for _, w := range widgets {
+		for title, ch := range widgetCharts(w) {
+			data := map[string]timeseries.TimeSeries{}
+			if sch := shifted[title]; sch != nil {
+				for _, s := range sch.Series {
+					data[s.Name] = s.Data
+				}
+			}
+			for _, s := range ch.Series {
+				ch.Shifted = append(ch.Shifted, &model.Series{Name: s.Name + suffix, Color: s.Color, Data: timeseries.Shift(data[s.Name], offset)})
+			}
+		}
+	}
+}
+
+func widgetCharts(w *model.Widget) map[string]*model.Chart {
+	res := map[string]*model.Chart{}
+	switch {
+	case w.Chart != nil:
+		res[w.Chart.Title] = w.Chart
+	case w.ChartGroup != nil:
+		for _, ch := range w.ChartGroup.Charts {
+			res[w.ChartGroup.Title+"/"+ch.Title] = ch
+		}
+	}
+	return res
+}
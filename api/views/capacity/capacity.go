@@ -0,0 +1,84 @@
+package capacity
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"math"
+	"sort"
+)
+
+const (
+	// a trend is considered significant if it explains at least half of the variance
+	minR2 = 0.5
+)
+
+type View struct {
+	Resources []Resource `json:"resources"`
+}
+
+type Resource struct {
+	Name             string              `json:"name"`
+	UsagePercent     float64             `json:"usage_percent"`
+	Trend            string              `json:"trend"`
+	GrowthPerDay     float64             `json:"growth_per_day,omitempty"`
+	ExhaustionAt     timeseries.Time     `json:"exhaustion_at,omitempty"`
+	TimeToExhaustion timeseries.Duration `json:"time_to_exhaustion,omitempty"`
+}
+
+func Render(w *model.World, node *model.Node) *View {
+	v := &View{}
+	v.add(w, "cpu", node.CpuUsagePercent)
+	if !timeseries.IsEmpty(node.MemoryAvailableBytes) {
+		used := timeseries.Aggregate(timeseries.Sub, node.MemoryTotalBytes, node.MemoryAvailableBytes)
+		v.add(w, "memory", percentage(used, node.MemoryTotalBytes))
+	}
+	disks := map[string]*model.Volume{}
+	for _, i := range node.Instances {
+		for _, vol := range i.Volumes {
+			if d := vol.Device.Value(); d != "" && !timeseries.IsEmpty(vol.UsedBytes) {
+				disks[d] = vol
+			}
+		}
+	}
+	devices := make([]string, 0, len(disks))
+	for d := range disks {
+		devices = append(devices, d)
+	}
+	sort.Strings(devices)
+	for _, d := range devices {
+		vol := disks[d]
+		v.add(w, "disk "+d, percentage(vol.UsedBytes, vol.CapacityBytes))
+	}
+	return v
+}
+
+func (v *View) add(w *model.World, name string, usage timeseries.TimeSeries) {
+	if timeseries.IsEmpty(usage) {
+		return
+	}
+	r := Resource{Name: name, UsagePercent: timeseries.Last(usage), Trend: "no trend"}
+	if math.IsNaN(r.UsagePercent) {
+		return
+	}
+	tr := timeseries.NewTrend(usage)
+	if tr != nil && tr.Slope > 0 && tr.R2 >= minR2 {
+		r.Trend = "growing"
+		r.GrowthPerDay = tr.Slope * float64(24*timeseries.Hour)
+		if at := tr.TimeToReach(100); !at.IsZero() {
+			r.ExhaustionAt = at
+			if at.After(w.Ctx.To) {
+				r.TimeToExhaustion = at.Sub(w.Ctx.To)
+			}
+		}
+	}
+	v.Resources = append(v.Resources, r)
+}
+
+func percentage(used, total timeseries.TimeSeries) timeseries.TimeSeries {
+	if timeseries.IsEmpty(used) || timeseries.IsEmpty(total) {
+		return nil
+	}
+	return timeseries.Map(func(t timeseries.Time, v float64) float64 {
+		return v * 100
+	}, timeseries.Aggregate(timeseries.Div, used, total))
+}
@@ -8,7 +8,8 @@ import (
 )
 
 type View struct {
-	Categories []Category `json:"categories"`
+	Categories    []Category `json:"categories"`
+	CategoryLabel string     `json:"category_label"`
 }
 
 type Category struct {
@@ -41,5 +42,5 @@ func Render(p *db.Project) *View {
 		return categories[i].Name < categories[j].Name
 	})
 
-	return &View{Categories: categories}
+	return &View{Categories: categories, CategoryLabel: p.Settings.ApplicationCategoryLabel}
 }
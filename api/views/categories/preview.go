@@ -0,0 +1,57 @@
+package categories
+
+import (
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"sort"
+)
+
+type Preview struct {
+	Applications []PreviewApplication `json:"applications"`
+	Moved        int                  `json:"moved"`
+}
+
+type PreviewApplication struct {
+	Id      model.ApplicationId       `json:"id"`
+	Current model.ApplicationCategory `json:"current"`
+	New     model.ApplicationCategory `json:"new"`
+	Moved   bool                      `json:"moved"`
+}
+
+// RenderPreview compares the current category of every application with the one it would get if the custom patterns
+// of the given categories were replaced with the candidate ones. The moved applications are listed first.
+func RenderPreview(w *model.World, p *db.Project, candidate map[model.ApplicationCategory][]string) *Preview {
+	patterns := map[model.ApplicationCategory][]string{}
+	for c, ps := range p.Settings.ApplicationCategories {
+		patterns[c] = ps
+	}
+	for c, ps := range candidate {
+		if len(ps) == 0 {
+			delete(patterns, c)
+			continue
+		}
+		patterns[c] = ps
+	}
+
+	v := &Preview{Applications: []PreviewApplication{}}
+	for _, app := range w.Applications {
+		a := PreviewApplication{
+			Id:      app.Id,
+			Current: model.CalcApplicationCategory(app, p.Settings.ApplicationCategories, p.Settings.ApplicationCategoryLabel),
+			New:     model.CalcApplicationCategory(app, patterns, p.Settings.ApplicationCategoryLabel),
+		}
+		if a.Current != a.New {
+			a.Moved = true
+			v.Moved++
+		}
+		v.Applications = append(v.Applications, a)
+	}
+	sort.Slice(v.Applications, func(i, j int) bool {
+		ai, aj := v.Applications[i], v.Applications[j]
+		if ai.Moved != aj.Moved {
+			return ai.Moved
+		}
+		return ai.Id.String() < aj.Id.String()
+	})
+	return v
+}
@@ -0,0 +1,33 @@
+package categories
+
+import (
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestRenderPreview(t *testing.T) {
+	w := model.NewWorld(0, timeseries.Time(3600), timeseries.Minute)
+	for _, id := range []string{"default/api", "default/db", "kube-system/coredns", "tools/vault"} {
+		ns, name, _ := strings.Cut(id, "/")
+		w.GetOrCreateApplication(model.NewApplicationId(ns, model.ApplicationKindDeployment, name))
+	}
+	p := &db.Project{}
+	p.Settings.ApplicationCategories = map[model.ApplicationCategory][]string{"infra": {"tools/*"}}
+
+	v := RenderPreview(w, p, map[model.ApplicationCategory][]string{
+		"infra":     nil,
+		"databases": {"*/db"},
+	})
+	assert.Equal(t, 2, v.Moved)
+	assert.Equal(t, []PreviewApplication{
+		{Id: model.NewApplicationId("default", model.ApplicationKindDeployment, "db"), Current: "application", New: "databases", Moved: true},
+		{Id: model.NewApplicationId("tools", model.ApplicationKindDeployment, "vault"), Current: "infra", New: "application", Moved: true},
+		{Id: model.NewApplicationId("default", model.ApplicationKindDeployment, "api"), Current: "application", New: "application"},
+		{Id: model.NewApplicationId("kube-system", model.ApplicationKindDeployment, "coredns"), Current: "control-plane", New: "control-plane"},
+	}, v.Applications)
+	assert.Equal(t, []string{"tools/*"}, p.Settings.ApplicationCategories["infra"])
+}
@@ -0,0 +1,65 @@
+package checks
+
+import (
+	"github.com/coroot/coroot/auditor"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"math"
+	"sort"
+)
+
+type View struct {
+	Id    model.CheckId   `json:"id"`
+	Title string          `json:"title"`
+	Unit  model.CheckUnit `json:"unit"`
+
+	Applications []Application `json:"applications"`
+}
+
+type Application struct {
+	Id        model.ApplicationId `json:"id"`
+	Status    model.Status        `json:"status"`
+	Message   string              `json:"message"`
+	Value     timeseries.Value    `json:"value"`
+	Threshold float64             `json:"threshold"`
+}
+
+// Render evaluates the check across all the applications of the world.
+func Render(w *model.World, cfg *model.CheckConfig) *View {
+	auditor.Audit(w)
+	return render(w, cfg)
+}
+
+// render lists the applications the check has been evaluated for, worst first:
+// by status, then by the value the status is based on, then by id.
+func render(w *model.World, cfg *model.CheckConfig) *View {
+	v := &View{Id: cfg.Id, Title: cfg.Title, Unit: cfg.Unit, Applications: []Application{}}
+	for _, app := range w.Applications {
+		ch := app.GetCheck(cfg.Id)
+		if ch == nil {
+			continue
+		}
+		v.Applications = append(v.Applications, Application{
+			Id:        app.Id,
+			Status:    ch.Status,
+			Message:   ch.Message,
+			Value:     timeseries.Value(ch.Value()),
+			Threshold: ch.Threshold,
+		})
+	}
+	sort.Slice(v.Applications, func(i, j int) bool {
+		ai, aj := v.Applications[i], v.Applications[j]
+		if ai.Status != aj.Status {
+			return ai.Status > aj.Status
+		}
+		vi, vj := float64(ai.Value), float64(aj.Value)
+		if math.IsNaN(vi) != math.IsNaN(vj) {
+			return math.IsNaN(vj)
+		}
+		if vi != vj && !math.IsNaN(vi) {
+			return vi > vj
+		}
+		return ai.Id.String() < aj.Id.String()
+	})
+	return v
+}
@@ -0,0 +1,39 @@
+package checks
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	w := model.NewWorld(0, timeseries.Time(3600), timeseries.Minute)
+	cfg := model.Checks.InstanceRestarts
+	app := func(name string, restarts int64) {
+		a := w.GetOrCreateApplication(model.NewApplicationId("default", model.ApplicationKindDeployment, name))
+		report := model.NewAuditReport(a.Id, w.Ctx, nil, model.AuditReportInstances)
+		if restarts >= 0 {
+			ch := report.CreateCheck(cfg)
+			ch.Inc(restarts)
+			ch.Calc()
+		}
+		a.Reports = append(a.Reports, report)
+	}
+	app("a", 0)
+	app("b", 5)
+	app("c", 10)
+	app("d", 5)
+	app("unchecked", -1)
+
+	v := render(w, &cfg)
+	assert.Equal(t, cfg.Id, v.Id)
+	var names []string
+	for _, a := range v.Applications {
+		names = append(names, a.Id.Name)
+	}
+	assert.Equal(t, []string{"c", "b", "d", "a"}, names)
+	assert.Equal(t, model.WARNING, v.Applications[0].Status)
+	assert.Equal(t, timeseries.Value(10), v.Applications[0].Value)
+	assert.Equal(t, model.OK, v.Applications[3].Status)
+}
@@ -2,12 +2,12 @@ package configs
 
 import (
 	"github.com/coroot/coroot/model"
-	"github.com/coroot/coroot/utils"
 	"k8s.io/klog"
 )
 
 type View struct {
-	configs model.CheckConfigs
+	configs   model.CheckConfigs
+	objective func(checkId model.CheckId, appId model.ApplicationId) float64
 
 	Checks []Check `json:"checks"`
 }
@@ -16,17 +16,27 @@ type Check struct {
 	model.Check
 	GlobalThreshold      float64       `json:"global_threshold"`
 	ProjectThreshold     *float64      `json:"project_threshold"`
+	NamespaceOverrides   []Namespace   `json:"namespace_overrides"`
 	ApplicationOverrides []Application `json:"application_overrides"`
 }
 
+type Namespace struct {
+	Name      string  `json:"name"`
+	Threshold float64 `json:"threshold"`
+}
+
 type Application struct {
 	Id        model.ApplicationId `json:"id"`
 	Threshold float64             `json:"threshold"`
 	Details   string              `json:"details"`
+	// whether the threshold is the objective inherited from the category or project defaults
+	Inherited bool `json:"inherited"`
 }
 
-func Render(configs model.CheckConfigs) *View {
-	v := &View{configs: configs}
+// Render lists the checks and their overrides. The objective function returns the inherited objective of the SLO checks
+// of the application that have no objective of their own.
+func Render(configs model.CheckConfigs, objective func(checkId model.CheckId, appId model.ApplicationId) float64) *View {
+	v := &View{configs: configs, objective: objective}
 	cs := model.Checks
 
 	v.addReport(model.AuditReportSLO, cs.SLOAvailability, cs.SLOLatency)
@@ -57,29 +67,28 @@ func (v *View) addReport(name model.AuditReportName, checks ...model.CheckConfig
 			for _, unk := range configs {
 				switch cfg := unk.(type) {
 				case model.CheckConfigSimple:
-					if appId.IsZero() {
+					switch {
+					case appId.IsZero():
 						t := cfg.Threshold
 						ch.ProjectThreshold = &t
-					} else {
+					case appId.IsNamespace():
+						ch.NamespaceOverrides = append(ch.NamespaceOverrides, Namespace{
+							Name:      appId.Namespace,
+							Threshold: cfg.Threshold,
+						})
+					default:
 						ch.ApplicationOverrides = append(ch.ApplicationOverrides, Application{
 							Id:        appId,
 							Threshold: cfg.Threshold,
 						})
 					}
 				case []model.CheckConfigSLOAvailability:
-					for _, c := range cfg {
-						ch.ApplicationOverrides = append(ch.ApplicationOverrides, Application{
-							Id:        appId,
-							Threshold: c.ObjectivePercentage,
-						})
+					for _, cc := range cfg {
+						ch.ApplicationOverrides = append(ch.ApplicationOverrides, v.sloOverride(c.Id, appId, cc.ObjectivePercentage, ""))
 					}
 				case []model.CheckConfigSLOLatency:
-					for _, c := range cfg {
-						ch.ApplicationOverrides = append(ch.ApplicationOverrides, Application{
-							Id:        appId,
-							Threshold: c.ObjectivePercentage,
-							Details:   "< " + utils.FormatLatency(c.ObjectiveBucket),
-						})
+					for _, cc := range cfg {
+						ch.ApplicationOverrides = append(ch.ApplicationOverrides, v.sloOverride(c.Id, appId, cc.ObjectivePercentage, "< "+cc.FormatBucket(cc.ObjectiveBucket)))
 					}
 				default:
 					klog.Warningln("unknown config type")
@@ -89,3 +98,12 @@ func (v *View) addReport(name model.AuditReportName, checks ...model.CheckConfig
 		v.Checks = append(v.Checks, ch)
 	}
 }
+
+func (v *View) sloOverride(checkId model.CheckId, appId model.ApplicationId, objective float64, details string) Application {
+	a := Application{Id: appId, Threshold: objective, Details: details}
+	if objective <= 0 && v.objective != nil {
+		a.Threshold = v.objective(checkId, appId)
+		a.Inherited = true
+	}
+	return a
+}
@@ -0,0 +1,33 @@
+package configs
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRenderInheritedObjective(t *testing.T) {
+	own := model.NewApplicationId("prod", model.ApplicationKindDeployment, "own")
+	inherited := model.NewApplicationId("prod", model.ApplicationKindDeployment, "inherited")
+	configs := model.CheckConfigs{
+		own:       {model.Checks.SLOAvailability.Id: []byte(`[{"total_requests_query": "t", "failed_requests_query": "f", "objective_percentage": 99.5}]`)},
+		inherited: {model.Checks.SLOAvailability.Id: []byte(`[{"total_requests_query": "t", "failed_requests_query": "f"}]`)},
+	}
+	objective := func(checkId model.CheckId, appId model.ApplicationId) float64 {
+		assert.Equal(t, model.Checks.SLOAvailability.Id, checkId)
+		assert.Equal(t, inherited, appId)
+		return 99.9
+	}
+	v := Render(configs, objective)
+	var overrides map[model.ApplicationId]Application
+	for _, c := range v.Checks {
+		if c.Id == model.Checks.SLOAvailability.Id {
+			overrides = map[model.ApplicationId]Application{}
+			for _, a := range c.ApplicationOverrides {
+				overrides[a.Id] = a
+			}
+		}
+	}
+	assert.Equal(t, Application{Id: own, Threshold: 99.5}, overrides[own])
+	assert.Equal(t, Application{Id: inherited, Threshold: 99.9, Inherited: true}, overrides[inherited])
+}
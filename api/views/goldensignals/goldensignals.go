@@ -0,0 +1,42 @@
+package goldensignals
+
+import (
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"sort"
+)
+
+type View struct {
+	Kinds []Kind `json:"kinds"`
+}
+
+type Kind struct {
+	Kind    model.ApplicationKind `json:"kind"`
+	Custom  bool                  `json:"custom"`
+	Signals []model.GoldenSignal  `json:"signals"`
+	Builtin []model.GoldenSignal  `json:"builtin"`
+}
+
+func Render(p *db.Project) *View {
+	kinds := map[model.ApplicationKind]bool{}
+	for k := range model.BuiltinGoldenSignals {
+		kinds[k] = true
+	}
+	for k := range p.Settings.GoldenSignals {
+		kinds[k] = true
+	}
+	v := &View{}
+	for k := range kinds {
+		_, custom := p.Settings.GoldenSignals[k]
+		v.Kinds = append(v.Kinds, Kind{
+			Kind:    k,
+			Custom:  custom,
+			Signals: model.GetGoldenSignals(k, p.Settings.GoldenSignals),
+			Builtin: model.BuiltinGoldenSignals[k],
+		})
+	}
+	sort.Slice(v.Kinds, func(i, j int) bool {
+		return v.Kinds[i].Kind < v.Kinds[j].Kind
+	})
+	return v
+}
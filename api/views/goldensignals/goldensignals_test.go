@@ -0,0 +1,31 @@
+package goldensignals
+
+import (
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	custom := []model.GoldenSignal{{Report: model.AuditReportCPU, Chart: "CPU usage"}}
+	p := &db.Project{Settings: db.Settings{GoldenSignals: map[model.ApplicationKind][]model.GoldenSignal{
+		model.ApplicationKindDeployment: custom,
+		"NewKind":                       custom,
+	}}}
+	v := Render(p)
+	assert.Len(t, v.Kinds, len(model.BuiltinGoldenSignals)+1)
+	kinds := map[model.ApplicationKind]Kind{}
+	for i, k := range v.Kinds {
+		if i > 0 {
+			assert.Less(t, string(v.Kinds[i-1].Kind), string(k.Kind))
+		}
+		kinds[k.Kind] = k
+	}
+
+	assert.Equal(t, Kind{Kind: model.ApplicationKindDeployment, Custom: true, Signals: custom, Builtin: model.BuiltinGoldenSignals[model.ApplicationKindDeployment]}, kinds[model.ApplicationKindDeployment])
+	assert.Equal(t, Kind{Kind: "NewKind", Custom: true, Signals: custom}, kinds["NewKind"])
+	sts := kinds[model.ApplicationKindStatefulSet]
+	assert.False(t, sts.Custom)
+	assert.Equal(t, sts.Builtin, sts.Signals)
+}
@@ -0,0 +1,225 @@
+package grafana
+
+import (
+	"fmt"
+	"github.com/coroot/coroot/constructor"
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/prom"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	panelWidth  = 12
+	panelHeight = 8
+)
+
+type Dashboard struct {
+	Title         string     `json:"title"`
+	Description   string     `json:"description"`
+	Tags          []string   `json:"tags"`
+	SchemaVersion int        `json:"schemaVersion"`
+	Time          TimeRange  `json:"time"`
+	Templating    Templating `json:"templating"`
+	Panels        []Panel    `json:"panels"`
+}
+
+type TimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type Templating struct {
+	List []Variable `json:"list"`
+}
+
+type Variable struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	Type  string `json:"type"`
+	Query string `json:"query"`
+}
+
+type Datasource struct {
+	Type string `json:"type"`
+	Uid  string `json:"uid"`
+}
+
+type GridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type Target struct {
+	RefId        string `json:"refId"`
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat"`
+}
+
+type FieldConfig struct {
+	Defaults struct {
+		Unit string `json:"unit"`
+	} `json:"defaults"`
+}
+
+type Panel struct {
+	Id          int         `json:"id"`
+	Type        string      `json:"type"`
+	Title       string      `json:"title"`
+	Datasource  Datasource  `json:"datasource"`
+	GridPos     GridPos     `json:"gridPos"`
+	Targets     []Target    `json:"targets"`
+	FieldConfig FieldConfig `json:"fieldConfig"`
+}
+
+type selectors struct {
+	containers   string // matches the containers of the application
+	destinations string // matches the requests to the application
+}
+
+type panel struct {
+	report model.AuditReportName
+	chart  string
+	title  string
+	unit   string
+	// queries with the $CONTAINERS and $DESTINATIONS placeholders
+	queries []Target
+}
+
+var panels = []panel{
+	{report: model.AuditReportSLO, chart: "Requests to", title: "Requests", unit: "reqps", queries: []Target{
+		{Expr: `sum by(status) (` + query("container_http_requests_count", "$DESTINATIONS") + `)`, LegendFormat: "{{status}}"},
+	}},
+	{report: model.AuditReportSLO, chart: "Availability", title: "Availability", unit: "percentunit", queries: []Target{
+		{Expr: `1 - sum(` + query("container_http_requests_count", `$DESTINATIONS, status=~"5.."`) + `) / sum(` + query("container_http_requests_count", "$DESTINATIONS") + `)`, LegendFormat: "availability"},
+	}},
+	{report: model.AuditReportSLO, chart: "Latency", title: "Latency", unit: "s", queries: []Target{
+		{Expr: `histogram_quantile(0.5, sum by(le) (` + query("container_http_requests_histogram", "$DESTINATIONS") + `))`, LegendFormat: "p50"},
+		{Expr: `histogram_quantile(0.95, sum by(le) (` + query("container_http_requests_histogram", "$DESTINATIONS") + `))`, LegendFormat: "p95"},
+		{Expr: `histogram_quantile(0.99, sum by(le) (` + query("container_http_requests_histogram", "$DESTINATIONS") + `))`, LegendFormat: "p99"},
+	}},
+	{report: model.AuditReportCPU, chart: "CPU usage of container", title: "CPU usage", unit: "short", queries: []Target{
+		{Expr: query("container_cpu_usage", "$CONTAINERS"), LegendFormat: "{{container_id}}"},
+	}},
+	{report: model.AuditReportMemory, chart: "Memory usage (RSS)", title: "Memory usage (RSS)", unit: "bytes", queries: []Target{
+		{Expr: query("container_memory_rss", "$CONTAINERS"), LegendFormat: "{{container_id}}"},
+	}},
+	{report: model.AuditReportMemory, chart: "Out of memory events", title: "Out of memory events", unit: "short", queries: []Target{
+		{Expr: `sum(increase(` + query("container_oom_kills_total", "$CONTAINERS") + `[$__rate_interval]))`, LegendFormat: "OOM kills"},
+	}},
+	{report: model.AuditReportInstances, chart: "Instances", title: "Instances", unit: "short", queries: []Target{
+		{Expr: `count(` + query("container_memory_rss", "$CONTAINERS") + `)`, LegendFormat: "instances"},
+	}},
+	{report: model.AuditReportStorage, chart: "Disk space", title: "Disk space used", unit: "bytes", queries: []Target{
+		{Expr: query("container_volume_used", "$CONTAINERS"), LegendFormat: "{{container_id}} {{mount_point}}"},
+	}},
+	{report: model.AuditReportNetwork, chart: "Network round-trip time", title: "Network round-trip time", unit: "s", queries: []Target{
+		{Expr: `max by(destination_ip) (` + query("container_net_latency", "$CONTAINERS") + `)`, LegendFormat: "{{destination_ip}}"},
+	}},
+}
+
+// query returns the constructor's query with the given matchers and the Grafana rate interval.
+func query(name, matchers string) string {
+	q := strings.ReplaceAll(constructor.QUERIES[name], "$RANGE", "$__rate_interval")
+	return prom.InjectSelector(q, matchers)
+}
+
+// Render builds a Grafana dashboard with a panel for every golden signal of the application
+// that can be expressed as a Prometheus query. The other signals are listed in the description.
+func Render(p *db.Project, app *model.Application, signals []model.GoldenSignal) *Dashboard {
+	d := &Dashboard{
+		Title:         fmt.Sprintf("%s (%s)", app.Id.Name, p.Name),
+		Tags:          []string{"coroot", p.Name},
+		SchemaVersion: 36,
+		Time:          TimeRange{From: "now-1h", To: "now"},
+		Templating: Templating{List: []Variable{
+			{Name: "datasource", Label: "Prometheus", Type: "datasource", Query: "prometheus"},
+		}},
+		Panels: []Panel{},
+	}
+	s := getSelectors(app)
+	var skipped []string
+	for _, signal := range signals {
+		pn := findPanel(signal)
+		if pn == nil || (s.destinations == "" && usesDestinations(pn)) {
+			skipped = append(skipped, string(signal.Report)+": "+signal.Chart)
+			continue
+		}
+		n := len(d.Panels)
+		panel := Panel{
+			Id:         n + 1,
+			Type:       "timeseries",
+			Title:      pn.title,
+			Datasource: Datasource{Type: "prometheus", Uid: "${datasource}"},
+			GridPos:    GridPos{H: panelHeight, W: panelWidth, X: (n % 2) * panelWidth, Y: (n / 2) * panelHeight},
+		}
+		panel.FieldConfig.Defaults.Unit = pn.unit
+		for i, t := range pn.queries {
+			expr := strings.NewReplacer("$CONTAINERS", s.containers, "$DESTINATIONS", s.destinations).Replace(t.Expr)
+			panel.Targets = append(panel.Targets, Target{
+				RefId:        string(rune('A' + i)),
+				Expr:         prom.InjectSelector(expr, p.Prometheus.ExtraSelector),
+				LegendFormat: t.LegendFormat,
+			})
+		}
+		d.Panels = append(d.Panels, panel)
+	}
+	d.Description = fmt.Sprintf("Exported from Coroot for %s, the Prometheus datasource is expected to point to %s.", app.Id, p.Prometheus.Url)
+	if len(skipped) > 0 {
+		d.Description += " Not exported: " + strings.Join(skipped, ", ") + "."
+	}
+	return d
+}
+
+func findPanel(signal model.GoldenSignal) *panel {
+	for i := range panels {
+		p := &panels[i]
+		chart, prefix := strings.ToLower(p.chart), strings.ToLower(signal.Chart)
+		if p.report == signal.Report && (strings.HasPrefix(chart, prefix) || strings.HasPrefix(prefix, chart)) {
+			return p
+		}
+	}
+	return nil
+}
+
+func usesDestinations(p *panel) bool {
+	for _, t := range p.queries {
+		if strings.Contains(t.Expr, "$DESTINATIONS") {
+			return true
+		}
+	}
+	return false
+}
+
+func getSelectors(app *model.Application) selectors {
+	var s selectors
+	name := regexp.QuoteMeta(app.Id.Name)
+	if app.Id.Kind == model.ApplicationKindUnknown {
+		s.containers = "container_id=~" + strconv.Quote(".*/"+name+`(\.service)?`)
+	} else {
+		s.containers = "container_id=~" + strconv.Quote("/k8s/"+regexp.QuoteMeta(app.Id.Namespace)+"/"+name+"-[^/]+/[^/]+")
+	}
+	destinations := map[string]bool{}
+	for _, i := range app.Instances {
+		for l := range i.TcpListens {
+			if !l.Proxied {
+				destinations[regexp.QuoteMeta(net.JoinHostPort(l.IP, l.Port))] = true
+			}
+		}
+	}
+	if len(destinations) > 0 {
+		ds := make([]string, 0, len(destinations))
+		for d := range destinations {
+			ds = append(ds, d)
+		}
+		sort.Strings(ds)
+		s.destinations = "actual_destination=~" + strconv.Quote(strings.Join(ds, "|"))
+	}
+	return s
+}
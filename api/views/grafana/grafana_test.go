@@ -0,0 +1,42 @@
+package grafana
+
+import (
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	p := &db.Project{Name: "prod", Prometheus: db.Prometheus{Url: "http://prometheus:9090", ExtraSelector: `{cluster="us-east"}`}}
+	app := model.NewApplication(model.NewApplicationId("default", model.ApplicationKindDeployment, "api"))
+	signals := []model.GoldenSignal{
+		{Report: model.AuditReportSLO, Chart: "Requests to"},
+		{Report: model.AuditReportCPU, Chart: "CPU usage"},
+		{Report: model.AuditReportLogs, Chart: "Messages"},
+	}
+
+	d := Render(p, app, signals)
+	assert.Equal(t, "api (prod)", d.Title)
+	require.Len(t, d.Panels, 1)
+	cpu := d.Panels[0]
+	assert.Equal(t, "CPU usage", cpu.Title)
+	assert.Equal(t, GridPos{H: panelHeight, W: panelWidth}, cpu.GridPos)
+	require.Len(t, cpu.Targets, 1)
+	assert.Contains(t, cpu.Targets[0].Expr, `container_id=~"/k8s/default/api-[^/]+/[^/]+"`)
+	assert.Contains(t, cpu.Targets[0].Expr, `cluster="us-east"`)
+	// the requests can't be selected without the addresses of the instances
+	assert.Contains(t, d.Description, "Not exported: SLO: Requests to, Logs: Messages.")
+
+	i := app.GetOrCreateInstance("api-1")
+	i.TcpListens[model.Listen{IP: "10.0.0.1", Port: "8080"}] = true
+	i.TcpListens[model.Listen{IP: "10.0.0.2", Port: "8080", Proxied: true}] = true
+	d = Render(p, app, signals)
+	require.Len(t, d.Panels, 2)
+	requests := d.Panels[0]
+	assert.Equal(t, "Requests", requests.Title)
+	assert.Contains(t, requests.Targets[0].Expr, `actual_destination=~"10\\.0\\.0\\.1:8080"`)
+	assert.Equal(t, GridPos{H: panelHeight, W: panelWidth, X: panelWidth}, d.Panels[1].GridPos)
+	assert.Contains(t, d.Description, "Not exported: Logs: Messages.")
+}
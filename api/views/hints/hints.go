@@ -0,0 +1,55 @@
+package hints
+
+import (
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"sort"
+)
+
+type View struct {
+	Hints []Hint `json:"hints"`
+}
+
+type Hint struct {
+	Type         model.ApplicationType `json:"type"`
+	Muted        bool                  `json:"muted"`
+	Applications []model.ApplicationId `json:"applications"`
+}
+
+// Render lists the application types whose exporters are missing for at least one application,
+// along with the types that are muted but no longer detected, so they can still be unmuted.
+func Render(p *db.Project, w *model.World) *View {
+	byType := map[model.ApplicationType]*Hint{}
+	for appType, muted := range p.Settings.ConfigurationHintsMuted {
+		if muted {
+			byType[appType] = &Hint{Type: appType, Muted: true}
+		}
+	}
+	if w != nil {
+		for _, app := range w.Applications {
+			for appType, ok := range app.InstrumentationStatus() {
+				if ok {
+					continue
+				}
+				h := byType[appType]
+				if h == nil {
+					h = &Hint{Type: appType}
+					byType[appType] = h
+				}
+				h.Applications = append(h.Applications, app.Id)
+			}
+		}
+	}
+
+	v := &View{}
+	for _, h := range byType {
+		sort.Slice(h.Applications, func(i, j int) bool {
+			return h.Applications[i].String() < h.Applications[j].String()
+		})
+		v.Hints = append(v.Hints, *h)
+	}
+	sort.Slice(v.Hints, func(i, j int) bool {
+		return v.Hints[i].Type < v.Hints[j].Type
+	})
+	return v
+}
@@ -0,0 +1,44 @@
+package hints
+
+import (
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	w := model.NewWorld(0, 3600, 60)
+	app := func(name string, appType model.ApplicationType, instrumented bool) model.ApplicationId {
+		a := w.GetOrCreateApplication(model.NewApplicationId("default", model.ApplicationKindStatefulSet, name))
+		i := a.GetOrCreateInstance(name + "-0")
+		c := model.NewContainer(name)
+		c.ApplicationTypes[appType] = true
+		i.Containers[c.Name] = c
+		if instrumented {
+			i.Postgres = &model.Postgres{}
+		}
+		return a.Id
+	}
+	pg2 := app("pg2", model.ApplicationTypePostgres, false)
+	pg1 := app("pg1", model.ApplicationTypePostgres, false)
+	app("pg3", model.ApplicationTypePostgres, true)
+	redis := app("redis", model.ApplicationTypeRedis, false)
+
+	p := &db.Project{Settings: db.Settings{ConfigurationHintsMuted: map[model.ApplicationType]bool{
+		model.ApplicationTypeRedis:     true,
+		model.ApplicationTypeMongodb:   true,
+		model.ApplicationTypeZookeeper: false,
+	}}}
+	assert.Equal(t, []Hint{
+		{Type: model.ApplicationTypeMongodb, Muted: true},
+		{Type: model.ApplicationTypePostgres, Applications: []model.ApplicationId{pg1, pg2}},
+		{Type: model.ApplicationTypeRedis, Muted: true, Applications: []model.ApplicationId{redis}},
+	}, Render(p, w).Hints)
+
+	// the muted types are listed even if the world isn't loaded
+	assert.Equal(t, []Hint{
+		{Type: model.ApplicationTypeMongodb, Muted: true},
+		{Type: model.ApplicationTypeRedis, Muted: true},
+	}, Render(p, nil).Hints)
+}
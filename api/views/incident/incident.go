@@ -0,0 +1,132 @@
+package incident
+
+import (
+	"fmt"
+	"github.com/coroot/coroot/auditor"
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"sort"
+)
+
+type View struct {
+	Key           string              `json:"key"`
+	ApplicationId model.ApplicationId `json:"application_id"`
+	OpenedAt      timeseries.Time     `json:"opened_at"`
+	ResolvedAt    timeseries.Time     `json:"resolved_at"`
+	ResolveReason string              `json:"resolve_reason,omitempty"`
+	Severity      model.Status        `json:"severity"`
+	Acknowledged  bool                `json:"acknowledged"`
+	SnoozedUntil  timeseries.Time     `json:"snoozed_until"`
+	RecomputedAt  timeseries.Time     `json:"recomputed_at"`
+
+	PeakBurnRate    timeseries.Value `json:"peak_burn_rate"`
+	CurrentBurnRate timeseries.Value `json:"current_burn_rate"`
+
+	// the incident of an upstream application this one is attributed to and the incidents attributed to this one
+	CausedBy   *Link  `json:"caused_by,omitempty"`
+	Dependents []Link `json:"dependents,omitempty"`
+
+	Application *Application    `json:"application"`
+	Timeline    []Event         `json:"timeline"`
+	Warnings    []string        `json:"warnings,omitempty"`
+	StaleSince  timeseries.Time `json:"stale_since,omitempty"`
+}
+
+type Application struct {
+	Status     model.Status         `json:"status"`
+	Indicators []model.Indicator    `json:"indicators"`
+	Reports    []*model.AuditReport `json:"reports"`
+}
+
+type Link struct {
+	Key           string              `json:"key"`
+	ApplicationId model.ApplicationId `json:"application_id"`
+	Severity      model.Status        `json:"severity"`
+	OpenedAt      timeseries.Time     `json:"opened_at"`
+	ResolvedAt    timeseries.Time     `json:"resolved_at"`
+}
+
+func newLink(i *db.Incident) Link {
+	return Link{Key: i.Key, ApplicationId: i.ApplicationId, Severity: i.Severity, OpenedAt: i.OpenedAt, ResolvedAt: i.ResolvedAt}
+}
+
+type Event struct {
+	Time    timeseries.Time `json:"time"`
+	Type    string          `json:"type"`
+	Message string          `json:"message"`
+}
+
+// Render describes the incident along with the state of the affected application in the given world.
+// The application is nil if it's no longer present in the world.
+// causedBy (may be nil) and dependents are the incidents linked to this one by the root-cause correlation.
+func Render(w *model.World, i *db.Incident, causedBy *db.Incident, dependents []db.Incident, deployments []db.Deployment, now timeseries.Time) *View {
+	v := &View{
+		Key:           i.Key,
+		ApplicationId: i.ApplicationId,
+		OpenedAt:      i.OpenedAt,
+		ResolvedAt:    i.ResolvedAt,
+		ResolveReason: i.ResolveReason,
+		Severity:      i.Severity,
+		Acknowledged:  i.IsAcknowledged(),
+		RecomputedAt:  i.RecomputedAt,
+		PeakBurnRate:  timeseries.Value(i.PeakBurnRate),
+
+		CurrentBurnRate: timeseries.Value(timeseries.NaN),
+		Warnings:        w.Warnings,
+		StaleSince:      w.StaleSince,
+	}
+	if i.IsSnoozed(now) {
+		v.SnoozedUntil = i.SnoozedUntil
+	}
+	if causedBy != nil {
+		l := newLink(causedBy)
+		v.CausedBy = &l
+	}
+	for _, d := range dependents {
+		v.Dependents = append(v.Dependents, newLink(&d))
+	}
+
+	auditor.Audit(w)
+	if app := w.GetApplication(i.ApplicationId); app != nil {
+		v.Application = &Application{
+			Status:     app.Status,
+			Indicators: model.CalcIndicators(app),
+			Reports:    app.Reports,
+		}
+		v.CurrentBurnRate = timeseries.Value(app.SLOBurnRate())
+	}
+
+	v.Timeline = timeline(i, deployments)
+	return v
+}
+
+func timeline(i *db.Incident, deployments []db.Deployment) []Event {
+	events := []Event{{Time: i.OpenedAt, Type: "opened", Message: "incident opened"}}
+	for _, c := range i.SeverityHistory {
+		if c.Time == i.OpenedAt {
+			continue
+		}
+		events = append(events, Event{Time: c.Time, Type: "severity", Message: fmt.Sprintf("severity changed to %s", c.Severity)})
+	}
+	if !i.SentAt.IsZero() {
+		events = append(events, Event{Time: i.SentAt, Type: "notified", Message: "notification sent"})
+	}
+	if !i.AcknowledgedAt.IsZero() {
+		events = append(events, Event{Time: i.AcknowledgedAt, Type: "acknowledged", Message: "incident acknowledged"})
+	}
+	for _, d := range deployments {
+		events = append(events, Event{Time: d.Timestamp, Type: "deployment", Message: fmt.Sprintf("version %s deployed", d.Version)})
+	}
+	if !i.ResolvedAt.IsZero() {
+		msg := "incident resolved"
+		if i.ResolveReason != "" {
+			msg += ": " + i.ResolveReason
+		}
+		events = append(events, Event{Time: i.ResolvedAt, Type: "resolved", Message: msg})
+	}
+	sort.SliceStable(events, func(a, b int) bool {
+		return events[a].Time < events[b].Time
+	})
+	return events
+}
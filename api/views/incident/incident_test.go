@@ -0,0 +1,54 @@
+package incident
+
+import (
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	appId := model.NewApplicationId("default", model.ApplicationKindDeployment, "api")
+	upstream := model.NewApplicationId("default", model.ApplicationKindStatefulSet, "db")
+	i := &db.Incident{
+		Key:           "abcd",
+		ApplicationId: appId,
+		OpenedAt:      100,
+		ResolvedAt:    500,
+		ResolveReason: db.IncidentResolveReasonDataLost,
+		Severity:      model.CRITICAL,
+		SentAt:        110,
+		SnoozedUntil:  2000,
+		SeverityHistory: []db.SeverityChange{
+			{Time: 100, Severity: model.WARNING},
+			{Time: 300, Severity: model.CRITICAL},
+		},
+	}
+	causedBy := &db.Incident{Key: "efgh", ApplicationId: upstream, OpenedAt: 90, Severity: model.WARNING}
+	deployments := []db.Deployment{{ApplicationId: appId, Version: "v2", Timestamp: 200}}
+
+	w := model.NewWorld(0, 600, 60)
+	v := Render(w, i, causedBy, nil, deployments, 1000)
+	assert.Equal(t, "abcd", v.Key)
+	assert.Nil(t, v.Application, "the application is no longer present")
+	assert.Equal(t, timeseries.Time(2000), v.SnoozedUntil)
+	require.NotNil(t, v.CausedBy)
+	assert.Equal(t, Link{Key: "efgh", ApplicationId: upstream, Severity: model.WARNING, OpenedAt: 90}, *v.CausedBy)
+	assert.Equal(t, []Event{
+		{Time: 100, Type: "opened", Message: "incident opened"},
+		{Time: 110, Type: "notified", Message: "notification sent"},
+		{Time: 200, Type: "deployment", Message: "version v2 deployed"},
+		{Time: 300, Type: "severity", Message: "severity changed to critical"},
+		{Time: 500, Type: "resolved", Message: "incident resolved: data lost"},
+	}, v.Timeline)
+
+	w = model.NewWorld(0, 600, 60)
+	w.GetOrCreateApplication(appId)
+	v = Render(w, i, nil, []db.Incident{*causedBy}, nil, 3000)
+	assert.NotNil(t, v.Application)
+	assert.Nil(t, v.CausedBy)
+	assert.Len(t, v.Dependents, 1)
+	assert.True(t, v.SnoozedUntil.IsZero(), "the snooze has expired")
+}
@@ -0,0 +1,30 @@
+package instance
+
+import (
+	"github.com/coroot/coroot/auditor"
+	"github.com/coroot/coroot/model"
+)
+
+type View struct {
+	Id          string               `json:"id"`
+	Application model.ApplicationId  `json:"application"`
+	Node        string               `json:"node"`
+	Status      model.Status         `json:"status"`
+	Indicators  []model.Indicator    `json:"indicators"`
+	Reports     []*model.AuditReport `json:"reports"`
+}
+
+func Render(w *model.World, app *model.Application, instance *model.Instance) *View {
+	audited := auditor.AuditInstance(w, app, instance)
+	v := &View{
+		Id:          instance.Name,
+		Application: app.Id,
+		Status:      audited.Status,
+		Indicators:  model.CalcIndicators(audited),
+		Reports:     audited.Reports,
+	}
+	if instance.Node != nil {
+		v.Node = instance.Node.Name.Value()
+	}
+	return v
+}
@@ -0,0 +1,60 @@
+package instance
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	w := model.NewWorld(0, timeseries.Time(3600), timeseries.Minute)
+	app := w.GetOrCreateApplication(model.NewApplicationId("default", model.ApplicationKindDeployment, "api"))
+	ts := func(v float64) timeseries.TimeSeries {
+		return timeseries.NewWithData(0, timeseries.Minute, []float64{v, v})
+	}
+
+	node := model.NewNode("machine-1")
+	node.Name.Update(ts(1), "node-1")
+	up := model.NewInstance("api-1", app.Id)
+	up.Node = node
+	c := model.NewContainer("app")
+	c.MemoryRss = ts(100)
+	c.Restarts = ts(3)
+	up.Containers[c.Name] = c
+	down := model.NewInstance("api-2", app.Id)
+	app.Instances = []*model.Instance{up, down}
+
+	v := Render(w, app, up)
+	assert.Equal(t, "api-1", v.Id)
+	assert.Equal(t, app.Id, v.Application)
+	assert.Equal(t, "node-1", v.Node)
+	// the application itself isn't changed
+	assert.Len(t, app.Instances, 2)
+	assert.Empty(t, app.Reports)
+
+	var instances *model.AuditReport
+	for _, r := range v.Reports {
+		assert.NotEqual(t, model.AuditReportSLO, r.Name)
+		if r.Name == model.AuditReportInstances {
+			instances = r
+		}
+	}
+	require.NotNil(t, instances)
+	var rows []string
+	for _, widget := range instances.Widgets {
+		if widget.Table != nil {
+			for _, r := range widget.Table.Rows {
+				rows = append(rows, r.Cells[0].Value)
+			}
+		}
+	}
+	assert.Equal(t, []string{"api-1"}, rows)
+	// the other instance being down doesn't affect the availability of this one
+	for _, ch := range instances.Checks {
+		if ch.Id == model.Checks.InstanceAvailability.Id {
+			assert.Equal(t, model.OK, ch.Status)
+		}
+	}
+}
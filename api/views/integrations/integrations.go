@@ -4,21 +4,39 @@ import (
 	"context"
 	"github.com/coroot/coroot/alerts"
 	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/timeseries"
 	"k8s.io/klog"
+	"time"
 )
 
 type View struct {
-	BaseUrl string `json:"base_url"`
-	Slack   *Slack `json:"slack,omitempty"`
+	BaseUrl    string      `json:"base_url"`
+	Slack      *Slack      `json:"slack,omitempty"`
+	OTLP       *OTLP       `json:"otlp,omitempty"`
+	QuietHours *QuietHours `json:"quiet_hours,omitempty"`
+}
+
+type QuietHours struct {
+	db.QuietHours
+	Active bool `json:"active"`
+}
+
+type OTLP struct {
+	Endpoint string              `json:"endpoint"`
+	Interval timeseries.Duration `json:"interval"`
+	Enabled  bool                `json:"enabled"`
 }
 
 type Slack struct {
 	Channel   string `json:"channel"`
 	Available bool   `json:"available"`
 	Enabled   bool   `json:"enabled"`
+
+	Breaker alerts.BreakerState `json:"breaker"`
+	Error   string              `json:"error,omitempty"`
 }
 
-func Render(ctx context.Context, p *db.Project) *View {
+func Render(ctx context.Context, p *db.Project, am *alerts.AlertManager) *View {
 	integrations := p.Settings.Integrations
 	v := &View{
 		BaseUrl: integrations.BaseUrl,
@@ -33,6 +51,16 @@ func Render(ctx context.Context, p *db.Project) *View {
 			klog.Warningln(err)
 		}
 		v.Slack.Available = ok
+		v.Slack.Breaker = am.IntegrationState(p.Id, alerts.IntegrationSlack)
+		if v.Slack.Breaker.Open {
+			v.Slack.Error = "integration disabled due to failures: " + v.Slack.Breaker.LastError
+		}
+	}
+	if cfg := integrations.OTLP; cfg != nil {
+		v.OTLP = &OTLP{Endpoint: cfg.Endpoint, Interval: cfg.GetInterval(), Enabled: cfg.Enabled}
+	}
+	if qh := integrations.QuietHours; qh != nil {
+		v.QuietHours = &QuietHours{QuietHours: *qh, Active: qh.IsActive(time.Now())}
 	}
 	return v
 }
@@ -0,0 +1,97 @@
+package inventory
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"math"
+	"sort"
+)
+
+type View struct {
+	Nodes []Node `json:"nodes"`
+
+	// the number of nodes that appeared or disappeared within the time window
+	Appeared    int `json:"appeared"`
+	Disappeared int `json:"disappeared"`
+}
+
+type Node struct {
+	Name      string          `json:"name"`
+	MachineId string          `json:"machine_id"`
+	FirstSeen timeseries.Time `json:"first_seen"`
+	LastSeen  timeseries.Time `json:"last_seen"`
+	Present   bool            `json:"present"`
+
+	// the last known capacity
+	CpuCores    timeseries.Value `json:"cpu_cores"`
+	MemoryBytes timeseries.Value `json:"memory_bytes"`
+
+	CloudProvider    string `json:"cloud_provider,omitempty"`
+	Region           string `json:"region,omitempty"`
+	AvailabilityZone string `json:"availability_zone,omitempty"`
+	InstanceType     string `json:"instance_type,omitempty"`
+}
+
+// Render lists all the nodes seen within the world's time window along with their lifetimes.
+// A node is seen at a point if any of its CPU or memory metrics has a value there.
+// Nodes seen within a step of the beginning (end) of the window are considered to exist before (after) it.
+// The nodes are ordered by the first seen time, then by name.
+func Render(w *model.World) *View {
+	v := &View{Nodes: []Node{}}
+	for _, n := range w.Nodes {
+		first, last := lifetime(n.CpuUsagePercent, n.CpuCapacity, n.MemoryTotalBytes)
+		if first.IsZero() {
+			continue
+		}
+		_, cpu := timeseries.LastNotNull(n.CpuCapacity)
+		_, memory := timeseries.LastNotNull(n.MemoryTotalBytes)
+		node := Node{
+			Name:             n.Name.Value(),
+			MachineId:        n.MachineID,
+			FirstSeen:        first,
+			LastSeen:         last,
+			Present:          !last.Before(w.Ctx.To.Add(-w.Ctx.Step)),
+			CpuCores:         timeseries.Value(cpu),
+			MemoryBytes:      timeseries.Value(memory),
+			CloudProvider:    n.CloudProvider.Value(),
+			Region:           n.Region.Value(),
+			AvailabilityZone: n.AvailabilityZone.Value(),
+			InstanceType:     n.InstanceType.Value(),
+		}
+		if first.After(w.Ctx.From.Add(w.Ctx.Step)) {
+			v.Appeared++
+		}
+		if !node.Present {
+			v.Disappeared++
+		}
+		v.Nodes = append(v.Nodes, node)
+	}
+	sort.Slice(v.Nodes, func(i, j int) bool {
+		ni, nj := v.Nodes[i], v.Nodes[j]
+		if ni.FirstSeen != nj.FirstSeen {
+			return ni.FirstSeen.Before(nj.FirstSeen)
+		}
+		return ni.Name < nj.Name
+	})
+	return v
+}
+
+func lifetime(series ...timeseries.TimeSeries) (timeseries.Time, timeseries.Time) {
+	var first, last timeseries.Time
+	for _, ts := range series {
+		iter := timeseries.Iter(ts)
+		for iter.Next() {
+			t, v := iter.Value()
+			if math.IsNaN(v) {
+				continue
+			}
+			if first.IsZero() || t.Before(first) {
+				first = t
+			}
+			if t.After(last) {
+				last = t
+			}
+		}
+	}
+	return first, last
+}
@@ -0,0 +1,57 @@
+package inventory
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	nan := timeseries.NaN
+	from := timeseries.Time(600)
+	w := model.NewWorld(from, from.Add(5*timeseries.Minute), timeseries.Minute)
+	node := func(name string, cpu, memory []float64) {
+		n := model.NewNode(name)
+		n.Name.Update(timeseries.NewWithData(from, timeseries.Minute, []float64{1}), name)
+		n.CpuUsagePercent = timeseries.NewWithData(from, timeseries.Minute, cpu)
+		n.CpuCapacity = timeseries.NewWithData(from, timeseries.Minute, cpu)
+		n.MemoryTotalBytes = timeseries.NewWithData(from, timeseries.Minute, memory)
+		w.Nodes = append(w.Nodes, n)
+	}
+	node("stable", []float64{2, 2, 2, 2, 2, 2}, []float64{8, 8, 8, 8, 8, 8})
+	node("gone", []float64{4, 4, 4, nan, nan, nan}, []float64{16, 16, 16, nan, nan, nan})
+	node("new", []float64{nan, nan, nan, 1, 1, 1}, []float64{nan, nan, nan, 4, 4, 4})
+	node("short", []float64{nan, nan, 2, nan, nan, nan}, []float64{nan, 4, nan, nan, nan, nan})
+	node("lagging", []float64{nan, 2, 2, 2, 2, nan}, []float64{nan, 4, 4, 4, 4, nan})
+	node("empty", []float64{nan, nan, nan, nan, nan, nan}, nil)
+
+	v := Render(w)
+	assert.Equal(t, 1, v.Appeared)
+	assert.Equal(t, 2, v.Disappeared)
+
+	var names []string
+	for _, n := range v.Nodes {
+		names = append(names, n.Name)
+	}
+	assert.Equal(t, []string{"gone", "stable", "lagging", "short", "new"}, names)
+
+	gone := v.Nodes[0]
+	assert.Equal(t, from, gone.FirstSeen)
+	assert.Equal(t, from.Add(2*timeseries.Minute), gone.LastSeen)
+	assert.False(t, gone.Present)
+	assert.Equal(t, timeseries.Value(4), gone.CpuCores)
+	assert.Equal(t, timeseries.Value(16), gone.MemoryBytes)
+
+	lagging := v.Nodes[2]
+	assert.Equal(t, from.Add(timeseries.Minute), lagging.FirstSeen)
+	assert.True(t, lagging.Present)
+
+	short := v.Nodes[3]
+	assert.Equal(t, from.Add(timeseries.Minute), short.FirstSeen)
+	assert.Equal(t, from.Add(2*timeseries.Minute), short.LastSeen)
+	assert.Equal(t, timeseries.Value(2), short.CpuCores)
+	assert.Equal(t, timeseries.Value(4), short.MemoryBytes)
+
+	assert.True(t, v.Nodes[4].Present)
+}
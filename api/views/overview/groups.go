@@ -0,0 +1,70 @@
+package overview
+
+import (
+	"github.com/coroot/coroot/model"
+	"sort"
+)
+
+type GroupBy string
+
+const (
+	GroupByNone      GroupBy = ""
+	GroupByNamespace GroupBy = "namespace"
+	GroupByCategory  GroupBy = "category"
+)
+
+func (g GroupBy) IsValid() bool {
+	switch g {
+	case GroupByNone, GroupByNamespace, GroupByCategory:
+		return true
+	}
+	return false
+}
+
+type Group struct {
+	Name string `json:"name"`
+	// the worst status of the applications within the group
+	Status       model.Status   `json:"status"`
+	Apps         int            `json:"apps"`
+	Incidents    int            `json:"incidents"`
+	Applications []*Application `json:"applications"`
+}
+
+// groupApplications groups the applications by their namespace or category.
+// The groups are ordered by status, the worst first, then by name.
+func groupApplications(apps []*Application, groupBy GroupBy) []*Group {
+	byName := map[string]*Group{}
+	for _, a := range apps {
+		name := a.Id.Namespace
+		if groupBy == GroupByCategory {
+			name = string(a.Category)
+		}
+		g := byName[name]
+		if g == nil {
+			g = &Group{Name: name}
+			byName[name] = g
+		}
+		g.Apps++
+		if a.Status > g.Status {
+			g.Status = a.Status
+		}
+		if a.Incident != nil {
+			g.Incidents++
+		}
+		g.Applications = append(g.Applications, a)
+	}
+	res := make([]*Group, 0, len(byName))
+	for _, g := range byName {
+		sort.Slice(g.Applications, func(i, j int) bool {
+			return g.Applications[i].Id.String() < g.Applications[j].Id.String()
+		})
+		res = append(res, g)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Status != res[j].Status {
+			return res[i].Status > res[j].Status
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
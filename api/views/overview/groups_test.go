@@ -0,0 +1,43 @@
+package overview
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGroupApplications(t *testing.T) {
+	app := func(ns, name string, category model.ApplicationCategory, status model.Status, incident bool) *Application {
+		a := &Application{Id: model.NewApplicationId(ns, model.ApplicationKindDeployment, name), Category: category, Status: status}
+		if incident {
+			a.Incident = &Incident{Key: name}
+		}
+		return a
+	}
+	apps := []*Application{
+		app("shop", "front", "application", model.OK, false),
+		app("shop", "cart", "application", model.CRITICAL, true),
+		app("db", "postgres", "application", model.WARNING, true),
+		app("monitoring", "prometheus", "monitoring", model.OK, false),
+	}
+
+	groups := groupApplications(apps, GroupByNamespace)
+	assert.Len(t, groups, 3)
+	assert.Equal(t, "shop", groups[0].Name)
+	assert.Equal(t, model.CRITICAL, groups[0].Status)
+	assert.Equal(t, 2, groups[0].Apps)
+	assert.Equal(t, 1, groups[0].Incidents)
+	assert.Equal(t, "cart", groups[0].Applications[0].Id.Name)
+	assert.Equal(t, "db", groups[1].Name)
+	assert.Equal(t, "monitoring", groups[2].Name)
+
+	groups = groupApplications(apps, GroupByCategory)
+	assert.Len(t, groups, 2)
+	assert.Equal(t, "application", groups[0].Name)
+	assert.Equal(t, 3, groups[0].Apps)
+	assert.Equal(t, 2, groups[0].Incidents)
+	assert.Equal(t, model.OK, groups[1].Status)
+
+	assert.True(t, GroupByNone.IsValid())
+	assert.False(t, GroupBy("team").IsValid())
+}
@@ -15,19 +15,51 @@ import (
 )
 
 type View struct {
-	Applications []*Application `json:"applications"`
-	Nodes        *model.Table   `json:"nodes"`
+	Applications []*Application  `json:"applications"`
+	Nodes        *model.Table    `json:"nodes"`
+	Warnings     []string        `json:"warnings,omitempty"`
+	StaleSince   timeseries.Time `json:"stale_since,omitempty"`
+	StatusFrom   timeseries.Time `json:"status_from,omitempty"`
+
+	// the overall status and health (0..1) of the project weighted by the tiers of the applications
+	Status    model.Status          `json:"status"`
+	Health    float64               `json:"health"`
+	Incidents []ApplicationIncident `json:"incidents"`
+
+	// the applications grouped by namespace or category, if requested (Applications is empty then)
+	Groups []*Group `json:"groups,omitempty"`
+
+	SeverityLabels model.SeverityLabels `json:"severity_labels"`
 }
 
 type Application struct {
 	Id         model.ApplicationId       `json:"id"`
 	Category   model.ApplicationCategory `json:"category"`
+	Tier       model.ApplicationTier     `json:"tier"`
 	Labels     model.Labels              `json:"labels"`
 	Status     model.Status              `json:"status"`
 	Indicators []model.Indicator         `json:"indicators"`
 
 	Upstreams   []Link `json:"upstreams"`
 	Downstreams []Link `json:"downstreams"`
+
+	Incident *Incident `json:"incident,omitempty"`
+}
+
+// Incident is the open incident of an application.
+type Incident struct {
+	Key      string               `json:"key"`
+	Severity model.Status         `json:"severity"`
+	CausedBy *model.ApplicationId `json:"caused_by,omitempty"` // the application with the open root-cause incident
+}
+
+// ApplicationIncident is an open incident in the list prioritized by the tiers of the applications.
+type ApplicationIncident struct {
+	ApplicationId model.ApplicationId   `json:"application_id"`
+	Tier          model.ApplicationTier `json:"tier"`
+	Key           string                `json:"key"`
+	Severity      model.Status          `json:"severity"`
+	OpenedAt      timeseries.Time       `json:"opened_at"`
 }
 
 type Link struct {
@@ -37,20 +69,50 @@ type Link struct {
 	Weight float32             `json:"weight"`
 }
 
-func Render(w *model.World, p *db.Project) *View {
+// Render describes the applications and nodes of the world. If current is not nil, it's expected to cover
+// a short trailing part of the world's time range, and the application statuses are taken from it,
+// so that a blip earlier in a long range doesn't mark an application as unhealthy.
+// The open incidents are attached to the applications along with their root causes.
+// If tier is set, only the applications of that tier are shown.
+// If groupBy is set, all the applications are listed within the groups instead of the flat list of the connected ones.
+// The statuses of the applications are combined from the statuses of their checks by the project's health rollup.
+func Render(w *model.World, current *model.World, p *db.Project, openIncidents map[model.ApplicationId]*db.Incident, tier model.ApplicationTier, groupBy GroupBy) *View {
 	var apps []*Application
 	used := map[model.ApplicationId]bool{}
 	auditor.Audit(w)
+	var statuses map[model.ApplicationId]model.Status
+	if current != nil {
+		auditor.Audit(current)
+		statuses = make(map[model.ApplicationId]model.Status, len(current.Applications))
+		for _, a := range current.Applications {
+			statuses[a.Id] = p.Settings.HealthRollup.Status(a)
+		}
+	}
 	for _, a := range w.Applications {
+		category := model.CalcApplicationCategory(a, p.Settings.ApplicationCategories, p.Settings.ApplicationCategoryLabel)
 		app := Application{
 			Id:          a.Id,
-			Category:    model.CalcApplicationCategory(a, p.Settings.ApplicationCategories),
+			Category:    category,
+			Tier:        p.Settings.ApplicationTiers.Tier(a.Id, category),
 			Labels:      a.Labels(),
-			Status:      a.Status,
+			Status:      p.Settings.HealthRollup.Status(a),
 			Indicators:  model.CalcIndicators(a),
 			Upstreams:   []Link{},
 			Downstreams: []Link{},
 		}
+		if status, ok := statuses[a.Id]; ok {
+			app.Status = status
+		}
+		if i := openIncidents[a.Id]; i != nil {
+			app.Incident = &Incident{Key: i.Key, Severity: i.Severity}
+			for appId, cause := range openIncidents {
+				if i.CausedBy != "" && cause.Key == i.CausedBy {
+					id := appId
+					app.Incident.CausedBy = &id
+					break
+				}
+			}
+		}
 
 		upstreams := map[model.ApplicationId]struct {
 			status      model.Status
@@ -102,14 +164,35 @@ func Render(w *model.World, p *db.Project) *View {
 
 		apps = append(apps, &app)
 	}
-	var appsUsed []*Application
+	var appsUsed, filtered []*Application
+	var tiered []model.TieredStatus
+	incidents := []ApplicationIncident{}
 	for _, a := range apps {
+		if tier > 0 && a.Tier != tier {
+			continue
+		}
+		filtered = append(filtered, a)
+		tiered = append(tiered, model.TieredStatus{Tier: a.Tier, Status: a.Status})
+		if i := openIncidents[a.Id]; i != nil {
+			incidents = append(incidents, ApplicationIncident{ApplicationId: a.Id, Tier: a.Tier, Key: i.Key, Severity: i.Severity, OpenedAt: i.OpenedAt})
+		}
 		if !used[a.Id] {
 			continue
 		}
 		appsUsed = append(appsUsed, a)
 	}
+	sort.Slice(incidents, func(i, j int) bool {
+		ii, ij := incidents[i], incidents[j]
+		if ii.Tier != ij.Tier {
+			return ii.Tier < ij.Tier
+		}
+		if ii.Severity != ij.Severity {
+			return ii.Severity > ij.Severity
+		}
+		return ii.OpenedAt.Before(ij.OpenedAt)
+	})
 
+	thresholds := model.GetMetricThresholds(p.Settings.MetricThresholds)
 	table := &model.Table{Header: []string{"Node", "Status", "Availability zone", "IP", "CPU", "Memory", "Network"}}
 	for _, n := range w.Nodes {
 		node := model.NewTableCell(n.Name.Value()).SetLink("node", n.Name.Value())
@@ -124,13 +207,14 @@ func Render(w *model.World, p *db.Project) *View {
 			node.AddTag("vCPU: " + strconv.Itoa(int(l)))
 		}
 		if l := timeseries.Last(n.CpuUsagePercent); !math.IsNaN(l) {
-			cpuPercent.SetProgress(int(l), "blue")
+			cpuPercent.SetProgress(int(l), "blue").SetProgressStatus(thresholds[model.MetricNodeCpuUsage].Status(l))
 		}
 
 		if total := timeseries.Last(n.MemoryTotalBytes); !math.IsNaN(total) {
 			node.AddTag("memory: " + humanize.Bytes(uint64(total)))
 			if avail := timeseries.Last(n.MemoryAvailableBytes); !math.IsNaN(avail) {
-				memoryPercent.SetProgress(int(100-avail/total*100), "deep-purple")
+				used := 100 - avail/total*100
+				memoryPercent.SetProgress(int(used), "deep-purple").SetProgressStatus(thresholds[model.MetricNodeMemoryUsage].Status(used))
 			}
 		}
 
@@ -179,5 +263,15 @@ func Render(w *model.World, p *db.Project) *View {
 			network,
 		)
 	}
-	return &View{Applications: appsUsed, Nodes: table}
+	v := &View{Applications: appsUsed, Nodes: table, Warnings: w.Warnings, StaleSince: w.StaleSince, SeverityLabels: model.GetSeverityLabels(p.Settings.SeverityLabels)}
+	v.Status, v.Health = model.WeightedStatus(tiered)
+	v.Incidents = incidents
+	if groupBy != GroupByNone {
+		v.Applications = nil
+		v.Groups = groupApplications(filtered, groupBy)
+	}
+	if current != nil {
+		v.StatusFrom = current.Ctx.From
+	}
+	return v
 }
@@ -1,6 +1,7 @@
 package project
 
 import (
+	"github.com/coroot/coroot/alerts"
 	"github.com/coroot/coroot/cache"
 	"github.com/coroot/coroot/db"
 	"github.com/coroot/coroot/model"
@@ -8,14 +9,23 @@ import (
 )
 
 type Prometheus struct {
-	Status model.Status `json:"status"`
-	Error  string       `json:"error"`
-	Cache  struct {
-		LagMax timeseries.Duration `json:"lag_max"`
-		LagAvg timeseries.Duration `json:"lag_avg"`
+	Status  model.Status `json:"status"`
+	Error   string       `json:"error"`
+	Version string       `json:"version,omitempty"`
+	Cache   struct {
+		LagMax    timeseries.Duration `json:"lag_max"`
+		LagAvg    timeseries.Duration `json:"lag_avg"`
+		ChunkSize timeseries.Duration `json:"chunk_size"`
+		Backfill  *Backfill           `json:"backfill,omitempty"`
 	} `json:"cache"`
 }
 
+type Backfill struct {
+	From     timeseries.Time `json:"from"`
+	To       timeseries.Time `json:"to"`
+	Progress float64         `json:"progress"`
+}
+
 type NodeAgent struct {
 	Status model.Status `json:"status"`
 	Nodes  int          `json:"nodes"`
@@ -40,9 +50,30 @@ type Status struct {
 	KubeStateMetrics *KubeStateMetrics `json:"kube_state_metrics"`
 
 	ApplicationExporters map[model.ApplicationType]ApplicationExporter `json:"application_exporters"`
+
+	// the circuit breaker states of the enabled notification integrations
+	Integrations map[string]alerts.BreakerState `json:"integrations,omitempty"`
+
+	SeverityLabels model.SeverityLabels `json:"severity_labels"`
+}
+
+type IncidentCounts struct {
+	Open     int `json:"open"`
+	Warning  int `json:"warning"`
+	Critical int `json:"critical"`
+}
+
+// StatusSummary is a lightweight version of Status that doesn't require loading the world.
+type StatusSummary struct {
+	Status     model.Status   `json:"status"`
+	Error      string         `json:"error"`
+	Prometheus Prometheus     `json:"prometheus"`
+	Incidents  IncidentCounts `json:"incidents"`
+
+	SeverityLabels model.SeverityLabels `json:"severity_labels"`
 }
 
-func RenderStatus(p *db.Project, cacheStatus *cache.Status, w *model.World) *Status {
+func RenderStatus(p *db.Project, cacheStatus *cache.Status, w *model.World, am *alerts.AlertManager) *Status {
 	res := &Status{
 		Status: model.OK,
 
@@ -53,22 +84,17 @@ func RenderStatus(p *db.Project, cacheStatus *cache.Status, w *model.World) *Sta
 		res.Error = "Project not found"
 		return res
 	}
+	res.SeverityLabels = model.GetSeverityLabels(p.Settings.SeverityLabels)
 
-	if cacheStatus.Error != "" {
-		res.Prometheus.Error = cacheStatus.Error
-		res.Prometheus.Status = model.WARNING
-		res.Status = model.WARNING
-	} else {
-		res.Prometheus.Cache.LagMax = cacheStatus.LagMax
-		res.Prometheus.Cache.LagAvg = cacheStatus.LagAvg
-		switch {
-		case w == nil:
-			res.Prometheus.Status = model.WARNING
-			res.Status = model.WARNING
-		case cacheStatus.LagMax > 5*p.Prometheus.RefreshInterval:
-			res.Prometheus.Status = model.INFO
-		default:
-			res.Prometheus.Status = model.OK
+	var staleSince timeseries.Time
+	if w != nil {
+		staleSince = w.StaleSince
+	}
+	res.Prometheus, res.Status = renderPrometheus(p, cacheStatus, w != nil, staleSince)
+
+	if cfg := p.Settings.Integrations.Slack; cfg != nil && cfg.Enabled {
+		res.Integrations = map[string]alerts.BreakerState{
+			alerts.IntegrationSlack: am.IntegrationState(p.Id, alerts.IntegrationSlack),
 		}
 	}
 
@@ -118,3 +144,65 @@ func RenderStatus(p *db.Project, cacheStatus *cache.Status, w *model.World) *Sta
 
 	return res
 }
+
+// RenderStatusSummary renders the status of Prometheus and the number of open incidents by severity.
+// cacheTo is the time of the latest cached data, see cache.Client.GetTo.
+func RenderStatusSummary(p *db.Project, cacheStatus *cache.Status, cacheTo timeseries.Time, openIncidents map[model.Status]int) *StatusSummary {
+	res := &StatusSummary{Status: model.OK}
+	if p == nil {
+		res.Error = "Project not found"
+		return res
+	}
+	res.SeverityLabels = model.GetSeverityLabels(p.Settings.SeverityLabels)
+
+	now := timeseries.Now()
+	hasData := !cacheTo.IsZero() && !cacheTo.Before(now.Add(-timeseries.Hour))
+	var staleSince timeseries.Time
+	if s := p.Prometheus.MaxStaleness; s > 0 && hasData && cacheTo.Before(now.Add(-s)) {
+		staleSince = cacheTo
+	}
+	res.Prometheus, res.Status = renderPrometheus(p, cacheStatus, hasData, staleSince)
+
+	for severity, count := range openIncidents {
+		res.Incidents.Open += count
+		switch severity {
+		case model.WARNING:
+			res.Incidents.Warning += count
+		case model.CRITICAL:
+			res.Incidents.Critical += count
+		}
+	}
+	return res
+}
+
+// renderPrometheus returns the status of Prometheus and the resulting status of the project.
+// hasData is false if there is no cached data for the last hour.
+func renderPrometheus(p *db.Project, cacheStatus *cache.Status, hasData bool, staleSince timeseries.Time) (Prometheus, model.Status) {
+	res := Prometheus{Version: cacheStatus.PrometheusVersion}
+	status := model.OK
+	if cacheStatus.Error != "" {
+		res.Error = cacheStatus.Error
+		res.Status = model.WARNING
+		return res, model.WARNING
+	}
+	res.Cache.LagMax = cacheStatus.LagMax
+	res.Cache.LagAvg = cacheStatus.LagAvg
+	res.Cache.ChunkSize = cacheStatus.ChunkSize
+	if b := cacheStatus.Backfill; b != nil {
+		res.Cache.Backfill = &Backfill{From: b.From, To: b.To, Progress: b.Progress}
+	}
+	switch {
+	case !hasData:
+		res.Status = model.WARNING
+		status = model.WARNING
+	case !staleSince.IsZero():
+		res.Error = "the cached data is stale, the latest data is from " + staleSince.ToStandard().UTC().Format("2006-01-02 15:04:05 UTC")
+		res.Status = model.WARNING
+		status = model.UNKNOWN
+	case cacheStatus.LagMax > 5*p.Prometheus.RefreshInterval:
+		res.Status = model.INFO
+	default:
+		res.Status = model.OK
+	}
+	return res, status
+}
@@ -0,0 +1,55 @@
+package project
+
+import (
+	"github.com/coroot/coroot/cache"
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRenderStatusStale(t *testing.T) {
+	p := &db.Project{Prometheus: db.Prometheus{RefreshInterval: 30}}
+	w := model.NewWorld(0, 3600, 60)
+	w.IntegrationStatus.NodeAgent.Installed = true
+
+	res := RenderStatus(p, &cache.Status{}, w, nil)
+	assert.Equal(t, model.OK, res.Status)
+	assert.Equal(t, model.OK, res.Prometheus.Status)
+	assert.Empty(t, res.Prometheus.Error)
+
+	w.StaleSince = 1668000000
+	res = RenderStatus(p, &cache.Status{}, w, nil)
+	assert.Equal(t, model.UNKNOWN, res.Status)
+	assert.Equal(t, model.WARNING, res.Prometheus.Status)
+	assert.Equal(t, "the cached data is stale, the latest data is from 2022-11-09 13:20:00 UTC", res.Prometheus.Error)
+
+	// a Prometheus error takes precedence
+	res = RenderStatus(p, &cache.Status{Error: "connection refused"}, w, nil)
+	assert.Equal(t, model.WARNING, res.Status)
+	assert.Equal(t, "connection refused", res.Prometheus.Error)
+}
+
+func TestRenderStatusSummary(t *testing.T) {
+	res := RenderStatusSummary(nil, nil, 0, nil)
+	assert.Equal(t, "Project not found", res.Error)
+
+	p := &db.Project{Prometheus: db.Prometheus{RefreshInterval: 30, MaxStaleness: 10 * timeseries.Minute}}
+	now := timeseries.Now()
+	res = RenderStatusSummary(p, &cache.Status{}, now, map[model.Status]int{model.WARNING: 2, model.CRITICAL: 1})
+	assert.Equal(t, model.OK, res.Status)
+	assert.Equal(t, model.OK, res.Prometheus.Status)
+	assert.Equal(t, IncidentCounts{Open: 3, Warning: 2, Critical: 1}, res.Incidents)
+	assert.Equal(t, model.GetSeverityLabels(nil), res.SeverityLabels)
+
+	// no data within the last hour
+	res = RenderStatusSummary(p, &cache.Status{}, 0, nil)
+	assert.Equal(t, model.WARNING, res.Status)
+	assert.Equal(t, model.WARNING, res.Prometheus.Status)
+
+	res = RenderStatusSummary(p, &cache.Status{}, now.Add(-30*timeseries.Minute), nil)
+	assert.Equal(t, model.UNKNOWN, res.Status)
+	assert.Contains(t, res.Prometheus.Error, "the cached data is stale")
+	assert.Equal(t, IncidentCounts{}, res.Incidents)
+}
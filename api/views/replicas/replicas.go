@@ -0,0 +1,132 @@
+package replicas
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"math"
+	"sort"
+)
+
+const (
+	outlierThreshold = 2 // standard deviations from the mean
+)
+
+type View struct {
+	Metrics  []string   `json:"metrics"`
+	Replicas []*Replica `json:"replicas"`
+}
+
+type Replica struct {
+	Name     string                      `json:"name"`
+	Values   map[string]timeseries.Value `json:"values"`
+	Outliers map[string]bool             `json:"outliers"`
+	Score    float64                     `json:"score"`
+}
+
+type metric struct {
+	name string
+	get  func(i *model.Instance) timeseries.TimeSeries
+}
+
+var metrics = []metric{
+	{name: "rps", get: func(i *model.Instance) timeseries.TimeSeries {
+		return model.GetConnectionsRequestsSum(i.Downstreams)
+	}},
+	{name: "latency", get: func(i *model.Instance) timeseries.TimeSeries {
+		return model.GetConnectionsRequestsLatency(i.Downstreams)
+	}},
+	{name: "errors", get: func(i *model.Instance) timeseries.TimeSeries {
+		return model.GetConnectionsErrorsSum(i.Downstreams)
+	}},
+	{name: "cpu", get: func(i *model.Instance) timeseries.TimeSeries {
+		var sum timeseries.TimeSeries
+		for _, c := range i.Containers {
+			sum = timeseries.Merge(sum, c.CpuUsage, timeseries.NanSum)
+		}
+		return sum
+	}},
+}
+
+// Render compares the average values of the key metrics of the application instances over the world's time window.
+// A value is flagged as an outlier if it deviates from the mean across all replicas by more than outlierThreshold standard deviations.
+// Replicas are ranked by the sum of their absolute z-scores, so the most unusual ones come first.
+func Render(app *model.Application) *View {
+	v := &View{}
+	for _, m := range metrics {
+		v.Metrics = append(v.Metrics, m.name)
+	}
+	for _, i := range app.Instances {
+		if i.Pod != nil && i.Pod.IsObsolete() {
+			continue
+		}
+		r := &Replica{Name: i.Name, Values: map[string]timeseries.Value{}, Outliers: map[string]bool{}}
+		for _, m := range metrics {
+			r.Values[m.name] = timeseries.Value(mean(m.get(i)))
+		}
+		v.Replicas = append(v.Replicas, r)
+	}
+	for _, m := range metrics {
+		values := make([]float64, 0, len(v.Replicas))
+		for _, r := range v.Replicas {
+			values = append(values, float64(r.Values[m.name]))
+		}
+		for idx, z := range zScores(values) {
+			if math.IsNaN(z) {
+				continue
+			}
+			r := v.Replicas[idx]
+			r.Score += math.Abs(z)
+			if math.Abs(z) > outlierThreshold {
+				r.Outliers[m.name] = true
+			}
+		}
+	}
+	sort.SliceStable(v.Replicas, func(i, j int) bool {
+		return v.Replicas[i].Score > v.Replicas[j].Score
+	})
+	return v
+}
+
+func mean(ts timeseries.TimeSeries) float64 {
+	sum := timeseries.Reduce(timeseries.NanSum, ts)
+	count := timeseries.Reduce(func(t timeseries.Time, acc, v float64) float64 {
+		if math.IsNaN(acc) {
+			acc = 0
+		}
+		if !math.IsNaN(v) {
+			acc++
+		}
+		return acc
+	}, ts)
+	if math.IsNaN(sum) || !(count > 0) {
+		return timeseries.NaN
+	}
+	return sum / count
+}
+
+func zScores(values []float64) []float64 {
+	res := make([]float64, len(values))
+	var sum, count float64
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			sum += v
+			count++
+		}
+	}
+	avg := sum / count
+	var variance float64
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			variance += (v - avg) * (v - avg)
+		}
+	}
+	stddev := math.Sqrt(variance / count)
+	for i, v := range values {
+		if count < 2 || stddev == 0 || math.IsNaN(v) {
+			res[i] = timeseries.NaN
+			continue
+		}
+		res[i] = (v - avg) / stddev
+	}
+	return res
+}
@@ -0,0 +1,48 @@
+package replicas
+
+import (
+	"fmt"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	app := model.NewApplication(model.NewApplicationId("default", model.ApplicationKindDeployment, "api"))
+	for idx, cpu := range []float64{1, 1, 1, 1, 1, 10} {
+		i := app.GetOrCreateInstance(fmt.Sprintf("api-%d", idx))
+		c := model.NewContainer("api")
+		c.CpuUsage = timeseries.NewWithData(0, 60, []float64{cpu, cpu})
+		i.Containers[c.Name] = c
+	}
+
+	v := Render(app)
+	assert.Equal(t, []string{"rps", "latency", "errors", "cpu"}, v.Metrics)
+	assert.Len(t, v.Replicas, 6)
+	outlier := v.Replicas[0]
+	assert.Equal(t, "api-5", outlier.Name)
+	assert.Equal(t, map[string]bool{"cpu": true}, outlier.Outliers)
+	assert.Equal(t, timeseries.Value(10), outlier.Values["cpu"])
+	assert.True(t, math.IsNaN(float64(outlier.Values["rps"])))
+	for _, r := range v.Replicas[1:] {
+		assert.Empty(t, r.Outliers)
+		assert.Less(t, r.Score, outlier.Score)
+	}
+}
+
+func TestZScores(t *testing.T) {
+	assert.InDeltaSlice(t, []float64{-math.Sqrt(1.5), math.Sqrt(1.5), 0}, zScores([]float64{1, 3, 2}), 1e-9)
+	nan := timeseries.NaN
+	res := zScores([]float64{5, nan})
+	assert.True(t, math.IsNaN(res[0]) && math.IsNaN(res[1]), "a single value has no deviation")
+	res = zScores([]float64{5, 5})
+	assert.True(t, math.IsNaN(res[0]) && math.IsNaN(res[1]), "equal values have no deviation")
+}
+
+func TestMean(t *testing.T) {
+	assert.Equal(t, 2., mean(timeseries.NewWithData(0, 60, []float64{1, timeseries.NaN, 3})))
+	assert.True(t, math.IsNaN(mean(timeseries.NewWithData(0, 60, []float64{timeseries.NaN}))))
+	assert.True(t, math.IsNaN(mean(nil)))
+}
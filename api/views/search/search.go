@@ -11,26 +11,43 @@ type View struct {
 }
 
 type Application struct {
-	Id model.ApplicationId `json:"id"`
+	Key string              `json:"key"`
+	Id  model.ApplicationId `json:"id"`
 }
 
 type Node struct {
+	Key  string `json:"key"`
 	Name string `json:"name"`
 }
 
+// Render lists the applications and nodes of the world ordered by name and then by key,
+// so the order doesn't depend on the world's internals. Keys stay the same across requests.
 func Render(w *model.World) *View {
-	res := &View{}
+	res := &View{Applications: []Application{}, Nodes: []Node{}}
 	for _, a := range w.Applications {
-		res.Applications = append(res.Applications, Application{Id: a.Id})
+		res.Applications = append(res.Applications, Application{Key: "app:" + a.Id.String(), Id: a.Id})
 	}
 	for _, n := range w.Nodes {
-		res.Nodes = append(res.Nodes, Node{Name: n.Name.Value()})
+		name := n.Name.Value()
+		key := "node:" + name
+		if n.MachineID != "" {
+			key += ":" + n.MachineID
+		}
+		res.Nodes = append(res.Nodes, Node{Key: key, Name: name})
 	}
 	sort.Slice(res.Applications, func(i, j int) bool {
-		return res.Applications[i].Id.Name < res.Applications[j].Id.Name
+		ai, aj := res.Applications[i], res.Applications[j]
+		if ai.Id.Name != aj.Id.Name {
+			return ai.Id.Name < aj.Id.Name
+		}
+		return ai.Key < aj.Key
 	})
 	sort.Slice(res.Nodes, func(i, j int) bool {
-		return res.Nodes[i].Name < res.Nodes[j].Name
+		ni, nj := res.Nodes[i], res.Nodes[j]
+		if ni.Name != nj.Name {
+			return ni.Name < nj.Name
+		}
+		return ni.Key < nj.Key
 	})
 	return res
 }
@@ -0,0 +1,38 @@
+package search
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestRenderOrder(t *testing.T) {
+	w := model.NewWorld(0, timeseries.Time(3600), timeseries.Minute)
+	for _, ns := range []string{"staging", "prod", "default"} {
+		for _, name := range []string{"db", "api"} {
+			w.GetOrCreateApplication(model.NewApplicationId(ns, model.ApplicationKindDeployment, name))
+		}
+	}
+	for _, id := range []string{"m2", "m1"} {
+		n := &model.Node{MachineID: id}
+		n.Name.Update(timeseries.NewWithData(0, timeseries.Minute, []float64{1}), "node-1")
+		w.Nodes = append(w.Nodes, n)
+	}
+
+	expected := Render(w)
+	assert.Equal(t, "app:default:Deployment:api", expected.Applications[0].Key)
+	assert.Equal(t, "app:staging:Deployment:db", expected.Applications[5].Key)
+	assert.Equal(t, []Node{{Key: "node:node-1:m1", Name: "node-1"}, {Key: "node:node-1:m2", Name: "node-1"}}, expected.Nodes)
+
+	for i := 0; i < 10; i++ {
+		rand.Shuffle(len(w.Applications), func(i, j int) {
+			w.Applications[i], w.Applications[j] = w.Applications[j], w.Applications[i]
+		})
+		rand.Shuffle(len(w.Nodes), func(i, j int) {
+			w.Nodes[i], w.Nodes[j] = w.Nodes[j], w.Nodes[i]
+		})
+		assert.Equal(t, expected, Render(w))
+	}
+}
@@ -0,0 +1,56 @@
+package sli
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+)
+
+type BurnRates struct {
+	Objective float64        `json:"objective"`
+	Error     string         `json:"error,omitempty"`
+	Rules     []BurnRateRule `json:"rules"`
+}
+
+// BurnRateRule is an alert rule along with the burn rates over its windows: the rule fires when both exceed the threshold.
+type BurnRateRule struct {
+	LongWindow  timeseries.Duration   `json:"long_window"`
+	ShortWindow timeseries.Duration   `json:"short_window"`
+	Threshold   float64               `json:"threshold"`
+	Severity    model.Status          `json:"severity"`
+	Long        timeseries.TimeSeries `json:"long"`
+	Short       timeseries.TimeSeries `json:"short"`
+}
+
+// AvailabilityBurnRates calculates the burn rates of an availability SLI at each of the pointsCount points from the given time.
+// The SLI series are expected to start a MaxAlertRuleWindow earlier to cover the windows of the first points.
+func AvailabilityBurnRates(sli *model.AvailabilitySLI, from timeseries.Time, pointsCount int, step timeseries.Duration) *BurnRates {
+	if sli.Error != "" {
+		return &BurnRates{Objective: sli.Config.ObjectivePercentage, Error: sli.Error}
+	}
+	bad, total := availabilityEvents(sli)
+	return burnRates(bad, total, sli.Config.ObjectivePercentage, from, pointsCount, step)
+}
+
+// LatencyBurnRates is the latency counterpart of AvailabilityBurnRates.
+func LatencyBurnRates(sli *model.LatencySLI, from timeseries.Time, pointsCount int, step timeseries.Duration) *BurnRates {
+	if sli.Error != "" {
+		return &BurnRates{Objective: sli.Config.ObjectivePercentage, Error: sli.Error}
+	}
+	bad, total := latencyEvents(sli)
+	return burnRates(bad, total, sli.Config.ObjectivePercentage, from, pointsCount, step)
+}
+
+func burnRates(bad, total timeseries.TimeSeries, objective float64, from timeseries.Time, pointsCount int, step timeseries.Duration) *BurnRates {
+	res := &BurnRates{Objective: objective, Rules: []BurnRateRule{}}
+	for _, r := range model.CalcAlertRuleBurnRates(from, pointsCount, step, bad, total, objective) {
+		res.Rules = append(res.Rules, BurnRateRule{
+			LongWindow:  r.Rule.LongWindow,
+			ShortWindow: r.Rule.ShortWindow,
+			Threshold:   r.Rule.BurnRateThreshold,
+			Severity:    r.Rule.Severity,
+			Long:        r.Long,
+			Short:       r.Short,
+		})
+	}
+	return res
+}
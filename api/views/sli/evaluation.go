@@ -0,0 +1,117 @@
+package sli
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+)
+
+type Evaluation struct {
+	Objective float64 `json:"objective"`
+	Error     string  `json:"error,omitempty"`
+
+	// the percentage of good requests over time
+	Sli    timeseries.TimeSeries `json:"sli"`
+	Target timeseries.TimeSeries `json:"target"`
+
+	// the totals over the whole range
+	TotalRequests float64          `json:"total_requests"`
+	BadRequests   float64          `json:"bad_requests"`
+	Compliance    timeseries.Value `json:"compliance"`
+
+	BurnRate  timeseries.Value      `json:"burn_rate"`
+	Window    timeseries.Duration   `json:"window"`
+	Severity  model.Status          `json:"severity"`
+	BurnRates timeseries.TimeSeries `json:"burn_rates"`
+
+	Budget model.BudgetForecast `json:"budget"`
+}
+
+// EvaluateAvailability evaluates an availability SLI the way the SLO check would at the end of the loaded range.
+func EvaluateAvailability(sli *model.AvailabilitySLI, to timeseries.Time) *Evaluation {
+	if sli.Error != "" {
+		return &Evaluation{Objective: sli.Config.ObjectivePercentage, Error: sli.Error}
+	}
+	bad, total := availabilityEvents(sli)
+	return evaluate(bad, total, sli.Config.ObjectivePercentage, to)
+}
+
+// EvaluateLatency evaluates a latency SLI.
+func EvaluateLatency(sli *model.LatencySLI, to timeseries.Time) *Evaluation {
+	if sli.Error != "" {
+		return &Evaluation{Objective: sli.Config.ObjectivePercentage, Error: sli.Error}
+	}
+	bad, total := latencyEvents(sli)
+	return evaluate(bad, total, sli.Config.ObjectivePercentage, to)
+}
+
+// availabilityEvents returns the bad and total requests of an availability SLI.
+func availabilityEvents(sli *model.AvailabilitySLI) (timeseries.TimeSeries, timeseries.TimeSeries) {
+	failed := sli.FailedRequests
+	if timeseries.IsEmpty(failed) {
+		failed = timeseries.Replace(sli.TotalRequests, 0)
+	} else {
+		failed = timeseries.Map(timeseries.NanToZero, failed)
+	}
+	return failed, sli.TotalRequests
+}
+
+// latencyEvents returns the bad and total requests of a latency SLI: the requests slower than the objective bucket are the bad ones.
+func latencyEvents(sli *model.LatencySLI) (timeseries.TimeSeries, timeseries.TimeSeries) {
+	total, fast := sli.GetTotalAndFast(false)
+	if timeseries.IsEmpty(fast) {
+		fast = timeseries.Replace(total, 0)
+	} else {
+		fast = timeseries.Map(timeseries.NanToZero, fast)
+	}
+	return timeseries.Aggregate(timeseries.Sub, total, fast), total
+}
+
+func evaluate(bad, total timeseries.TimeSeries, objective float64, to timeseries.Time) *Evaluation {
+	e := &Evaluation{Objective: objective, Severity: model.UNKNOWN, BurnRate: timeseries.Value(timeseries.NaN), Compliance: timeseries.Value(timeseries.NaN)}
+	if timeseries.IsEmpty(total) {
+		e.Budget = model.BudgetForecast{Status: model.BudgetForecastInsufficientData, Remaining: 1}
+		return e
+	}
+	e.Sli = timeseries.Aggregate(
+		func(t timeseries.Time, total, bad float64) float64 {
+			return (total - bad) / total * 100
+		},
+		total, bad,
+	)
+	e.Target = timeseries.Replace(total, objective)
+	e.TotalRequests = timeseries.NanToZero(0, timeseries.Reduce(timeseries.NanSum, total))
+	e.BadRequests = timeseries.NanToZero(0, timeseries.Reduce(timeseries.NanSum, bad))
+	if e.TotalRequests > 0 {
+		e.Compliance = timeseries.Value((e.TotalRequests - e.BadRequests) / e.TotalRequests * 100)
+	}
+
+	br := model.CheckBurnRates(to, bad, total, objective)
+	e.Severity = br.Severity
+	if br.Severity > model.UNKNOWN {
+		e.BurnRate, e.Window = timeseries.Value(br.Value), br.Window
+	}
+
+	var times []timeseries.Time
+	iter := timeseries.Iter(total)
+	for iter.Next() {
+		t, _ := iter.Value()
+		times = append(times, t)
+	}
+	if len(times) > 0 {
+		rates := model.CheckBurnRatesAt(times, bad, total, objective)
+		step := timeseries.Duration(0)
+		if len(times) > 1 {
+			step = times[1].Sub(times[0])
+		}
+		burnRates := timeseries.New(times[0], len(times), step)
+		for i, r := range rates {
+			if r.Severity > model.UNKNOWN {
+				burnRates.Set(times[i], r.Value)
+			}
+		}
+		e.BurnRates = burnRates
+	}
+
+	e.Budget = model.ForecastBudgetExhaustion(bad, total, objective, model.ErrorBudgetPeriod)
+	return e
+}
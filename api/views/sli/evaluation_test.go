@@ -0,0 +1,42 @@
+package sli
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEvaluateAvailability(t *testing.T) {
+	step := timeseries.Minute
+	to := timeseries.Time(0).Add(2 * timeseries.Hour)
+	n := int(2 * timeseries.Hour / step)
+	total := timeseries.New(0, n, step)
+	failed := timeseries.New(0, n, step)
+	for i := 0; i < n; i++ {
+		ts := timeseries.Time(0).Add(timeseries.Duration(i) * step)
+		total.Set(ts, 100)
+		if i >= n-10 {
+			failed.Set(ts, 100)
+		}
+	}
+
+	e := EvaluateAvailability(&model.AvailabilitySLI{
+		Config:         model.CheckConfigSLOAvailability{ObjectivePercentage: 99},
+		TotalRequests:  total,
+		FailedRequests: failed,
+	}, to)
+	assert.Equal(t, float64(12000), e.TotalRequests)
+	assert.Equal(t, float64(1000), e.BadRequests)
+	assert.InDelta(t, 91.67, float64(e.Compliance), 0.01)
+	assert.Equal(t, model.CRITICAL, e.Severity)
+	assert.Equal(t, timeseries.Hour, e.Window)
+	assert.Equal(t, "", e.Error)
+
+	e = EvaluateAvailability(&model.AvailabilitySLI{Config: model.CheckConfigSLOAvailability{ObjectivePercentage: 99}}, to)
+	assert.Equal(t, model.UNKNOWN, e.Severity)
+	assert.Equal(t, model.BudgetForecastInsufficientData, e.Budget.Status)
+
+	e = EvaluateAvailability(&model.AvailabilitySLI{Error: "bad_data: parse error"}, to)
+	assert.Equal(t, "bad_data: parse error", e.Error)
+}
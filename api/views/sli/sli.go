@@ -0,0 +1,105 @@
+package sli
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"math"
+)
+
+const (
+	DefaultPoints = 100
+)
+
+type View struct {
+	Configured bool                  `json:"configured"`
+	Objective  float64               `json:"objective"`
+	Sli        timeseries.TimeSeries `json:"sli"`
+	Target     timeseries.TimeSeries `json:"target"`
+
+	Forecast *model.BudgetForecast `json:"forecast,omitempty"`
+
+	// the query pairs of a weighted (composite) availability SLI
+	Components []Component `json:"components,omitempty"`
+}
+
+type Component struct {
+	Query  string                `json:"query"`
+	Weight float64               `json:"weight"` // normalized
+	Sli    timeseries.TimeSeries `json:"sli"`
+}
+
+// Render returns the availability of the application (the percentage of successful requests) downsampled to at most maxPoints points.
+// Buckets without requests are NaN. The view also includes the forecast of the error budget exhaustion.
+func Render(app *model.Application, maxPoints int) *View {
+	v := &View{}
+	if len(app.AvailabilitySLIs) == 0 {
+		return v
+	}
+	sli := app.AvailabilitySLIs[0]
+	v.Configured = true
+	v.Objective = sli.Config.ObjectivePercentage
+	forecast := model.ForecastBudgetExhaustion(sli.FailedRequests, sli.TotalRequests, v.Objective, model.ErrorBudgetPeriod)
+	v.Forecast = &forecast
+
+	if maxPoints <= 0 {
+		maxPoints = DefaultPoints
+	}
+	v.Sli = downsample(sli.TotalRequests, sli.FailedRequests, maxPoints)
+	if v.Sli == nil {
+		return v
+	}
+	v.Target = timeseries.Replace(v.Sli, v.Objective)
+	for _, c := range sli.Components {
+		v.Components = append(v.Components, Component{
+			Query:  c.Queries.TotalRequestsQuery,
+			Weight: c.Weight,
+			Sli:    downsample(c.TotalRequests, c.FailedRequests, maxPoints),
+		})
+	}
+	return v
+}
+
+// downsample returns the percentage of successful requests aggregated to at most maxPoints points, nil if there is no data.
+func downsample(totalRequests, failedRequests timeseries.TimeSeries, maxPoints int) timeseries.TimeSeries {
+	var times []timeseries.Time
+	var total []float64
+	iter := timeseries.Iter(totalRequests)
+	for iter.Next() {
+		t, value := iter.Value()
+		times = append(times, t)
+		total = append(total, value)
+	}
+	if len(times) == 0 {
+		return nil
+	}
+	failed := map[timeseries.Time]float64{}
+	iter = timeseries.Iter(failedRequests)
+	for iter.Next() {
+		t, value := iter.Value()
+		failed[t] = value
+	}
+
+	factor := (len(times) + maxPoints - 1) / maxPoints
+	step := timeseries.Duration(0)
+	if len(times) > 1 {
+		step = times[1].Sub(times[0])
+	}
+	data := make([]float64, 0, maxPoints)
+	for from := 0; from < len(times); from += factor {
+		var totalSum, failedSum float64
+		for i := from; i < from+factor && i < len(times); i++ {
+			if !math.IsNaN(total[i]) {
+				totalSum += total[i]
+			}
+			if f := failed[times[i]]; !math.IsNaN(f) {
+				failedSum += f
+			}
+		}
+		if totalSum <= 0 {
+			data = append(data, timeseries.NaN)
+			continue
+		}
+		data = append(data, (1-failedSum/totalSum)*100)
+	}
+	return timeseries.NewWithData(times[0], step*timeseries.Duration(factor), data)
+}
@@ -0,0 +1,71 @@
+package sli
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRenderComponents(t *testing.T) {
+	nan := timeseries.NaN
+	api := model.AvailabilityComponent{
+		Queries:        model.AvailabilityQueries{TotalRequestsQuery: "api_total", Weight: 3},
+		Weight:         .75,
+		TotalRequests:  timeseries.NewWithData(0, 60, []float64{100, 100, 100, 100}),
+		FailedRequests: timeseries.NewWithData(0, 60, []float64{0, 10, 0, 10}),
+	}
+	admin := model.AvailabilityComponent{
+		Queries:        model.AvailabilityQueries{TotalRequestsQuery: "admin_total", Weight: 1},
+		Weight:         .25,
+		TotalRequests:  timeseries.NewWithData(0, 60, []float64{10, 10, nan, nan}),
+		FailedRequests: timeseries.NewWithData(0, 60, []float64{10, 0, nan, nan}),
+	}
+	app := model.NewApplication(model.NewApplicationId("prod", model.ApplicationKindDeployment, "api"))
+	sli := &model.AvailabilitySLI{Config: model.CheckConfigSLOAvailability{ObjectivePercentage: 99}, Components: []model.AvailabilityComponent{api, admin}}
+	sli.TotalRequests, sli.FailedRequests = model.CompositeAvailability(sli.Components)
+	app.AvailabilitySLIs = append(app.AvailabilitySLIs, sli)
+
+	v := Render(app, 2)
+	assert.True(t, v.Configured)
+	assert.Equal(t, "InMemoryTimeSeries(0, 2, 120, [83.750000 95])", v.Sli.String())
+	if assert.Len(t, v.Components, 2) {
+		assert.Equal(t, Component{Query: "api_total", Weight: .75, Sli: timeseries.NewWithData(0, 120, []float64{95, 95})}, v.Components[0])
+		assert.Equal(t, "admin_total", v.Components[1].Query)
+		assert.Equal(t, .25, v.Components[1].Weight)
+		assert.Equal(t, "InMemoryTimeSeries(0, 2, 120, [50 .])", v.Components[1].Sli.String())
+	}
+
+	// a plain SLI has no breakdown
+	app.AvailabilitySLIs[0] = &model.AvailabilitySLI{TotalRequests: api.TotalRequests, FailedRequests: api.FailedRequests}
+	v = Render(app, 0)
+	assert.Nil(t, v.Components)
+	assert.Equal(t, "InMemoryTimeSeries(0, 4, 60, [100 90 100 90])", v.Sli.String())
+}
+
+func TestRender(t *testing.T) {
+	nan := timeseries.NaN
+	app := model.NewApplication(model.NewApplicationId("prod", model.ApplicationKindDeployment, "api"))
+	v := Render(app, 0)
+	assert.False(t, v.Configured)
+	assert.Nil(t, v.Sli)
+
+	app.AvailabilitySLIs = append(app.AvailabilitySLIs, &model.AvailabilitySLI{
+		Config:         model.CheckConfigSLOAvailability{ObjectivePercentage: 99},
+		TotalRequests:  timeseries.NewWithData(0, 60, []float64{100, 100, 0, nan, 50}),
+		FailedRequests: timeseries.NewWithData(0, 60, []float64{10, nan, 0, nan, 5}),
+	})
+	v = Render(app, 3)
+	assert.True(t, v.Configured)
+	assert.Equal(t, 99., v.Objective)
+	assert.NotNil(t, v.Forecast)
+	// 5 points are aggregated into buckets of 2, the bucket without requests is NaN
+	assert.Equal(t, "InMemoryTimeSeries(0, 3, 120, [95 . 90])", v.Sli.String())
+	assert.Equal(t, []float64{99, 99, 99}, timeseries.LastN(v.Target, 3))
+
+	app.AvailabilitySLIs[0].TotalRequests = nil
+	v = Render(app, 3)
+	assert.True(t, v.Configured)
+	assert.Nil(t, v.Sli)
+	assert.Nil(t, v.Target)
+}
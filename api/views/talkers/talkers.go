@@ -0,0 +1,111 @@
+package talkers
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"math"
+	"net"
+	"sort"
+)
+
+const (
+	DefaultLimit = 20
+)
+
+type View struct {
+	Connections []*Connection `json:"connections"`
+	Total       int           `json:"total"`
+}
+
+type Connection struct {
+	SourceApp      model.ApplicationId  `json:"source_app"`
+	SourceInstance string               `json:"source_instance"`
+	DestApp        *model.ApplicationId `json:"dest_app,omitempty"`
+	DestInstance   string               `json:"dest_instance,omitempty"`
+	Destination    string               `json:"destination"`
+
+	Bytes timeseries.Value `json:"bytes"` // bytes per second, sent and received
+	Rps   timeseries.Value `json:"rps"`
+}
+
+// ForApplication ranks the incoming and outgoing connections of the application instances.
+func ForApplication(app *model.Application, limit int, ascending bool) *View {
+	var connections []*model.Connection
+	for _, i := range app.Instances {
+		connections = append(connections, i.Upstreams...)
+		connections = append(connections, i.Downstreams...)
+	}
+	return render(connections, limit, ascending)
+}
+
+// ForNode ranks the incoming and outgoing connections of the instances running on the node.
+func ForNode(node *model.Node, limit int, ascending bool) *View {
+	var connections []*model.Connection
+	for _, i := range node.Instances {
+		connections = append(connections, i.Upstreams...)
+		connections = append(connections, i.Downstreams...)
+	}
+	return render(connections, limit, ascending)
+}
+
+// render ranks the connections by the average traffic over the world's time window, the heaviest first
+// unless ascending is set. Connections without traffic data are placed last in both orders.
+func render(connections []*model.Connection, limit int, ascending bool) *View {
+	v := &View{Connections: []*Connection{}}
+	seen := map[*model.Connection]bool{}
+	for _, c := range connections {
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+		tc := &Connection{
+			SourceApp:      c.Instance.OwnerId,
+			SourceInstance: c.Instance.Name,
+			Destination:    net.JoinHostPort(c.ActualRemoteIP, c.ActualRemotePort),
+			Bytes:          timeseries.Value(mean(timeseries.Merge(c.BytesSent, c.BytesReceived, timeseries.NanSum))),
+			Rps:            timeseries.Value(mean(model.GetConnectionsRequestsSum([]*model.Connection{c}))),
+		}
+		if ri := c.RemoteInstance; ri != nil {
+			tc.DestApp = &ri.OwnerId
+			tc.DestInstance = ri.Name
+		}
+		v.Connections = append(v.Connections, tc)
+	}
+	sort.SliceStable(v.Connections, func(i, j int) bool {
+		bi, bj := float64(v.Connections[i].Bytes), float64(v.Connections[j].Bytes)
+		if math.IsNaN(bj) {
+			return !math.IsNaN(bi)
+		}
+		if math.IsNaN(bi) {
+			return false
+		}
+		if ascending {
+			return bi < bj
+		}
+		return bi > bj
+	})
+	v.Total = len(v.Connections)
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if len(v.Connections) > limit {
+		v.Connections = v.Connections[:limit]
+	}
+	return v
+}
+
+func mean(ts timeseries.TimeSeries) float64 {
+	var sum, count float64
+	iter := timeseries.Iter(ts)
+	for iter.Next() {
+		_, v := iter.Value()
+		if !math.IsNaN(v) {
+			sum += v
+			count++
+		}
+	}
+	if count == 0 {
+		return timeseries.NaN
+	}
+	return sum / count
+}
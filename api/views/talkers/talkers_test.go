@@ -0,0 +1,48 @@
+package talkers
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	app := model.NewApplication(model.NewApplicationId("prod", model.ApplicationKindDeployment, "api"))
+	db := model.NewApplication(model.NewApplicationId("prod", model.ApplicationKindStatefulSet, "db"))
+	api1 := app.GetOrCreateInstance("api-1")
+	db1 := db.GetOrCreateInstance("db-1")
+	connection := func(dest string, sent, received []float64) *model.Connection {
+		c := api1.GetOrCreateUpstreamConnection(model.Labels{"destination": dest, "actual_destination": dest}, "api")
+		if sent != nil {
+			c.BytesSent = timeseries.NewWithData(0, 60, sent)
+			c.BytesReceived = timeseries.NewWithData(0, 60, received)
+		}
+		return c
+	}
+	toDb := connection("10.0.0.1:5432", []float64{100, 300}, []float64{10, timeseries.NaN})
+	toDb.RemoteInstance = db1
+	db1.Downstreams = append(db1.Downstreams, toDb)
+	connection("10.0.0.2:6379", []float64{1000, 1000}, []float64{0, 0})
+	connection("10.0.0.3:80", nil, nil)
+
+	v := ForApplication(app, 0, false)
+	assert.Equal(t, 3, v.Total)
+	if assert.Len(t, v.Connections, 3) {
+		assert.Equal(t, "10.0.0.2:6379", v.Connections[0].Destination)
+		assert.Equal(t, timeseries.Value(1000), v.Connections[0].Bytes)
+		assert.Equal(t, "10.0.0.1:5432", v.Connections[1].Destination)
+		assert.Equal(t, timeseries.Value(205), v.Connections[1].Bytes)
+		assert.Equal(t, db.Id, *v.Connections[1].DestApp)
+		assert.Equal(t, "db-1", v.Connections[1].DestInstance)
+		assert.Equal(t, "10.0.0.3:80", v.Connections[2].Destination)
+	}
+
+	// the connection is listed once even though it's both an upstream and a downstream of the application
+	app.Instances = append(app.Instances, db1)
+	v = ForApplication(app, 1, true)
+	assert.Equal(t, 3, v.Total)
+	if assert.Len(t, v.Connections, 1) {
+		assert.Equal(t, "10.0.0.1:5432", v.Connections[0].Destination)
+	}
+}
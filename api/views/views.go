@@ -2,47 +2,137 @@ package views
 
 import (
 	"context"
+	"github.com/coroot/coroot/alerts"
+	"github.com/coroot/coroot/api/views/annotations"
 	"github.com/coroot/coroot/api/views/application"
+	"github.com/coroot/coroot/api/views/capacity"
 	"github.com/coroot/coroot/api/views/categories"
+	"github.com/coroot/coroot/api/views/checks"
 	"github.com/coroot/coroot/api/views/configs"
+	"github.com/coroot/coroot/api/views/goldensignals"
+	"github.com/coroot/coroot/api/views/grafana"
+	"github.com/coroot/coroot/api/views/hints"
+	"github.com/coroot/coroot/api/views/incident"
+	"github.com/coroot/coroot/api/views/instance"
 	"github.com/coroot/coroot/api/views/integrations"
+	"github.com/coroot/coroot/api/views/inventory"
 	"github.com/coroot/coroot/api/views/node"
 	"github.com/coroot/coroot/api/views/overview"
 	"github.com/coroot/coroot/api/views/project"
+	"github.com/coroot/coroot/api/views/replicas"
 	"github.com/coroot/coroot/api/views/search"
+	"github.com/coroot/coroot/api/views/sli"
+	"github.com/coroot/coroot/api/views/talkers"
 	"github.com/coroot/coroot/cache"
 	"github.com/coroot/coroot/db"
 	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
 )
 
-func Status(p *db.Project, cacheStatus *cache.Status, w *model.World) *project.Status {
-	return project.RenderStatus(p, cacheStatus, w)
+func Status(p *db.Project, cacheStatus *cache.Status, w *model.World, am *alerts.AlertManager) *project.Status {
+	return project.RenderStatus(p, cacheStatus, w, am)
 }
 
-func Overview(w *model.World, p *db.Project) *overview.View {
-	return overview.Render(w, p)
+func StatusSummary(p *db.Project, cacheStatus *cache.Status, cacheTo timeseries.Time, openIncidents map[model.Status]int) *project.StatusSummary {
+	return project.RenderStatusSummary(p, cacheStatus, cacheTo, openIncidents)
 }
 
-func Application(w *model.World, app *model.Application, incidents []db.Incident) *application.View {
-	return application.Render(w, app, incidents)
+func ConfigurationHints(p *db.Project, w *model.World) *hints.View {
+	return hints.Render(p, w)
+}
+
+func Annotations(incidents []db.Incident, deployments []db.Deployment, now timeseries.Time) []annotations.Annotation {
+	return annotations.Render(incidents, deployments, now)
+}
+
+func Overview(w *model.World, current *model.World, p *db.Project, openIncidents map[model.ApplicationId]*db.Incident, tier model.ApplicationTier, groupBy overview.GroupBy) *overview.View {
+	return overview.Render(w, current, p, openIncidents, tier, groupBy)
+}
+
+func Check(w *model.World, cfg *model.CheckConfig) *checks.View {
+	return checks.Render(w, cfg)
+}
+
+func Application(w *model.World, shifted *model.World, offset timeseries.Duration, app *model.Application, incidents []db.Incident, deployments []db.Deployment, goldenSignals []model.GoldenSignal, severityLabels model.SeverityLabels, logsLink model.LogsLinkTemplate) *application.View {
+	return application.Render(w, shifted, offset, app, incidents, deployments, goldenSignals, severityLabels, logsLink)
+}
+
+func GoldenSignals(p *db.Project) *goldensignals.View {
+	return goldensignals.Render(p)
+}
+
+func Instance(w *model.World, app *model.Application, i *model.Instance) *instance.View {
+	return instance.Render(w, app, i)
+}
+
+func Incident(w *model.World, i *db.Incident, causedBy *db.Incident, dependents []db.Incident, deployments []db.Deployment, now timeseries.Time) *incident.View {
+	return incident.Render(w, i, causedBy, dependents, deployments, now)
+}
+
+func GrafanaDashboard(p *db.Project, app *model.Application, goldenSignals []model.GoldenSignal) *grafana.Dashboard {
+	return grafana.Render(p, app, goldenSignals)
+}
+
+func EvaluateAvailabilitySLO(s *model.AvailabilitySLI, to timeseries.Time) *sli.Evaluation {
+	return sli.EvaluateAvailability(s, to)
+}
+
+func EvaluateLatencySLO(s *model.LatencySLI, to timeseries.Time) *sli.Evaluation {
+	return sli.EvaluateLatency(s, to)
+}
+
+func AvailabilityBurnRates(s *model.AvailabilitySLI, from timeseries.Time, pointsCount int, step timeseries.Duration) *sli.BurnRates {
+	return sli.AvailabilityBurnRates(s, from, pointsCount, step)
+}
+
+func LatencyBurnRates(s *model.LatencySLI, from timeseries.Time, pointsCount int, step timeseries.Duration) *sli.BurnRates {
+	return sli.LatencyBurnRates(s, from, pointsCount, step)
+}
+
+func SLI(app *model.Application, maxPoints int) *sli.View {
+	return sli.Render(app, maxPoints)
+}
+
+func Replicas(app *model.Application) *replicas.View {
+	return replicas.Render(app)
+}
+
+func AppTopTalkers(app *model.Application, limit int, ascending bool) *talkers.View {
+	return talkers.ForApplication(app, limit, ascending)
+}
+
+func NodeTopTalkers(n *model.Node, limit int, ascending bool) *talkers.View {
+	return talkers.ForNode(n, limit, ascending)
 }
 
 func Node(w *model.World, n *model.Node) *model.AuditReport {
 	return node.Render(w, n)
 }
 
+func NodeCapacity(w *model.World, n *model.Node) *capacity.View {
+	return capacity.Render(w, n)
+}
+
+func NodeInventory(w *model.World) *inventory.View {
+	return inventory.Render(w)
+}
+
 func Search(w *model.World) *search.View {
 	return search.Render(w)
 }
 
-func Configs(checkConfigs model.CheckConfigs) *configs.View {
-	return configs.Render(checkConfigs)
+func Configs(checkConfigs model.CheckConfigs, objective func(checkId model.CheckId, appId model.ApplicationId) float64) *configs.View {
+	return configs.Render(checkConfigs, objective)
 }
 
 func Categories(p *db.Project) *categories.View {
 	return categories.Render(p)
 }
 
-func Integrations(ctx context.Context, p *db.Project) *integrations.View {
-	return integrations.Render(ctx, p)
+func CategoriesPreview(w *model.World, p *db.Project, candidate map[model.ApplicationCategory][]string) *categories.Preview {
+	return categories.RenderPreview(w, p, candidate)
+}
+
+func Integrations(ctx context.Context, p *db.Project, am *alerts.AlertManager) *integrations.View {
+	return integrations.Render(ctx, p, am)
 }
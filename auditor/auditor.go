@@ -28,29 +28,51 @@ func Audit(w *model.World) {
 		a.postgres()
 		a.redis()
 		a.logs()
+		a.finalize()
+	}
+}
 
-		events := calcAppEvents(app)
-		for _, r := range a.reports {
-			widgets := enrichWidgets(r.Widgets, events)
-			sort.SliceStable(widgets, func(i, j int) bool {
-				return widgets[i].Table != nil
-			})
-			r.Widgets = widgets
+// AuditInstance audits a single instance of the application as if it were the only one.
+// The SLO report is skipped since it describes the application as a whole.
+func AuditInstance(w *model.World, app *model.Application, instance *model.Instance) *model.Application {
+	single := model.NewApplication(app.Id)
+	single.Instances = []*model.Instance{instance}
+	a := &appAuditor{
+		w:   w,
+		app: single,
+	}
+	a.instances()
+	a.cpu()
+	a.memory()
+	a.storage()
+	a.network()
+	a.postgres()
+	a.redis()
+	a.logs()
+	a.finalize()
+	return single
+}
 
-			for _, ch := range r.Checks {
-				ch.Calc()
-				if ch.Status > r.Status {
-					r.Status = ch.Status
-				}
-			}
-			switch r.Name {
-			case model.AuditReportPostgres, model.AuditReportRedis, model.AuditReportInstances, model.AuditReportSLO:
-				if app.Status < r.Status {
-					app.Status = r.Status
-				}
+func (a *appAuditor) finalize() {
+	app := a.app
+	events := calcAppEvents(app)
+	for _, r := range a.reports {
+		widgets := enrichWidgets(r.Widgets, events)
+		sort.SliceStable(widgets, func(i, j int) bool {
+			return widgets[i].Table != nil
+		})
+		r.Widgets = widgets
+
+		for _, ch := range r.Checks {
+			ch.Calc()
+			if ch.Status > r.Status {
+				r.Status = ch.Status
 			}
-			app.Reports = append(app.Reports, r)
 		}
+		if r.Name.AffectsApplicationStatus() && app.Status < r.Status {
+			app.Status = r.Status
+		}
+		app.Reports = append(app.Reports, r)
 	}
 }
 
@@ -17,36 +17,26 @@ func (a *appAuditor) slo() {
 	clientRequests(a.app, report)
 }
 
+// availability evaluates the burn rates using the raw SLI series (RefreshInterval resolution, MaxAlertRuleWindow range),
+// which don't depend on the time window and the step of the world, the display series are used only for the chart.
 func availability(ctx timeseries.Context, app *model.Application, report *model.AuditReport) {
 	check := report.CreateCheck(model.Checks.SLOAvailability)
+	if check.Error != "" {
+		return
+	}
 	if len(app.AvailabilitySLIs) == 0 {
 		check.SetStatus(model.UNKNOWN, "not configured")
 		return
 	}
 	sli := app.AvailabilitySLIs[0]
-	if timeseries.IsEmpty(sli.TotalRequests) {
-		check.SetStatus(model.WARNING, "no data")
+	// the objective may be inherited, so the one of the SLI is used rather than the one of the config
+	check.Threshold = sli.Config.ObjectivePercentage
+	if sli.Error != "" {
+		check.SetError("failed to query the SLI: %s", sli.Error)
 		return
 	}
-
-	failed := sli.FailedRequests
-	if timeseries.IsEmpty(failed) {
-		failed = timeseries.Replace(sli.TotalRequests, 0)
-	}
-	successfulPercentage := timeseries.Aggregate(
-		func(t timeseries.Time, total, failed float64) float64 {
-			return (total - failed) / total * 100
-		},
-		sli.TotalRequests, timeseries.Map(timeseries.NanToZero, failed),
-	)
-	chart := report.
-		GetOrCreateChart("Availability").
-		AddSeries("successful requests", successfulPercentage)
-	chart.Threshold = &model.Series{
-		Name:  "target",
-		Color: "red",
-		Fill:  true,
-		Data:  timeseries.Replace(sli.TotalRequests, sli.Config.ObjectivePercentage),
+	if !timeseries.IsEmpty(sli.TotalRequests) {
+		availabilityChart(sli, report)
 	}
 
 	if dataIsMissing(sli.TotalRequestsRaw) {
@@ -55,42 +45,85 @@ func availability(ctx timeseries.Context, app *model.Application, report *model.
 	}
 
 	failedRaw := sli.FailedRequestsRaw
-	if timeseries.IsEmpty(failed) {
+	if timeseries.IsEmpty(failedRaw) {
 		failedRaw = timeseries.Replace(sli.TotalRequestsRaw, 0)
 	} else {
 		failedRaw = timeseries.Map(timeseries.NanToZero, failedRaw)
 	}
 	if br := model.CheckBurnRates(ctx.To, failedRaw, sli.TotalRequestsRaw, sli.Config.ObjectivePercentage); br.Severity > model.UNKNOWN {
+		check.SetBurnRate(br.Value)
 		check.SetStatus(br.Severity, formatSLOStatus(br))
 	}
 }
 
+func availabilityChart(sli *model.AvailabilitySLI, report *model.AuditReport) {
+	failed := sli.FailedRequests
+	if timeseries.IsEmpty(failed) {
+		failed = timeseries.Replace(sli.TotalRequests, 0)
+	}
+	successfulPercentage := successfulRequestsPercentage(sli.TotalRequests, failed)
+	chart := report.GetOrCreateChart("Availability")
+	if len(sli.Components) > 0 {
+		chart.AddSeries("composite", successfulPercentage)
+		for _, c := range sli.Components {
+			if timeseries.IsEmpty(c.TotalRequests) {
+				continue
+			}
+			name := fmt.Sprintf("%s (weight %.0f%%)", c.Queries.TotalRequestsQuery, c.Weight*100)
+			chart.AddSeries(name, successfulRequestsPercentage(c.TotalRequests, c.FailedRequests))
+		}
+	} else {
+		chart.AddSeries("successful requests", successfulPercentage)
+	}
+	chart.Threshold = &model.Series{
+		Name:  "target",
+		Color: "red",
+		Fill:  true,
+		Data:  timeseries.Replace(sli.TotalRequests, sli.Config.ObjectivePercentage),
+	}
+}
+
+func successfulRequestsPercentage(total, failed timeseries.TimeSeries) timeseries.TimeSeries {
+	return timeseries.Aggregate(
+		func(t timeseries.Time, total, failed float64) float64 {
+			return (total - failed) / total * 100
+		},
+		total, timeseries.Map(timeseries.NanToZero, failed),
+	)
+}
+
 func latency(ctx timeseries.Context, app *model.Application, report *model.AuditReport) {
 	check := report.CreateCheck(model.Checks.SLOLatency)
+	if check.Error != "" {
+		return
+	}
 	if len(app.LatencySLIs) == 0 {
 		check.SetStatus(model.UNKNOWN, "not configured")
 		return
 	}
 	sli := app.LatencySLIs[0]
-	total, fast := sli.GetTotalAndFast(false)
-	if timeseries.IsEmpty(total) || timeseries.IsEmpty(fast) {
-		check.SetStatus(model.WARNING, "no data")
+	// the objective may be inherited, so the one of the SLI is used rather than the one of the config
+	check.Threshold = sli.Config.ObjectivePercentage
+	if sli.Error != "" {
+		check.SetError("failed to query the SLI: %s", sli.Error)
 		return
 	}
-	fastPercentage := timeseries.Aggregate(
-		func(t timeseries.Time, total, fast float64) float64 {
-			return fast / total * 100
-		},
-		total, fast,
-	)
-	chart := report.
-		GetOrCreateChart("Latency").
-		AddSeries("requests served faster than "+utils.FormatLatency(sli.Config.ObjectiveBucket), fastPercentage)
-	chart.Threshold = &model.Series{
-		Name:  "target",
-		Color: "red",
-		Fill:  true,
-		Data:  timeseries.Replace(total, sli.Config.ObjectivePercentage),
+	if total, fast := sli.GetTotalAndFast(false); !timeseries.IsEmpty(total) && !timeseries.IsEmpty(fast) {
+		fastPercentage := timeseries.Aggregate(
+			func(t timeseries.Time, total, fast float64) float64 {
+				return fast / total * 100
+			},
+			total, fast,
+		)
+		chart := report.
+			GetOrCreateChart("Latency").
+			AddSeries("requests served faster than "+sli.Config.FormatBucket(sli.Config.ObjectiveBucket), fastPercentage)
+		chart.Threshold = &model.Series{
+			Name:  "target",
+			Color: "red",
+			Fill:  true,
+			Data:  timeseries.Replace(total, sli.Config.ObjectivePercentage),
+		}
 	}
 
 	totalRaw, fastRaw := sli.GetTotalAndFast(true)
@@ -106,6 +139,7 @@ func latency(ctx timeseries.Context, app *model.Application, report *model.Audit
 	}
 	slowRaw := timeseries.Aggregate(timeseries.Sub, totalRaw, fastRaw)
 	if br := model.CheckBurnRates(ctx.To, slowRaw, totalRaw, sli.Config.ObjectivePercentage); br.Severity > model.UNKNOWN {
+		check.SetBurnRate(br.Value)
 		check.SetStatus(br.Severity, formatSLOStatus(br))
 	}
 }
@@ -114,8 +148,8 @@ func requestsChart(app *model.Application, report *model.AuditReport) {
 	ch := report.GetOrCreateChart(fmt.Sprintf("Requests to the <var>%s</var> app, per second", app.Id.Name)).Sorted().Stacked()
 	if len(app.LatencySLIs) > 0 {
 		sli := app.LatencySLIs[0]
-		if hist := sli.Histogram; len(hist) > 0 {
-			for _, s := range histogramSeries(sli.Histogram, sli.Config.ObjectiveBucket) {
+		if hist := sli.DisplayHistogram(); len(hist) > 0 {
+			for _, s := range histogramSeries(hist, sli.Config.ObjectiveBucket) {
 				ch.Series = append(ch.Series, s)
 			}
 		}
@@ -174,7 +208,7 @@ func clientRequests(app *model.Application, report *model.AuditReport) {
 		requests := model.NewTableCell().SetUnit("/s")
 		if last := timeseries.Last(s.rps); !math.IsNaN(last) {
 			requests.SetValue(utils.FormatFloat(last))
-			requests.AddTag("%.0f%%", last*100/rpsTotal)
+			requests.AddTag("%s", utils.FormatPercent(last*100/rpsTotal))
 		}
 
 		latency := model.NewTableCell().SetUnit("ms")
@@ -185,7 +219,7 @@ func clientRequests(app *model.Application, report *model.AuditReport) {
 		errors := model.NewTableCell().SetUnit("/s")
 		if last := timeseries.Last(model.GetConnectionsErrorsSum(s.connections)); !math.IsNaN(last) {
 			errors.SetValue(utils.FormatFloat(last))
-			errors.AddTag("%.0f%%", last*100/timeseries.Last(s.rps))
+			errors.AddTag("%s", utils.FormatPercent(last*100/timeseries.Last(s.rps)))
 		}
 
 		t.AddRow(client, chart, requests, latency, errors)
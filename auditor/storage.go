@@ -48,7 +48,7 @@ func (a *appAuditor) storage() {
 					}
 					ioPercent := model.NewTableCell()
 					if last := timeseries.Last(d.IOUtilizationPercent); !math.IsNaN(last) {
-						ioPercent.SetValue(fmt.Sprintf("%.0f%%", last))
+						ioPercent.SetValue(utils.FormatPercent(last))
 					}
 					space := model.NewTableCell()
 					capacity := timeseries.Last(v.CapacityBytes)
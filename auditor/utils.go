@@ -68,18 +68,20 @@ func histogramSeries(histogram []model.HistogramBucket, objectiveBucket float64)
 		}
 		data := b.TimeSeries
 		legend := ""
+		approximate := b.Interpolated
 		if i == 0 {
 			legend = fmt.Sprintf("0-%.0f ms", b.Le*1000)
 		} else {
 			prev := histogram[i-1]
 			data = timeseries.Aggregate(timeseries.Sub, data, prev.TimeSeries)
+			approximate = approximate || prev.Interpolated
 			if prev.Le >= 0.1 {
 				legend = fmt.Sprintf("%s-%s s", humanize.Ftoa(prev.Le), humanize.Ftoa(b.Le))
 			} else {
 				legend = fmt.Sprintf("%.0f-%.0f ms", prev.Le*1000, b.Le*1000)
 			}
 		}
-		res = append(res, &model.Series{Name: legend, Data: data, Color: color})
+		res = append(res, &model.Series{Name: legend, Data: data, Color: color, Approximate: approximate})
 	}
 	return res
 }
@@ -19,9 +19,20 @@ import (
 )
 
 const (
-	chunkSize = timeseries.Hour
+	DefaultChunkSize = timeseries.Hour
 )
 
+// ChunkSizes are the allowed chunk durations. They match the compaction steps,
+// so chunks written with a previous size are gradually compacted into the bigger ones.
+var ChunkSizes = []timeseries.Duration{timeseries.Hour, 4 * timeseries.Hour, 12 * timeseries.Hour}
+
+func chunkSize(p *db.Project) timeseries.Duration {
+	if s := p.Prometheus.CacheChunkSize; s > 0 {
+		return s
+	}
+	return DefaultChunkSize
+}
+
 type Cache struct {
 	lock      sync.RWMutex
 	byProject map[db.ProjectId]map[string]*queryData
@@ -31,17 +42,32 @@ type Cache struct {
 
 	refreshIntervalMin timeseries.Duration
 
+	// the start of the gaps being caught up by project, see updateBackfill
+	backfills map[db.ProjectId]timeseries.Time
+
 	pendingCompactions prometheus.Gauge
 	compactedChunks    *prometheus.CounterVec
+	queries            *prometheus.CounterVec
 }
 
 type queryData struct {
 	chunksOnDisk map[string]*chunk.Meta
+
+	// warnings about incomplete data by chunk start, kept in memory only
+	warnings map[timeseries.Time]chunkWarning
+	// the error of the last download if it timed out: the data after the last downloaded point is missing until a retry succeeds
+	timeout string
+}
+
+type chunkWarning struct {
+	to      timeseries.Time
+	message string
 }
 
 func newQueryData() *queryData {
 	return &queryData{
 		chunksOnDisk: map[string]*chunk.Meta{},
+		warnings:     map[timeseries.Time]chunkWarning{},
 	}
 }
 
@@ -60,6 +86,7 @@ func NewCache(cfg Config, database *db.DB) (*Cache, error) {
 		byProject: map[db.ProjectId]map[string]*queryData{},
 		db:        database,
 		state:     state,
+		backfills: map[db.ProjectId]timeseries.Time{},
 
 		pendingCompactions: prometheus.NewGauge(
 			prometheus.GaugeOpts{
@@ -72,6 +99,12 @@ func NewCache(cfg Config, database *db.DB) (*Cache, error) {
 			},
 			[]string{"src", "dst"},
 		),
+		queries: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "coroot_cache_queries_total",
+			},
+			[]string{"result"},
+		),
 	}
 	if err := cache.initCacheIndexFromDir(); err != nil {
 		return nil, err
@@ -79,6 +112,7 @@ func NewCache(cfg Config, database *db.DB) (*Cache, error) {
 
 	prometheus.MustRegister(cache.pendingCompactions)
 	prometheus.MustRegister(cache.compactedChunks)
+	prometheus.MustRegister(cache.queries)
 
 	go cache.updater()
 	go cache.gc()
@@ -139,7 +173,7 @@ func chunkJitter(projectId db.ProjectId, queryHash string) timeseries.Duration {
 	queryKey := fmt.Sprintf("%s-%s", projectId, queryHash)
 	h := fnv.New32a()
 	_, _ = h.Write([]byte(queryKey))
-	return timeseries.Duration(h.Sum32()%uint32(chunkSize/timeseries.Minute)) * timeseries.Minute
+	return timeseries.Duration(h.Sum32()%uint32(DefaultChunkSize/timeseries.Minute)) * timeseries.Minute
 }
 
 func QueryId(projectId db.ProjectId, query string) (string, timeseries.Duration) {
@@ -8,12 +8,14 @@ import (
 	"github.com/coroot/coroot/model"
 	"github.com/coroot/coroot/prom"
 	"github.com/coroot/coroot/timeseries"
+	"github.com/coroot/coroot/utils"
 )
 
 type Client struct {
 	cache           *Cache
 	projectId       db.ProjectId
 	refreshInterval timeseries.Duration
+	chunkSize       timeseries.Duration
 	promClient      prom.Client
 }
 
@@ -22,10 +24,21 @@ func (c *Cache) GetCacheClient(p *db.Project) *Client {
 		cache:           c,
 		projectId:       p.Id,
 		refreshInterval: p.Prometheus.RefreshInterval,
+		chunkSize:       chunkSize(p),
 	}
 }
 
 func (c *Client) QueryRange(ctx context.Context, query string, from, to timeseries.Time, step timeseries.Duration) ([]model.MetricValues, error) {
+	res, _, err := c.QueryRangeWithWarnings(ctx, query, from, to, step)
+	return res, err
+}
+
+// QueryRangeWithWarnings returns the cached data along with the warnings about it being incomplete:
+// Prometheus responded with warnings to the queries fetching the range, or the latest fetch has timed out.
+func (c *Client) QueryRangeWithWarnings(ctx context.Context, query string, from, to timeseries.Time, step timeseries.Duration) ([]model.MetricValues, []string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
 	from = from.Truncate(step)
 	to = to.Truncate(step)
 	c.cache.lock.RLock()
@@ -33,13 +46,15 @@ func (c *Client) QueryRange(ctx context.Context, query string, from, to timeseri
 
 	byProject, ok := c.cache.byProject[c.projectId]
 	if !ok {
-		return nil, fmt.Errorf("unknown project: %s", c.projectId)
+		return nil, nil, fmt.Errorf("unknown project: %s", c.projectId)
 	}
 	queryHash := hash(query)
 	qData, ok := byProject[queryHash]
 	if !ok {
-		return nil, fmt.Errorf("unknown query: %s", query)
+		c.cache.queries.WithLabelValues("miss").Inc()
+		return nil, nil, fmt.Errorf("unknown query: %s", query)
 	}
+	c.cache.queries.WithLabelValues("hit").Inc()
 	start := from
 	end := to
 	res := map[uint64]model.MetricValues{}
@@ -50,20 +65,62 @@ func (c *Client) QueryRange(ctx context.Context, query string, from, to timeseri
 		}
 		err := chunk.Read(ch.Path, from, resPoints, step, res)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 	r := make([]model.MetricValues, 0, len(res))
 	for _, mv := range res {
 		r = append(r, mv)
 	}
-	return r, nil
+	var warnings []string
+	if qData.timeout != "" {
+		warnings = append(warnings, qData.timeout)
+	}
+	for ts, w := range qData.warnings {
+		if ts <= end && w.to >= start {
+			warnings = append(warnings, w.message)
+		}
+	}
+	if len(warnings) > 0 {
+		warnings = utils.NewStringSet(warnings...).Items()
+	}
+	return r, warnings, nil
 }
 
 func (c *Client) Ping(ctx context.Context) error {
 	return fmt.Errorf("not implemented")
 }
 
+// BuildInfo returns the latest recorded version of the Prometheus server.
+func (c *Client) BuildInfo(ctx context.Context) (*prom.BuildInfo, error) {
+	v, err := c.cache.getPrometheusVersion(c.projectId, timeseries.Now())
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, fmt.Errorf("unknown Prometheus version")
+	}
+	return &prom.BuildInfo{Version: v.Version, Revision: v.Revision}, nil
+}
+
+// GetPrometheusVersions returns the distinct versions of the Prometheus server the data within the given range has been fetched from.
+func (c *Client) GetPrometheusVersions(from, to timeseries.Time) ([]string, error) {
+	versions, err := c.cache.getPrometheusVersions(c.projectId, from, to)
+	if err != nil {
+		return nil, err
+	}
+	set := utils.NewStringSet()
+	var res []string
+	for _, v := range versions {
+		s := v.String()
+		if !set.Has(s) {
+			set.Add(s)
+			res = append(res, s)
+		}
+	}
+	return res, nil
+}
+
 func (c *Client) GetTo() (timeseries.Time, error) {
 	to, err := c.cache.getMinUpdateTime(c.projectId)
 	if err != nil {
@@ -76,7 +133,12 @@ func (c *Client) GetTo() (timeseries.Time, error) {
 }
 
 func (c *Client) GetStatus() (*Status, error) {
-	return c.cache.getStatus(c.projectId)
+	s, err := c.cache.getStatus(c.projectId)
+	if err != nil {
+		return nil, err
+	}
+	s.ChunkSize = c.chunkSize
+	return s, nil
 }
 
 func (c *Cache) getPromClient(p *db.Project) prom.Client {
@@ -84,7 +146,11 @@ func (c *Cache) getPromClient(p *db.Project) prom.Client {
 	if p.Prometheus.BasicAuth != nil {
 		user, password = p.Prometheus.BasicAuth.User, p.Prometheus.BasicAuth.Password
 	}
-	client, err := prom.NewApiClient(p.Prometheus.Url, user, password, p.Prometheus.TlsSkipVerify)
+	tlsConfig, err := p.Prometheus.TLSConfig()
+	if err != nil {
+		return NewErrorClient(err)
+	}
+	client, err := prom.NewApiClient(p.Prometheus.Url, user, password, tlsConfig, p.Prometheus.GetQueryTimeout().ToStandard())
 	if err != nil {
 		return NewErrorClient(err)
 	}
@@ -106,3 +172,7 @@ func (e ErrorClient) QueryRange(ctx context.Context, query string, from, to time
 func (e ErrorClient) Ping(ctx context.Context) error {
 	return e.err
 }
+
+func (e ErrorClient) BuildInfo(ctx context.Context) (*prom.BuildInfo, error) {
+	return nil, e.err
+}
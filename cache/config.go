@@ -9,6 +9,19 @@ type Config struct {
 	Path       string
 	GC         *GcConfig
 	Compaction *CompactionConfig
+
+	// the max number of chunks of a query downloaded per update iteration (0 means unlimited),
+	// so that after a Prometheus outage the gap is caught up gradually, without a burst of heavy queries
+	BackfillChunksPerIteration int
+}
+
+// maxBackfill returns how far back a query can be backfilled: there's no point in downloading data
+// that is going to be removed by GC right away.
+func (cfg Config) maxBackfill() timeseries.Duration {
+	if cfg.GC == nil || cfg.GC.TTL <= 0 {
+		return 0
+	}
+	return timeseries.Duration(cfg.GC.TTL / time.Second)
 }
 
 type GcConfig struct {
@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"github.com/coroot/coroot/timeseries"
+	"sort"
+)
+
+type Interval struct {
+	From timeseries.Time `json:"from"`
+	To   timeseries.Time `json:"to"`
+}
+
+// GetCoverage returns the time ranges within [from, to] for which the cache has data for at least one query of the project.
+func (c *Client) GetCoverage(from, to timeseries.Time) ([]Interval, error) {
+	cacheTo, err := c.GetTo()
+	if err != nil {
+		return nil, err
+	}
+	if cacheTo.IsZero() {
+		return nil, nil
+	}
+	if cacheTo.Before(to) {
+		to = cacheTo
+	}
+
+	c.cache.lock.RLock()
+	var intervals []Interval
+	for _, qData := range c.cache.byProject[c.projectId] {
+		for _, ch := range qData.chunksOnDisk {
+			intervals = append(intervals, Interval{From: ch.From, To: ch.From.Add(timeseries.Duration(ch.PointsCount-1) * ch.Step)})
+		}
+	}
+	c.cache.lock.RUnlock()
+
+	var res []Interval
+	for _, i := range mergeIntervals(intervals, c.refreshInterval) {
+		if i.To.Before(from) || i.From.After(to) {
+			continue
+		}
+		if i.From.Before(from) {
+			i.From = from
+		}
+		if i.To.After(to) {
+			i.To = to
+		}
+		res = append(res, i)
+	}
+	return res, nil
+}
+
+// GetGaps returns the time ranges within [from, to] not covered by the cache.
+func (c *Client) GetGaps(from, to timeseries.Time) ([]Interval, error) {
+	coverage, err := c.GetCoverage(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return gaps(coverage, from, to, c.refreshInterval), nil
+}
+
+// mergeIntervals joins overlapping intervals and those separated by no more than a step.
+func mergeIntervals(intervals []Interval, step timeseries.Duration) []Interval {
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].From.Before(intervals[j].From)
+	})
+	var res []Interval
+	for _, i := range intervals {
+		if n := len(res); n > 0 && !i.From.After(res[n-1].To.Add(step)) {
+			if i.To.After(res[n-1].To) {
+				res[n-1].To = i.To
+			}
+			continue
+		}
+		res = append(res, i)
+	}
+	return res
+}
+
+func gaps(coverage []Interval, from, to timeseries.Time, step timeseries.Duration) []Interval {
+	var res []Interval
+	t := from
+	for _, i := range coverage {
+		if i.From.After(t.Add(step)) {
+			res = append(res, Interval{From: t, To: i.From})
+		}
+		if i.To.After(t) {
+			t = i.To
+		}
+	}
+	if to.After(t.Add(step)) {
+		res = append(res, Interval{From: t, To: to})
+	}
+	return res
+}
@@ -0,0 +1,18 @@
+package cache
+
+import (
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCoverage(t *testing.T) {
+	step := 30 * timeseries.Second
+	merged := mergeIntervals([]Interval{{From: 3600, To: 7170}, {From: 0, To: 3570}, {From: 1000, To: 2000}, {From: 10800, To: 14370}}, step)
+	assert.Equal(t, []Interval{{From: 0, To: 7170}, {From: 10800, To: 14370}}, merged)
+
+	assert.Equal(t, []Interval{{From: 7170, To: 10800}}, gaps(merged, 0, 14370, step))
+	assert.Equal(t, []Interval{{From: -100, To: 0}, {From: 7170, To: 10800}, {From: 14370, To: 15000}}, gaps(merged, -100, 15000, step))
+	assert.Nil(t, gaps([]Interval{{From: 100, To: 7000}}, 100, 7000, step))
+	assert.Equal(t, []Interval{{From: 0, To: 100}}, gaps(nil, 0, 100, step))
+}
@@ -30,10 +30,61 @@ func (p *PrometheusQueryState) Migrate(m *db.Migrator) error {
 	return err
 }
 
+// PrometheusVersion records the version of the Prometheus server the project's data has been fetched from since the given time.
+type PrometheusVersion struct {
+	ProjectId db.ProjectId
+	Since     timeseries.Time
+	Version   string
+	Revision  string
+}
+
+func (p *PrometheusVersion) Migrate(m *db.Migrator) error {
+	err := m.Exec(`
+	CREATE TABLE IF NOT EXISTS prometheus_version (
+		project_id TEXT NOT NULL,
+		since INTEGER NOT NULL,
+		version TEXT NOT NULL,
+		revision TEXT NOT NULL,
+		PRIMARY KEY(project_id, since)
+	)`)
+	return err
+}
+
+func (p *PrometheusVersion) String() string {
+	if p.Revision == "" {
+		return p.Version
+	}
+	return p.Version + " (" + p.Revision + ")"
+}
+
 type Status struct {
-	Error  string
-	LagMax timeseries.Duration
-	LagAvg timeseries.Duration
+	Error             string
+	LagMax            timeseries.Duration
+	LagAvg            timeseries.Duration
+	ChunkSize         timeseries.Duration
+	PrometheusVersion string
+	Backfill          *BackfillStatus
+}
+
+// BackfillStatus is the progress of catching up the gap left by a Prometheus outage.
+type BackfillStatus struct {
+	From     timeseries.Time // the start of the gap
+	To       timeseries.Time // the time up to which all the queries have been downloaded
+	Progress float64         // 0..1
+}
+
+func calcBackfillStatus(from, to, now timeseries.Time) *BackfillStatus {
+	s := &BackfillStatus{From: from, To: to}
+	if total := now.Sub(from); total > 0 {
+		s.Progress = float64(to.Sub(from)) / float64(total)
+	}
+	if s.Progress < 0 {
+		s.Progress = 0
+	}
+	if s.Progress > 1 {
+		s.Progress = 1
+	}
+	return s
 }
 
 func openStateDB(path string) (*sql.DB, error) {
@@ -42,7 +93,7 @@ func openStateDB(path string) (*sql.DB, error) {
 		return nil, err
 	}
 	database.SetMaxOpenConns(1)
-	if err := db.NewMigrator(db.TypeSqlite, database).Migrate(&PrometheusQueryState{}); err != nil {
+	if err := db.NewMigrator(db.TypeSqlite, database).Migrate(&PrometheusQueryState{}, &PrometheusVersion{}); err != nil {
 		return nil, err
 	}
 	return database, nil
@@ -85,12 +136,23 @@ func (c *Cache) deleteProject(projectId db.ProjectId) error {
 	if _, err := c.state.Exec("DELETE FROM prometheus_query_state WHERE project_id = $1", projectId); err != nil {
 		return err
 	}
+	if _, err := c.state.Exec("DELETE FROM prometheus_version WHERE project_id = $1", projectId); err != nil {
+		return err
+	}
 	return nil
 }
 
+// queryTimeoutErrorPrefix marks the query states whose last download timed out.
+const queryTimeoutErrorPrefix = "timeout: "
+
+// getMinUpdateTime returns the time the data of all the queries of the project has been downloaded up to.
+// The queries whose downloads time out are ignored unless all of them do, so they don't hold back the whole project.
 func (c *Cache) getMinUpdateTime(projectId db.ProjectId) (timeseries.Time, error) {
 	var min sql.NullInt64
-	err := c.state.QueryRow("SELECT min(last_ts) FROM prometheus_query_state WHERE project_id = $1", projectId).Scan(&min)
+	err := c.state.QueryRow(
+		"SELECT coalesce(min(CASE WHEN last_error NOT LIKE $1 THEN last_ts END), min(last_ts)) FROM prometheus_query_state WHERE project_id = $2",
+		queryTimeoutErrorPrefix+"%", projectId,
+	).Scan(&min)
 	if err != nil {
 		return 0, err
 	}
@@ -108,6 +170,11 @@ func (c *Cache) getStatus(projectId db.ProjectId) (*Status, error) {
 	if err := c.state.QueryRow("SELECT max($1 - last_ts), avg($1 - last_ts) FROM prometheus_query_state WHERE project_id = $2", now, projectId).Scan(&max, &avg); err != nil {
 		return nil, err
 	}
+	if v, err := c.getPrometheusVersion(projectId, now); err != nil {
+		return nil, err
+	} else if v != nil {
+		s.PrometheusVersion = v.String()
+	}
 	if max.Valid && avg.Valid {
 		s.LagMax = timeseries.Duration(max.Float64)
 		s.LagAvg = timeseries.Duration(avg.Float64)
@@ -115,5 +182,68 @@ func (c *Cache) getStatus(projectId db.ProjectId) (*Status, error) {
 		s.LagMax = BackFillInterval
 		s.LagAvg = BackFillInterval
 	}
+	c.lock.RLock()
+	from, ok := c.backfills[projectId]
+	c.lock.RUnlock()
+	if ok {
+		s.Backfill = calcBackfillStatus(from, now.Add(-s.LagMax), now)
+	}
 	return &s, nil
 }
+
+// savePrometheusVersion records the version if it differs from the latest recorded one.
+func (c *Cache) savePrometheusVersion(v *PrometheusVersion) error {
+	last, err := c.getPrometheusVersion(v.ProjectId, v.Since)
+	if err != nil {
+		return err
+	}
+	if last != nil && last.Version == v.Version && last.Revision == v.Revision {
+		return nil
+	}
+	_, err = c.state.Exec(
+		"INSERT OR REPLACE INTO prometheus_version (project_id, since, version, revision) values ($1, $2, $3, $4)",
+		v.ProjectId, v.Since, v.Version, v.Revision)
+	return err
+}
+
+// getPrometheusVersion returns the version in effect at the given time or nil if none has been recorded.
+func (c *Cache) getPrometheusVersion(projectId db.ProjectId, t timeseries.Time) (*PrometheusVersion, error) {
+	v := &PrometheusVersion{ProjectId: projectId}
+	err := c.state.QueryRow(
+		"SELECT since, version, revision FROM prometheus_version WHERE project_id = $1 AND since <= $2 ORDER BY since DESC LIMIT 1",
+		projectId, t).Scan(&v.Since, &v.Version, &v.Revision)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// getPrometheusVersions returns the versions in effect within the given range, the oldest first.
+func (c *Cache) getPrometheusVersions(projectId db.ProjectId, from, to timeseries.Time) ([]*PrometheusVersion, error) {
+	var res []*PrometheusVersion
+	first, err := c.getPrometheusVersion(projectId, from)
+	if err != nil {
+		return nil, err
+	}
+	if first != nil {
+		res = append(res, first)
+	}
+	rows, err := c.state.Query(
+		"SELECT since, version, revision FROM prometheus_version WHERE project_id = $1 AND since > $2 AND since <= $3 ORDER BY since",
+		projectId, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		v := &PrometheusVersion{ProjectId: projectId}
+		if err = rows.Scan(&v.Since, &v.Version, &v.Revision); err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+	}
+	return res, rows.Err()
+}
@@ -64,6 +64,11 @@ func (c *Cache) gc() {
 						delete(qData.chunksOnDisk, path)
 					}
 				}
+				for ts, w := range qData.warnings {
+					if w.to < minTs {
+						delete(qData.warnings, ts)
+					}
+				}
 				if len(qData.chunksOnDisk) == 0 {
 					delete(c.byProject[projectId], queryHash)
 				}
@@ -20,6 +20,7 @@ import (
 const (
 	QueryConcurrency = 10
 	BackFillInterval = 4 * timeseries.Hour
+	buildInfoTimeout = 10 * time.Second
 )
 
 func (c *Cache) updater() {
@@ -70,19 +71,24 @@ func (c *Cache) updaterWorker(projects *sync.Map, projectId db.ProjectId) {
 				return
 			}
 			var queries []string
-			for _, q := range constructor.QUERIES {
-				queries = append(queries, q)
+			for _, qs := range []map[string]string{constructor.QUERIES, constructor.TRAFFIC_QUERIES} {
+				for _, q := range qs {
+					queries = append(queries, q)
+				}
 			}
 			for appId := range checkConfigs {
 				for _, l := range checkConfigs.GetLatency(appId) {
 					queries = append(queries, l.Histogram())
 				}
 				for _, a := range checkConfigs.GetAvailability(appId) {
-					queries = append(queries, a.Total(), a.Failed())
+					for _, q := range a.Queries() {
+						queries = append(queries, q.Total(), q.Failed())
+					}
 				}
 			}
 			actualQueries := map[string]*PrometheusQueryState{}
 			for _, q := range queries {
+				q = prom.InjectSelector(q, project.Prometheus.ExtraSelector)
 				state := byQuery[q]
 				if state == nil {
 					state = &PrometheusQueryState{ProjectId: projectId, Query: q, LastTs: now.Add(-BackFillInterval)}
@@ -105,6 +111,7 @@ func (c *Cache) updaterWorker(projects *sync.Map, projectId db.ProjectId) {
 			}
 
 			promClient := c.getPromClient(project)
+			c.updatePrometheusVersion(promClient, projectId, now)
 			wg := sync.WaitGroup{}
 			tasks := make(chan *PrometheusQueryState)
 			for i := 0; i < QueryConcurrency; i++ {
@@ -121,6 +128,7 @@ func (c *Cache) updaterWorker(projects *sync.Map, projectId db.ProjectId) {
 			}
 			close(tasks)
 			wg.Wait()
+			c.updateBackfill(project, actualQueries)
 		}()
 		refreshInterval := project.Prometheus.RefreshInterval
 		if refreshInterval < c.refreshIntervalMin {
@@ -130,17 +138,61 @@ func (c *Cache) updaterWorker(projects *sync.Map, projectId db.ProjectId) {
 	}
 }
 
+func (c *Cache) updatePrometheusVersion(promClient prom.Client, projectId db.ProjectId, now timeseries.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), buildInfoTimeout)
+	defer cancel()
+	bi, err := promClient.BuildInfo(ctx)
+	if err != nil {
+		// some Prometheus-compatible servers don't expose the buildinfo endpoint
+		klog.Infof("%s: failed to get Prometheus build info: %s", projectId, err)
+		return
+	}
+	if err := c.savePrometheusVersion(&PrometheusVersion{ProjectId: projectId, Since: now, Version: bi.Version, Revision: bi.Revision}); err != nil {
+		klog.Errorln("failed to save Prometheus version:", err)
+	}
+}
+
 func (c *Cache) download(ctx context.Context, promClient prom.Client, project *db.Project, state *PrometheusQueryState) {
 	queryHash, jitter := QueryId(project.Id, state.Query)
 	refreshInterval := project.Prometheus.RefreshInterval
 	now := timeseries.Now()
 	step := project.Prometheus.RefreshInterval
-	pointsCount := int(chunkSize / step)
+	size := chunkSize(project)
+	pointsCount := int(size / step)
 
-	for _, i := range calcIntervals(state.LastTs, refreshInterval, now.Add(-refreshInterval), jitter) {
+	lastTs := state.LastTs
+	if max := c.cfg.maxBackfill(); max > 0 && lastTs.Before(now.Add(-max)) {
+		lastTs = now.Add(-max)
+	}
+	intervals := calcIntervals(lastTs, refreshInterval, now.Add(-refreshInterval), size, jitter)
+	if n := c.cfg.BackfillChunksPerIteration; n > 0 && len(intervals) > n {
+		klog.Infof("%s: backfilling %d chunks of %s, %d of them in this iteration", project.Id, len(intervals), state.Query, n)
+		intervals = intervals[:n]
+	}
+	for _, i := range intervals {
 		promCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
-		vs, err := promClient.QueryRange(promCtx, state.Query, i.chunkTs, i.toTs, project.Prometheus.RefreshInterval)
+		vs, warnings, err := prom.QueryRangeWithWarnings(promCtx, promClient, state.Query, i.chunkTs, i.toTs, project.Prometheus.RefreshInterval)
 		cancel()
+		warning := ""
+		if len(warnings) > 0 {
+			warning = prom.FormatWarnings(warnings)
+		}
+		switch {
+		case err == nil:
+		case prom.IsTimeout(err):
+			// the interval is retried in the next iteration, meanwhile the readers get the data downloaded so far
+			// along with a warning, and the query doesn't hold back the whole project (see getMinUpdateTime)
+			klog.Warningf("%s, its data since %s will be retried: %s", err, i.chunkTs.ToStandard(), state.Query)
+			state.LastError = queryTimeoutErrorPrefix + err.Error()
+			c.lock.Lock()
+			c.getOrCreateQueryData(project.Id, queryHash).timeout = err.Error()
+			err = c.saveState(state)
+			c.lock.Unlock()
+			if err != nil {
+				klog.Errorln("failed to save query state:", err)
+			}
+			return
+		}
 		if err != nil {
 			state.LastError = err.Error()
 			if err := c.saveState(state); err != nil {
@@ -156,6 +208,15 @@ func (c *Cache) download(ctx context.Context, promClient prom.Client, project *d
 		finalized := chunkEnd == i.toTs
 
 		err = c.writeChunk(project.Id, queryHash, i.chunkTs, pointsCount, step, finalized, vs)
+		if err == nil {
+			qData := c.byProject[project.Id][queryHash]
+			qData.timeout = ""
+			if warning != "" {
+				qData.warnings[i.chunkTs] = chunkWarning{to: i.toTs, message: warning}
+			} else {
+				delete(qData.warnings, i.chunkTs)
+			}
+		}
 		c.lock.Unlock()
 		if err != nil {
 			klog.Errorln("failed to save chunk:", err)
@@ -174,21 +235,53 @@ func (c *Cache) download(ctx context.Context, promClient prom.Client, project *d
 	}
 }
 
-func (c *Cache) writeChunk(projectID db.ProjectId, queryHash string, from timeseries.Time, pointsCount int, step timeseries.Duration, finalized bool, metrics []model.MetricValues) error {
+// updateBackfill tracks the catching up of a gap left by a Prometheus outage: it starts when the data of a query
+// falls behind by more than a chunk and ends when all the queries of the project are up-to-date.
+func (c *Cache) updateBackfill(project *db.Project, states map[string]*PrometheusQueryState) {
+	if len(states) == 0 {
+		return
+	}
+	var minLastTs timeseries.Time
+	for _, s := range states {
+		if minLastTs.IsZero() || s.LastTs.Before(minLastTs) {
+			minLastTs = s.LastTs
+		}
+	}
+	behind := timeseries.Now().Sub(minLastTs) > chunkSize(project)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, ok := c.backfills[project.Id]
+	switch {
+	case behind && !ok:
+		klog.Infof("%s: backfilling the data since %s", project.Id, minLastTs.ToStandard())
+		c.backfills[project.Id] = minLastTs
+	case !behind && ok:
+		klog.Infof("%s: the data has been backfilled", project.Id)
+		delete(c.backfills, project.Id)
+	}
+}
+
+// getOrCreateQueryData must be called with the lock held.
+func (c *Cache) getOrCreateQueryData(projectID db.ProjectId, queryHash string) *queryData {
 	byProject, ok := c.byProject[projectID]
-	projectDir := path.Join(c.cfg.Path, string(projectID))
 	if !ok {
 		byProject = map[string]*queryData{}
 		c.byProject[projectID] = byProject
-		if err := utils.CreateDirectoryIfNotExists(projectDir); err != nil {
-			return err
-		}
 	}
 	qData, ok := byProject[queryHash]
 	if !ok {
 		qData = newQueryData()
 		byProject[queryHash] = qData
 	}
+	return qData
+}
+
+func (c *Cache) writeChunk(projectID db.ProjectId, queryHash string, from timeseries.Time, pointsCount int, step timeseries.Duration, finalized bool, metrics []model.MetricValues) error {
+	projectDir := path.Join(c.cfg.Path, string(projectID))
+	if err := utils.CreateDirectoryIfNotExists(projectDir); err != nil {
+		return err
+	}
+	qData := c.getOrCreateQueryData(projectID, queryHash)
 	chunkFilePath := path.Join(projectDir, fmt.Sprintf(
 		"%s-%s-%d-%d-%d.db",
 		projectID, queryHash, from, pointsCount, step))
@@ -216,7 +309,7 @@ func (i interval) String() string {
 	)
 }
 
-func calcIntervals(lastSavedTime timeseries.Time, scrapeInterval timeseries.Duration, now timeseries.Time, jitter timeseries.Duration) []interval {
+func calcIntervals(lastSavedTime timeseries.Time, scrapeInterval timeseries.Duration, now timeseries.Time, chunkSize, jitter timeseries.Duration) []interval {
 	to := now.Truncate(scrapeInterval)
 	from := lastSavedTime.Add(scrapeInterval)
 	if to < from {
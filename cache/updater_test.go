@@ -1,9 +1,16 @@
 package cache
 
 import (
+	"context"
 	"fmt"
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/prom"
 	"github.com/coroot/coroot/timeseries"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path"
 	"testing"
 	"time"
 )
@@ -22,7 +29,7 @@ func TestCacheUpdater_calcIntervals(t *testing.T) {
 
 	calc := func(lastSaved, now string) string {
 		jitter := 12 * timeseries.Minute
-		return fmt.Sprintf(`%s`, calcIntervals(ts(lastSaved), scrapeInterval, ts(now), jitter))
+		return fmt.Sprintf(`%s`, calcIntervals(ts(lastSaved), scrapeInterval, ts(now), DefaultChunkSize, jitter))
 	}
 
 	assert.Equal(t, // initial fetching
@@ -61,4 +68,75 @@ func TestCacheUpdater_calcIntervals(t *testing.T) {
 		"[]",
 		calc("2020-11-13T12:11:30", "2020-11-13T12:12:25"),
 	)
+
+	assert.Equal(t, // 4h chunks
+		"[(2020-11-13T08:12:00, 14400, 2020-11-13T11:48:30)]",
+		fmt.Sprintf(`%s`, calcIntervals(ts("2020-11-13T09:49:11"), scrapeInterval, ts("2020-11-13T11:49:11"), 4*timeseries.Hour, 12*timeseries.Minute)),
+	)
+}
+
+func TestCalcBackfillStatus(t *testing.T) {
+	now := timeseries.Time(10000)
+	s := calcBackfillStatus(now.Add(-4*timeseries.Hour), now.Add(-timeseries.Hour), now)
+	assert.Equal(t, 0.75, s.Progress)
+	assert.Equal(t, now.Add(-timeseries.Hour), s.To)
+
+	assert.Equal(t, 0., calcBackfillStatus(now.Add(-timeseries.Hour), now.Add(-2*timeseries.Hour), now).Progress)
+	assert.Equal(t, 1., calcBackfillStatus(now.Add(-timeseries.Hour), now.Add(timeseries.Minute), now).Progress)
+}
+
+type testPromClient struct {
+	err error
+}
+
+func (c *testPromClient) QueryRange(ctx context.Context, query string, from, to timeseries.Time, step timeseries.Duration) ([]model.MetricValues, error) {
+	return nil, c.err
+}
+
+func (c *testPromClient) Ping(ctx context.Context) error {
+	return c.err
+}
+
+func (c *testPromClient) BuildInfo(ctx context.Context) (*prom.BuildInfo, error) {
+	return nil, c.err
+}
+
+func TestCacheUpdater_downloadTimeout(t *testing.T) {
+	dir := t.TempDir()
+	state, err := openStateDB(path.Join(dir, "db.sqlite"))
+	require.NoError(t, err)
+	defer state.Close()
+	c := &Cache{
+		cfg:       Config{Path: dir},
+		byProject: map[db.ProjectId]map[string]*queryData{},
+		state:     state,
+		backfills: map[db.ProjectId]timeseries.Time{},
+		queries:   prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_cache_queries_total"}, []string{"result"}),
+	}
+	project := &db.Project{Id: "test", Prometheus: db.Prometheus{RefreshInterval: 30 * timeseries.Second}}
+	now := timeseries.Now()
+	lastTs := now.Add(-10 * timeseries.Minute).Truncate(30 * timeseries.Second)
+	slow := &PrometheusQueryState{ProjectId: project.Id, Query: "slow", LastTs: lastTs}
+	fast := &PrometheusQueryState{ProjectId: project.Id, Query: "fast", LastTs: now.Add(-timeseries.Minute)}
+	require.NoError(t, c.saveState(fast))
+
+	c.download(context.Background(), &testPromClient{err: context.DeadlineExceeded}, project, slow)
+	assert.Equal(t, lastTs, slow.LastTs)
+	assert.Equal(t, "timeout: context deadline exceeded", slow.LastError)
+
+	// the timed out query doesn't hold back the project, its readers get a warning
+	to, err := c.getMinUpdateTime(project.Id)
+	require.NoError(t, err)
+	assert.Equal(t, fast.LastTs, to)
+	_, warnings, err := c.GetCacheClient(project).QueryRangeWithWarnings(context.Background(), "slow", lastTs, now, 30*timeseries.Second)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"context deadline exceeded"}, warnings)
+
+	// the retry succeeds
+	c.download(context.Background(), &testPromClient{}, project, slow)
+	assert.True(t, slow.LastTs.After(lastTs))
+	assert.Equal(t, "", slow.LastError)
+	_, warnings, err = c.GetCacheClient(project).QueryRangeWithWarnings(context.Background(), "slow", lastTs, now, 30*timeseries.Second)
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
 }
@@ -15,10 +15,22 @@ type Constructor struct {
 	prom         prom.Client
 	rawStep      timeseries.Duration
 	checkConfigs model.CheckConfigs
+	appIdentity  *model.ApplicationIdentity
+	exclusions   *model.ApplicationExclusions
+	traffic      bool
 }
 
-func New(prom prom.Client, rawStep timeseries.Duration, checkConfigs model.CheckConfigs) *Constructor {
-	return &Constructor{prom: prom, rawStep: rawStep, checkConfigs: checkConfigs}
+// New creates a constructor. The extraSelector label matchers are injected into every query,
+// so they must match the ones used by the cache updater. The series of HA Prometheus replicas are deduplicated
+// by replicaLabel if it's set (see prom.DedupReplicas). appIdentity and exclusions may be nil.
+func New(client prom.Client, rawStep timeseries.Duration, checkConfigs model.CheckConfigs, extraSelector, replicaLabel string, appIdentity *model.ApplicationIdentity, exclusions *model.ApplicationExclusions) *Constructor {
+	return &Constructor{prom: prom.WithReplicaDedup(prom.WithSelector(client, extraSelector), replicaLabel), rawStep: rawStep, checkConfigs: checkConfigs, appIdentity: appIdentity, exclusions: exclusions}
+}
+
+// WithTraffic makes the constructor load the bytes sent and received through the connections (see TRAFFIC_QUERIES).
+func (c *Constructor) WithTraffic() *Constructor {
+	c.traffic = true
+	return c
 }
 
 type Profile struct {
@@ -27,6 +39,10 @@ type Profile struct {
 }
 
 func (c *Constructor) LoadWorld(ctx context.Context, from, to timeseries.Time, step timeseries.Duration, prof *Profile) (*model.World, error) {
+	defer func(t time.Time) {
+		worldLoadDuration.Observe(time.Since(t).Seconds())
+	}(time.Now())
+
 	w := model.NewWorld(from, to, step)
 
 	w.CheckConfigs = c.checkConfigs
@@ -48,28 +64,99 @@ func (c *Constructor) LoadWorld(ctx context.Context, from, to timeseries.Time, s
 		}
 	}
 
+	warnings := newQueryWarnings()
+
+	queries := QUERIES
+	if c.traffic {
+		queries = make(map[string]string, len(QUERIES)+len(TRAFFIC_QUERIES))
+		for _, qs := range []map[string]string{QUERIES, TRAFFIC_QUERIES} {
+			for name, q := range qs {
+				queries[name] = q
+			}
+		}
+	}
+
 	var metrics map[string][]model.MetricValues
+	var queryWarnings map[string]string
 	var err error
 	stage("query", func() {
-		metrics, err = prom.ParallelQueryRange(ctx, c.prom, from, to, step, QUERIES, prof.Queries)
+		metrics, queryWarnings, err = prom.ParallelQueryRange(ctx, c.prom, from, to, step, queries, prof.Queries)
 	})
 	if err != nil {
 		return nil, err
 	}
+	for name, warning := range queryWarnings {
+		warnings.add(name, warning)
+	}
 	klog.Infof("got metrics in %s", time.Since(t).Truncate(time.Millisecond))
 
 	stage("load_nodes", func() { loadNodes(w, metrics) })
 	stage("load_k8s_metadata", func() { loadKubernetesMetadata(w, metrics) })
+	stage("app_identity", func() { applyApplicationIdentity(w, c.appIdentity) })
 	stage("load_rds", func() { loadRds(w, metrics) })
 	stage("load_containers", func() { loadContainers(w, metrics) })
 	stage("enrich_instances", func() { enrichInstances(w, metrics) })
 	stage("join_db_cluster", func() { joinDBClusterComponents(w) })
-	stage("load_sli", func() { loadSLIs(ctx, w, c.prom, c.rawStep, from, to, step) })
+	stage("exclude_apps", func() { excludeApplications(w, c.exclusions) })
+	stage("load_sli", func() { loadSLIs(ctx, w, c.prom, warnings, c.rawStep, from, to, step) })
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	w.Warnings = warnings.list()
 
 	klog.Infof("got %d nodes, %d services, %d applications", len(w.Nodes), len(w.Services), len(w.Applications))
 	return w, nil
 }
 
+// excludeApplications removes the excluded applications from the world along with the connections to their instances,
+// so they neither appear in the views nor produce incidents.
+func excludeApplications(w *model.World, exclusions *model.ApplicationExclusions) {
+	if exclusions.IsEmpty() {
+		return
+	}
+	excluded := map[model.ApplicationId]bool{}
+	apps := w.Applications[:0]
+	for _, app := range w.Applications {
+		if exclusions.Excludes(app.Id) {
+			excluded[app.Id] = true
+			continue
+		}
+		apps = append(apps, app)
+	}
+	if len(excluded) == 0 {
+		return
+	}
+	w.Applications = apps
+	for _, app := range w.Applications {
+		for _, i := range app.Instances {
+			upstreams := i.Upstreams[:0]
+			for _, c := range i.Upstreams {
+				if c.RemoteInstance == nil || !excluded[c.RemoteInstance.OwnerId] {
+					upstreams = append(upstreams, c)
+				}
+			}
+			i.Upstreams = upstreams
+			downstreams := i.Downstreams[:0]
+			for _, c := range i.Downstreams {
+				if c.Instance == nil || !excluded[c.Instance.OwnerId] {
+					downstreams = append(downstreams, c)
+				}
+			}
+			i.Downstreams = downstreams
+		}
+	}
+	for _, n := range w.Nodes {
+		instances := n.Instances[:0]
+		for _, i := range n.Instances {
+			if !excluded[i.OwnerId] {
+				instances = append(instances, i)
+			}
+		}
+		n.Instances = instances
+	}
+}
+
 func enrichInstances(w *model.World, metrics map[string][]model.MetricValues) {
 	for queryName := range metrics {
 		for _, m := range metrics[queryName] {
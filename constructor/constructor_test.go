@@ -0,0 +1,145 @@
+package constructor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/prom"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowClient simulates a Prometheus server that answers only when the query is given up.
+type slowClient struct {
+	started  int32
+	inFlight int32
+	first    chan struct{}
+	once     sync.Once
+}
+
+func newSlowClient() *slowClient {
+	return &slowClient{first: make(chan struct{})}
+}
+
+func (c *slowClient) QueryRange(ctx context.Context, query string, from, to timeseries.Time, step timeseries.Duration) ([]model.MetricValues, error) {
+	atomic.AddInt32(&c.started, 1)
+	atomic.AddInt32(&c.inFlight, 1)
+	defer atomic.AddInt32(&c.inFlight, -1)
+	c.once.Do(func() { close(c.first) })
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(time.Minute):
+		return nil, nil
+	}
+}
+
+func (c *slowClient) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (c *slowClient) BuildInfo(ctx context.Context) (*prom.BuildInfo, error) {
+	return &prom.BuildInfo{}, nil
+}
+
+// timingOutClient simulates queries exceeding the query timeout while the caller is still waiting.
+type timingOutClient struct {
+	slowClient
+}
+
+func (c *timingOutClient) QueryRange(ctx context.Context, query string, from, to timeseries.Time, step timeseries.Duration) ([]model.MetricValues, error) {
+	return nil, fmt.Errorf("query timed out: %w", context.DeadlineExceeded)
+}
+
+func TestLoadWorldCancellation(t *testing.T) {
+	to := timeseries.Time(3600)
+	from := to.Add(-timeseries.Hour)
+
+	client := newSlowClient()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-client.first
+		cancel()
+	}()
+	done := make(chan struct{})
+	var w *model.World
+	var err error
+	go func() {
+		w, err = New(client, timeseries.Minute, nil, "", "", nil, nil).LoadWorld(ctx, from, to, timeseries.Minute, nil)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the world load didn't stop after the cancellation")
+	}
+	assert.True(t, errors.Is(err, context.Canceled), err)
+	assert.Nil(t, w)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&client.inFlight))
+	assert.LessOrEqual(t, int(atomic.LoadInt32(&client.started)), len(QUERIES))
+
+	client = newSlowClient()
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	w, err = New(client, timeseries.Minute, nil, "", "", nil, nil).LoadWorld(ctx, from, to, timeseries.Minute, nil)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+	assert.Nil(t, w)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&client.inFlight))
+
+	// timed out queries degrade the world unless the caller has gone
+	w, err = New(&timingOutClient{}, timeseries.Minute, nil, "", "", nil, nil).LoadWorld(context.Background(), from, to, timeseries.Minute, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, w)
+	assert.NotEmpty(t, w.Warnings)
+}
+
+// trafficClient returns the traffic of a single connection of a systemd service, and nothing for other queries.
+type trafficClient struct {
+	slowClient
+	lock    sync.Mutex
+	queries []string
+}
+
+func (c *trafficClient) QueryRange(ctx context.Context, query string, from, to timeseries.Time, step timeseries.Duration) ([]model.MetricValues, error) {
+	c.lock.Lock()
+	c.queries = append(c.queries, query)
+	c.lock.Unlock()
+	ls := model.Labels{"machine_id": "m1", "container_id": "/system.slice/api.service", "destination": "10.0.0.1:5432"}
+	switch query {
+	case QUERIES["node_info"]:
+		return []model.MetricValues{{Labels: model.Labels{"machine_id": "m1", "hostname": "node-1"}, Values: timeseries.NewWithData(from, step, []float64{1, 1})}}, nil
+	case TRAFFIC_QUERIES["container_net_tcp_bytes_sent"]:
+		return []model.MetricValues{{Labels: ls, Values: timeseries.NewWithData(from, step, []float64{100, 300})}}, nil
+	case TRAFFIC_QUERIES["container_net_tcp_bytes_received"]:
+		return []model.MetricValues{{Labels: ls, Values: timeseries.NewWithData(from, step, []float64{10, 30})}}, nil
+	}
+	return nil, nil
+}
+
+func TestLoadWorldTraffic(t *testing.T) {
+	from := timeseries.Time(0)
+	to := from.Add(timeseries.Minute)
+
+	client := &trafficClient{}
+	w, err := New(client, timeseries.Minute, nil, "", "", nil, nil).LoadWorld(context.Background(), from, to, timeseries.Minute, nil)
+	assert.NoError(t, err)
+	assert.Len(t, client.queries, len(QUERIES))
+	assert.NotContains(t, client.queries, TRAFFIC_QUERIES["container_net_tcp_bytes_sent"])
+	assert.Nil(t, w.GetApplication(model.NewApplicationId("", model.ApplicationKindUnknown, "api")))
+
+	client = &trafficClient{}
+	w, err = New(client, timeseries.Minute, nil, "", "", nil, nil).WithTraffic().LoadWorld(context.Background(), from, to, timeseries.Minute, nil)
+	assert.NoError(t, err)
+	assert.Len(t, client.queries, len(QUERIES)+len(TRAFFIC_QUERIES))
+	app := w.GetApplication(model.NewApplicationId("", model.ApplicationKindUnknown, "api"))
+	if assert.NotNil(t, app) && assert.Len(t, app.Instances, 1) && assert.Len(t, app.Instances[0].Upstreams, 1) {
+		c := app.Instances[0].Upstreams[0]
+		assert.Equal(t, 400., timeseries.Reduce(timeseries.NanSum, c.BytesSent))
+		assert.Equal(t, 40., timeseries.Reduce(timeseries.NanSum, c.BytesReceived))
+	}
+}
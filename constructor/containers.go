@@ -96,6 +96,14 @@ func loadContainers(w *model.World, metrics map[string][]model.MetricValues) {
 				if c := getOrCreateConnection(instance, mc.container, m, w); c != nil {
 					c.Active = timeseries.Merge(c.Active, m.Values, timeseries.Any)
 				}
+			case "container_net_tcp_bytes_sent":
+				if c := getOrCreateConnection(instance, mc.container, m, w); c != nil {
+					c.BytesSent = timeseries.Merge(c.BytesSent, m.Values, timeseries.NanSum)
+				}
+			case "container_net_tcp_bytes_received":
+				if c := getOrCreateConnection(instance, mc.container, m, w); c != nil {
+					c.BytesReceived = timeseries.Merge(c.BytesReceived, m.Values, timeseries.NanSum)
+				}
 			case "container_net_tcp_listen_info":
 				ip, port, err := net.SplitHostPort(m.Labels["listen_addr"])
 				if err != nil {
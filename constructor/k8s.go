@@ -117,6 +117,14 @@ func podLabels(metrics []model.MetricValues, pods map[podId]*model.Instance) {
 			klog.Warningln("unknown pod:", id)
 			continue
 		}
+		if instance.Pod != nil {
+			instance.Pod.Labels = map[string]string{}
+			for k, v := range m.Labels {
+				if strings.HasPrefix(k, "label_") {
+					instance.Pod.Labels[strings.TrimPrefix(k, "label_")] = v
+				}
+			}
+		}
 		cluster, role := "", ""
 		switch {
 		case m.Labels["label_postgres_operator_crunchydata_com_cluster"] != "":
@@ -210,3 +218,52 @@ func podContainerStatus(queryName string, metrics []model.MetricValues, pods map
 		}
 	}
 }
+
+// applyApplicationIdentity moves the pods having all the identity labels to the applications composed by these labels.
+// The desired instances of an owner are carried over only if all its pods have moved to the same application.
+func applyApplicationIdentity(w *model.World, identity *model.ApplicationIdentity) {
+	if identity.IsEmpty() {
+		return
+	}
+	custom := map[model.ApplicationId]*model.Application{}
+	var apps []*model.Application
+	for _, app := range w.Applications {
+		var kept []*model.Instance
+		targets := map[*model.Application]bool{}
+		for _, i := range app.Instances {
+			var id model.ApplicationId
+			ok := false
+			if i.Pod != nil {
+				id, ok = identity.IdFor(app.Id.Namespace, i.Pod.Labels)
+			}
+			if !ok {
+				kept = append(kept, i)
+				continue
+			}
+			target := custom[id]
+			if target == nil {
+				target = model.NewApplication(id)
+				custom[id] = target
+				apps = append(apps, target)
+			}
+			i.OwnerId = id
+			target.Instances = append(target.Instances, i)
+			targets[target] = true
+		}
+		if len(targets) == 0 {
+			apps = append(apps, app)
+			continue
+		}
+		if len(kept) > 0 {
+			app.Instances = kept
+			apps = append(apps, app)
+			continue
+		}
+		if len(targets) == 1 && app.DesiredInstances != nil {
+			for target := range targets {
+				target.DesiredInstances = timeseries.Merge(target.DesiredInstances, app.DesiredInstances, timeseries.NanSum)
+			}
+		}
+	}
+	w.Applications = apps
+}
@@ -0,0 +1,81 @@
+package constructor
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestApplyApplicationIdentity(t *testing.T) {
+	w := model.NewWorld(0, 60, 15)
+	pod := func(appId model.ApplicationId, name string, labels map[string]string) {
+		i := w.GetOrCreateApplication(appId).GetOrCreateInstance(name)
+		i.Pod = &model.Pod{Labels: labels}
+	}
+	stable := model.NewApplicationId("ns", model.ApplicationKindDeployment, "api-stable")
+	canary := model.NewApplicationId("ns", model.ApplicationKindDeployment, "api-canary")
+	other := model.NewApplicationId("ns", model.ApplicationKindDeployment, "other")
+	pod(stable, "api-stable-1", map[string]string{"app_kubernetes_io_name": "api"})
+	pod(stable, "api-stable-2", map[string]string{"app_kubernetes_io_name": "api"})
+	pod(canary, "api-canary-1", map[string]string{"app_kubernetes_io_name": "api"})
+	pod(other, "other-1", map[string]string{"app": "other"})
+	w.GetApplication(stable).DesiredInstances = timeseries.NewWithData(0, 15, []float64{2, 2, 2, 2, 2})
+	w.GetApplication(canary).DesiredInstances = timeseries.NewWithData(0, 15, []float64{1, 1, 1, 1, 1})
+
+	applyApplicationIdentity(w, nil)
+	assert.Len(t, w.Applications, 3)
+
+	applyApplicationIdentity(w, &model.ApplicationIdentity{Labels: []string{"app.kubernetes.io/name"}})
+	assert.Len(t, w.Applications, 2)
+	id := model.NewApplicationId("ns", model.ApplicationKindCustom, "api")
+	api := w.GetApplication(id)
+	if assert.NotNil(t, api) {
+		assert.Len(t, api.Instances, 3)
+		for _, i := range api.Instances {
+			assert.Equal(t, id, i.OwnerId)
+		}
+		assert.Equal(t, 3., timeseries.Last(api.DesiredInstances))
+	}
+	assert.NotNil(t, w.GetApplication(other))
+	assert.Nil(t, w.GetApplication(stable))
+}
+
+func TestExcludeApplications(t *testing.T) {
+	w := model.NewWorld(0, 60, 15)
+	api := w.GetOrCreateApplication(model.NewApplicationId("default", model.ApplicationKindDeployment, "api"))
+	dns := w.GetOrCreateApplication(model.NewApplicationId("kube-system", model.ApplicationKindDeployment, "coredns"))
+	db := w.GetOrCreateApplication(model.NewApplicationId("default", model.ApplicationKindStatefulSet, "db"))
+	apiInstance, dnsInstance, dbInstance := api.GetOrCreateInstance("api-1"), dns.GetOrCreateInstance("coredns-1"), db.GetOrCreateInstance("db-1")
+	toDns := &model.Connection{Instance: apiInstance, RemoteInstance: dnsInstance}
+	toDb := &model.Connection{Instance: apiInstance, RemoteInstance: dbInstance}
+	apiInstance.Upstreams = []*model.Connection{toDns, toDb}
+	dnsInstance.Downstreams = []*model.Connection{toDns}
+	dbInstance.Downstreams = []*model.Connection{toDb}
+	node := model.NewNode("node-1")
+	node.Instances = []*model.Instance{apiInstance, dnsInstance}
+	w.Nodes = append(w.Nodes, node)
+
+	excludeApplications(w, nil)
+	assert.Len(t, w.Applications, 3)
+
+	excludeApplications(w, &model.ApplicationExclusions{Patterns: []string{"kube-system/*"}})
+	assert.Len(t, w.Applications, 2)
+	assert.Nil(t, w.GetApplication(dns.Id))
+	assert.Equal(t, []*model.Connection{toDb}, apiInstance.Upstreams)
+	assert.Equal(t, []*model.Instance{apiInstance}, node.Instances)
+}
+
+func TestPodLabels(t *testing.T) {
+	w := model.NewWorld(0, 60, 15)
+	i := w.GetOrCreateApplication(model.NewApplicationId("ns", model.ApplicationKindDeployment, "api")).GetOrCreateInstance("api-1")
+	i.Pod = &model.Pod{}
+	pods := map[podId]*model.Instance{{pod: "api-1", ns: "ns"}: i}
+
+	podLabels([]model.MetricValues{
+		{Labels: model.Labels{"pod": "api-1", "namespace": "ns", "label_app_kubernetes_io_team": "payments", "uid": "123"}},
+		{Labels: model.Labels{"pod": "unknown", "namespace": "ns", "label_team": "sre"}},
+	}, pods)
+	assert.Equal(t, map[string]string{"app_kubernetes_io_team": "payments"}, i.Pod.Labels)
+	assert.Equal(t, "payments", w.GetApplication(i.OwnerId).KubernetesLabel("app.kubernetes.io/team"))
+}
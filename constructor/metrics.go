@@ -0,0 +1,16 @@
+package constructor
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var worldLoadDuration = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Name:    "coroot_world_load_duration_seconds",
+		Buckets: []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	},
+)
+
+func init() {
+	prometheus.MustRegister(worldLoadDuration)
+}
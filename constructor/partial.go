@@ -0,0 +1,38 @@
+package constructor
+
+import (
+	"fmt"
+	"k8s.io/klog"
+	"sort"
+	"sync"
+)
+
+// queryWarnings collects the queries whose results are incomplete: the data returned along with warnings
+// is used as is, timed out queries yield no data. The affected queries are recorded as world warnings.
+type queryWarnings struct {
+	lock     sync.Mutex
+	warnings map[string]string
+}
+
+func newQueryWarnings() *queryWarnings {
+	return &queryWarnings{warnings: map[string]string{}}
+}
+
+// add records the warning about the query, which is identified by its name if it has one.
+func (w *queryWarnings) add(name, warning string) {
+	klog.Warningf("incomplete data for %s: %s", name, warning)
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.warnings[name] = warning
+}
+
+func (w *queryWarnings) list() []string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	res := make([]string, 0, len(w.warnings))
+	for name, warning := range w.warnings {
+		res = append(res, fmt.Sprintf("%s: %s", name, warning))
+	}
+	sort.Strings(res)
+	return res
+}
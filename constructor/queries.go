@@ -1,5 +1,12 @@
 package constructor
 
+// TRAFFIC_QUERIES are cached along with QUERIES, but loaded only by the views that need the traffic of the connections
+// (see Constructor.WithTraffic), since the metrics have a series per connection.
+var TRAFFIC_QUERIES = map[string]string{
+	"container_net_tcp_bytes_sent":     `rate(container_net_tcp_bytes_sent_total[$RANGE])`,
+	"container_net_tcp_bytes_received": `rate(container_net_tcp_bytes_received_total[$RANGE])`,
+}
+
 var QUERIES = map[string]string{
 	"up": `up`,
 
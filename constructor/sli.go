@@ -10,36 +10,173 @@ import (
 	"strconv"
 )
 
-func loadSLIs(ctx context.Context, w *model.World, prom prom.Client, rawStep timeseries.Duration, from, to timeseries.Time, step timeseries.Duration) {
-	for appId := range w.CheckConfigs {
-		app := w.GetApplication(appId)
-		if app == nil {
-			continue
+// loadSLIs loads two sets of the SLI series: the display ones covering the world's time window at the world's step
+// and the raw ones used to evaluate the burn rates. The raw series always cover MaxAlertRuleWindow before the end
+// of the window at rawStep, so that the alerting accuracy doesn't depend on the range being viewed.
+func loadSLIs(ctx context.Context, w *model.World, prom prom.Client, warnings *queryWarnings, rawStep timeseries.Duration, from, to timeseries.Time, step timeseries.Duration) {
+	for _, app := range w.Applications {
+		if ctx.Err() != nil {
+			return
 		}
+		appId := app.Id
 		rawFrom := to.Add(-model.MaxAlertRuleWindow)
 		for _, cfg := range w.CheckConfigs.GetAvailability(appId) {
-			qTotal, qFailed := cfg.Total(), cfg.Failed()
-			sli := &model.AvailabilitySLI{
-				Config:            cfg,
-				TotalRequests:     queryAvailability(ctx, prom, qTotal, from, to, step),
-				TotalRequestsRaw:  queryAvailability(ctx, prom, qTotal, rawFrom, to, rawStep),
-				FailedRequests:    queryAvailability(ctx, prom, qFailed, from, to, step),
-				FailedRequestsRaw: queryAvailability(ctx, prom, qFailed, rawFrom, to, rawStep),
+			sli := &model.AvailabilitySLI{Config: cfg}
+			client := &queryErrorRecorder{Client: prom, warnings: warnings}
+			if cfg.IsWeighted() {
+				sli.Components = loadAvailabilityComponents(ctx, client, cfg.Queries(), from, to, step)
+				sli.TotalRequests, sli.FailedRequests = model.CompositeAvailability(sli.Components)
+			} else {
+				sli.TotalRequests, sli.FailedRequests = loadAvailability(ctx, client, cfg, from, to, step)
 			}
+			sli.TotalRequestsRaw, sli.FailedRequestsRaw = loadAvailability(ctx, client, cfg, rawFrom, to, rawStep)
+			sli.Error = client.Error()
 			app.AvailabilitySLIs = append(app.AvailabilitySLIs, sli)
 		}
 		for _, cfg := range w.CheckConfigs.GetLatency(appId) {
 			q := cfg.Histogram()
+			client := &queryErrorRecorder{Client: prom, warnings: warnings}
 			sli := &model.LatencySLI{
 				Config:       cfg,
-				Histogram:    queryLatency(ctx, prom, q, from, to, step),
-				HistogramRaw: queryLatency(ctx, prom, q, rawFrom, to, rawStep),
+				Histogram:    queryLatency(ctx, client, q, from, to, step),
+				HistogramRaw: queryLatency(ctx, client, q, rawFrom, to, rawStep),
 			}
+			sli.Error = client.Error()
 			app.LatencySLIs = append(app.LatencySLIs, sli)
 		}
 	}
 }
 
+// LoadAvailabilitySLI loads the series of an availability SLI over the range without assigning it to an application,
+// e.g., to evaluate a config before saving it. The query error, if any, is recorded in the SLI.
+func LoadAvailabilitySLI(ctx context.Context, client prom.Client, cfg model.CheckConfigSLOAvailability, from, to timeseries.Time, step timeseries.Duration) *model.AvailabilitySLI {
+	sli := &model.AvailabilitySLI{Config: cfg}
+	rec := &queryErrorRecorder{Client: client}
+	if cfg.IsWeighted() {
+		sli.Components = loadAvailabilityComponents(ctx, rec, cfg.Queries(), from, to, step)
+		sli.TotalRequests, sli.FailedRequests = model.CompositeAvailability(sli.Components)
+	} else {
+		sli.TotalRequests, sli.FailedRequests = loadAvailability(ctx, rec, cfg, from, to, step)
+	}
+	sli.TotalRequestsRaw, sli.FailedRequestsRaw = sli.TotalRequests, sli.FailedRequests
+	sli.Error = rec.Error()
+	return sli
+}
+
+// LoadLatencySLI is the latency counterpart of LoadAvailabilitySLI.
+func LoadLatencySLI(ctx context.Context, client prom.Client, cfg model.CheckConfigSLOLatency, from, to timeseries.Time, step timeseries.Duration) *model.LatencySLI {
+	rec := &queryErrorRecorder{Client: client}
+	sli := &model.LatencySLI{Config: cfg, Histogram: queryLatency(ctx, rec, cfg.Histogram(), from, to, step)}
+	sli.HistogramRaw = sli.Histogram
+	sli.Error = rec.Error()
+	return sli
+}
+
+// LoadRawSLIs reloads the raw SLI series of the world's applications for the given range,
+// e.g., to re-evaluate the burn rates over a past period.
+func (c *Constructor) LoadRawSLIs(ctx context.Context, w *model.World, from, to timeseries.Time) {
+	warnings := newQueryWarnings()
+	for _, app := range w.Applications {
+		if ctx.Err() != nil {
+			break
+		}
+		for _, sli := range app.AvailabilitySLIs {
+			rec := &queryErrorRecorder{Client: c.prom, warnings: warnings}
+			sli.TotalRequestsRaw, sli.FailedRequestsRaw = loadAvailability(ctx, rec, sli.Config, from, to, c.rawStep)
+			sli.Error = rec.Error()
+		}
+		for _, sli := range app.LatencySLIs {
+			rec := &queryErrorRecorder{Client: c.prom, warnings: warnings}
+			sli.HistogramRaw = queryLatency(ctx, rec, sli.Config.Histogram(), from, to, c.rawStep)
+			sli.Error = rec.Error()
+		}
+	}
+	w.Warnings = append(w.Warnings, warnings.list()...)
+}
+
+// queryErrorRecorder records the first error of the queries of an SLI, so that a failing query of one application
+// makes its SLO check UNKNOWN rather than silently reporting no data.
+// If warnings is set, incomplete results are recorded there instead: partial data is used as is, and timed out
+// queries yield no data.
+type queryErrorRecorder struct {
+	prom.Client
+	warnings *queryWarnings
+	err      error
+}
+
+func (c *queryErrorRecorder) QueryRange(ctx context.Context, query string, from, to timeseries.Time, step timeseries.Duration) ([]model.MetricValues, error) {
+	res, warnings, err := prom.QueryRangeWithWarnings(ctx, c.Client, query, from, to, step)
+	if c.warnings != nil {
+		switch {
+		case err == nil && len(warnings) > 0:
+			c.warnings.add(query, prom.FormatWarnings(warnings))
+		case err != nil && ctx.Err() == nil && prom.IsTimeout(err):
+			c.warnings.add(query, err.Error())
+			return nil, nil
+		}
+	}
+	if err != nil && c.err == nil && ctx.Err() == nil {
+		c.err = err
+	}
+	return res, err
+}
+
+func (c *queryErrorRecorder) Error() string {
+	if c.err == nil {
+		return ""
+	}
+	return c.err.Error()
+}
+
+// loadAvailability sums up the total and failed requests of all the query pairs or, if the pairs are weighted,
+// combines them into a composite availability. A pair with no total requests is skipped,
+// a pair with no failed requests is considered error-free.
+func loadAvailability(ctx context.Context, prom prom.Client, cfg model.CheckConfigSLOAvailability, from, to timeseries.Time, step timeseries.Duration) (timeseries.TimeSeries, timeseries.TimeSeries) {
+	queries := cfg.Queries()
+	if cfg.IsWeighted() {
+		return model.CompositeAvailability(loadAvailabilityComponents(ctx, prom, queries, from, to, step))
+	}
+	if len(queries) == 1 {
+		q := queries[0]
+		return queryAvailability(ctx, prom, q.Total(), from, to, step), queryAvailability(ctx, prom, q.Failed(), from, to, step)
+	}
+	var total, failed timeseries.TimeSeries
+	for _, q := range queries {
+		t := queryAvailability(ctx, prom, q.Total(), from, to, step)
+		if timeseries.IsEmpty(t) {
+			continue
+		}
+		f := queryAvailability(ctx, prom, q.Failed(), from, to, step)
+		if timeseries.IsEmpty(f) {
+			f = timeseries.Replace(t, 0)
+		}
+		total = timeseries.Merge(total, t, timeseries.NanSum)
+		failed = timeseries.Merge(failed, f, timeseries.NanSum)
+	}
+	return total, failed
+}
+
+// loadAvailabilityComponents queries every pair separately and normalizes the weights.
+func loadAvailabilityComponents(ctx context.Context, prom prom.Client, queries []model.AvailabilityQueries, from, to timeseries.Time, step timeseries.Duration) []model.AvailabilityComponent {
+	var sum float64
+	for _, q := range queries {
+		sum += q.Weight
+	}
+	res := make([]model.AvailabilityComponent, 0, len(queries))
+	for _, q := range queries {
+		c := model.AvailabilityComponent{Queries: q, Weight: q.Weight / sum}
+		c.TotalRequests = queryAvailability(ctx, prom, q.Total(), from, to, step)
+		if !timeseries.IsEmpty(c.TotalRequests) {
+			c.FailedRequests = queryAvailability(ctx, prom, q.Failed(), from, to, step)
+			if timeseries.IsEmpty(c.FailedRequests) {
+				c.FailedRequests = timeseries.Replace(c.TotalRequests, 0)
+			}
+		}
+		res = append(res, c)
+	}
+	return res
+}
+
 func queryAvailability(ctx context.Context, prom prom.Client, query string, from, to timeseries.Time, step timeseries.Duration) timeseries.TimeSeries {
 	values, err := prom.QueryRange(ctx, query, from, to, step)
 	if err != nil {
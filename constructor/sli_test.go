@@ -0,0 +1,139 @@
+package constructor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/prom"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type queryRange struct {
+	from, to timeseries.Time
+	step     timeseries.Duration
+}
+
+// recordingClient returns a constant series for every query and records the requested ranges.
+type recordingClient struct {
+	ranges []queryRange
+}
+
+func (c *recordingClient) QueryRange(ctx context.Context, query string, from, to timeseries.Time, step timeseries.Duration) ([]model.MetricValues, error) {
+	c.ranges = append(c.ranges, queryRange{from: from, to: to, step: step})
+	count := int(to.Sub(from)/step) + 1
+	data := make([]float64, count)
+	for i := range data {
+		data[i] = 1
+	}
+	return []model.MetricValues{{Labels: model.Labels{"le": "0.1"}, Values: timeseries.NewWithData(from, step, data)}}, nil
+}
+
+func (c *recordingClient) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (c *recordingClient) BuildInfo(ctx context.Context) (*prom.BuildInfo, error) {
+	return &prom.BuildInfo{}, nil
+}
+
+func TestLoadSLIsRawResolution(t *testing.T) {
+	to := timeseries.Time(30 * 24 * 3600)
+	from := to.Add(-7 * timeseries.Day)
+	displayStep, rawStep := 15*timeseries.Minute, 30*timeseries.Second
+
+	appId := model.NewApplicationId("default", model.ApplicationKindDeployment, "app")
+	w := model.NewWorld(from, to, displayStep)
+	w.GetOrCreateApplication(appId)
+	w.CheckConfigs = model.CheckConfigs{appId: {
+		model.Checks.SLOAvailability.Id: json.RawMessage(`[{"total_requests_query": "total", "failed_requests_query": "failed", "objective_percentage": 99}]`),
+		model.Checks.SLOLatency.Id:      json.RawMessage(`[{"histogram_query": "histogram", "objective_bucket": 0.1, "objective_percentage": 99}]`),
+	}}
+	client := &recordingClient{}
+	loadSLIs(context.Background(), w, client, newQueryWarnings(), rawStep, from, to, displayStep)
+
+	display := queryRange{from: from, to: to, step: displayStep}
+	raw := queryRange{from: to.Add(-model.MaxAlertRuleWindow), to: to, step: rawStep}
+	assert.Equal(t, []queryRange{display, display, raw, raw, display, raw}, client.ranges)
+
+}
+
+// seriesClient returns the predefined series of the queries, and nothing for unknown queries.
+type seriesClient struct {
+	recordingClient
+	series map[string][]float64
+}
+
+func (c *seriesClient) QueryRange(ctx context.Context, query string, from, to timeseries.Time, step timeseries.Duration) ([]model.MetricValues, error) {
+	data, ok := c.series[query]
+	if !ok {
+		return nil, nil
+	}
+	return []model.MetricValues{{Values: timeseries.NewWithData(from, step, data)}}, nil
+}
+
+func TestLoadAvailabilityQueryPairs(t *testing.T) {
+	pair := func(total, failed string) model.AvailabilityQueries {
+		return model.AvailabilityQueries{TotalRequestsQuery: total, FailedRequestsQuery: failed}
+	}
+	cfg := model.CheckConfigSLOAvailability{
+		AvailabilityQueries: pair("http_total", "http_failed"),
+		AdditionalQueries:   []model.AvailabilityQueries{pair("grpc_total", "grpc_failed"), pair("unknown_total", "unknown_failed")},
+	}
+	client := &seriesClient{series: map[string][]float64{
+		pair("http_total", "").Total():   {100, 200},
+		pair("", "http_failed").Failed(): {1, 2},
+		pair("grpc_total", "").Total():   {10, 20},
+	}}
+	// the grpc pair has no failed requests, the unknown pair has no traffic at all
+	total, failed := loadAvailability(context.Background(), client, cfg, 0, 60, timeseries.Minute)
+	assert.Equal(t, 330., timeseries.Reduce(timeseries.NanSum, total))
+	assert.Equal(t, 3., timeseries.Reduce(timeseries.NanSum, failed))
+
+	cfg.AdditionalQueries = nil
+	total, failed = loadAvailability(context.Background(), client, cfg, 0, 60, timeseries.Minute)
+	assert.Equal(t, 300., timeseries.Reduce(timeseries.NanSum, total))
+	assert.Equal(t, 3., timeseries.Reduce(timeseries.NanSum, failed))
+}
+
+// partialClient returns the data of recordingClient along with a warning, and times out the "slow" query.
+type partialClient struct {
+	recordingClient
+}
+
+func (c *partialClient) QueryRangeWithWarnings(ctx context.Context, query string, from, to timeseries.Time, step timeseries.Duration) ([]model.MetricValues, []string, error) {
+	if query == "slow" {
+		return nil, nil, fmt.Errorf("query timed out: %w", context.DeadlineExceeded)
+	}
+	res, err := c.recordingClient.QueryRange(ctx, query, from, to, step)
+	return res, []string{"some chunks are missing"}, err
+}
+
+func TestQueryErrorRecorderWarnings(t *testing.T) {
+	from, to, step := timeseries.Time(0), timeseries.Time(600), timeseries.Minute
+	warnings := newQueryWarnings()
+	rec := &queryErrorRecorder{Client: &partialClient{}, warnings: warnings}
+
+	res, err := rec.QueryRange(context.Background(), "total", from, to, step)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	res, err = rec.QueryRange(context.Background(), "slow", from, to, step)
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+	assert.Equal(t, "", rec.Error())
+	assert.Equal(t, []string{
+		"slow: query timed out: context deadline exceeded",
+		"total: partial data: some chunks are missing",
+	}, warnings.list())
+
+	// without a collector, the partial data is used as is and timeouts are errors
+	rec = &queryErrorRecorder{Client: &partialClient{}}
+	res, err = rec.QueryRange(context.Background(), "total", from, to, step)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	_, err = rec.QueryRange(context.Background(), "slow", from, to, step)
+	assert.Error(t, err)
+	assert.Equal(t, "query timed out: context deadline exceeded", rec.Error())
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/coroot/coroot/timeseries"
+)
+
+type AuditLogEntry struct {
+	Time   timeseries.Time `json:"time"`
+	Actor  string          `json:"actor"`
+	Object string          `json:"object"`
+	Old    json.RawMessage `json:"old"`
+	New    json.RawMessage `json:"new"`
+}
+
+func (e *AuditLogEntry) Migrate(m *Migrator) error {
+	return m.Exec(`
+	CREATE TABLE IF NOT EXISTS audit_log (
+		project_id TEXT NOT NULL REFERENCES project(id),
+		ts INT NOT NULL,
+		actor TEXT NOT NULL,
+		object TEXT NOT NULL,
+		old TEXT,
+		new TEXT
+	);
+	CREATE INDEX IF NOT EXISTS audit_log_project_id_ts ON audit_log (project_id, ts);
+`)
+}
+
+func (db *DB) GetAuditLog(projectId ProjectId, limit int) ([]AuditLogEntry, error) {
+	rows, err := db.db.Query(
+		"SELECT ts, actor, object, old, new FROM audit_log WHERE project_id = $1 ORDER BY ts DESC LIMIT $2",
+		projectId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []AuditLogEntry
+	for rows.Next() {
+		var e AuditLogEntry
+		var old, new string
+		if err := rows.Scan(&e.Time, &e.Actor, &e.Object, &old, &new); err != nil {
+			return nil, err
+		}
+		e.Old, e.New = json.RawMessage(old), json.RawMessage(new)
+		res = append(res, e)
+	}
+	return res, rows.Err()
+}
+
+// addAuditLogEntry records a change of the object made by the actor. Nothing is recorded if the object hasn't changed.
+func (db *DB) addAuditLogEntry(projectId ProjectId, actor, object string, old, new any) error {
+	return addAuditLogEntry(db.db, projectId, actor, object, old, new)
+}
+
+func addAuditLogEntry(q queryer, projectId ProjectId, actor, object string, old, new any) error {
+	o, err := json.Marshal(old)
+	if err != nil {
+		return err
+	}
+	n, err := json.Marshal(new)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(o, n) {
+		return nil
+	}
+	_, err = q.Exec(
+		"INSERT INTO audit_log (project_id, ts, actor, object, old, new) VALUES ($1, $2, $3, $4, $5, $6)",
+		projectId, timeseries.Now(), actor, object, string(o), string(n))
+	return err
+}
@@ -1,10 +1,13 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
 	"k8s.io/klog"
 )
 
@@ -21,7 +24,7 @@ func (cc *CheckConfigs) Migrate(m *Migrator) error {
 }
 
 func (db *DB) GetCheckConfigs(projectId ProjectId) (model.CheckConfigs, error) {
-	rows, err := db.db.Query("SELECT application_id, configs FROM check_configs WHERE project_id = $1", projectId)
+	rows, err := db.reader().Query("SELECT application_id, configs FROM check_configs WHERE project_id = $1", projectId)
 	if err != nil {
 		return nil, err
 	}
@@ -48,10 +51,37 @@ func (db *DB) GetCheckConfigs(projectId ProjectId) (model.CheckConfigs, error) {
 	return res, nil
 }
 
-func (db *DB) SaveCheckConfig(projectId ProjectId, appId model.ApplicationId, checkId model.CheckId, cfg any) error {
+func (db *DB) SaveCheckConfig(projectId ProjectId, appId model.ApplicationId, checkId model.CheckId, cfg any, actor string) error {
+	return saveCheckConfig(db.db, projectId, appId, checkId, cfg, actor)
+}
+
+// CheckConfigUpdate is a config of a check for an application or a level of them (see model.CheckConfigs).
+// A nil config removes the one defined for the application.
+type CheckConfigUpdate struct {
+	ApplicationId model.ApplicationId
+	Config        any
+}
+
+// SaveCheckConfigs saves the configs of the check for several applications in a single transaction,
+// so either all of them are saved or none.
+func (db *DB) SaveCheckConfigs(ctx context.Context, projectId ProjectId, checkId model.CheckId, updates []CheckConfigUpdate, actor string) error {
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, u := range updates {
+		if err := saveCheckConfig(tx, projectId, u.ApplicationId, checkId, u.Config, actor); err != nil {
+			return fmt.Errorf("failed to save the config for %s: %w", u.ApplicationId, err)
+		}
+	}
+	return tx.Commit()
+}
+
+func saveCheckConfig(q queryer, projectId ProjectId, appId model.ApplicationId, checkId model.CheckId, cfg any, actor string) error {
 	appIdStr := appId.String()
 	var configs sql.NullString
-	err := db.db.QueryRow("SELECT configs FROM check_configs WHERE project_id = $1 AND application_id = $2", projectId, appIdStr).Scan(&configs)
+	err := q.QueryRow("SELECT configs FROM check_configs WHERE project_id = $1 AND application_id = $2", projectId, appIdStr).Scan(&configs)
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return err
 	}
@@ -65,6 +95,7 @@ func (db *DB) SaveCheckConfig(projectId ProjectId, appId model.ApplicationId, ch
 	if err != nil {
 		return err
 	}
+	old := cs[checkId]
 	if string(c) == "null" {
 		delete(cs, checkId)
 	} else {
@@ -74,12 +105,54 @@ func (db *DB) SaveCheckConfig(projectId ProjectId, appId model.ApplicationId, ch
 	if err != nil {
 		return err
 	}
-	res, err := db.db.Exec("UPDATE check_configs SET configs = $1 WHERE project_id = $2 AND application_id = $3", data, projectId, appIdStr)
-	rowsAffected, _ := res.RowsAffected()
-	if rowsAffected == 0 {
-		if _, err := db.db.Exec("INSERT INTO check_configs (project_id, application_id, configs) VALUES ($1, $2, $3)", projectId, appIdStr, data); err != nil {
+	res, err := q.Exec("UPDATE check_configs SET configs = $1 WHERE project_id = $2 AND application_id = $3", data, projectId, appIdStr)
+	if err != nil {
+		return err
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		if _, err := q.Exec("INSERT INTO check_configs (project_id, application_id, configs) VALUES ($1, $2, $3)", projectId, appIdStr, data); err != nil {
 			return err
 		}
 	}
-	return err
+	if err := addAuditLogEntry(q, projectId, actor, "check_config:"+appIdStr+":"+string(checkId), old, json.RawMessage(c)); err != nil {
+		return err
+	}
+	if isSLOCheck(checkId) && !hasSLOConfigs(cs) {
+		return resolveIncidentOfRemovedSLO(q, projectId, appId)
+	}
+	return nil
+}
+
+func isSLOCheck(checkId model.CheckId) bool {
+	return checkId == model.Checks.SLOAvailability.Id || checkId == model.Checks.SLOLatency.Id
+}
+
+func hasSLOConfigs(cs map[model.CheckId]json.RawMessage) bool {
+	for checkId, c := range cs {
+		if isSLOCheck(checkId) && string(c) != "null" && string(c) != "[]" {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveIncidentOfRemovedSLO resolves the open incident of the application once it has no SLO configured,
+// since the incident can no longer be resolved by evaluating the SLOs.
+// The alert manager announces the resolution if the incident has been notified about.
+func resolveIncidentOfRemovedSLO(q queryer, projectId ProjectId, appId model.ApplicationId) error {
+	var i Incident
+	err := q.QueryRow(
+		"SELECT "+incidentColumns+" FROM incident WHERE project_id = $1 AND application_id = $2 AND resolved_at = 0 ORDER BY opened_at DESC LIMIT 1",
+		projectId, appId.String()).Scan(i.fields()...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := resolveIncident(q, projectId, appId, &i, timeseries.Now(), IncidentResolveReasonCheckRemoved); err != nil {
+		return err
+	}
+	klog.Infof("%s: incident %s of %s resolved: no SLO configured", projectId, i.Key, appId)
+	return nil
 }
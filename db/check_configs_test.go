@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSaveCheckConfigResolvesIncidentOfRemovedSLO(t *testing.T) {
+	db, err := Open(t.TempDir(), "", "")
+	require.NoError(t, err)
+	projectId, err := db.SaveProject(Project{Name: "test"}, "")
+	require.NoError(t, err)
+	appId := model.NewApplicationId("default", model.ApplicationKindDeployment, "app")
+
+	availability := []model.CheckConfigSLOAvailability{{
+		AvailabilityQueries: model.AvailabilityQueries{TotalRequestsQuery: "total", FailedRequestsQuery: "failed"},
+		ObjectivePercentage: 99,
+	}}
+	latency := []model.CheckConfigSLOLatency{{HistogramQuery: "histogram", ObjectiveBucket: 0.1, ObjectivePercentage: 99}}
+	require.NoError(t, db.SaveCheckConfig(projectId, appId, model.Checks.SLOAvailability.Id, availability, ""))
+	require.NoError(t, db.SaveCheckConfig(projectId, appId, model.Checks.SLOLatency.Id, latency, ""))
+
+	now := timeseries.Now()
+	incident, err := db.CreateOrUpdateIncident(projectId, appId, now.Add(-timeseries.Minute), model.CRITICAL, nil, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, db.MarkIncidentAsSent(projectId, appId, incident, now.Add(-timeseries.Minute)))
+
+	// the latency SLO is still configured
+	require.NoError(t, db.SaveCheckConfig(projectId, appId, model.Checks.SLOAvailability.Id, []model.CheckConfigSLOAvailability{}, ""))
+	open, err := db.GetOpenIncidents(projectId)
+	require.NoError(t, err)
+	assert.Len(t, open, 1)
+
+	require.NoError(t, db.SaveCheckConfig(projectId, appId, model.Checks.SLOLatency.Id, nil, ""))
+	open, err = db.GetOpenIncidents(projectId)
+	require.NoError(t, err)
+	assert.Empty(t, open)
+
+	resolved, err := db.GetIncidentByKey(projectId, incident.Key)
+	require.NoError(t, err)
+	assert.False(t, resolved.ResolvedAt.IsZero())
+	assert.Equal(t, IncidentResolveReasonCheckRemoved, resolved.ResolveReason)
+
+	unannounced, err := db.GetUnannouncedResolutions(projectId, IncidentResolveReasonCheckRemoved, now.Add(-timeseries.Hour))
+	require.NoError(t, err)
+	require.Len(t, unannounced, 1)
+	assert.Equal(t, appId, unannounced[0].ApplicationId)
+}
+
+func TestSaveCheckConfigsIsAtomic(t *testing.T) {
+	db, err := Open(t.TempDir(), "", "")
+	require.NoError(t, err)
+	projectId, err := db.SaveProject(Project{Name: "test"}, "")
+	require.NoError(t, err)
+	appId := model.NewApplicationId("default", model.ApplicationKindDeployment, "app")
+	checkId := model.Checks.CPUContainer.Id
+
+	err = db.SaveCheckConfigs(context.Background(), projectId, checkId, []CheckConfigUpdate{
+		{ApplicationId: model.ApplicationIdZero, Config: model.CheckConfigSimple{Threshold: 50}},
+		{ApplicationId: appId, Config: make(chan int)}, // can't be marshalled
+	}, "")
+	assert.Error(t, err)
+	configs, err := db.GetCheckConfigs(projectId)
+	require.NoError(t, err)
+	assert.Empty(t, configs)
+
+	err = db.SaveCheckConfigs(context.Background(), projectId, checkId, []CheckConfigUpdate{
+		{ApplicationId: model.ApplicationIdZero, Config: model.CheckConfigSimple{Threshold: 50}},
+		{ApplicationId: appId, Config: model.CheckConfigSimple{Threshold: 70}},
+	}, "")
+	require.NoError(t, err)
+	configs, err = db.GetCheckConfigs(projectId)
+	require.NoError(t, err)
+	assert.Equal(t, float64(70), configs.GetSimple(checkId, appId).Threshold)
+	assert.Equal(t, float64(50), configs.GetSimple(checkId, model.NewApplicationId("other", model.ApplicationKindDeployment, "app")).Threshold)
+}
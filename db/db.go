@@ -25,9 +25,19 @@ var (
 type DB struct {
 	typ Type
 	db  *sql.DB
+
+	// the optional read-only connection (e.g., to a replica) used by the read paths, see reader
+	replica *sql.DB
+
+	projects *projectCache
 }
 
-func Open(dataDir string, pgConnString string) (*DB, error) {
+// Open opens the database: Postgres if pgConnString is set, sqlite in the dataDir otherwise.
+// If pgReplicaConnString is set, the reads that can tolerate a replication lag are served by that Postgres instance.
+func Open(dataDir string, pgConnString, pgReplicaConnString string) (*DB, error) {
+	if pgReplicaConnString != "" && pgConnString == "" {
+		return nil, fmt.Errorf("a read replica is supported only for postgres")
+	}
 	var db *sql.DB
 	var err error
 	var typ Type
@@ -44,16 +54,32 @@ func Open(dataDir string, pgConnString string) (*DB, error) {
 		return nil, err
 	}
 	db.SetMaxOpenConns(1)
-	if err := NewMigrator(typ, db).Migrate(&Project{}, &CheckConfigs{}, &Incident{}); err != nil {
+	if err := NewMigrator(typ, db).Migrate(&Project{}, &CheckConfigs{}, &Incident{}, &Deployment{}, &AuditLogEntry{}); err != nil {
 		return nil, err
 	}
-	return &DB{typ: typ, db: db}, nil
+	res := &DB{typ: typ, db: db, projects: newProjectCache(projectCacheTTL)}
+	if pgReplicaConnString != "" {
+		klog.Infoln("using postgres read replica")
+		if res.replica, err = postgres(pgReplicaConnString); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
 }
 
 func (db *DB) Type() Type {
 	return db.typ
 }
 
+// reader returns the connection for the reads that can tolerate a replication lag: the replica if configured.
+// Reads preceding writes (read-modify-write) must use the primary to not overwrite recent changes.
+func (db *DB) reader() *sql.DB {
+	if db.replica != nil {
+		return db.replica
+	}
+	return db.db
+}
+
 func sqlite(path string) (*sql.DB, error) {
 	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=rwc", path))
 	if err != nil {
@@ -69,6 +95,12 @@ func postgres(dsn string) (*sql.DB, error) {
 	return sql.Open("postgres", dsn)
 }
 
+// queryer is implemented by both *sql.DB and *sql.Tx, so the same code can be run within a transaction or not.
+type queryer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
 type Table interface {
 	Migrate(m *Migrator) error
 }
@@ -100,7 +132,7 @@ func (m *Migrator) Exec(query string, args ...any) error {
 func (m *Migrator) AddColumnIfNotExists(table, column, dataType string) error {
 	switch m.typ {
 	case TypeSqlite:
-		rows, err := m.db.Query("SELECT name FROM pragma_table_info('project');")
+		rows, err := m.db.Query("SELECT name FROM pragma_table_info($1)", table)
 		if err != nil {
 			return nil
 		}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"k8s.io/klog"
+)
+
+type Deployment struct {
+	ApplicationId model.ApplicationId
+	Version       string
+	Timestamp     timeseries.Time
+}
+
+func (d *Deployment) Migrate(m *Migrator) error {
+	return m.Exec(`
+	CREATE TABLE IF NOT EXISTS deployment (
+		project_id TEXT NOT NULL REFERENCES project(id),
+		application_id TEXT NOT NULL,
+		version TEXT NOT NULL,
+		ts INT NOT NULL,
+		PRIMARY KEY (project_id, application_id, ts)
+	)`)
+}
+
+func (db *DB) GetDeploymentsByApp(projectId ProjectId, appId model.ApplicationId, from, to timeseries.Time) ([]Deployment, error) {
+	rows, err := db.db.Query(
+		"SELECT version, ts FROM deployment WHERE project_id = $1 AND application_id = $2 AND ts >= $3 AND ts <= $4 ORDER BY ts",
+		projectId, appId.String(), from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []Deployment
+	d := Deployment{ApplicationId: appId}
+	for rows.Next() {
+		if err := rows.Scan(&d.Version, &d.Timestamp); err != nil {
+			return nil, err
+		}
+		res = append(res, d)
+	}
+	return res, rows.Err()
+}
+
+func (db *DB) GetDeployments(projectId ProjectId, from, to timeseries.Time) ([]Deployment, error) {
+	rows, err := db.db.Query(
+		"SELECT application_id, version, ts FROM deployment WHERE project_id = $1 AND ts >= $2 AND ts <= $3 ORDER BY ts",
+		projectId, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []Deployment
+	for rows.Next() {
+		var d Deployment
+		var appIdStr string
+		if err := rows.Scan(&appIdStr, &d.Version, &d.Timestamp); err != nil {
+			return nil, err
+		}
+		if d.ApplicationId, err = model.NewApplicationIdFromString(appIdStr); err != nil {
+			klog.Warningln(err)
+			continue
+		}
+		res = append(res, d)
+	}
+	return res, rows.Err()
+}
+
+func (db *DB) SaveDeployments(projectId ProjectId, deployments []Deployment) error {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, d := range deployments {
+		_, err := tx.Exec(
+			"INSERT INTO deployment (project_id, application_id, version, ts) VALUES ($1, $2, $3, $4) ON CONFLICT (project_id, application_id, ts) DO UPDATE SET version = excluded.version",
+			projectId, d.ApplicationId.String(), d.Version, d.Timestamp)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,37 @@
+package db
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDeployments(t *testing.T) {
+	db, err := Open(t.TempDir(), "", "")
+	require.NoError(t, err)
+	projectId, err := db.SaveProject(Project{Name: "test"}, "")
+	require.NoError(t, err)
+	app1 := model.NewApplicationId("default", model.ApplicationKindDeployment, "app1")
+	app2 := model.NewApplicationId("default", model.ApplicationKindDeployment, "app2")
+
+	require.NoError(t, db.SaveDeployments(projectId, []Deployment{
+		{ApplicationId: app1, Version: "v1", Timestamp: 100},
+		{ApplicationId: app1, Version: "v2", Timestamp: 200},
+		{ApplicationId: app2, Version: "v1", Timestamp: 150},
+	}))
+	// a deployment with the same timestamp replaces the version
+	require.NoError(t, db.SaveDeployments(projectId, []Deployment{{ApplicationId: app1, Version: "v3", Timestamp: 200}}))
+
+	res, err := db.GetDeploymentsByApp(projectId, app1, 0, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, []Deployment{{ApplicationId: app1, Version: "v1", Timestamp: 100}, {ApplicationId: app1, Version: "v3", Timestamp: 200}}, res)
+
+	res, err = db.GetDeploymentsByApp(projectId, app1, 150, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, []Deployment{{ApplicationId: app1, Version: "v3", Timestamp: 200}}, res)
+
+	res, err = db.GetDeployments(projectId, 100, 150)
+	require.NoError(t, err)
+	assert.Equal(t, []Deployment{{ApplicationId: app1, Version: "v1", Timestamp: 100}, {ApplicationId: app2, Version: "v1", Timestamp: 150}}, res)
+}
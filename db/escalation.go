@@ -0,0 +1,38 @@
+package db
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+)
+
+type EscalationPolicy struct {
+	WarningToCriticalAfter timeseries.Duration `json:"warning_to_critical_after"`
+}
+
+// Escalate bumps the severity of a long-lasting WARNING incident to CRITICAL.
+// Incidents that have been acknowledged or snoozed are never escalated, but they aren't de-escalated either:
+// an incident escalated before the acknowledgement stays CRITICAL.
+func (p *EscalationPolicy) Escalate(i *Incident, severity model.Status, now timeseries.Time) model.Status {
+	if p == nil || p.WarningToCriticalAfter <= 0 || severity != model.WARNING {
+		return severity
+	}
+	if i.IsAcknowledged() || i.IsSnoozed(now) {
+		if i.Severity > severity {
+			return i.Severity
+		}
+		return severity
+	}
+	if now.Sub(i.OpenedAt) >= p.WarningToCriticalAfter {
+		return model.CRITICAL
+	}
+	return severity
+}
+
+func (db *DB) SaveEscalationPolicy(id ProjectId, policy *EscalationPolicy) error {
+	p, err := db.GetProjectForUpdate(id)
+	if err != nil {
+		return err
+	}
+	p.Settings.Escalation = policy
+	return db.saveProjectSettings(p)
+}
@@ -0,0 +1,33 @@
+package db
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEscalate(t *testing.T) {
+	now := timeseries.Time(1668000000)
+	p := &EscalationPolicy{WarningToCriticalAfter: timeseries.Hour}
+	i := &Incident{OpenedAt: now.Add(-2 * timeseries.Hour), Severity: model.WARNING}
+
+	var nilPolicy *EscalationPolicy
+	assert.Equal(t, model.WARNING, nilPolicy.Escalate(i, model.WARNING, now))
+	assert.Equal(t, model.WARNING, p.Escalate(i, model.WARNING, now.Add(-90*timeseries.Minute)))
+	assert.Equal(t, model.CRITICAL, p.Escalate(i, model.WARNING, now))
+	assert.Equal(t, model.CRITICAL, p.Escalate(i, model.CRITICAL, now))
+
+	// acknowledged and snoozed incidents aren't escalated
+	i.AcknowledgedAt = now.Add(-timeseries.Minute)
+	assert.Equal(t, model.WARNING, p.Escalate(i, model.WARNING, now))
+	i.AcknowledgedAt = 0
+	i.SnoozedUntil = now.Add(timeseries.Minute)
+	assert.Equal(t, model.WARNING, p.Escalate(i, model.WARNING, now))
+
+	// an incident acknowledged after the escalation keeps its severity
+	i.SnoozedUntil = 0
+	i.Severity = model.CRITICAL
+	i.AcknowledgedAt = now.Add(-timeseries.Minute)
+	assert.Equal(t, model.CRITICAL, p.Escalate(i, model.WARNING, now))
+}
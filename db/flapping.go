@@ -0,0 +1,49 @@
+package db
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+)
+
+type FlappingPolicy struct {
+	Cooldown timeseries.Duration `json:"cooldown"`
+
+	// the number of consecutive evaluations required to raise and to lower the SLO status
+	FireAfter  int `json:"fire_after"`
+	ClearAfter int `json:"clear_after"`
+
+	// how long the SLO status must stay unhealthy before an incident is opened
+	GracePeriod timeseries.Duration `json:"grace_period"`
+}
+
+func (p *FlappingPolicy) Damping() model.Damping {
+	if p == nil {
+		return model.Damping{}
+	}
+	return model.Damping{FireAfter: p.FireAfter, ClearAfter: p.ClearAfter}
+}
+
+func (p *FlappingPolicy) OpeningGrace() model.OpeningGrace {
+	if p == nil {
+		return model.OpeningGrace{}
+	}
+	return model.OpeningGrace{Period: p.GracePeriod}
+}
+
+// IsFlapping reports whether a resolved incident should be reopened instead of opening a new one:
+// the application became unhealthy again within the cooldown after the resolution.
+func (p *FlappingPolicy) IsFlapping(i *Incident, now timeseries.Time) bool {
+	if p == nil || p.Cooldown <= 0 || i.OpenedAt.IsZero() || i.ResolvedAt.IsZero() {
+		return false
+	}
+	return now.Sub(i.ResolvedAt) <= p.Cooldown
+}
+
+func (db *DB) SaveFlappingPolicy(id ProjectId, policy *FlappingPolicy) error {
+	p, err := db.GetProjectForUpdate(id)
+	if err != nil {
+		return err
+	}
+	p.Settings.Flapping = policy
+	return db.saveProjectSettings(p)
+}
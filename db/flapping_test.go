@@ -0,0 +1,63 @@
+package db
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestIsFlapping(t *testing.T) {
+	now := timeseries.Time(1668000000)
+	p := &FlappingPolicy{Cooldown: 5 * timeseries.Minute}
+	resolved := &Incident{OpenedAt: now.Add(-timeseries.Hour), ResolvedAt: now.Add(-5 * timeseries.Minute)}
+
+	var nilPolicy *FlappingPolicy
+	assert.False(t, nilPolicy.IsFlapping(resolved, now))
+	assert.False(t, (&FlappingPolicy{}).IsFlapping(resolved, now))
+	assert.True(t, p.IsFlapping(resolved, now))
+	assert.False(t, p.IsFlapping(resolved, now.Add(timeseries.Second)))
+	assert.False(t, p.IsFlapping(&Incident{OpenedAt: now.Add(-timeseries.Hour)}, now))
+	assert.False(t, p.IsFlapping(&Incident{}, now))
+}
+
+func TestCreateOrUpdateIncidentFlapping(t *testing.T) {
+	db, err := Open(t.TempDir(), "", "")
+	require.NoError(t, err)
+	projectId, err := db.SaveProject(Project{Name: "test"}, "")
+	require.NoError(t, err)
+	appId := model.NewApplicationId("default", model.ApplicationKindDeployment, "app")
+	flapping := &FlappingPolicy{Cooldown: 5 * timeseries.Minute}
+	update := func(now timeseries.Time, severity model.Status) *Incident {
+		i, err := db.CreateOrUpdateIncident(projectId, appId, now, severity, nil, flapping, nil)
+		require.NoError(t, err)
+		return i
+	}
+
+	opened := update(100, model.WARNING)
+	require.NotNil(t, opened)
+	update(200, model.OK)
+
+	// reopened within the cooldown with the same severity: no notification
+	assert.Nil(t, update(250, model.WARNING))
+	// reopened with a higher severity: notify
+	update(300, model.OK)
+	i := update(320, model.CRITICAL)
+	require.NotNil(t, i)
+	assert.Equal(t, opened.Key, i.Key)
+	assert.Equal(t, model.CRITICAL, i.Severity)
+	assert.Equal(t, 2, i.FlapCount)
+
+	incidents, err := db.GetIncidentsByApp(projectId, appId, 0, 1000)
+	require.NoError(t, err)
+	require.Len(t, incidents, 1)
+	assert.True(t, incidents[0].ResolvedAt.IsZero())
+
+	// after the cooldown a new incident is opened
+	update(400, model.OK)
+	i = update(timeseries.Time(400).Add(flapping.Cooldown+timeseries.Second), model.WARNING)
+	require.NotNil(t, i)
+	assert.NotEqual(t, opened.Key, i.Key)
+	assert.Equal(t, 0, i.FlapCount)
+}
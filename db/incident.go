@@ -2,22 +2,67 @@ package db
 
 import (
 	"database/sql"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"github.com/coroot/coroot/model"
 	"github.com/coroot/coroot/timeseries"
 	"github.com/coroot/coroot/utils"
+	"k8s.io/klog"
+	"strconv"
+	"strings"
 )
 
+const (
+	IncidentResolveReasonDataLost      = "data lost"
+	IncidentResolveReasonRecomputedEnd = "end of the recomputed range"
+	IncidentResolveReasonCheckRemoved  = "check removed"
+)
+
+const incidentColumns = "key, opened_at, resolved_at, severity, sent_at, acknowledged_at, snoozed_until, flap_count, resolve_reason, peak_burn_rate, recomputed_at, caused_by"
+
 type Incident struct {
-	Key        string
-	OpenedAt   timeseries.Time
-	ResolvedAt timeseries.Time
-	Severity   model.Status
-	SentAt     timeseries.Time
+	Key            string
+	OpenedAt       timeseries.Time
+	ResolvedAt     timeseries.Time
+	Severity       model.Status
+	SentAt         timeseries.Time // the time of the last notification
+	AcknowledgedAt timeseries.Time
+	SnoozedUntil   timeseries.Time
+	FlapCount      int
+	ResolveReason  string
+	PeakBurnRate   float64
+	RecomputedAt   timeseries.Time // non-zero if the incident was produced by re-evaluating the past rather than live
+	CausedBy       string          // the key of the incident of an upstream application this one is attributed to
+
+	ApplicationId   model.ApplicationId
+	SeverityHistory []SeverityChange
+	Reminder        bool // the incident is returned only to repeat the notification
+}
+
+type SeverityChange struct {
+	Time     timeseries.Time `json:"time"`
+	Severity model.Status    `json:"severity"`
+}
+
+func (i *Incident) IsAcknowledged() bool {
+	return !i.AcknowledgedAt.IsZero()
+}
+
+func (i *Incident) IsRecomputed() bool {
+	return !i.RecomputedAt.IsZero()
+}
+
+func (i *Incident) IsSnoozed(now timeseries.Time) bool {
+	return i.SnoozedUntil.After(now)
+}
+
+func (i *Incident) fields() []any {
+	return []any{&i.Key, &i.OpenedAt, &i.ResolvedAt, &i.Severity, &i.SentAt, &i.AcknowledgedAt, &i.SnoozedUntil, &i.FlapCount, &i.ResolveReason, &i.PeakBurnRate, &i.RecomputedAt, &i.CausedBy}
 }
 
 func (cc *Incident) Migrate(m *Migrator) error {
-	return m.Exec(`
+	err := m.Exec(`
 	CREATE TABLE IF NOT EXISTS incident (
 		project_id TEXT NOT NULL REFERENCES project(id),
 		application_id TEXT NOT NULL,
@@ -30,19 +75,76 @@ func (cc *Incident) Migrate(m *Migrator) error {
 	);
 	CREATE UNIQUE INDEX IF NOT EXISTS incident_key ON incident (project_id, key);
 `)
+	if err != nil {
+		return err
+	}
+	if err := m.AddColumnIfNotExists("incident", "acknowledged_at", "INT NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := m.AddColumnIfNotExists("incident", "snoozed_until", "INT NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := m.AddColumnIfNotExists("incident", "flap_count", "INT NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := m.AddColumnIfNotExists("incident", "resolve_reason", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := m.AddColumnIfNotExists("incident", "peak_burn_rate", "REAL NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := m.AddColumnIfNotExists("incident", "recomputed_at", "INT NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := m.AddColumnIfNotExists("incident", "caused_by", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	return m.Exec(`
+	CREATE TABLE IF NOT EXISTS incident_severity (
+		project_id TEXT NOT NULL REFERENCES project(id),
+		application_id TEXT NOT NULL,
+		opened_at INT NOT NULL,
+		ts INT NOT NULL,
+		severity INT NOT NULL,
+		PRIMARY KEY (project_id, application_id, opened_at, ts)
+	)`)
 }
 
 func (db *DB) GetIncidentByKey(projectId ProjectId, key string) (*Incident, error) {
-	i := &Incident{Key: key}
-	err := db.db.QueryRow(
-		"SELECT opened_at, resolved_at, severity, sent_at FROM incident WHERE project_id = $1 AND key = $2 LIMIT 1",
-		projectId, key).Scan(&i.OpenedAt, &i.ResolvedAt, &i.Severity, &i.SentAt)
-	return i, err
+	i := &Incident{}
+	var appIdStr string
+	err := db.reader().QueryRow(
+		"SELECT application_id, "+incidentColumns+" FROM incident WHERE project_id = $1 AND key = $2 LIMIT 1",
+		projectId, key).Scan(append([]any{&appIdStr}, i.fields()...)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if i.ApplicationId, err = model.NewApplicationIdFromString(appIdStr); err != nil {
+		return nil, err
+	}
+	if i.SeverityHistory, err = getSeverityHistory(db.reader(), projectId, i.ApplicationId, i.OpenedAt); err != nil {
+		return nil, err
+	}
+	return i, nil
 }
 
 func (db *DB) GetIncidentsByApp(projectId ProjectId, appId model.ApplicationId, from, to timeseries.Time) ([]Incident, error) {
-	rows, err := db.db.Query(
-		"SELECT key, opened_at, resolved_at, severity, sent_at FROM incident WHERE project_id = $1 AND application_id = $2 AND opened_at <= $3 AND (resolved_at = 0 OR resolved_at >= $4)",
+	return getIncidentsByApp(db.reader(), projectId, appId, from, to)
+}
+
+// GetIncidentsByAppForUpdate is like GetIncidentsByApp, but it reads from the primary.
+// Use it to choose the incidents to be replaced, a lagging replica may lack the recently opened ones.
+func (db *DB) GetIncidentsByAppForUpdate(projectId ProjectId, appId model.ApplicationId, from, to timeseries.Time) ([]Incident, error) {
+	return getIncidentsByApp(db.db, projectId, appId, from, to)
+}
+
+func getIncidentsByApp(conn *sql.DB, projectId ProjectId, appId model.ApplicationId, from, to timeseries.Time) ([]Incident, error) {
+	overlapping := "project_id = $1 AND application_id = $2 AND opened_at <= $3 AND (resolved_at = 0 OR resolved_at >= $4)"
+	rows, err := conn.Query(
+		"SELECT "+incidentColumns+" FROM incident WHERE "+overlapping,
 		projectId, appId.String(), to, from)
 	if err != nil {
 		return nil, err
@@ -51,12 +153,317 @@ func (db *DB) GetIncidentsByApp(projectId ProjectId, appId model.ApplicationId,
 	var res []Incident
 	var i Incident
 	for rows.Next() {
-		if err := rows.Scan(&i.Key, &i.OpenedAt, &i.ResolvedAt, &i.Severity, &i.SentAt); err != nil {
+		if err := rows.Scan(i.fields()...); err != nil {
+			return nil, err
+		}
+		i.ApplicationId = appId
+		res = append(res, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return res, nil
+	}
+	// the severity histories of all the incidents are fetched in one query
+	rows, err = conn.Query(
+		"SELECT opened_at, ts, severity FROM incident_severity WHERE project_id = $1 AND application_id = $2 AND opened_at IN (SELECT opened_at FROM incident WHERE "+overlapping+") ORDER BY opened_at, ts",
+		projectId, appId.String(), to, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	histories := map[timeseries.Time][]SeverityChange{}
+	var openedAt timeseries.Time
+	var c SeverityChange
+	for rows.Next() {
+		if err := rows.Scan(&openedAt, &c.Time, &c.Severity); err != nil {
+			return nil, err
+		}
+		histories[openedAt] = append(histories[openedAt], c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for idx := range res {
+		res[idx].SeverityHistory = histories[res[idx].OpenedAt]
+	}
+	return res, nil
+}
+
+// GetIncidents returns the incidents of all applications of the project that overlap the given time range.
+func (db *DB) GetIncidents(projectId ProjectId, from, to timeseries.Time) ([]Incident, error) {
+	rows, err := db.reader().Query(
+		"SELECT application_id, "+incidentColumns+" FROM incident WHERE project_id = $1 AND opened_at <= $2 AND (resolved_at = 0 OR resolved_at >= $3) ORDER BY opened_at",
+		projectId, to, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []Incident
+	for rows.Next() {
+		var i Incident
+		var appIdStr string
+		if err := rows.Scan(append([]any{&appIdStr}, i.fields()...)...); err != nil {
+			return nil, err
+		}
+		if i.ApplicationId, err = model.NewApplicationIdFromString(appIdStr); err != nil {
+			klog.Warningln(err)
+			continue
+		}
+		res = append(res, i)
+	}
+	return res, rows.Err()
+}
+
+// IncidentCursor points to the last incident of a page, the next page starts right after it.
+type IncidentCursor struct {
+	OpenedAt timeseries.Time
+	Key      string
+}
+
+// String returns the opaque representation of the cursor to pass to clients.
+func (c IncidentCursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%s", c.OpenedAt, c.Key)))
+}
+
+func ParseIncidentCursor(s string) (*IncidentCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	parts := strings.SplitN(string(data), ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	openedAt, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &IncidentCursor{OpenedAt: timeseries.Time(openedAt), Key: parts[1]}, nil
+}
+
+// GetIncidentsPage returns up to limit incidents overlapping the given time range that follow the cursor (from the first one if it's nil)
+// ordered by the opening time and the key, so that the pages don't overlap or skip incidents opened at the same time.
+// If appId is not zero, only the incidents of that application are returned.
+// The returned cursor points to the next page, it's nil if there are no more incidents.
+func (db *DB) GetIncidentsPage(projectId ProjectId, appId model.ApplicationId, from, to timeseries.Time, after *IncidentCursor, limit int) ([]Incident, *IncidentCursor, error) {
+	var res []Incident
+	for {
+		// the rows with invalid application ids are skipped, so the page is refetched until it's full or there are no more rows
+		want := limit + 1 - len(res)
+		incidents, last, fetched, err := db.getIncidentsPage(projectId, appId, from, to, after, want)
+		if err != nil {
+			return nil, nil, err
+		}
+		res = append(res, incidents...)
+		if len(res) > limit || fetched < want {
+			break
+		}
+		after = last
+	}
+	var next *IncidentCursor
+	if len(res) > limit {
+		res = res[:limit]
+		last := res[len(res)-1]
+		next = &IncidentCursor{OpenedAt: last.OpenedAt, Key: last.Key}
+	}
+	return res, next, nil
+}
+
+// getIncidentsPage fetches up to limit rows following the cursor and returns the valid incidents,
+// the cursor of the last fetched row and the number of rows fetched.
+func (db *DB) getIncidentsPage(projectId ProjectId, appId model.ApplicationId, from, to timeseries.Time, after *IncidentCursor, limit int) ([]Incident, *IncidentCursor, int, error) {
+	query := "SELECT application_id, " + incidentColumns + " FROM incident WHERE project_id = $1 AND opened_at <= $2 AND (resolved_at = 0 OR resolved_at >= $3)"
+	args := []any{projectId, to, from}
+	if !appId.IsZero() {
+		args = append(args, appId.String())
+		query += fmt.Sprintf(" AND application_id = $%d", len(args))
+	}
+	if after != nil {
+		args = append(args, after.OpenedAt, after.Key)
+		query += fmt.Sprintf(" AND (opened_at > $%d OR (opened_at = $%d AND key > $%d))", len(args)-1, len(args)-1, len(args))
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY opened_at, key LIMIT $%d", len(args))
+
+	rows, err := db.reader().Query(query, args...)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer rows.Close()
+	var res []Incident
+	var last *IncidentCursor
+	fetched := 0
+	for rows.Next() {
+		var i Incident
+		var appIdStr string
+		if err := rows.Scan(append([]any{&appIdStr}, i.fields()...)...); err != nil {
+			return nil, nil, 0, err
+		}
+		fetched++
+		last = &IncidentCursor{OpenedAt: i.OpenedAt, Key: i.Key}
+		if i.ApplicationId, err = model.NewApplicationIdFromString(appIdStr); err != nil {
+			klog.Warningln(err)
+			continue
+		}
+		res = append(res, i)
+	}
+	return res, last, fetched, rows.Err()
+}
+
+// GetUnannouncedResolutions returns the incidents resolved since the given time for the given reason
+// that have been notified about, but whose resolution hasn't been.
+func (db *DB) GetUnannouncedResolutions(projectId ProjectId, reason string, since timeseries.Time) ([]Incident, error) {
+	rows, err := db.db.Query(
+		"SELECT application_id, "+incidentColumns+" FROM incident WHERE project_id = $1 AND resolve_reason = $2 AND resolved_at >= $3 AND sent_at != 0 AND sent_at < resolved_at ORDER BY opened_at",
+		projectId, reason, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []Incident
+	for rows.Next() {
+		var i Incident
+		var appIdStr string
+		if err := rows.Scan(append([]any{&appIdStr}, i.fields()...)...); err != nil {
+			return nil, err
+		}
+		if i.ApplicationId, err = model.NewApplicationIdFromString(appIdStr); err != nil {
+			klog.Warningln(err)
+			continue
+		}
+		res = append(res, i)
+	}
+	return res, rows.Err()
+}
+
+// GetIncidentsCausedBy returns the incidents attributed to the incident with the given key, see SetIncidentCause.
+func (db *DB) GetIncidentsCausedBy(projectId ProjectId, key string) ([]Incident, error) {
+	rows, err := db.reader().Query(
+		"SELECT application_id, "+incidentColumns+" FROM incident WHERE project_id = $1 AND caused_by = $2 ORDER BY opened_at",
+		projectId, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []Incident
+	for rows.Next() {
+		var i Incident
+		var appIdStr string
+		if err := rows.Scan(append([]any{&appIdStr}, i.fields()...)...); err != nil {
 			return nil, err
 		}
+		if i.ApplicationId, err = model.NewApplicationIdFromString(appIdStr); err != nil {
+			klog.Warningln(err)
+			continue
+		}
 		res = append(res, i)
 	}
-	return res, err
+	return res, rows.Err()
+}
+
+func getSeverityHistory(conn *sql.DB, projectId ProjectId, appId model.ApplicationId, openedAt timeseries.Time) ([]SeverityChange, error) {
+	rows, err := conn.Query(
+		"SELECT ts, severity FROM incident_severity WHERE project_id = $1 AND application_id = $2 AND opened_at = $3 ORDER BY ts",
+		projectId, appId.String(), openedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []SeverityChange
+	var c SeverityChange
+	for rows.Next() {
+		if err := rows.Scan(&c.Time, &c.Severity); err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+	return res, rows.Err()
+}
+
+func (db *DB) addSeverityChange(projectId ProjectId, appIdStr string, i *Incident, now timeseries.Time) error {
+	_, err := db.db.Exec(
+		"INSERT INTO incident_severity (project_id, application_id, opened_at, ts, severity) VALUES ($1, $2, $3, $4, $5) ON CONFLICT DO NOTHING",
+		projectId, appIdStr, i.OpenedAt, now, i.Severity)
+	return err
+}
+
+// GetOpenIncidents returns the unresolved incidents of the project by application.
+func (db *DB) GetOpenIncidents(projectId ProjectId) (map[model.ApplicationId]*Incident, error) {
+	rows, err := db.db.Query(
+		"SELECT application_id, "+incidentColumns+" FROM incident WHERE project_id = $1 AND resolved_at = 0",
+		projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := map[model.ApplicationId]*Incident{}
+	for rows.Next() {
+		var appIdStr string
+		i := &Incident{}
+		if err := rows.Scan(append([]any{&appIdStr}, i.fields()...)...); err != nil {
+			return nil, err
+		}
+		appId, err := model.NewApplicationIdFromString(appIdStr)
+		if err != nil {
+			klog.Warningln(err)
+			continue
+		}
+		res[appId] = i
+	}
+	return res, rows.Err()
+}
+
+// CountOpenIncidents returns the number of open incidents of the project by severity.
+func (db *DB) CountOpenIncidents(projectId ProjectId) (map[model.Status]int, error) {
+	rows, err := db.db.Query(
+		"SELECT severity, count(*) FROM incident WHERE project_id = $1 AND resolved_at = 0 GROUP BY severity",
+		projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := map[model.Status]int{}
+	for rows.Next() {
+		var severity model.Status
+		var count int
+		if err := rows.Scan(&severity, &count); err != nil {
+			return nil, err
+		}
+		res[severity] = count
+	}
+	return res, rows.Err()
+}
+
+func (db *DB) ResolveIncident(projectId ProjectId, appId model.ApplicationId, i *Incident, now timeseries.Time, reason string) error {
+	return resolveIncident(db.db, projectId, appId, i, now, reason)
+}
+
+func resolveIncident(q queryer, projectId ProjectId, appId model.ApplicationId, i *Incident, now timeseries.Time, reason string) error {
+	i.ResolvedAt = now
+	i.ResolveReason = reason
+	_, err := q.Exec(
+		"UPDATE incident SET resolved_at = $1, resolve_reason = $2 WHERE project_id = $3 AND application_id = $4 AND opened_at = $5",
+		i.ResolvedAt, i.ResolveReason, projectId, appId.String(), i.OpenedAt)
+	return err
+}
+
+// SetIncidentCause attributes the incident of the application to the incident of an upstream application with the given key.
+func (db *DB) SetIncidentCause(projectId ProjectId, appId model.ApplicationId, i *Incident, causedBy string) error {
+	i.CausedBy = causedBy
+	_, err := db.db.Exec(
+		"UPDATE incident SET caused_by = $1 WHERE project_id = $2 AND application_id = $3 AND opened_at = $4",
+		i.CausedBy, projectId, appId.String(), i.OpenedAt)
+	return err
+}
+
+// UpdateIncidentPeakBurnRate raises the peak burn rate of the open incident of the application.
+func (db *DB) UpdateIncidentPeakBurnRate(projectId ProjectId, appId model.ApplicationId, burnRate float64) error {
+	_, err := db.db.Exec(
+		"UPDATE incident SET peak_burn_rate = $1 WHERE project_id = $2 AND application_id = $3 AND resolved_at = 0 AND peak_burn_rate < $1",
+		burnRate, projectId, appId.String())
+	return err
 }
 
 func (db *DB) MarkIncidentAsSent(projectId ProjectId, appId model.ApplicationId, i *Incident, now timeseries.Time) error {
@@ -66,23 +473,136 @@ func (db *DB) MarkIncidentAsSent(projectId ProjectId, appId model.ApplicationId,
 	return err
 }
 
-func (db *DB) CreateOrUpdateIncident(projectId ProjectId, appId model.ApplicationId, now timeseries.Time, severity model.Status) (*Incident, error) {
+func (db *DB) AcknowledgeIncident(projectId ProjectId, key string, now timeseries.Time) error {
+	return db.updateIncident("UPDATE incident SET acknowledged_at = $1 WHERE project_id = $2 AND key = $3", now, projectId, key)
+}
+
+func (db *DB) SnoozeIncident(projectId ProjectId, key string, until timeseries.Time) error {
+	return db.updateIncident("UPDATE incident SET snoozed_until = $1 WHERE project_id = $2 AND key = $3", until, projectId, key)
+}
+
+type IncidentAck struct {
+	AcknowledgedAt timeseries.Time `json:"acknowledged_at,omitempty"`
+	SnoozedUntil   timeseries.Time `json:"snoozed_until,omitempty"`
+	Comment        string          `json:"comment,omitempty"`
+}
+
+// AcknowledgeIncidents acknowledges the given incidents at once, or snoozes them if snoozedUntil is not zero.
+// Each change is recorded in the audit log along with the comment explaining it.
+func (db *DB) AcknowledgeIncidents(projectId ProjectId, incidents []*Incident, now, snoozedUntil timeseries.Time, comment, actor string) error {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, i := range incidents {
+		old := IncidentAck{AcknowledgedAt: i.AcknowledgedAt, SnoozedUntil: i.SnoozedUntil}
+		ack := IncidentAck{AcknowledgedAt: i.AcknowledgedAt, SnoozedUntil: snoozedUntil, Comment: comment}
+		if snoozedUntil.IsZero() {
+			ack = IncidentAck{AcknowledgedAt: now, SnoozedUntil: i.SnoozedUntil, Comment: comment}
+		}
+		_, err = tx.Exec(
+			"UPDATE incident SET acknowledged_at = $1, snoozed_until = $2 WHERE project_id = $3 AND key = $4",
+			ack.AcknowledgedAt, ack.SnoozedUntil, projectId, i.Key)
+		if err != nil {
+			return err
+		}
+		if err = addAuditLogEntry(tx, projectId, actor, "incident:"+i.Key, old, ack); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (db *DB) updateIncident(query string, args ...any) error {
+	res, err := db.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ReplaceIncidents deletes the resolved incidents of the application that lie entirely within the given range
+// and stores the given ones instead, marking them as recomputed at now. It returns the number of the deleted incidents.
+func (db *DB) ReplaceIncidents(projectId ProjectId, appId model.ApplicationId, from, to timeseries.Time, incidents []*Incident, now timeseries.Time) (int, error) {
+	appIdStr := appId.String()
+	tx, err := db.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	within := "project_id = $1 AND application_id = $2 AND opened_at >= $3 AND resolved_at != 0 AND resolved_at <= $4"
+	_, err = tx.Exec(
+		"DELETE FROM incident_severity WHERE project_id = $1 AND application_id = $2 AND opened_at IN (SELECT opened_at FROM incident WHERE "+within+")",
+		projectId, appIdStr, from, to)
+	if err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec("DELETE FROM incident WHERE "+within, projectId, appIdStr, from, to)
+	if err != nil {
+		return 0, err
+	}
+	deleted, _ := res.RowsAffected()
+	for _, i := range incidents {
+		i.RecomputedAt = now
+		_, err := tx.Exec(
+			"INSERT INTO incident (project_id, application_id, key, opened_at, resolved_at, severity, flap_count, resolve_reason, peak_burn_rate, recomputed_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+			projectId, appIdStr, i.Key, i.OpenedAt, i.ResolvedAt, i.Severity, i.FlapCount, i.ResolveReason, i.PeakBurnRate, i.RecomputedAt)
+		if err != nil {
+			return 0, err
+		}
+		for _, c := range i.SeverityHistory {
+			_, err := tx.Exec(
+				"INSERT INTO incident_severity (project_id, application_id, opened_at, ts, severity) VALUES ($1, $2, $3, $4, $5) ON CONFLICT DO NOTHING",
+				projectId, appIdStr, i.OpenedAt, c.Time, c.Severity)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	return int(deleted), tx.Commit()
+}
+
+func (db *DB) CreateOrUpdateIncident(projectId ProjectId, appId model.ApplicationId, now timeseries.Time, severity model.Status, escalation *EscalationPolicy, flapping *FlappingPolicy, repeat *RepeatPolicy) (*Incident, error) {
 	appIdStr := appId.String()
 	var last Incident
 	err := db.db.QueryRow(
-		"SELECT key, opened_at, resolved_at, severity, sent_at FROM incident WHERE project_id = $1 AND application_id = $2 ORDER BY opened_at DESC LIMIT 1",
-		projectId, appIdStr).Scan(&last.Key, &last.OpenedAt, &last.ResolvedAt, &last.Severity, &last.SentAt)
+		"SELECT "+incidentColumns+" FROM incident WHERE project_id = $1 AND application_id = $2 ORDER BY opened_at DESC LIMIT 1",
+		projectId, appIdStr).Scan(last.fields()...)
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return nil, err
 	}
 
+	if severity > model.OK && flapping.IsFlapping(&last, now) { // reopen, notify only if the severity has increased
+		escalated := severity > last.Severity
+		last.ResolvedAt = 0
+		last.ResolveReason = ""
+		last.FlapCount++
+		if escalated {
+			last.Severity = severity
+		}
+		_, err := db.db.Exec(
+			"UPDATE incident SET resolved_at = 0, resolve_reason = '', severity = $1, flap_count = $2 WHERE project_id = $3 AND application_id = $4 AND opened_at = $5",
+			last.Severity, last.FlapCount, projectId, appIdStr, last.OpenedAt)
+		if err != nil || !escalated {
+			return nil, err
+		}
+		return &last, db.addSeverityChange(projectId, appIdStr, &last, now)
+	}
+
 	if last.OpenedAt.IsZero() || !last.ResolvedAt.IsZero() {
 		if severity > model.OK { // open
 			i := Incident{Key: utils.NanoId(8), OpenedAt: now, Severity: severity}
 			_, err := db.db.Exec(
 				"INSERT INTO incident (project_id, application_id, key, opened_at, severity) VALUES ($1, $2, $3, $4, $5)",
 				projectId, appIdStr, i.Key, i.OpenedAt, i.Severity)
-			return &i, err
+			if err != nil {
+				return nil, err
+			}
+			return &i, db.addSeverityChange(projectId, appIdStr, &i, now)
 		}
 		return nil, nil
 	}
@@ -95,17 +615,27 @@ func (db *DB) CreateOrUpdateIncident(projectId ProjectId, appId model.Applicatio
 		return &last, err
 	}
 
+	severity = escalation.Escalate(&last, severity, now)
+
 	if severity != last.Severity { // update severity
 		last.Severity = severity
 		_, err := db.db.Exec(
 			"UPDATE incident SET severity = $1 WHERE project_id = $2 AND application_id = $3 AND opened_at = $4",
 			last.Severity, projectId, appIdStr, last.OpenedAt)
-		return &last, err
+		if err != nil {
+			return nil, err
+		}
+		return &last, db.addSeverityChange(projectId, appIdStr, &last, now)
 	}
 
 	if last.SentAt.IsZero() {
 		return &last, nil
 	}
 
+	if repeat.IsDue(&last, now) {
+		last.Reminder = true
+		return &last, nil
+	}
+
 	return nil, nil
 }
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"fmt"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGetIncidentsPage(t *testing.T) {
+	db, err := Open(t.TempDir(), "", "")
+	require.NoError(t, err)
+	projectId, err := db.SaveProject(Project{Name: "test"}, "")
+	require.NoError(t, err)
+	app1 := model.NewApplicationId("default", model.ApplicationKindDeployment, "app1")
+	app2 := model.NewApplicationId("default", model.ApplicationKindDeployment, "app2")
+
+	now := timeseries.Now()
+	incident := func(key string, openedAt timeseries.Time) *Incident {
+		return &Incident{Key: key, OpenedAt: openedAt, ResolvedAt: openedAt.Add(timeseries.Minute), Severity: model.CRITICAL}
+	}
+	_, err = db.ReplaceIncidents(projectId, app1, 0, now, []*Incident{incident("a", 100), incident("c", 200), incident("e", 300)}, now)
+	require.NoError(t, err)
+	_, err = db.ReplaceIncidents(projectId, app2, 0, now, []*Incident{incident("b", 200), incident("d", 400)}, now)
+	require.NoError(t, err)
+
+	var keys []string
+	var cursor *IncidentCursor
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 3)
+		var incidents []Incident
+		incidents, cursor, err = db.GetIncidentsPage(projectId, model.ApplicationId{}, 0, now, cursor, 2)
+		require.NoError(t, err)
+		for _, i := range incidents {
+			keys = append(keys, i.Key)
+		}
+		if cursor == nil {
+			break
+		}
+		cursor, err = ParseIncidentCursor(cursor.String())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"a", "b", "c", "e", "d"}, keys)
+
+	incidents, cursor, err := db.GetIncidentsPage(projectId, app2, 0, now, nil, 2)
+	require.NoError(t, err)
+	assert.Nil(t, cursor)
+	assert.Len(t, incidents, 2)
+	assert.Equal(t, app2, incidents[0].ApplicationId)
+
+	// the rows with invalid application ids don't shorten the page
+	_, err = db.db.Exec("UPDATE incident SET application_id = 'invalid-' || key WHERE key IN ('b', 'c')")
+	require.NoError(t, err)
+	incidents, cursor, err = db.GetIncidentsPage(projectId, model.ApplicationId{}, 0, now, nil, 2)
+	require.NoError(t, err)
+	if assert.Len(t, incidents, 2) {
+		assert.Equal(t, "a", incidents[0].Key)
+		assert.Equal(t, "e", incidents[1].Key)
+	}
+	require.NotNil(t, cursor)
+	incidents, cursor, err = db.GetIncidentsPage(projectId, model.ApplicationId{}, 0, now, cursor, 2)
+	require.NoError(t, err)
+	assert.Nil(t, cursor)
+	if assert.Len(t, incidents, 1) {
+		assert.Equal(t, "d", incidents[0].Key)
+	}
+
+	_, err = ParseIncidentCursor("garbage")
+	assert.Error(t, err)
+}
+
+func TestCountOpenIncidents(t *testing.T) {
+	db, err := Open(t.TempDir(), "", "")
+	require.NoError(t, err)
+	projectId, err := db.SaveProject(Project{Name: "test"}, "")
+	require.NoError(t, err)
+
+	counts, err := db.CountOpenIncidents(projectId)
+	require.NoError(t, err)
+	assert.Empty(t, counts)
+
+	for i, severity := range []model.Status{model.WARNING, model.CRITICAL, model.CRITICAL, model.WARNING} {
+		appId := model.NewApplicationId("default", model.ApplicationKindDeployment, fmt.Sprintf("app%d", i))
+		_, err := db.CreateOrUpdateIncident(projectId, appId, 100, severity, nil, nil, nil)
+		require.NoError(t, err)
+	}
+	// resolved incidents aren't counted
+	_, err = db.CreateOrUpdateIncident(projectId, model.NewApplicationId("default", model.ApplicationKindDeployment, "app3"), 200, model.OK, nil, nil, nil)
+	require.NoError(t, err)
+
+	counts, err = db.CountOpenIncidents(projectId)
+	require.NoError(t, err)
+	assert.Equal(t, map[model.Status]int{model.WARNING: 1, model.CRITICAL: 2}, counts)
+}
+
+func TestGetIncidentsByAppSeverityHistory(t *testing.T) {
+	db, err := Open(t.TempDir(), "", "")
+	require.NoError(t, err)
+	projectId, err := db.SaveProject(Project{Name: "test"}, "")
+	require.NoError(t, err)
+
+	app1 := model.NewApplicationId("default", model.ApplicationKindDeployment, "app1")
+	app2 := model.NewApplicationId("default", model.ApplicationKindDeployment, "app2")
+	changes := []struct {
+		app      model.ApplicationId
+		now      timeseries.Time
+		severity model.Status
+	}{
+		{app1, 100, model.WARNING},
+		{app2, 120, model.WARNING},
+		{app1, 150, model.CRITICAL},
+		{app1, 200, model.OK},
+		{app1, 300, model.WARNING},
+	}
+	for _, c := range changes {
+		_, err := db.CreateOrUpdateIncident(projectId, c.app, c.now, c.severity, nil, nil, nil)
+		require.NoError(t, err)
+	}
+
+	incidents, err := db.GetIncidentsByApp(projectId, app1, 0, 1000)
+	require.NoError(t, err)
+	histories := map[timeseries.Time][]SeverityChange{}
+	for _, i := range incidents {
+		histories[i.OpenedAt] = i.SeverityHistory
+	}
+	assert.Equal(t, map[timeseries.Time][]SeverityChange{
+		100: {{Time: 100, Severity: model.WARNING}, {Time: 150, Severity: model.CRITICAL}},
+		300: {{Time: 300, Severity: model.WARNING}},
+	}, histories)
+
+	incidents, err = db.GetIncidentsByApp(projectId, app1, 250, 1000)
+	require.NoError(t, err)
+	require.Len(t, incidents, 1)
+	assert.Equal(t, []SeverityChange{{Time: 300, Severity: model.WARNING}}, incidents[0].SeverityHistory)
+}
@@ -1,19 +1,99 @@
 package db
 
+import (
+	"github.com/coroot/coroot/timeseries"
+	"github.com/coroot/coroot/utils"
+)
+
 type Integrations struct {
 	BaseUrl string `json:"base_url"`
 
 	Slack *IntegrationSlack `json:"slack,omitempty"`
+	OTLP  *IntegrationOTLP  `json:"otlp,omitempty"`
+
+	QuietHours *QuietHours `json:"quiet_hours,omitempty"`
 }
 
+const (
+	DefaultIntegrationTimeout      = 30 * timeseries.Second
+	DefaultBreakerFailureThreshold = 5
+	DefaultBreakerCooldown         = 10 * timeseries.Minute
+)
+
 type IntegrationSlack struct {
-	Token          string `json:"token"`
-	DefaultChannel string `json:"default_channel"`
-	Enabled        bool   `json:"enabled"`
+	Token          string              `json:"token" secret:"true"`
+	DefaultChannel string              `json:"default_channel"`
+	Enabled        bool                `json:"enabled"`
+	Timeout        timeseries.Duration `json:"timeout,omitempty"`
+	// the integration is disabled for BreakerCooldown after BreakerFailureThreshold consecutive failures
+	BreakerFailureThreshold int                 `json:"breaker_failure_threshold,omitempty"`
+	BreakerCooldown         timeseries.Duration `json:"breaker_cooldown,omitempty"`
+}
+
+// GetTimeout returns the configured timeout of sending a notification or DefaultIntegrationTimeout if it's not set.
+func (s *IntegrationSlack) GetTimeout() timeseries.Duration {
+	if s.Timeout <= 0 {
+		return DefaultIntegrationTimeout
+	}
+	return s.Timeout
+}
+
+// GetBreakerFailureThreshold returns the configured number of consecutive failures disabling the integration
+// or DefaultBreakerFailureThreshold if it's not set.
+func (s *IntegrationSlack) GetBreakerFailureThreshold() int {
+	if s.BreakerFailureThreshold <= 0 {
+		return DefaultBreakerFailureThreshold
+	}
+	return s.BreakerFailureThreshold
+}
+
+// GetBreakerCooldown returns the configured time the integration is disabled for after failures
+// or DefaultBreakerCooldown if it's not set.
+func (s *IntegrationSlack) GetBreakerCooldown() timeseries.Duration {
+	if s.BreakerCooldown <= 0 {
+		return DefaultBreakerCooldown
+	}
+	return s.BreakerCooldown
+}
+
+const DefaultOTLPExportInterval = timeseries.Minute
+
+// IntegrationOTLP is an OpenTelemetry collector the SLIs, the burn rates and the statuses of the SLO checks
+// of the project's applications are periodically pushed to as gauges (OTLP/HTTP with JSON encoding).
+type IntegrationOTLP struct {
+	// the URL of the metrics endpoint of the collector, e.g., http://otel-collector:4318/v1/metrics
+	Endpoint string `json:"endpoint"`
+	// the headers added to the requests, e.g., Authorization
+	Headers  []IntegrationHeader `json:"headers,omitempty"`
+	Interval timeseries.Duration `json:"interval,omitempty"`
+	Enabled  bool                `json:"enabled"`
+	Timeout  timeseries.Duration `json:"timeout,omitempty"`
+}
+
+type IntegrationHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value" secret:"true"`
+}
+
+// GetInterval returns the configured export interval or DefaultOTLPExportInterval if it's not set.
+// The metrics are exported after the SLO checks, so the actual interval is a multiple of the SLO check interval.
+func (o *IntegrationOTLP) GetInterval() timeseries.Duration {
+	if o.Interval <= 0 {
+		return DefaultOTLPExportInterval
+	}
+	return o.Interval
+}
+
+// GetTimeout returns the configured timeout of an export request or DefaultIntegrationTimeout if it's not set.
+func (o *IntegrationOTLP) GetTimeout() timeseries.Duration {
+	if o.Timeout <= 0 {
+		return DefaultIntegrationTimeout
+	}
+	return o.Timeout
 }
 
 func (db *DB) SaveIntegrationsBaseUrl(id ProjectId, baseUrl string) error {
-	p, err := db.GetProject(id)
+	p, err := db.GetProjectForUpdate(id)
 	if err != nil {
 		return err
 	}
@@ -22,10 +102,34 @@ func (db *DB) SaveIntegrationsBaseUrl(id ProjectId, baseUrl string) error {
 }
 
 func (db *DB) SaveIntegrationsSlack(id ProjectId, slack *IntegrationSlack) error {
-	p, err := db.GetProject(id)
+	p, err := db.GetProjectForUpdate(id)
 	if err != nil {
 		return err
 	}
 	p.Settings.Integrations.Slack = slack
 	return db.saveProjectSettings(p)
 }
+
+func (db *DB) SaveIntegrationsOTLP(id ProjectId, otlp *IntegrationOTLP, actor string) error {
+	p, err := db.GetProjectForUpdate(id)
+	if err != nil {
+		return err
+	}
+	old := p.Settings.Integrations.OTLP
+	p.Settings.Integrations.OTLP = otlp
+	if err := db.saveProjectSettings(p); err != nil {
+		return err
+	}
+	return db.addAuditLogEntry(id, actor, "integration_otlp", old.auditView(), otlp.auditView())
+}
+
+// auditView returns a copy of the integration with the header values replaced with placeholders.
+func (o *IntegrationOTLP) auditView() *IntegrationOTLP {
+	if o == nil {
+		return nil
+	}
+	v := *o
+	v.Headers = append([]IntegrationHeader(nil), o.Headers...)
+	utils.MaskSecretFields(&v)
+	return &v
+}
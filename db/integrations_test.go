@@ -0,0 +1,40 @@
+package db
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSaveIntegrationsOTLPAuditLog(t *testing.T) {
+	db, err := Open(t.TempDir(), "", "")
+	require.NoError(t, err)
+	id, err := db.SaveProject(Project{Name: "test"}, "")
+	require.NoError(t, err)
+
+	cfg := &IntegrationOTLP{
+		Endpoint: "http://otel-collector:4318/v1/metrics",
+		Headers:  []IntegrationHeader{{Name: "Authorization", Value: "Bearer token"}},
+		Enabled:  true,
+	}
+	require.NoError(t, db.SaveIntegrationsOTLP(id, cfg, "10.0.0.1"))
+	assert.Equal(t, "Bearer token", cfg.Headers[0].Value)
+
+	p, err := db.GetProject(id)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer token", p.Settings.Integrations.OTLP.Headers[0].Value)
+
+	entries, err := db.GetAuditLog(id, 10)
+	require.NoError(t, err)
+	var e AuditLogEntry
+	for _, e = range entries {
+		if e.Object == "integration_otlp" {
+			break
+		}
+	}
+	require.Equal(t, "integration_otlp", e.Object)
+	assert.Equal(t, "10.0.0.1", e.Actor)
+	assert.Equal(t, "null", string(e.Old))
+	assert.Contains(t, string(e.New), `"name":"Authorization"`)
+	assert.NotContains(t, string(e.New), "Bearer token")
+}
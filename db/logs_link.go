@@ -0,0 +1,19 @@
+package db
+
+import (
+	"github.com/coroot/coroot/model"
+)
+
+// SaveLogsLink sets the template of the links to the logs of the project's application instances; empty disables them.
+func (db *DB) SaveLogsLink(id ProjectId, template model.LogsLinkTemplate, actor string) error {
+	p, err := db.GetProjectForUpdate(id)
+	if err != nil {
+		return err
+	}
+	old := p.Settings.LogsLink
+	p.Settings.LogsLink = template
+	if err := db.saveProjectSettings(p); err != nil {
+		return err
+	}
+	return db.addAuditLogEntry(id, actor, "logs_link", old, template)
+}
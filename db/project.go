@@ -1,19 +1,24 @@
 package db
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/coroot/coroot/model"
 	"github.com/coroot/coroot/timeseries"
 	"github.com/coroot/coroot/utils"
 	"github.com/lib/pq"
 	"github.com/mattn/go-sqlite3"
 	"strings"
+	"time"
 )
 
 const (
 	DefaultRefreshInterval = 30
+	DefaultQueryTimeout    = 30 * timeseries.Second
 )
 
 type ProjectId string
@@ -21,27 +26,97 @@ type ProjectId string
 type Project struct {
 	Id   ProjectId
 	Name string
+	Tags Tags
 
 	Prometheus Prometheus
 	Settings   Settings
 }
 
 type Prometheus struct {
-	Url             string              `json:"url"`
+	Url             string              `json:"url" secret:"url"`
 	RefreshInterval timeseries.Duration `json:"refresh_interval"`
 	TlsSkipVerify   bool                `json:"tls_skip_verify"`
 	BasicAuth       *BasicAuth          `json:"basic_auth"`
+	ExtraSelector   string              `json:"extra_selector"`
+	QueryTimeout    timeseries.Duration `json:"query_timeout,omitempty"`
+	CacheChunkSize  timeseries.Duration `json:"cache_chunk_size,omitempty"`
+	MaxStaleness    timeseries.Duration `json:"max_staleness,omitempty"`
+	// the label distinguishing the replicas of an HA Prometheus pair, their series are deduplicated by the constructor
+	ReplicaLabel string `json:"replica_label,omitempty"`
+	// PEM-encoded CA certificates verifying the server (the system ones are used if not set)
+	// and the client certificate and key for mutual TLS
+	TlsCA   string `json:"tls_ca,omitempty"`
+	TlsCert string `json:"tls_cert,omitempty"`
+	TlsKey  string `json:"tls_key,omitempty" secret:"true"`
+}
+
+// GetQueryTimeout returns the configured query timeout or DefaultQueryTimeout if it's not set.
+func (p Prometheus) GetQueryTimeout() timeseries.Duration {
+	if p.QueryTimeout <= 0 {
+		return DefaultQueryTimeout
+	}
+	return p.QueryTimeout
+}
+
+// TLSConfig returns the TLS configuration of the connections to Prometheus.
+func (p Prometheus) TLSConfig() (*tls.Config, error) {
+	cfg := &tls.Config{InsecureSkipVerify: p.TlsSkipVerify}
+	if p.TlsCA != "" {
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM([]byte(p.TlsCA)) {
+			return nil, errors.New("invalid CA certificate: no PEM-encoded certificates found")
+		}
+	}
+	if p.TlsCert != "" || p.TlsKey != "" {
+		if p.TlsCert == "" || p.TlsKey == "" {
+			return nil, errors.New("both the client certificate and key are required")
+		}
+		cert, err := tls.X509KeyPair([]byte(p.TlsCert), []byte(p.TlsKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate or key: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
 }
 
 type Settings struct {
-	ConfigurationHintsMuted map[model.ApplicationType]bool         `json:"configuration_hints_muted"`
-	ApplicationCategories   map[model.ApplicationCategory][]string `json:"application_categories"`
-	Integrations            Integrations                           `json:"integrations"`
+	ConfigurationHintsMuted  map[model.ApplicationType]bool                 `json:"configuration_hints_muted"`
+	ApplicationCategories    map[model.ApplicationCategory][]string         `json:"application_categories"`
+	ApplicationCategoryLabel string                                         `json:"application_category_label,omitempty"`
+	Integrations             Integrations                                   `json:"integrations"`
+	Escalation               *EscalationPolicy                              `json:"escalation,omitempty"`
+	Flapping                 *FlappingPolicy                                `json:"flapping,omitempty"`
+	Repeat                   *RepeatPolicy                                  `json:"repeat,omitempty"`
+	GoldenSignals            map[model.ApplicationKind][]model.GoldenSignal `json:"golden_signals,omitempty"`
+	SeverityLabels           model.SeverityLabels                           `json:"severity_labels,omitempty"`
+	ApplicationSnoozes       map[model.ApplicationId]ApplicationSnooze      `json:"application_snoozes,omitempty"`
+	MetricThresholds         model.MetricThresholds                         `json:"metric_thresholds,omitempty"`
+	ApplicationIdentity      *model.ApplicationIdentity                     `json:"application_identity,omitempty"`
+	ApplicationExclusions    *model.ApplicationExclusions                   `json:"application_exclusions,omitempty"`
+	ApplicationTiers         *model.ApplicationTiers                        `json:"application_tiers,omitempty"`
+	SLODefaults              *SLODefaults                                   `json:"slo_defaults,omitempty"`
+	HealthRollup             *model.HealthRollup                            `json:"health_rollup,omitempty"`
+	LogsLink                 model.LogsLinkTemplate                         `json:"logs_link,omitempty"`
+	SavedViews               []SavedView                                    `json:"saved_views,omitempty"`
+}
+
+type Tags map[string]string
+
+// Match reports whether the project has the given tag.
+// The filter is either "key" (any value) or "key:value".
+func (t Tags) Match(filter string) bool {
+	key, value, withValue := strings.Cut(filter, ":")
+	v, ok := t[key]
+	if !ok {
+		return false
+	}
+	return !withValue || v == value
 }
 
 type BasicAuth struct {
 	User     string `json:"user"`
-	Password string `json:"password"`
+	Password string `json:"password" secret:"true"`
 }
 
 func (p *Project) Migrate(m *Migrator) error {
@@ -57,21 +132,24 @@ func (p *Project) Migrate(m *Migrator) error {
 	if err := m.AddColumnIfNotExists("project", "settings", "text"); err != nil {
 		return err
 	}
+	if err := m.AddColumnIfNotExists("project", "tags", "text"); err != nil {
+		return err
+	}
 	return nil
 }
 
 func (db *DB) GetProjects() ([]*Project, error) {
-	rows, err := db.db.Query("SELECT id, name, prometheus, settings FROM project")
+	rows, err := db.db.Query("SELECT id, name, prometheus, settings, tags FROM project")
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	var res []*Project
 	var prometheus string
-	var settings sql.NullString
+	var settings, tags sql.NullString
 	for rows.Next() {
 		var p Project
-		if err := rows.Scan(&p.Id, &p.Name, &prometheus, &settings); err != nil {
+		if err := rows.Scan(&p.Id, &p.Name, &prometheus, &settings, &tags); err != nil {
 			return nil, err
 		}
 		if err := json.Unmarshal([]byte(prometheus), &p.Prometheus); err != nil {
@@ -85,6 +163,11 @@ func (db *DB) GetProjects() ([]*Project, error) {
 				return nil, err
 			}
 		}
+		if tags.Valid {
+			if err := json.Unmarshal([]byte(tags.String), &p.Tags); err != nil {
+				return nil, err
+			}
+		}
 		res = append(res, &p)
 	}
 	return res, nil
@@ -108,31 +191,83 @@ func (db *DB) GetProjectNames() (map[ProjectId]string, error) {
 	return res, nil
 }
 
+// GetProject returns the project, possibly from the read replica or cached for projectCacheTTL.
+// Use GetProjectForUpdate to read a project before changing it.
 func (db *DB) GetProject(id ProjectId) (*Project, error) {
-	p := Project{Id: id}
-	var prometheus string
-	var settings sql.NullString
-	if err := db.db.QueryRow("SELECT name, prometheus, settings FROM project WHERE id = $1", id).Scan(&p.Name, &prometheus, &settings); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, ErrNotFound
+	now := time.Now()
+	row, ok := db.projects.get(id, now)
+	if !ok {
+		var err error
+		if row, err = getProjectRow(db.reader(), id); err != nil {
+			return nil, err
 		}
+		db.projects.set(id, row, now)
+	}
+	return row.decode(id)
+}
+
+// GetProjectForUpdate reads the project from the primary bypassing the cache.
+// Use it for the reads preceding writes, e.g., to restore the masked secrets of a submitted form.
+func (db *DB) GetProjectForUpdate(id ProjectId) (*Project, error) {
+	row, err := getProjectRow(db.db, id)
+	if err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal([]byte(prometheus), &p.Prometheus); err != nil {
+	return row.decode(id)
+}
+
+// refreshProject caches the project row read from the primary right after a write,
+// so that the following reads see the change even if the replica lags behind.
+func (db *DB) refreshProject(id ProjectId) {
+	now := time.Now()
+	row, err := getProjectRow(db.db, id)
+	if err != nil {
+		db.projects.invalidate(id)
+		return
+	}
+	db.projects.set(id, row, now)
+}
+
+func getProjectRow(q queryer, id ProjectId) (projectRow, error) {
+	var row projectRow
+	var settings, tags sql.NullString
+	if err := q.QueryRow("SELECT name, prometheus, settings, tags FROM project WHERE id = $1", id).Scan(&row.name, &row.prometheus, &settings, &tags); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return row, ErrNotFound
+		}
+		return row, err
+	}
+	if settings.Valid {
+		row.settings = &settings.String
+	}
+	if tags.Valid {
+		row.tags = &tags.String
+	}
+	return row, nil
+}
+
+func (row projectRow) decode(id ProjectId) (*Project, error) {
+	p := Project{Id: id, Name: row.name}
+	if err := json.Unmarshal([]byte(row.prometheus), &p.Prometheus); err != nil {
 		return nil, err
 	}
 	if p.Prometheus.RefreshInterval == 0 {
 		p.Prometheus.RefreshInterval = DefaultRefreshInterval
 	}
-	if settings.Valid {
-		if err := json.Unmarshal([]byte(settings.String), &p.Settings); err != nil {
+	if row.settings != nil {
+		if err := json.Unmarshal([]byte(*row.settings), &p.Settings); err != nil {
+			return nil, err
+		}
+	}
+	if row.tags != nil {
+		if err := json.Unmarshal([]byte(*row.tags), &p.Tags); err != nil {
 			return nil, err
 		}
 	}
 	return &p, nil
 }
 
-func (db *DB) SaveProject(p Project) (ProjectId, error) {
+func (db *DB) SaveProject(p Project, actor string) (ProjectId, error) {
 	if p.Prometheus.RefreshInterval == 0 {
 		p.Prometheus.RefreshInterval = DefaultRefreshInterval
 	}
@@ -140,21 +275,52 @@ func (db *DB) SaveProject(p Project) (ProjectId, error) {
 	if err != nil {
 		return "", err
 	}
+	tags, err := json.Marshal(p.Tags)
+	if err != nil {
+		return "", err
+	}
 	if p.Id == "" {
 		p.Id = ProjectId(utils.NanoId(8))
-		_, err := db.db.Exec("INSERT INTO project (id, name, prometheus) VALUES ($1, $2, $3)", p.Id, p.Name, string(prometheus))
+		_, err := db.db.Exec("INSERT INTO project (id, name, prometheus, tags) VALUES ($1, $2, $3, $4)", p.Id, p.Name, string(prometheus), string(tags))
 		if e, ok := err.(sqlite3.Error); ok && e.Code == sqlite3.ErrConstraint {
 			return "", ErrConflict
 		}
 		if e, ok := err.(*pq.Error); ok && e.Code.Name() == "unique_violation" {
 			return "", ErrConflict
 		}
-		return p.Id, err
+		if err != nil {
+			return "", err
+		}
+		return p.Id, db.addAuditLogEntry(p.Id, actor, "project", nil, p.auditView())
+	}
+	old, err := db.GetProjectForUpdate(p.Id)
+	if err != nil {
+		return "", err
 	}
-	if _, err := db.db.Exec("UPDATE project SET name = $1, prometheus = $2 WHERE id = $3", p.Name, string(prometheus), p.Id); err != nil {
+	if _, err := db.db.Exec("UPDATE project SET name = $1, prometheus = $2, tags = $3 WHERE id = $4", p.Name, string(prometheus), string(tags), p.Id); err != nil {
+		db.projects.invalidate(p.Id)
 		return "", err
 	}
-	return p.Id, err
+	db.refreshProject(p.Id)
+	return p.Id, db.addAuditLogEntry(p.Id, actor, "project", old.auditView(), p.auditView())
+}
+
+// ProjectAuditView is the project's configuration as it is recorded in the audit log.
+type ProjectAuditView struct {
+	Name       string     `json:"name"`
+	Tags       Tags       `json:"tags"`
+	Prometheus Prometheus `json:"prometheus"`
+}
+
+// auditView returns the project's configuration with the secrets replaced with placeholders.
+func (p *Project) auditView() ProjectAuditView {
+	v := ProjectAuditView{Name: p.Name, Tags: p.Tags, Prometheus: p.Prometheus}
+	if v.Prometheus.BasicAuth != nil {
+		basicAuth := *v.Prometheus.BasicAuth
+		v.Prometheus.BasicAuth = &basicAuth
+	}
+	utils.MaskSecretFields(&v)
+	return v
 }
 
 func (db *DB) DeleteProject(id ProjectId) error {
@@ -166,17 +332,27 @@ func (db *DB) DeleteProject(id ProjectId) error {
 	if _, err := tx.Exec("DELETE FROM check_configs WHERE project_id = $1", id); err != nil {
 		return err
 	}
+	if _, err := tx.Exec("DELETE FROM incident_severity WHERE project_id = $1", id); err != nil {
+		return err
+	}
 	if _, err := tx.Exec("DELETE FROM incident WHERE project_id = $1", id); err != nil {
 		return err
 	}
+	if _, err := tx.Exec("DELETE FROM deployment WHERE project_id = $1", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM audit_log WHERE project_id = $1", id); err != nil {
+		return err
+	}
 	if _, err := tx.Exec("DELETE FROM project WHERE id = $1", id); err != nil {
 		return err
 	}
+	defer db.projects.invalidate(id)
 	return tx.Commit()
 }
 
 func (db *DB) ToggleConfigurationHint(id ProjectId, appType model.ApplicationType, mute bool) error {
-	p, err := db.GetProject(id)
+	p, err := db.GetProjectForUpdate(id)
 	if err != nil {
 		return err
 	}
@@ -191,11 +367,21 @@ func (db *DB) ToggleConfigurationHint(id ProjectId, appType model.ApplicationTyp
 	return db.saveProjectSettings(p)
 }
 
-func (db *DB) SaveApplicationCategory(id ProjectId, name, newName model.ApplicationCategory, customPatterns []string) error {
-	p, err := db.GetProject(id)
+func (db *DB) SaveApplicationCategory(id ProjectId, name, newName model.ApplicationCategory, customPatterns []string, actor string) error {
+	p, err := db.GetProjectForUpdate(id)
+	if err != nil {
+		return err
+	}
+	old, err := json.Marshal(p.Settings.ApplicationCategories)
 	if err != nil {
 		return err
 	}
+	save := func() error {
+		if err := db.saveProjectSettings(p); err != nil {
+			return err
+		}
+		return db.addAuditLogEntry(id, actor, "application_categories", json.RawMessage(old), p.Settings.ApplicationCategories)
+	}
 
 	var ps []string
 	for _, p := range customPatterns {
@@ -211,7 +397,7 @@ func (db *DB) SaveApplicationCategory(id ProjectId, name, newName model.Applicat
 			return nil
 		}
 		delete(p.Settings.ApplicationCategories, name)
-		return db.saveProjectSettings(p)
+		return save()
 	}
 
 	if p.Settings.ApplicationCategories == nil {
@@ -222,7 +408,138 @@ func (db *DB) SaveApplicationCategory(id ProjectId, name, newName model.Applicat
 		name = newName
 	}
 	p.Settings.ApplicationCategories[name] = ps
-	return db.saveProjectSettings(p)
+	return save()
+}
+
+func (db *DB) SaveApplicationCategoryLabel(id ProjectId, label string, actor string) error {
+	p, err := db.GetProjectForUpdate(id)
+	if err != nil {
+		return err
+	}
+	old := p.Settings.ApplicationCategoryLabel
+	p.Settings.ApplicationCategoryLabel = label
+	if err := db.saveProjectSettings(p); err != nil {
+		return err
+	}
+	return db.addAuditLogEntry(id, actor, "application_category_label", old, label)
+}
+
+// SaveGoldenSignals overrides the golden signals of the application kind; an empty list restores the built-in ones.
+func (db *DB) SaveGoldenSignals(id ProjectId, kind model.ApplicationKind, signals []model.GoldenSignal, actor string) error {
+	p, err := db.GetProjectForUpdate(id)
+	if err != nil {
+		return err
+	}
+	old := p.Settings.GoldenSignals[kind]
+	if len(signals) == 0 {
+		delete(p.Settings.GoldenSignals, kind)
+	} else {
+		if p.Settings.GoldenSignals == nil {
+			p.Settings.GoldenSignals = map[model.ApplicationKind][]model.GoldenSignal{}
+		}
+		p.Settings.GoldenSignals[kind] = signals
+	}
+	if err := db.saveProjectSettings(p); err != nil {
+		return err
+	}
+	return db.addAuditLogEntry(id, actor, "golden_signals:"+string(kind), old, signals)
+}
+
+func (db *DB) SaveSeverityLabels(id ProjectId, labels model.SeverityLabels, actor string) error {
+	p, err := db.GetProjectForUpdate(id)
+	if err != nil {
+		return err
+	}
+	old := p.Settings.SeverityLabels
+	if len(labels) == 0 {
+		labels = nil
+	}
+	p.Settings.SeverityLabels = labels
+	if err := db.saveProjectSettings(p); err != nil {
+		return err
+	}
+	return db.addAuditLogEntry(id, actor, "severity_labels", old, labels)
+}
+
+// SaveMetricThresholds overrides the thresholds of the given metrics; an empty map restores the built-in ones.
+func (db *DB) SaveMetricThresholds(id ProjectId, thresholds model.MetricThresholds, actor string) error {
+	p, err := db.GetProjectForUpdate(id)
+	if err != nil {
+		return err
+	}
+	old := p.Settings.MetricThresholds
+	if len(thresholds) == 0 {
+		thresholds = nil
+	}
+	p.Settings.MetricThresholds = thresholds
+	if err := db.saveProjectSettings(p); err != nil {
+		return err
+	}
+	return db.addAuditLogEntry(id, actor, "metric_thresholds", old, thresholds)
+}
+
+// SaveApplicationIdentity changes the labels composing the application ids; an empty identity restores the default ids.
+func (db *DB) SaveApplicationIdentity(id ProjectId, identity *model.ApplicationIdentity, actor string) error {
+	p, err := db.GetProjectForUpdate(id)
+	if err != nil {
+		return err
+	}
+	old := p.Settings.ApplicationIdentity
+	if identity.IsEmpty() {
+		identity = nil
+	}
+	p.Settings.ApplicationIdentity = identity
+	if err := db.saveProjectSettings(p); err != nil {
+		return err
+	}
+	return db.addAuditLogEntry(id, actor, "application_identity", old, identity)
+}
+
+// SaveApplicationExclusions changes the patterns of the applications excluded from the world; empty ones exclude nothing.
+func (db *DB) SaveApplicationExclusions(id ProjectId, exclusions *model.ApplicationExclusions, actor string) error {
+	p, err := db.GetProjectForUpdate(id)
+	if err != nil {
+		return err
+	}
+	old := p.Settings.ApplicationExclusions
+	if exclusions.IsEmpty() {
+		exclusions = nil
+	}
+	p.Settings.ApplicationExclusions = exclusions
+	if err := db.saveProjectSettings(p); err != nil {
+		return err
+	}
+	return db.addAuditLogEntry(id, actor, "application_exclusions", old, exclusions)
+}
+
+func (db *DB) SaveApplicationTiers(id ProjectId, tiers *model.ApplicationTiers, actor string) error {
+	p, err := db.GetProjectForUpdate(id)
+	if err != nil {
+		return err
+	}
+	old := p.Settings.ApplicationTiers
+	if tiers.IsEmpty() {
+		tiers = nil
+	}
+	p.Settings.ApplicationTiers = tiers
+	if err := db.saveProjectSettings(p); err != nil {
+		return err
+	}
+	return db.addAuditLogEntry(id, actor, "application_tiers", old, tiers)
+}
+
+// SaveHealthRollup sets the function combining the check statuses into application statuses; nil restores the default.
+func (db *DB) SaveHealthRollup(id ProjectId, rollup *model.HealthRollup, actor string) error {
+	p, err := db.GetProjectForUpdate(id)
+	if err != nil {
+		return err
+	}
+	old := p.Settings.HealthRollup
+	p.Settings.HealthRollup = rollup
+	if err := db.saveProjectSettings(p); err != nil {
+		return err
+	}
+	return db.addAuditLogEntry(id, actor, "health_rollup", old, rollup)
 }
 
 func (db *DB) saveProjectSettings(p *Project) error {
@@ -230,6 +547,10 @@ func (db *DB) saveProjectSettings(p *Project) error {
 	if err != nil {
 		return err
 	}
-	_, err = db.db.Exec("UPDATE project SET settings = $1 WHERE id = $2", settings, p.Id)
-	return err
+	if _, err = db.db.Exec("UPDATE project SET settings = $1 WHERE id = $2", settings, p.Id); err != nil {
+		db.projects.invalidate(p.Id)
+		return err
+	}
+	db.refreshProject(p.Id)
+	return nil
 }
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"sync"
+	"time"
+)
+
+// projectCacheTTL is how long a project read is reused. The project is read on nearly every request,
+// often several times, while it rarely changes, and the changes made through this instance invalidate the cache anyway.
+const projectCacheTTL = 5 * time.Second
+
+type projectRow struct {
+	name       string
+	prometheus string
+	settings   *string
+	tags       *string
+}
+
+type projectCacheEntry struct {
+	row      projectRow
+	cachedAt time.Time
+}
+
+// projectCache holds the raw project rows rather than the decoded projects,
+// so that every caller gets its own copy it can modify.
+type projectCache struct {
+	ttl     time.Duration
+	lock    sync.Mutex
+	entries map[ProjectId]projectCacheEntry
+}
+
+func newProjectCache(ttl time.Duration) *projectCache {
+	return &projectCache{ttl: ttl, entries: map[ProjectId]projectCacheEntry{}}
+}
+
+func (c *projectCache) get(id ProjectId, now time.Time) (projectRow, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	e, ok := c.entries[id]
+	if !ok || now.Sub(e.cachedAt) >= c.ttl {
+		delete(c.entries, id)
+		return projectRow{}, false
+	}
+	return e.row, true
+}
+
+// set caches the row read at the given time. A row read before the cached one is ignored,
+// e.g., a replica read started before a write and completed after the written row has been cached.
+func (c *projectCache) set(id ProjectId, row projectRow, now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, ok := c.entries[id]; ok && e.cachedAt.After(now) {
+		return
+	}
+	c.entries[id] = projectCacheEntry{row: row, cachedAt: now}
+}
+
+func (c *projectCache) invalidate(id ProjectId) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.entries, id)
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestProjectCache(t *testing.T) {
+	c := newProjectCache(time.Second)
+	now := time.Now()
+	c.set("p1", projectRow{name: "test"}, now)
+	row, ok := c.get("p1", now.Add(500*time.Millisecond))
+	assert.True(t, ok)
+	assert.Equal(t, "test", row.name)
+	_, ok = c.get("p1", now.Add(time.Second))
+	assert.False(t, ok)
+
+	c.set("p1", projectRow{name: "test"}, now)
+	c.invalidate("p1")
+	_, ok = c.get("p1", now)
+	assert.False(t, ok)
+}
+
+func TestGetProjectIsConsistentAfterWrites(t *testing.T) {
+	db, err := Open(t.TempDir(), "", "")
+	require.NoError(t, err)
+	id, err := db.SaveProject(Project{Name: "test"}, "")
+	require.NoError(t, err)
+
+	p, err := db.GetProject(id)
+	require.NoError(t, err)
+
+	// the callers get their own copies
+	p.Name = "changed"
+	p, err = db.GetProject(id)
+	require.NoError(t, err)
+	assert.Equal(t, "test", p.Name)
+
+	p.Name = "renamed"
+	_, err = db.SaveProject(*p, "")
+	require.NoError(t, err)
+	p, err = db.GetProject(id)
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", p.Name)
+
+	require.NoError(t, db.SaveLogsLink(id, "https://logs/{{.App}}", ""))
+	p, err = db.GetProject(id)
+	require.NoError(t, err)
+	assert.Equal(t, "https://logs/{{.App}}", string(p.Settings.LogsLink))
+
+	require.NoError(t, db.DeleteProject(id))
+	_, err = db.GetProject(id)
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestGetProjectWithLaggingReplica(t *testing.T) {
+	db, err := Open(t.TempDir(), "", "")
+	require.NoError(t, err)
+	id, err := db.SaveProject(Project{Name: "test"}, "")
+	require.NoError(t, err)
+
+	// the replica has only the initial version of the project
+	replica, err := sqlite(path.Join(t.TempDir(), "replica.sqlite"))
+	require.NoError(t, err)
+	require.NoError(t, NewMigrator(TypeSqlite, replica).Migrate(&Project{}))
+	_, err = replica.Exec("INSERT INTO project (id, name, prometheus) VALUES ($1, $2, $3)", id, "test", "{}")
+	require.NoError(t, err)
+	db.replica = replica
+
+	p, err := db.GetProject(id)
+	require.NoError(t, err)
+	p.Name = "renamed"
+	_, err = db.SaveProject(*p, "")
+	require.NoError(t, err)
+	require.NoError(t, db.SaveLogsLink(id, "https://logs/{{.App}}", ""))
+
+	p, err = db.GetProject(id)
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", p.Name)
+	assert.Equal(t, "https://logs/{{.App}}", string(p.Settings.LogsLink))
+
+	// a replica read started before the write doesn't replace the written row
+	db.projects.set(id, projectRow{name: "test", prometheus: "{}"}, time.Now().Add(-time.Second))
+	p, err = db.GetProject(id)
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", p.Name)
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+const quietHoursLayout = "15:04"
+
+// QuietHours is a daily time window during which WARNING notifications are suppressed.
+// The window may span midnight, e.g., 22:00-07:00.
+type QuietHours struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone"`
+}
+
+func (qh *QuietHours) Validate() error {
+	if _, err := time.Parse(quietHoursLayout, qh.Start); err != nil {
+		return fmt.Errorf("invalid start time: %s", qh.Start)
+	}
+	if _, err := time.Parse(quietHoursLayout, qh.End); err != nil {
+		return fmt.Errorf("invalid end time: %s", qh.End)
+	}
+	if _, err := time.LoadLocation(qh.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone: %s", qh.Timezone)
+	}
+	return nil
+}
+
+// IsActive reports whether the given moment falls within the quiet hours in the configured timezone.
+func (qh *QuietHours) IsActive(now time.Time) bool {
+	if qh == nil {
+		return false
+	}
+	loc, err := time.LoadLocation(qh.Timezone)
+	if err != nil {
+		return false
+	}
+	start, err1 := time.Parse(quietHoursLayout, qh.Start)
+	end, err2 := time.Parse(quietHoursLayout, qh.End)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	now = now.In(loc)
+	t := now.Hour()*60 + now.Minute()
+	s := start.Hour()*60 + start.Minute()
+	e := end.Hour()*60 + end.Minute()
+	switch {
+	case s == e:
+		return false
+	case s < e:
+		return t >= s && t < e
+	default:
+		return t >= s || t < e
+	}
+}
+
+func (db *DB) SaveQuietHours(id ProjectId, qh *QuietHours, actor string) error {
+	p, err := db.GetProjectForUpdate(id)
+	if err != nil {
+		return err
+	}
+	old := p.Settings.Integrations.QuietHours
+	p.Settings.Integrations.QuietHours = qh
+	if err := db.saveProjectSettings(p); err != nil {
+		return err
+	}
+	return db.addAuditLogEntry(id, actor, "quiet_hours", old, qh)
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestQuietHoursIsActive(t *testing.T) {
+	at := func(s string) time.Time {
+		res, err := time.Parse(time.RFC3339, s)
+		assert.NoError(t, err)
+		return res
+	}
+	var qh *QuietHours
+	assert.False(t, qh.IsActive(at("2022-11-09T23:30:00Z")))
+
+	qh = &QuietHours{Start: "22:00", End: "07:00", Timezone: "UTC"}
+	assert.NoError(t, qh.Validate())
+	assert.True(t, qh.IsActive(at("2022-11-09T22:00:00Z")))
+	assert.True(t, qh.IsActive(at("2022-11-10T06:59:00Z")))
+	assert.False(t, qh.IsActive(at("2022-11-10T07:00:00Z")))
+	assert.False(t, qh.IsActive(at("2022-11-09T12:00:00Z")))
+
+	qh = &QuietHours{Start: "12:00", End: "13:00", Timezone: "Europe/Berlin"}
+	assert.True(t, qh.IsActive(at("2022-11-09T11:30:00Z")))
+	assert.False(t, qh.IsActive(at("2022-11-09T12:30:00Z")))
+
+	qh = &QuietHours{Start: "10:00", End: "10:00", Timezone: "UTC"}
+	assert.False(t, qh.IsActive(at("2022-11-09T10:00:00Z")))
+
+	assert.Error(t, (&QuietHours{Start: "25:00", End: "07:00", Timezone: "UTC"}).Validate())
+	assert.Error(t, (&QuietHours{Start: "22:00", End: "07:00", Timezone: "Mars/Olympus"}).Validate())
+}
+
+func TestSaveQuietHoursAuditLog(t *testing.T) {
+	db, err := Open(t.TempDir(), "", "")
+	require.NoError(t, err)
+	id, err := db.SaveProject(Project{Name: "test"}, "")
+	require.NoError(t, err)
+
+	require.NoError(t, db.SaveQuietHours(id, &QuietHours{Start: "22:00", End: "07:00", Timezone: "UTC"}, "10.0.0.1"))
+	require.NoError(t, db.SaveQuietHours(id, nil, "10.0.0.2"))
+
+	entries, err := db.GetAuditLog(id, 10)
+	require.NoError(t, err)
+	byActor := map[string]AuditLogEntry{}
+	for _, e := range entries {
+		if e.Object == "quiet_hours" {
+			byActor[e.Actor] = e
+		}
+	}
+	require.Len(t, byActor, 2)
+	assert.Equal(t, "null", string(byActor["10.0.0.1"].Old))
+	assert.JSONEq(t, `{"start":"22:00","end":"07:00","timezone":"UTC"}`, string(byActor["10.0.0.1"].New))
+	assert.JSONEq(t, `{"start":"22:00","end":"07:00","timezone":"UTC"}`, string(byActor["10.0.0.2"].Old))
+	assert.Equal(t, "null", string(byActor["10.0.0.2"].New))
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+)
+
+type RepeatPolicy struct {
+	Interval timeseries.Duration `json:"interval"`
+}
+
+// IsDue reports whether a reminder about the open CRITICAL incident should be sent:
+// the last notification was sent at least the interval ago, and the incident is neither acknowledged nor snoozed.
+func (p *RepeatPolicy) IsDue(i *Incident, now timeseries.Time) bool {
+	if p == nil || p.Interval <= 0 {
+		return false
+	}
+	if !i.ResolvedAt.IsZero() || i.Severity != model.CRITICAL || i.SentAt.IsZero() {
+		return false
+	}
+	if i.IsAcknowledged() || i.IsSnoozed(now) {
+		return false
+	}
+	return now.Sub(i.SentAt) >= p.Interval
+}
+
+func (db *DB) SaveRepeatPolicy(id ProjectId, policy *RepeatPolicy, actor string) error {
+	p, err := db.GetProjectForUpdate(id)
+	if err != nil {
+		return err
+	}
+	old := p.Settings.Repeat
+	p.Settings.Repeat = policy
+	if err := db.saveProjectSettings(p); err != nil {
+		return err
+	}
+	return db.addAuditLogEntry(id, actor, "repeat_policy", old, policy)
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestIsDue(t *testing.T) {
+	now := timeseries.Time(1668000000)
+	p := &RepeatPolicy{Interval: timeseries.Hour}
+	i := &Incident{OpenedAt: now.Add(-2 * timeseries.Hour), Severity: model.CRITICAL, SentAt: now.Add(-timeseries.Hour)}
+
+	var nilPolicy *RepeatPolicy
+	assert.False(t, nilPolicy.IsDue(i, now))
+	assert.False(t, (&RepeatPolicy{}).IsDue(i, now))
+	assert.True(t, p.IsDue(i, now))
+	assert.False(t, p.IsDue(i, now.Add(-timeseries.Second)))
+
+	i.Severity = model.WARNING
+	assert.False(t, p.IsDue(i, now))
+	i.Severity = model.CRITICAL
+
+	i.AcknowledgedAt = now.Add(-timeseries.Minute)
+	assert.False(t, p.IsDue(i, now))
+	i.AcknowledgedAt = 0
+	i.SnoozedUntil = now.Add(timeseries.Minute)
+	assert.False(t, p.IsDue(i, now))
+	i.SnoozedUntil = 0
+
+	i.ResolvedAt = now
+	assert.False(t, p.IsDue(i, now))
+	i.ResolvedAt = 0
+
+	i.SentAt = 0
+	assert.False(t, p.IsDue(i, now))
+}
+
+func TestCreateOrUpdateIncidentRepeat(t *testing.T) {
+	db, err := Open(t.TempDir(), "", "")
+	require.NoError(t, err)
+	projectId, err := db.SaveProject(Project{Name: "test"}, "")
+	require.NoError(t, err)
+	appId := model.NewApplicationId("default", model.ApplicationKindDeployment, "app")
+	repeat := &RepeatPolicy{Interval: timeseries.Hour}
+	update := func(now timeseries.Time, severity model.Status) *Incident {
+		i, err := db.CreateOrUpdateIncident(projectId, appId, now, severity, nil, nil, repeat)
+		require.NoError(t, err)
+		return i
+	}
+
+	i := update(100, model.CRITICAL)
+	require.NotNil(t, i)
+	assert.False(t, i.Reminder)
+	require.NoError(t, db.MarkIncidentAsSent(projectId, appId, i, 100))
+
+	assert.Nil(t, update(timeseries.Time(100).Add(timeseries.Hour-timeseries.Second), model.CRITICAL))
+	due := timeseries.Time(100).Add(timeseries.Hour)
+	r := update(due, model.CRITICAL)
+	require.NotNil(t, r)
+	assert.True(t, r.Reminder)
+	assert.Equal(t, i.Key, r.Key)
+
+	// the interval is counted from the last notification
+	require.NoError(t, db.MarkIncidentAsSent(projectId, appId, r, due))
+	assert.Nil(t, update(due.Add(timeseries.Minute), model.CRITICAL))
+
+	require.NoError(t, db.AcknowledgeIncident(projectId, i.Key, due.Add(timeseries.Minute)))
+	assert.Nil(t, update(due.Add(timeseries.Hour), model.CRITICAL))
+}
+
+func TestSaveRepeatPolicyAuditLog(t *testing.T) {
+	db, err := Open(t.TempDir(), "", "")
+	require.NoError(t, err)
+	id, err := db.SaveProject(Project{Name: "test"}, "")
+	require.NoError(t, err)
+
+	require.NoError(t, db.SaveRepeatPolicy(id, &RepeatPolicy{Interval: 4 * timeseries.Hour}, "10.0.0.1"))
+
+	entries, err := db.GetAuditLog(id, 10)
+	require.NoError(t, err)
+	var e AuditLogEntry
+	for _, e = range entries {
+		if e.Object == "repeat_policy" {
+			break
+		}
+	}
+	require.Equal(t, "repeat_policy", e.Object)
+	assert.Equal(t, "10.0.0.1", e.Actor)
+	assert.Equal(t, "null", string(e.Old))
+	assert.JSONEq(t, `{"interval":14400000}`, string(e.New))
+}
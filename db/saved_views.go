@@ -0,0 +1,72 @@
+package db
+
+import "sort"
+
+// SavedView is a named set of the query parameters of a page (filters, groupings, the time range, etc.)
+// shared among the users of the project.
+type SavedView struct {
+	Name string `json:"name"`
+	// the page the view is applied to, e.g., overview or application
+	Scope string            `json:"scope"`
+	Query map[string]string `json:"query"`
+}
+
+// GetSavedViews returns the views of the scope (all of them if the scope is empty) sorted by scope and name.
+func (s *Settings) GetSavedViews(scope string) []SavedView {
+	res := make([]SavedView, 0, len(s.SavedViews))
+	for _, v := range s.SavedViews {
+		if scope == "" || v.Scope == scope {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// SaveView adds the view to the project replacing the existing one with the same scope and name.
+func (db *DB) SaveView(id ProjectId, view SavedView, actor string) error {
+	p, err := db.GetProjectForUpdate(id)
+	if err != nil {
+		return err
+	}
+	old := p.Settings.SavedViews
+	res := []SavedView{view}
+	for _, v := range old {
+		if v.Scope != view.Scope || v.Name != view.Name {
+			res = append(res, v)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Scope != res[j].Scope {
+			return res[i].Scope < res[j].Scope
+		}
+		return res[i].Name < res[j].Name
+	})
+	p.Settings.SavedViews = res
+	if err := db.saveProjectSettings(p); err != nil {
+		return err
+	}
+	return db.addAuditLogEntry(id, actor, "saved_views", old, res)
+}
+
+// DeleteView removes the view with the given scope and name from the project, ErrNotFound if there is no such view.
+func (db *DB) DeleteView(id ProjectId, scope, name string, actor string) error {
+	p, err := db.GetProjectForUpdate(id)
+	if err != nil {
+		return err
+	}
+	old := p.Settings.SavedViews
+	var res []SavedView
+	for _, v := range old {
+		if v.Scope != scope || v.Name != name {
+			res = append(res, v)
+		}
+	}
+	if len(res) == len(old) {
+		return ErrNotFound
+	}
+	p.Settings.SavedViews = res
+	if err := db.saveProjectSettings(p); err != nil {
+		return err
+	}
+	return db.addAuditLogEntry(id, actor, "saved_views", old, res)
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"github.com/coroot/coroot/model"
+)
+
+// SLOObjectiveSource is where the objective an SLO check inherits comes from.
+type SLOObjectiveSource string
+
+const (
+	SLOObjectiveSourceCategory SLOObjectiveSource = "category"
+	SLOObjectiveSourceProject  SLOObjectiveSource = "project"
+	SLOObjectiveSourceBuiltin  SLOObjectiveSource = "builtin"
+)
+
+type SLOObjectives struct {
+	AvailabilityObjective float64 `json:"availability_objective,omitempty"`
+	LatencyObjective      float64 `json:"latency_objective,omitempty"`
+}
+
+func (o SLOObjectives) get(checkId model.CheckId) float64 {
+	switch checkId {
+	case model.Checks.SLOAvailability.Id:
+		return o.AvailabilityObjective
+	case model.Checks.SLOLatency.Id:
+		return o.LatencyObjective
+	}
+	return 0
+}
+
+// SLODefaults are the objectives inherited by the SLO checks of the project's applications that don't define their own
+// (the objective of their config is zero). The objectives of a category take precedence over the project-wide ones,
+// zero means the next level: the project-wide objective or the built-in default objective of the check.
+type SLODefaults struct {
+	AvailabilityObjective float64                                     `json:"availability_objective,omitempty"`
+	LatencyObjective      float64                                     `json:"latency_objective,omitempty"`
+	Categories            map[model.ApplicationCategory]SLOObjectives `json:"categories,omitempty"`
+}
+
+// Objective returns the objective inherited by the SLO check of an application of the category along with its source.
+func (d *SLODefaults) Objective(checkId model.CheckId, category model.ApplicationCategory) (float64, SLOObjectiveSource) {
+	if d != nil {
+		if v := d.Categories[category].get(checkId); v > 0 {
+			return v, SLOObjectiveSourceCategory
+		}
+		if v := (SLOObjectives{AvailabilityObjective: d.AvailabilityObjective, LatencyObjective: d.LatencyObjective}).get(checkId); v > 0 {
+			return v, SLOObjectiveSourceProject
+		}
+	}
+	switch checkId {
+	case model.Checks.SLOAvailability.Id:
+		return model.Checks.SLOAvailability.DefaultThreshold, SLOObjectiveSourceBuiltin
+	case model.Checks.SLOLatency.Id:
+		return model.Checks.SLOLatency.DefaultThreshold, SLOObjectiveSourceBuiltin
+	}
+	return 0, ""
+}
+
+// ApplySLOObjectives sets the inherited objectives of the SLIs of the world's applications having no objective of their own.
+func (p *Project) ApplySLOObjectives(w *model.World) {
+	for _, app := range w.Applications {
+		var category *model.ApplicationCategory
+		objective := func(checkId model.CheckId) float64 {
+			if category == nil {
+				c := model.CalcApplicationCategory(app, p.Settings.ApplicationCategories, p.Settings.ApplicationCategoryLabel)
+				category = &c
+			}
+			v, _ := p.Settings.SLODefaults.Objective(checkId, *category)
+			return v
+		}
+		for _, sli := range app.AvailabilitySLIs {
+			if sli.Config.ObjectivePercentage <= 0 {
+				sli.Config.ObjectivePercentage = objective(model.Checks.SLOAvailability.Id)
+			}
+		}
+		for _, sli := range app.LatencySLIs {
+			if sli.Config.ObjectivePercentage <= 0 {
+				sli.Config.ObjectivePercentage = objective(model.Checks.SLOLatency.Id)
+			}
+		}
+	}
+}
+
+// SaveSLODefaults overrides the default SLO objectives of the project; nil restores the built-in ones.
+func (db *DB) SaveSLODefaults(id ProjectId, defaults *SLODefaults, actor string) error {
+	p, err := db.GetProjectForUpdate(id)
+	if err != nil {
+		return err
+	}
+	old := p.Settings.SLODefaults
+	p.Settings.SLODefaults = defaults
+	if err := db.saveProjectSettings(p); err != nil {
+		return err
+	}
+	return db.addAuditLogEntry(id, actor, "slo_defaults", old, defaults)
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSLODefaultsObjective(t *testing.T) {
+	availability, latency := model.Checks.SLOAvailability.Id, model.Checks.SLOLatency.Id
+
+	var d *SLODefaults
+	v, source := d.Objective(availability, model.ApplicationCategoryApplication)
+	assert.Equal(t, model.Checks.SLOAvailability.DefaultThreshold, v)
+	assert.Equal(t, SLOObjectiveSourceBuiltin, source)
+
+	d = &SLODefaults{
+		AvailabilityObjective: 99.5,
+		Categories: map[model.ApplicationCategory]SLOObjectives{
+			"payments": {AvailabilityObjective: 99.95},
+			"batch":    {LatencyObjective: 90},
+		},
+	}
+	v, source = d.Objective(availability, "payments")
+	assert.Equal(t, 99.95, v)
+	assert.Equal(t, SLOObjectiveSourceCategory, source)
+
+	v, source = d.Objective(availability, "batch")
+	assert.Equal(t, 99.5, v)
+	assert.Equal(t, SLOObjectiveSourceProject, source)
+
+	v, source = d.Objective(latency, "batch")
+	assert.Equal(t, float64(90), v)
+	assert.Equal(t, SLOObjectiveSourceCategory, source)
+
+	v, source = d.Objective(latency, "payments")
+	assert.Equal(t, model.Checks.SLOLatency.DefaultThreshold, v)
+	assert.Equal(t, SLOObjectiveSourceBuiltin, source)
+}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+)
+
+// ApplicationSnooze suppresses the notifications about the incidents of an application until the given time.
+type ApplicationSnooze struct {
+	Until   timeseries.Time `json:"until"`
+	Comment string          `json:"comment,omitempty"`
+}
+
+func (s *Settings) IsApplicationSnoozed(appId model.ApplicationId, now timeseries.Time) bool {
+	snooze, ok := s.ApplicationSnoozes[appId]
+	return ok && snooze.Until.After(now)
+}
+
+// SnoozeApplications adds the given snoozes to the project. An existing snooze is replaced only by a longer one.
+// Expired snoozes are dropped.
+func (db *DB) SnoozeApplications(id ProjectId, snoozes map[model.ApplicationId]ApplicationSnooze, now timeseries.Time, actor string) error {
+	p, err := db.GetProjectForUpdate(id)
+	if err != nil {
+		return err
+	}
+	old := p.Settings.ApplicationSnoozes
+	res := map[model.ApplicationId]ApplicationSnooze{}
+	for appId, s := range old {
+		if s.Until.After(now) {
+			res[appId] = s
+		}
+	}
+	for appId, s := range snoozes {
+		if s.Until.After(res[appId].Until) {
+			res[appId] = s
+		}
+	}
+	if len(res) == 0 {
+		res = nil
+	}
+	p.Settings.ApplicationSnoozes = res
+	if err := db.saveProjectSettings(p); err != nil {
+		return err
+	}
+	return db.addAuditLogEntry(id, actor, "application_snoozes", old, res)
+}
@@ -45,7 +45,11 @@ export default class Api {
                 console.error(e);
             }
         }).catch((error) => {
-            const err = error.response && error.response.data && error.response.data.trim() || defaultErrorMessage;
+            let data = error.response && error.response.data;
+            if (data && typeof data === 'object') {
+                data = data.message || data.error;
+            }
+            const err = data && data.trim() || defaultErrorMessage;
             cb(null, err);
         })
     }
@@ -119,12 +123,28 @@ export default class Api {
         this.post(this.projectPath(`integrations${type ? '/'+type : ''}`), form, cb);
     }
 
+    previewNotificationRoute(params, cb) {
+        this.request({method: 'get', url: this.projectPath('integrations/route_preview'), params}, cb);
+    }
+
+    getSavedViews(scope, cb) {
+        this.request({method: 'get', url: this.projectPath('views'), params: {scope}}, cb);
+    }
+
+    saveView(view, cb) {
+        this.post(this.projectPath('views'), view, cb);
+    }
+
+    deleteView(scope, name, cb) {
+        this.request({method: 'delete', url: this.projectPath('views'), params: {scope, name}}, cb);
+    }
+
     getApplication(appId, cb) {
         this.get(this.projectPath(`app/${appId}`), cb);
     }
 
-    getCheckConfig(appId, checkId, cb) {
-        this.get(this.projectPath(`app/${appId}/check/${checkId}/config`), cb);
+    getCheckConfig(appId, checkId, level, cb) {
+        this.get(this.projectPath(`app/${appId}/check/${checkId}/config?level=${level}`), cb);
     }
 
     saveCheckConfig(appId, checkId, form, cb) {
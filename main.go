@@ -9,11 +9,13 @@ import (
 	"github.com/coroot/coroot/timeseries"
 	"github.com/coroot/coroot/utils"
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"gopkg.in/alecthomas/kingpin.v2"
 	"k8s.io/klog"
 	"net/http"
 	_ "net/http/pprof"
 	"path"
+	_ "time/tzdata"
 )
 
 var version = "unknown"
@@ -23,23 +25,41 @@ func main() {
 	dataDir := kingpin.Flag("data-dir", `path to data directory`).Envar("DATA_DIR").Default("/data").String()
 	cacheTTL := kingpin.Flag("cache-ttl", "cache TTL").Envar("CACHE_TTL").Default("720h").Duration()
 	cacheGcInterval := kingpin.Flag("cache-gc-interval", "cache GC interval").Envar("CACHE_GC_INTERVAL").Default("10m").Duration()
+	cacheBackfillChunks := kingpin.Flag("cache-backfill-chunks-per-iteration", "max number of chunks of a query downloaded per cache update when catching up a gap (0 means unlimited)").Envar("CACHE_BACKFILL_CHUNKS_PER_ITERATION").Default("24").Int()
 	pgConnString := kingpin.Flag("pg-connection-string", "Postgres connection string (sqlite is used if not set)").Envar("PG_CONNECTION_STRING").String()
+	pgReplicaConnString := kingpin.Flag("pg-replica-connection-string", "Postgres read replica connection string to offload the reads from the primary").Envar("PG_REPLICA_CONNECTION_STRING").String()
 	disableStats := kingpin.Flag("disable-usage-statistics", "disable usage statistics").Envar("DISABLE_USAGE_STATISTICS").Bool()
 	readOnly := kingpin.Flag("read-only", "enable the read-only mode when configuration changes don't take effect").Envar("READ_ONLY").Bool()
+	maskSecrets := kingpin.Flag("mask-secrets", "hide credentials and tokens in the API responses (always enabled in the read-only mode)").Envar("MASK_SECRETS").Bool()
+	trustForwardedFor := kingpin.Flag("trust-forwarded-for", "take the client address recorded in the audit log from the X-Forwarded-For header (enable only behind a reverse proxy setting it)").Envar("TRUST_FORWARDED_FOR").Bool()
 	bootstrapPrometheusUrl := kingpin.Flag("bootstrap-prometheus-url", "if set, Coroot will create a project for this Prometheus URL").Envar("BOOTSTRAP_PROMETHEUS_URL").String()
 	bootstrapRefreshInterval := kingpin.Flag("bootstrap-refresh-interval", "refresh interval for the project created upon bootstrap").Envar("BOOTSTRAP_REFRESH_INTERVAL").Duration()
 	sloCheckInterval := kingpin.Flag("slo-check-interval", "how often to check SLO compliance").Envar("SLO_CHECK_INTERVAL").Default("1m").Duration()
+	incidentDataLossThreshold := kingpin.Flag("incident-data-loss-threshold", "auto-resolve incidents of applications that have had no data for this long (0 disables)").Envar("INCIDENT_DATA_LOSS_THRESHOLD").Default("1h").Duration()
+	maxRequestBodySize := kingpin.Flag("max-request-body-size", "max size of an API request body").Envar("MAX_REQUEST_BODY_SIZE").Default("1MB").Bytes()
+	statsSampleRate := kingpin.Flag("usage-statistics-sample-rate", "register 1 in N API requests in the usage statistics").Envar("USAGE_STATISTICS_SAMPLE_RATE").Default("1").Uint64()
+	requestBodyReadTimeout := kingpin.Flag("request-body-read-timeout", "max time to read an API request including its body").Envar("REQUEST_BODY_READ_TIMEOUT").Default("10s").Duration()
+	maxWorldLoads := kingpin.Flag("max-concurrent-world-loads", "max number of worlds constructed concurrently (0 means unlimited)").Envar("MAX_CONCURRENT_WORLD_LOADS").Default("0").Int()
+	worldLoadQueueTimeout := kingpin.Flag("world-load-queue-timeout", "max time a request waits for a world load slot before getting 503").Envar("WORLD_LOAD_QUEUE_TIMEOUT").Default("30s").Duration()
+	maxIncidentsPageSize := kingpin.Flag("max-incidents-page-size", "max number of incidents returned by the incident list in one page").Envar("MAX_INCIDENTS_PAGE_SIZE").Default("1000").Int()
+	checkConfigsSaveTimeout := kingpin.Flag("check-configs-save-timeout", "max time of saving the configs of a check, the transaction is rolled back after it").Envar("CHECK_CONFIGS_SAVE_TIMEOUT").Default("30s").Duration()
+	requestStatsRetention := kingpin.Flag("request-stats-retention", "how long the per-minute stats of the API requests are kept in memory").Envar("REQUEST_STATS_RETENTION").Default("1h").Duration()
+	numberLocale := kingpin.Flag("number-locale", "locale defining the decimal and grouping separators of formatted numbers, e.g., en, de, fr (no grouping and a dot decimal separator if not set)").Envar("NUMBER_LOCALE").String()
 
 	kingpin.Version(version)
 	kingpin.Parse()
 
 	klog.Infof("version: %s, read-only: %t", version, *readOnly)
 
+	if err := utils.SetNumberLocale(*numberLocale); err != nil {
+		klog.Exitln(err)
+	}
+
 	if err := utils.CreateDirectoryIfNotExists(*dataDir); err != nil {
 		klog.Exitln(err)
 	}
 
-	database, err := db.Open(*dataDir, *pgConnString)
+	database, err := db.Open(*dataDir, *pgConnString, *pgReplicaConnString)
 	if err != nil {
 		klog.Exitln(err)
 	}
@@ -58,7 +78,7 @@ func main() {
 				},
 			}
 			klog.Infof("creating project: %s(%s, %s)", p.Name, *bootstrapPrometheusUrl, *bootstrapRefreshInterval)
-			if _, err := database.SaveProject(p); err != nil {
+			if _, err := database.SaveProject(p, "bootstrap"); err != nil {
 				klog.Exitln(err)
 			}
 		}
@@ -70,6 +90,7 @@ func main() {
 			TTL:      *cacheTTL,
 			Interval: *cacheGcInterval,
 		},
+		BackfillChunksPerIteration: *cacheBackfillChunks,
 	}
 	promCache, err := cache.NewCache(cacheConfig, database)
 	if err != nil {
@@ -78,32 +99,96 @@ func main() {
 
 	var statsCollector *stats.Collector
 	if !*disableStats {
-		statsCollector = stats.NewCollector(*dataDir, version, database, promCache)
+		statsCollector = stats.NewCollector(*dataDir, version, database, promCache, *requestStatsRetention)
 	}
 
+	alertManager := alerts.NewAlertManager(database, promCache, *incidentDataLossThreshold)
 	if *sloCheckInterval > 0 {
-		alerts.NewAlertManager(database, promCache).Start(*sloCheckInterval)
+		alertManager.Start(*sloCheckInterval)
 	}
 
-	api := api.NewApi(promCache, database, statsCollector, *readOnly)
+	api := api.NewApi(promCache, database, statsCollector, api.Options{
+		AlertManager:            alertManager,
+		ReadOnly:                *readOnly,
+		MaskSecrets:             *maskSecrets,
+		TrustForwardedFor:       *trustForwardedFor,
+		MaxBodySize:             int64(*maxRequestBodySize),
+		StatsSampleRate:         *statsSampleRate,
+		MaxWorldLoads:           *maxWorldLoads,
+		WorldLoadQueueTimeout:   *worldLoadQueueTimeout,
+		MaxIncidentsPageSize:    *maxIncidentsPageSize,
+		CheckConfigsSaveTimeout: *checkConfigsSaveTimeout,
+	})
 
 	r := mux.NewRouter()
+	r.Use(api.RequestId)
+	r.Use(api.Instrument)
+	r.Use(api.CollectStats)
 	r.PathPrefix("/debug/pprof/").Handler(http.DefaultServeMux)
 	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodGet)
+	r.Handle("/metrics", promhttp.Handler()).Methods(http.MethodGet)
 
 	r.HandleFunc("/api/projects", api.Projects).Methods(http.MethodGet)
+	r.HandleFunc("/api/search", api.GlobalSearch).Methods(http.MethodGet)
+	r.HandleFunc("/api/stats/requests", api.RequestStats).Methods(http.MethodGet)
 	r.HandleFunc("/api/project/", api.Project).Methods(http.MethodGet, http.MethodPost)
 	r.HandleFunc("/api/project/{project}", api.Project).Methods(http.MethodGet, http.MethodPost, http.MethodDelete)
 	r.HandleFunc("/api/project/{project}/status", api.Status).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/cache/coverage", api.CacheCoverage).Methods(http.MethodGet)
+	r.HandleFunc("/api/project/{project}/configuration_hints", api.ConfigurationHints).Methods(http.MethodGet)
+	r.HandleFunc("/api/project/{project}/annotations", api.Annotations).Methods(http.MethodGet)
 	r.HandleFunc("/api/project/{project}/overview", api.Overview).Methods(http.MethodGet)
+	r.HandleFunc("/api/project/{project}/live", api.Live).Methods(http.MethodGet)
 	r.HandleFunc("/api/project/{project}/search", api.Search).Methods(http.MethodGet)
 	r.HandleFunc("/api/project/{project}/configs", api.Configs).Methods(http.MethodGet)
+	r.HandleFunc("/api/project/{project}/configs/lint", api.LintCheckConfigs).Methods(http.MethodGet)
+	r.HandleFunc("/api/project/{project}/configs/list", api.ListCheckConfigs).Methods(http.MethodGet)
 	r.HandleFunc("/api/project/{project}/categories", api.Categories).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/categories/label", api.CategoryLabel).Methods(http.MethodPost)
+	r.HandleFunc("/api/project/{project}/categories/preview", api.CategoriesPreview).Methods(http.MethodPost)
+	r.HandleFunc("/api/project/{project}/golden_signals", api.GoldenSignals).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/severity_labels", api.SeverityLabels).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/application_identity", api.ApplicationIdentity).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/application_exclusions", api.ApplicationExclusions).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/application_tiers", api.ApplicationTiers).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/health_rollup", api.HealthRollup).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/views", api.SavedViews).Methods(http.MethodGet, http.MethodPost, http.MethodDelete)
+	r.HandleFunc("/api/project/{project}/metric_thresholds", api.MetricThresholds).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/slo_defaults", api.SLODefaults).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/logs_link", api.LogsLink).Methods(http.MethodGet, http.MethodPost)
 	r.HandleFunc("/api/project/{project}/integrations", api.Integrations).Methods(http.MethodGet, http.MethodPost)
 	r.HandleFunc("/api/project/{project}/integrations/slack", api.IntegrationsSlack).Methods(http.MethodGet, http.MethodPost, http.MethodDelete)
+	r.HandleFunc("/api/project/{project}/integrations/otlp", api.IntegrationsOTLP).Methods(http.MethodGet, http.MethodPost, http.MethodDelete)
+	r.HandleFunc("/api/project/{project}/integrations/quiet_hours", api.IntegrationsQuietHours).Methods(http.MethodGet, http.MethodPost, http.MethodDelete)
+	r.HandleFunc("/api/project/{project}/integrations/route_preview", api.NotificationRoutePreview).Methods(http.MethodGet)
+	r.HandleFunc("/api/project/{project}/deployments", api.Deployments).Methods(http.MethodPost)
+	r.HandleFunc("/api/project/{project}/escalation", api.Escalation).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/flapping", api.Flapping).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/repeat", api.Repeat).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/audit_log", api.AuditLog).Methods(http.MethodGet)
+	r.HandleFunc("/api/project/{project}/incidents", api.Incidents).Methods(http.MethodGet)
+	r.HandleFunc("/api/project/{project}/incidents/stats", api.IncidentStats).Methods(http.MethodGet)
+	r.HandleFunc("/api/project/{project}/incidents/recompute", api.RecomputeIncidents).Methods(http.MethodPost)
+	r.HandleFunc("/api/project/{project}/incidents/acknowledge", api.AcknowledgeIncidents).Methods(http.MethodPost)
+	r.HandleFunc("/api/project/{project}/alertmanager/silences", api.ImportAlertmanagerSilences).Methods(http.MethodPost)
+	r.HandleFunc("/api/project/{project}/incident/{incident}", api.Incident).Methods(http.MethodGet, http.MethodPost)
 	r.HandleFunc("/api/project/{project}/app/{app}", api.App).Methods(http.MethodGet)
+	r.HandleFunc("/api/project/{project}/app/{app}/deployments", api.Deployments).Methods(http.MethodPost)
+	r.HandleFunc("/api/project/{project}/app/{app}/replicas", api.AppReplicas).Methods(http.MethodGet)
+	r.HandleFunc("/api/project/{project}/app/{app}/grafana", api.AppGrafanaDashboard).Methods(http.MethodGet)
+	r.HandleFunc("/api/project/{project}/app/{app}/sli", api.AppSLI).Methods(http.MethodGet)
+	r.HandleFunc("/api/project/{project}/app/{app}/slo/burn_rates", api.AppSLOBurnRates).Methods(http.MethodGet)
+	r.HandleFunc("/api/project/{project}/app/{app}/top_talkers", api.AppTopTalkers).Methods(http.MethodGet)
+	r.HandleFunc("/api/project/{project}/app/{app}/instance/{instance}", api.Instance).Methods(http.MethodGet)
 	r.HandleFunc("/api/project/{project}/app/{app}/check/{check}/config", api.Check).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/nodes", api.NodeInventory).Methods(http.MethodGet)
+	r.HandleFunc("/api/project/{project}/cluster", api.Cluster).Methods(http.MethodGet)
+	r.HandleFunc("/api/project/{project}/checks/{check}", api.CheckAcrossApplications).Methods(http.MethodGet)
 	r.HandleFunc("/api/project/{project}/node/{node}", api.Node).Methods(http.MethodGet)
+	r.HandleFunc("/api/project/{project}/node/{node}/capacity", api.NodeCapacity).Methods(http.MethodGet)
+	r.HandleFunc("/api/project/{project}/node/{node}/top_talkers", api.NodeTopTalkers).Methods(http.MethodGet)
+	r.HandleFunc("/api/project/{project}/promql/inspect", api.PromQLInspect).Methods(http.MethodGet)
+	r.HandleFunc("/api/project/{project}/slo/evaluate", api.EvaluateSLO).Methods(http.MethodPost)
 	r.PathPrefix("/api/project/{project}/prom").HandlerFunc(api.Prom)
 
 	r.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir("./static"))))
@@ -112,5 +197,11 @@ func main() {
 	})
 
 	klog.Infoln("listening on", *listen)
-	klog.Fatalln(http.ListenAndServe(*listen, r))
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           utils.GzipHandler(1024, r),
+		ReadHeaderTimeout: *requestBodyReadTimeout,
+		ReadTimeout:       *requestBodyReadTimeout,
+	}
+	klog.Fatalln(server.ListenAndServe())
 }
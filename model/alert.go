@@ -1,6 +1,10 @@
 package model
 
-import "github.com/coroot/coroot/timeseries"
+import (
+	"github.com/coroot/coroot/timeseries"
+	"math"
+	"sort"
+)
 
 type AlertRule struct {
 	LongWindow        timeseries.Duration
@@ -42,8 +46,6 @@ func CheckBurnRates(now timeseries.Time, bad, total timeseries.TimeSeries, objec
 		return BurnRate{Severity: UNKNOWN}
 	}
 
-	objective := 1 - objectivePercentage/100
-
 	sumFrom := func(ts timeseries.TimeSeries, from timeseries.Time) float64 {
 		return timeseries.Reduce(func(t timeseries.Time, accumulator, v float64) float64 {
 			if t.Before(from) {
@@ -53,10 +55,39 @@ func CheckBurnRates(now timeseries.Time, bad, total timeseries.TimeSeries, objec
 		}, ts)
 	}
 
+	return checkBurnRates(now, objectivePercentage, func(from timeseries.Time) (float64, float64) {
+		return sumFrom(bad, from), sumFrom(total, from)
+	})
+}
+
+// CheckBurnRatesAt evaluates the burn rates at each of the given moments the way CheckBurnRates would
+// if the data ended at that moment. The window sums are taken from cumulative sums, so re-evaluating
+// a long period costs roughly as much as a single CheckBurnRates call.
+func CheckBurnRatesAt(times []timeseries.Time, bad, total timeseries.TimeSeries, objectivePercentage float64) []BurnRate {
+	res := make([]BurnRate, len(times))
+	b, t := newCumulativeSum(bad), newCumulativeSum(total)
+	for i, now := range times {
+		if !b.hasDataAt(now) || !t.hasDataAt(now) {
+			res[i] = BurnRate{Severity: UNKNOWN}
+			continue
+		}
+		res[i] = checkBurnRates(now, objectivePercentage, func(from timeseries.Time) (float64, float64) {
+			return b.between(from, now), t.between(from, now)
+		})
+	}
+	return res
+}
+
+// checkBurnRates applies the alert rules, sums returns the sums of the bad and total events since the given time.
+func checkBurnRates(now timeseries.Time, objectivePercentage float64, sums func(from timeseries.Time) (float64, float64)) BurnRate {
+	burnRate := func(from timeseries.Time) float64 {
+		bad, total := sums(from)
+		return calcBurnRate(bad, total, objectivePercentage)
+	}
+
 	first := BurnRate{}
 	for _, r := range AlertRules {
-		from := now.Add(-r.LongWindow)
-		br := sumFrom(bad, from) / sumFrom(total, from) / objective
+		br := burnRate(now.Add(-r.LongWindow))
 		if first.Window == 0 {
 			first.Window = r.LongWindow
 			first.Value = br
@@ -64,8 +95,7 @@ func CheckBurnRates(now timeseries.Time, bad, total timeseries.TimeSeries, objec
 		if br < r.BurnRateThreshold {
 			continue
 		}
-		from = now.Add(-r.ShortWindow)
-		br = sumFrom(bad, from) / sumFrom(total, from) / objective
+		br = burnRate(now.Add(-r.ShortWindow))
 		if br < r.BurnRateThreshold {
 			continue
 		}
@@ -74,3 +104,146 @@ func CheckBurnRates(now timeseries.Time, bad, total timeseries.TimeSeries, objec
 	first.Severity = OK
 	return first
 }
+
+// calcBurnRate returns how fast the error budget is being consumed: the ratio of the bad events to the allowed ones.
+func calcBurnRate(bad, total, objectivePercentage float64) float64 {
+	return bad / total / (1 - objectivePercentage/100)
+}
+
+type AlertRuleBurnRates struct {
+	Rule AlertRule
+	// the burn rates over the rule's windows ending at each point, the rule fires when both exceed the threshold
+	Long  *timeseries.InMemoryTimeSeries
+	Short *timeseries.InMemoryTimeSeries
+}
+
+// CalcAlertRuleBurnRates evaluates the burn rates over the windows of each alert rule as time series covering
+// pointsCount points from the given time. The points lacking the data to evaluate are NaN.
+// To get the burn rates at the beginning, bad and total should start a MaxAlertRuleWindow earlier.
+func CalcAlertRuleBurnRates(from timeseries.Time, pointsCount int, step timeseries.Duration, bad, total timeseries.TimeSeries, objectivePercentage float64) []AlertRuleBurnRates {
+	b, t := newCumulativeSum(bad), newCumulativeSum(total)
+	res := make([]AlertRuleBurnRates, 0, len(AlertRules))
+	for _, r := range AlertRules {
+		res = append(res, AlertRuleBurnRates{
+			Rule:  r,
+			Long:  timeseries.New(from, pointsCount, step),
+			Short: timeseries.New(from, pointsCount, step),
+		})
+	}
+	for i := 0; i < pointsCount; i++ {
+		now := from.Add(timeseries.Duration(i) * step)
+		if !b.hasDataAt(now) || !t.hasDataAt(now) {
+			continue
+		}
+		burnRate := func(window timeseries.Duration) float64 {
+			from := now.Add(-window)
+			return calcBurnRate(b.between(from, now), t.between(from, now), objectivePercentage)
+		}
+		for _, r := range res {
+			r.Long.Set(now, burnRate(r.Rule.LongWindow))
+			r.Short.Set(now, burnRate(r.Rule.ShortWindow))
+		}
+	}
+	return res
+}
+
+type cumulativeSum struct {
+	times []timeseries.Time
+	sums  []float64 // sums[i] is the sum of the non-NaN values up to times[i] inclusive
+}
+
+func newCumulativeSum(ts timeseries.TimeSeries) *cumulativeSum {
+	cs := &cumulativeSum{}
+	var sum float64
+	iter := timeseries.Iter(ts)
+	for iter.Next() {
+		t, v := iter.Value()
+		if !math.IsNaN(v) {
+			sum += v
+		}
+		cs.times = append(cs.times, t)
+		cs.sums = append(cs.sums, sum)
+	}
+	return cs
+}
+
+func (cs *cumulativeSum) hasDataAt(now timeseries.Time) bool {
+	return len(cs.times) > 0 && !cs.times[0].After(now)
+}
+
+// upTo returns the sum of the values at or before t.
+func (cs *cumulativeSum) upTo(t timeseries.Time) float64 {
+	i := sort.Search(len(cs.times), func(i int) bool { return cs.times[i].After(t) })
+	if i == 0 {
+		return 0
+	}
+	return cs.sums[i-1]
+}
+
+func (cs *cumulativeSum) between(from, to timeseries.Time) float64 {
+	return cs.upTo(to) - cs.upTo(from.Add(-1))
+}
+
+// Damping requires a severity change to persist for several consecutive evaluations before it takes effect:
+// FireAfter evaluations for a raise and ClearAfter evaluations for a decrease.
+type Damping struct {
+	FireAfter  int
+	ClearAfter int
+}
+
+type DampingState struct {
+	Severity Status
+	pending  Status
+	count    int
+}
+
+// Apply returns the damped severity and updates the state. The first known severity is accepted immediately.
+func (d Damping) Apply(state *DampingState, severity Status) Status {
+	if severity == UNKNOWN || state.Severity == UNKNOWN || severity == state.Severity {
+		if severity != UNKNOWN {
+			state.Severity = severity
+		}
+		state.pending, state.count = UNKNOWN, 0
+		return severity
+	}
+	required := d.FireAfter
+	if severity < state.Severity {
+		required = d.ClearAfter
+	}
+	if (severity > state.Severity) != (state.pending > state.Severity) {
+		state.count = 0
+	}
+	state.pending = severity
+	state.count++
+	if state.count >= required {
+		state.Severity, state.pending, state.count = severity, UNKNOWN, 0
+	}
+	return state.Severity
+}
+
+// OpeningGrace requires an unhealthy status to persist for Period before an incident is opened,
+// which absorbs transient spikes. Unlike Damping, it's based on time and doesn't delay the changes of open incidents.
+type OpeningGrace struct {
+	Period timeseries.Duration
+}
+
+type OpeningGraceState struct {
+	unhealthySince timeseries.Time
+}
+
+// Apply returns the status to evaluate the incident of an application with: if the application has no open incident,
+// an unhealthy status is replaced with OK until it's persisted for the period. The state tracks when the status
+// became unhealthy, so it has to be applied to every evaluation.
+func (g OpeningGrace) Apply(state *OpeningGraceState, status Status, incidentIsOpen bool, now timeseries.Time) Status {
+	if status <= OK {
+		state.unhealthySince = 0
+		return status
+	}
+	if state.unhealthySince.IsZero() {
+		state.unhealthySince = now
+	}
+	if g.Period <= 0 || incidentIsOpen || now.Sub(state.unhealthySince) >= g.Period {
+		return status
+	}
+	return OK
+}
@@ -0,0 +1,114 @@
+package model
+
+import (
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestDamping(t *testing.T) {
+	d := Damping{FireAfter: 3, ClearAfter: 2}
+	s := &DampingState{}
+
+	apply := func(severities ...Status) []Status {
+		var res []Status
+		for _, severity := range severities {
+			res = append(res, d.Apply(s, severity))
+		}
+		return res
+	}
+
+	assert.Equal(t, []Status{OK, OK}, apply(OK, OK))
+	assert.Equal(t, []Status{OK, OK, OK}, apply(WARNING, OK, WARNING))
+	assert.Equal(t, []Status{OK, CRITICAL, CRITICAL}, apply(WARNING, CRITICAL, CRITICAL))
+	assert.Equal(t, []Status{CRITICAL, CRITICAL, CRITICAL, OK}, apply(OK, CRITICAL, OK, OK))
+	assert.Equal(t, []Status{UNKNOWN, OK}, apply(UNKNOWN, OK))
+
+	s = &DampingState{}
+	assert.Equal(t, CRITICAL, Damping{}.Apply(s, CRITICAL))
+	assert.Equal(t, OK, Damping{}.Apply(s, OK))
+}
+
+func TestOpeningGrace(t *testing.T) {
+	g := OpeningGrace{Period: 2 * timeseries.Minute}
+	s := &OpeningGraceState{}
+	now := timeseries.Time(1000000)
+
+	assert.Equal(t, OK, g.Apply(s, OK, false, now))
+	assert.Equal(t, OK, g.Apply(s, CRITICAL, false, now.Add(timeseries.Minute)))
+	assert.Equal(t, OK, g.Apply(s, WARNING, false, now.Add(2*timeseries.Minute)))
+	assert.Equal(t, CRITICAL, g.Apply(s, CRITICAL, false, now.Add(3*timeseries.Minute)))
+
+	// a transient spike
+	assert.Equal(t, OK, g.Apply(s, OK, false, now.Add(4*timeseries.Minute)))
+	assert.Equal(t, OK, g.Apply(s, CRITICAL, false, now.Add(5*timeseries.Minute)))
+	assert.Equal(t, OK, g.Apply(s, OK, false, now.Add(6*timeseries.Minute)))
+	assert.Equal(t, OK, g.Apply(s, CRITICAL, false, now.Add(7*timeseries.Minute)))
+
+	// open incidents are updated immediately
+	assert.Equal(t, CRITICAL, g.Apply(s, CRITICAL, true, now.Add(8*timeseries.Minute)))
+	assert.Equal(t, UNKNOWN, g.Apply(s, UNKNOWN, true, now.Add(9*timeseries.Minute)))
+
+	assert.Equal(t, WARNING, OpeningGrace{}.Apply(&OpeningGraceState{}, WARNING, false, now))
+}
+
+func TestCheckBurnRatesAt(t *testing.T) {
+	step := 10 * timeseries.Minute
+	from := timeseries.Time(1000000)
+	var total, bad []float64
+	for i := 0; i < 600; i++ {
+		total = append(total, 100)
+		switch {
+		case i == 50:
+			bad = append(bad, timeseries.NaN)
+		case i >= 400 && i < 420:
+			bad = append(bad, 50)
+		default:
+			bad = append(bad, 0)
+		}
+	}
+
+	var times []timeseries.Time
+	for i := 0; i < len(total); i += 7 {
+		times = append(times, from.Add(timeseries.Duration(i)*step))
+	}
+	times = append(times, from.Add(-step))
+
+	actual := CheckBurnRatesAt(times, timeseries.NewWithData(from, step, bad), timeseries.NewWithData(from, step, total), 99)
+	for i, now := range times {
+		n := int(now.Sub(from)/step) + 1
+		if n <= 0 {
+			assert.Equal(t, UNKNOWN, actual[i].Severity)
+			continue
+		}
+		expected := CheckBurnRates(now, timeseries.NewWithData(from, step, bad[:n]), timeseries.NewWithData(from, step, total[:n]), 99)
+		assert.Equal(t, expected.Severity, actual[i].Severity, now)
+		assert.InDelta(t, expected.Value, actual[i].Value, 1e-9, now)
+	}
+}
+
+func TestCalcAlertRuleBurnRates(t *testing.T) {
+	step := timeseries.Minute
+	var total, bad []float64
+	for i := 0; i < 180; i++ {
+		total = append(total, 100)
+		if i < 60 {
+			bad = append(bad, 0)
+		} else {
+			bad = append(bad, 10)
+		}
+	}
+	from := timeseries.Time(0).Add(-step)
+	res := CalcAlertRuleBurnRates(from, 181, step, timeseries.NewWithData(0, step, bad), timeseries.NewWithData(0, step, total), 99)
+	assert.Len(t, res, len(AlertRules))
+	r := res[0]
+	assert.Equal(t, AlertRules[0], r.Rule)
+	long, short := r.Long.Data(), r.Short.Data()
+	assert.True(t, math.IsNaN(long[0]))
+	assert.Equal(t, float64(0), long[1])
+	assert.InDelta(t, 10./61, long[61], 1e-9)
+	assert.InDelta(t, 10./6, short[61], 1e-9)
+	assert.InDelta(t, 10, long[180], 1e-9)
+	assert.InDelta(t, 10, short[180], 1e-9)
+}
@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"github.com/coroot/coroot/timeseries"
 	"github.com/coroot/coroot/utils"
+	"regexp"
 	"strconv"
 	"strings"
 )
 
+var labelNameRe = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
 type Application struct {
 	Id ApplicationId
 
@@ -36,6 +39,34 @@ func (app *Application) SLOStatus() Status {
 	return UNKNOWN
 }
 
+// SLOBurnRate returns the highest error budget burn rate among the SLO checks.
+func (app *Application) SLOBurnRate() float64 {
+	var res float64
+	for _, r := range app.Reports {
+		if r.Name != AuditReportSLO {
+			continue
+		}
+		for _, ch := range r.Checks {
+			if br := ch.BurnRate(); br > res {
+				res = br
+			}
+		}
+	}
+	return res
+}
+
+// GetCheck returns the check with the given id from the audit reports of the application.
+func (app *Application) GetCheck(id CheckId) *Check {
+	for _, r := range app.Reports {
+		for _, ch := range r.Checks {
+			if ch.Id == id {
+				return ch
+			}
+		}
+	}
+	return nil
+}
+
 func (app *Application) GetInstance(name string) *Instance {
 	for _, i := range app.Instances {
 		if i.Name == name {
@@ -93,6 +124,36 @@ func (app *Application) Labels() Labels {
 	return res
 }
 
+// KubernetesLabel returns the value of the pod label of the application's instances.
+// The name is sanitized the same way kube-state-metrics does it, e.g., app.kubernetes.io/team -> app_kubernetes_io_team.
+func (app *Application) KubernetesLabel(name string) string {
+	name = labelNameRe.ReplaceAllString(name, "_")
+	for _, i := range app.Instances {
+		if i.Pod == nil {
+			continue
+		}
+		if v := i.Pod.Labels[name]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// MatchLabels reports whether the application has all the given labels,
+// either among its own labels or the Kubernetes labels of its pods.
+func (app *Application) MatchLabels(selector Labels) bool {
+	if len(selector) == 0 {
+		return true
+	}
+	own := app.Labels()
+	for k, v := range selector {
+		if own[k] != v && app.KubernetesLabel(k) != v {
+			return false
+		}
+	}
+	return true
+}
+
 func (app *Application) IsRedis() bool {
 	for _, i := range app.Instances {
 		if i.Redis != nil {
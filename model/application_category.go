@@ -30,7 +30,14 @@ var BuiltinCategoryPatterns = map[ApplicationCategory][]string{
 	},
 }
 
-func CalcApplicationCategory(app *Application, customPatterns map[ApplicationCategory][]string) ApplicationCategory {
+// CalcApplicationCategory returns the value of the categoryLabel Kubernetes label of the application if it's set,
+// otherwise, the category is determined by the builtin and custom patterns.
+func CalcApplicationCategory(app *Application, customPatterns map[ApplicationCategory][]string, categoryLabel string) ApplicationCategory {
+	if categoryLabel != "" {
+		if v := app.KubernetesLabel(categoryLabel); v != "" {
+			return ApplicationCategory(v)
+		}
+	}
 	categories := make([]ApplicationCategory, 0, len(BuiltinCategoryPatterns)+len(customPatterns))
 	for c := range BuiltinCategoryPatterns {
 		categories = append(categories, c)
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCalcApplicationCategory(t *testing.T) {
+	app := NewApplication(NewApplicationId("monitoring", ApplicationKindDeployment, "exporter"))
+	custom := map[ApplicationCategory][]string{"payments": {"payments/*"}}
+	assert.Equal(t, ApplicationCategoryMonitoring, CalcApplicationCategory(app, custom, ""))
+	assert.Equal(t, ApplicationCategoryMonitoring, CalcApplicationCategory(app, custom, "app.kubernetes.io/team"))
+
+	i := app.GetOrCreateInstance("exporter-1")
+	i.Pod = &Pod{Labels: map[string]string{"app_kubernetes_io_team": "sre"}}
+	assert.Equal(t, ApplicationCategory("sre"), CalcApplicationCategory(app, custom, "app.kubernetes.io/team"))
+	assert.Equal(t, ApplicationCategoryMonitoring, CalcApplicationCategory(app, custom, "team"))
+
+	app = NewApplication(NewApplicationId("payments", ApplicationKindDeployment, "api"))
+	assert.Equal(t, ApplicationCategory("payments"), CalcApplicationCategory(app, custom, "app.kubernetes.io/team"))
+	assert.Equal(t, ApplicationCategoryApplication, CalcApplicationCategory(app, nil, ""))
+}
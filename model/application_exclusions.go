@@ -0,0 +1,41 @@
+package model
+
+import (
+	"fmt"
+	"github.com/coroot/coroot/utils"
+	"strings"
+)
+
+// ApplicationExclusions are "<namespace>/<name>" glob patterns (e.g., "kube-system/*") of the applications
+// left out of the world along with the connections to them. They are applied on every world construction,
+// so removing a pattern brings the applications back with all their data.
+type ApplicationExclusions struct {
+	Patterns []string `json:"patterns"`
+}
+
+func (e *ApplicationExclusions) IsEmpty() bool {
+	return e == nil || len(e.Patterns) == 0
+}
+
+func (e *ApplicationExclusions) Validate() error {
+	if e == nil {
+		return nil
+	}
+	for _, p := range e.Patterns {
+		ns, name, ok := strings.Cut(p, "/")
+		if !ok || ns == "" || name == "" || strings.Contains(name, "/") {
+			return fmt.Errorf("invalid pattern %q: must be <namespace>/<name>", p)
+		}
+		if !utils.GlobValidate([]string{p}) {
+			return fmt.Errorf("invalid pattern %q", p)
+		}
+	}
+	return nil
+}
+
+func (e *ApplicationExclusions) Excludes(id ApplicationId) bool {
+	if e.IsEmpty() {
+		return false
+	}
+	return utils.GlobMatch(id.Namespace+"/"+id.Name, e.Patterns)
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestApplicationExclusions(t *testing.T) {
+	var empty *ApplicationExclusions
+	assert.NoError(t, empty.Validate())
+	assert.False(t, empty.Excludes(NewApplicationId("kube-system", ApplicationKindDeployment, "coredns")))
+
+	e := &ApplicationExclusions{Patterns: []string{"kube-system/*", "*/prometheus-*"}}
+	assert.NoError(t, e.Validate())
+	assert.True(t, e.Excludes(NewApplicationId("kube-system", ApplicationKindDeployment, "coredns")))
+	assert.True(t, e.Excludes(NewApplicationId("monitoring", ApplicationKindStatefulSet, "prometheus-server")))
+	assert.False(t, e.Excludes(NewApplicationId("default", ApplicationKindDeployment, "api")))
+	assert.False(t, e.Excludes(NewApplicationId("", ApplicationKindExternalService, "prometheus")))
+
+	for _, p := range []string{"kube-system", "/coredns", "ns/", "ns/a/b", "ns/[a"} {
+		assert.Error(t, (&ApplicationExclusions{Patterns: []string{p}}).Validate(), p)
+	}
+}
@@ -53,6 +53,14 @@ func (a ApplicationId) IsZero() bool {
 	return a == ApplicationIdZero
 }
 
+func (a ApplicationId) NamespaceId() ApplicationId {
+	return ApplicationId{Namespace: a.Namespace}
+}
+
+func (a ApplicationId) IsNamespace() bool {
+	return a.Namespace != "" && a.Kind == "" && a.Name == ""
+}
+
 func (a ApplicationId) String() string {
 	return fmt.Sprintf("%s:%s:%s", a.Namespace, a.Kind, a.Name)
 }
@@ -0,0 +1,53 @@
+package model
+
+import "strings"
+
+// ApplicationIdentity defines the Kubernetes pod labels composing the application ids (e.g., app.kubernetes.io/name),
+// so that pods of different owners can be identified as one application. The id of such an application
+// is "<namespace>:Custom:<label values joined with '/'>", so it stays the same as long as the labels do.
+// Pods lacking any of the labels keep the ids derived from their owners.
+type ApplicationIdentity struct {
+	Labels []string `json:"labels"`
+}
+
+func (ai *ApplicationIdentity) IsEmpty() bool {
+	return ai == nil || len(ai.Labels) == 0
+}
+
+// IdFor returns the id of the application the pod with the given labels belongs to.
+// The label names are matched in the form exported by kube-state-metrics (e.g., app_kubernetes_io_name).
+func (ai *ApplicationIdentity) IdFor(ns string, podLabels map[string]string) (ApplicationId, bool) {
+	if ai.IsEmpty() {
+		return ApplicationId{}, false
+	}
+	values := make([]string, 0, len(ai.Labels))
+	for _, l := range ai.Labels {
+		v := podLabels[labelNameRe.ReplaceAllString(l, "_")]
+		if v == "" {
+			return ApplicationId{}, false
+		}
+		values = append(values, v)
+	}
+	return NewApplicationId(ns, ApplicationKindCustom, strings.Join(values, "/")), true
+}
+
+// MissingLabels returns the identity labels that none of the pods of the world has.
+func (ai *ApplicationIdentity) MissingLabels(w *World) []string {
+	if ai.IsEmpty() {
+		return nil
+	}
+	var res []string
+	for _, l := range ai.Labels {
+		found := false
+		for _, app := range w.Applications {
+			if app.KubernetesLabel(l) != "" {
+				found = true
+				break
+			}
+		}
+		if !found {
+			res = append(res, l)
+		}
+	}
+	return res
+}
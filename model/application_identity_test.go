@@ -0,0 +1,29 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestApplicationIdentity(t *testing.T) {
+	var empty *ApplicationIdentity
+	_, ok := empty.IdFor("ns", map[string]string{"app": "a"})
+	assert.False(t, ok)
+
+	ai := &ApplicationIdentity{Labels: []string{"app.kubernetes.io/name", "app.kubernetes.io/instance"}}
+	id, ok := ai.IdFor("ns", map[string]string{"app_kubernetes_io_name": "api", "app_kubernetes_io_instance": "eu", "pod_template_hash": "123"})
+	assert.True(t, ok)
+	assert.Equal(t, ApplicationId{Namespace: "ns", Kind: ApplicationKindCustom, Name: "api/eu"}, id)
+	parsed, err := NewApplicationIdFromString(id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, id, parsed)
+
+	_, ok = ai.IdFor("ns", map[string]string{"app_kubernetes_io_name": "api"})
+	assert.False(t, ok)
+
+	app := NewApplication(NewApplicationId("ns", ApplicationKindDeployment, "api"))
+	i := app.GetOrCreateInstance("api-1")
+	i.Pod = &Pod{Labels: map[string]string{"app_kubernetes_io_name": "api"}}
+	w := &World{Applications: []*Application{app}}
+	assert.Equal(t, []string{"app.kubernetes.io/instance"}, ai.MissingLabels(w))
+}
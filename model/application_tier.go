@@ -0,0 +1,110 @@
+package model
+
+import (
+	"fmt"
+	"github.com/coroot/coroot/utils"
+)
+
+// ApplicationTier is the importance of an application: tier 1 applications are the most critical ones.
+type ApplicationTier int
+
+const (
+	ApplicationTierMax     ApplicationTier = 3
+	ApplicationTierDefault ApplicationTier = 2
+)
+
+func (t ApplicationTier) IsValid() bool {
+	return t >= 1 && t <= ApplicationTierMax
+}
+
+// Weight is the contribution of an application of the tier to the overall status of a project:
+// tier 1 outweighs tier 2, which outweighs tier 3.
+func (t ApplicationTier) Weight() float64 {
+	return float64(ApplicationTierMax - t + 1)
+}
+
+// ApplicationTiers assign tiers to applications by "<namespace>/<name>" glob patterns or by their categories.
+// A pattern takes precedence over a category, if several patterns match, the most important tier wins.
+// The applications matching neither are of ApplicationTierDefault, so by default all of them are equally important.
+type ApplicationTiers struct {
+	Patterns   map[ApplicationTier][]string            `json:"patterns,omitempty"`
+	Categories map[ApplicationCategory]ApplicationTier `json:"categories,omitempty"`
+}
+
+func (t *ApplicationTiers) IsEmpty() bool {
+	return t == nil || (len(t.Patterns) == 0 && len(t.Categories) == 0)
+}
+
+func (t *ApplicationTiers) Validate() error {
+	if t == nil {
+		return nil
+	}
+	for tier, ps := range t.Patterns {
+		if !tier.IsValid() {
+			return fmt.Errorf("invalid tier %d: must be between 1 and %d", tier, ApplicationTierMax)
+		}
+		if !utils.GlobValidate(ps) {
+			return fmt.Errorf("invalid patterns of tier %d", tier)
+		}
+	}
+	for c, tier := range t.Categories {
+		if !tier.IsValid() {
+			return fmt.Errorf("invalid tier %d of category %q: must be between 1 and %d", tier, c, ApplicationTierMax)
+		}
+	}
+	return nil
+}
+
+func (t *ApplicationTiers) Tier(id ApplicationId, category ApplicationCategory) ApplicationTier {
+	if t.IsEmpty() {
+		return ApplicationTierDefault
+	}
+	for tier := ApplicationTier(1); tier <= ApplicationTierMax; tier++ {
+		if utils.GlobMatch(id.Namespace+"/"+id.Name, t.Patterns[tier]) {
+			return tier
+		}
+	}
+	if tier, ok := t.Categories[category]; ok {
+		return tier
+	}
+	return ApplicationTierDefault
+}
+
+type TieredStatus struct {
+	Tier   ApplicationTier
+	Status Status
+}
+
+// WeightedStatus calculates the overall status of a project from the statuses of its applications.
+// The health is the weighted share of the applications without issues (WARNING counts as half an issue),
+// so an outage of a tier 1 application lowers it more than that of a tier 3 one. The status is the worst one
+// among the tier 1 and 2 applications, while tier 3 applications can't make the project more than WARNING.
+// Applications with the UNKNOWN status are ignored.
+func WeightedStatus(statuses []TieredStatus) (Status, float64) {
+	status := UNKNOWN
+	var total, healthy float64
+	for _, s := range statuses {
+		if s.Status == UNKNOWN {
+			continue
+		}
+		w := s.Tier.Weight()
+		total += w
+		switch s.Status {
+		case OK, INFO:
+			healthy += w
+		case WARNING:
+			healthy += w / 2
+		}
+		st := s.Status
+		if s.Tier == ApplicationTierMax && st > WARNING {
+			st = WARNING
+		}
+		if st > status {
+			status = st
+		}
+	}
+	if total == 0 {
+		return status, 1
+	}
+	return status, healthy / total
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestApplicationTiers(t *testing.T) {
+	var empty *ApplicationTiers
+	app := NewApplicationId("prod", ApplicationKindDeployment, "checkout")
+	assert.Equal(t, ApplicationTierDefault, empty.Tier(app, ApplicationCategoryApplication))
+
+	tiers := &ApplicationTiers{
+		Patterns: map[ApplicationTier][]string{
+			1: {"prod/checkout", "prod/payments*"},
+			3: {"prod/*"},
+		},
+		Categories: map[ApplicationCategory]ApplicationTier{
+			ApplicationCategoryMonitoring: 3,
+		},
+	}
+	assert.NoError(t, tiers.Validate())
+	assert.Equal(t, ApplicationTier(1), tiers.Tier(app, ApplicationCategoryApplication))
+	assert.Equal(t, ApplicationTier(3), tiers.Tier(NewApplicationId("prod", ApplicationKindCronJob, "cleanup"), ApplicationCategoryApplication))
+	assert.Equal(t, ApplicationTier(3), tiers.Tier(NewApplicationId("monitoring", ApplicationKindDeployment, "grafana"), ApplicationCategoryMonitoring))
+	assert.Equal(t, ApplicationTierDefault, tiers.Tier(NewApplicationId("dev", ApplicationKindDeployment, "checkout"), ApplicationCategoryApplication))
+
+	assert.Error(t, (&ApplicationTiers{Patterns: map[ApplicationTier][]string{4: {"*"}}}).Validate())
+	assert.Error(t, (&ApplicationTiers{Categories: map[ApplicationCategory]ApplicationTier{"db": 0}}).Validate())
+}
+
+func TestWeightedStatus(t *testing.T) {
+	status, health := WeightedStatus(nil)
+	assert.Equal(t, UNKNOWN, status)
+	assert.Equal(t, 1., health)
+
+	status, health = WeightedStatus([]TieredStatus{{Tier: 1, Status: OK}, {Tier: 3, Status: CRITICAL}, {Tier: 2, Status: UNKNOWN}})
+	assert.Equal(t, WARNING, status)
+	assert.Equal(t, 0.75, health)
+
+	status, health = WeightedStatus([]TieredStatus{{Tier: 1, Status: CRITICAL}, {Tier: 3, Status: OK}})
+	assert.Equal(t, CRITICAL, status)
+	assert.Equal(t, 0.25, health)
+
+	status, health = WeightedStatus([]TieredStatus{{Tier: 2, Status: WARNING}, {Tier: 2, Status: OK}})
+	assert.Equal(t, WARNING, status)
+	assert.Equal(t, 0.75, health)
+}
@@ -21,6 +21,15 @@ const (
 	AuditReportNode      AuditReportName = "Node"
 )
 
+// AffectsApplicationStatus reports whether the status of the report contributes to the status of the application.
+func (n AuditReportName) AffectsApplicationStatus() bool {
+	switch n {
+	case AuditReportPostgres, AuditReportRedis, AuditReportInstances, AuditReportSLO:
+		return true
+	}
+	return false
+}
+
 type AuditReport struct {
 	appId        ApplicationId
 	ctx          timeseries.Context
@@ -125,7 +134,7 @@ func (c *AuditReport) CreateCheck(cfg CheckConfig) *Check {
 			ch.ConditionFormatTemplate = strings.Replace(
 				ch.ConditionFormatTemplate,
 				"<bucket>",
-				utils.FormatLatency(configs[0].ObjectiveBucket),
+				configs[0].FormatBucket(configs[0].ObjectiveBucket),
 				1,
 			)
 		} else {
@@ -135,6 +144,9 @@ func (c *AuditReport) CreateCheck(cfg CheckConfig) *Check {
 	default:
 		ch.Threshold = c.checkConfigs.GetSimple(cfg.Id, c.appId).Threshold
 	}
+	if err := c.checkConfigs.ConfigError(c.appId, cfg.Id); err != nil {
+		ch.SetError("invalid config: %s", err)
+	}
 	c.Checks = append(c.Checks, ch)
 	return ch
 }
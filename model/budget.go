@@ -0,0 +1,128 @@
+package model
+
+import (
+	"github.com/coroot/coroot/timeseries"
+	"math"
+)
+
+type BudgetForecastStatus string
+
+const (
+	BudgetForecastInsufficientData BudgetForecastStatus = "insufficient_data"
+	BudgetForecastNotExhausting    BudgetForecastStatus = "not_exhausting"
+	BudgetForecastExhausting       BudgetForecastStatus = "exhausting"
+	BudgetForecastExhausted        BudgetForecastStatus = "exhausted"
+)
+
+const (
+	ErrorBudgetPeriod = 28 * timeseries.Day
+
+	// the minimum number of points with requests needed to forecast
+	budgetForecastMinPoints = 10
+
+	// the smoothing factors of the level and the trend of the burn rate
+	budgetForecastAlpha = 0.3
+	budgetForecastBeta  = 0.1
+	// the trend is damped so that it doesn't grow without bound over a long horizon
+	budgetForecastPhi = 0.98
+)
+
+type BudgetForecast struct {
+	Status BudgetForecastStatus `json:"status"`
+	// the fraction of the error budget of the period left after the observed requests
+	Remaining float64 `json:"remaining"`
+	// the smoothed burn rate at the end of the observed data
+	BurnRate    float64         `json:"burn_rate"`
+	ExhaustedAt timeseries.Time `json:"exhausted_at,omitempty"`
+	// from 0 to 1, how well the model predicted the observed burn rate
+	Confidence float64 `json:"confidence"`
+}
+
+// ForecastBudgetExhaustion estimates when the error budget of the period is exhausted if the burn rate
+// keeps following its recent level and trend (Holt's double exponential smoothing with a damped trend).
+// Each observed point consumes burnRate*step/period of the budget. The projection is limited to one period:
+// if the budget lasts longer, it's considered not exhausting.
+func ForecastBudgetExhaustion(bad, total timeseries.TimeSeries, objectivePercentage float64, period timeseries.Duration) BudgetForecast {
+	res := BudgetForecast{Status: BudgetForecastInsufficientData, Remaining: 1}
+	objective := 1 - objectivePercentage/100
+	if objective <= 0 || period <= 0 {
+		return res
+	}
+	badByTime := map[timeseries.Time]float64{}
+	iter := timeseries.Iter(bad)
+	for iter.Next() {
+		t, v := iter.Value()
+		badByTime[t] = v
+	}
+	var times []timeseries.Time
+	var rates []float64
+	iter = timeseries.Iter(total)
+	for iter.Next() {
+		t, v := iter.Value()
+		if math.IsNaN(v) || v <= 0 {
+			continue
+		}
+		b := badByTime[t]
+		if math.IsNaN(b) {
+			b = 0
+		}
+		times = append(times, t)
+		rates = append(rates, b/v/objective)
+	}
+	if len(rates) < budgetForecastMinPoints {
+		return res
+	}
+	step := times[1].Sub(times[0])
+	for i := 2; i < len(times); i++ {
+		if d := times[i].Sub(times[i-1]); d < step {
+			step = d
+		}
+	}
+	for _, r := range rates {
+		res.Remaining -= r * float64(step) / float64(period)
+	}
+	last := times[len(times)-1]
+
+	level, trend := rates[0], 0.
+	var absErr, sum float64
+	for _, r := range rates[1:] {
+		f := level + budgetForecastPhi*trend
+		absErr += math.Abs(r - f)
+		sum += r
+		prev := level
+		level = budgetForecastAlpha*r + (1-budgetForecastAlpha)*f
+		trend = budgetForecastBeta*(level-prev) + (1-budgetForecastBeta)*budgetForecastPhi*trend
+	}
+	res.BurnRate = math.Max(level, 0)
+	res.Confidence = 1
+	if sum > 0 {
+		res.Confidence = 1 / (1 + absErr/sum)
+	}
+
+	if res.Remaining <= 0 {
+		res.Remaining = 0
+		res.Status = BudgetForecastExhausted
+		res.ExhaustedAt = last
+		return res
+	}
+	if sum == 0 {
+		res.Status = BudgetForecastNotExhausting
+		return res
+	}
+
+	remaining := res.Remaining
+	damping := 0.
+	for h := timeseries.Duration(0); h < period; h += step {
+		damping = budgetForecastPhi * (1 + damping)
+		rate := math.Max(level+damping*trend, 0)
+		consumed := rate * float64(step) / float64(period)
+		if consumed >= remaining {
+			res.Status = BudgetForecastExhausting
+			res.ExhaustedAt = last.Add(h + timeseries.Duration(float64(step)*remaining/consumed))
+			return res
+		}
+		remaining -= consumed
+	}
+	res.Status = BudgetForecastNotExhausting
+	return res
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func burnCurve(points int, burnRate func(i int) float64) (timeseries.TimeSeries, timeseries.TimeSeries) {
+	step := timeseries.Minute
+	total := timeseries.New(0, points, step)
+	bad := timeseries.New(0, points, step)
+	for i := 0; i < points; i++ {
+		t := timeseries.Time(0).Add(timeseries.Duration(i) * step)
+		total.Set(t, 1000)
+		bad.Set(t, 1000*0.001*burnRate(i)) // the objective is 99.9%
+	}
+	return bad, total
+}
+
+func TestForecastBudgetExhaustion(t *testing.T) {
+	period := timeseries.Day
+	last := timeseries.Time(59 * 60)
+
+	bad, total := burnCurve(5, func(i int) float64 { return 10 })
+	f := ForecastBudgetExhaustion(bad, total, 99.9, period)
+	assert.Equal(t, BudgetForecastInsufficientData, f.Status)
+
+	bad, total = burnCurve(60, func(i int) float64 { return 0 })
+	f = ForecastBudgetExhaustion(bad, total, 99.9, period)
+	assert.Equal(t, BudgetForecastNotExhausting, f.Status)
+	assert.Equal(t, 1., f.Remaining)
+	assert.Equal(t, 1., f.Confidence)
+
+	// a constant burn rate of 12 consumes a day budget in 2 hours, one of which has passed
+	bad, total = burnCurve(60, func(i int) float64 { return 12 })
+	f = ForecastBudgetExhaustion(bad, total, 99.9, period)
+	assert.Equal(t, BudgetForecastExhausting, f.Status)
+	assert.InDelta(t, 0.5, f.Remaining, 0.0001)
+	assert.InDelta(t, 12, f.BurnRate, 0.0001)
+	assert.InDelta(t, float64(last.Add(timeseries.Hour)), float64(f.ExhaustedAt), 60)
+	assert.InDelta(t, 1, f.Confidence, 0.0001)
+
+	// a growing burn rate exhausts the budget sooner than the constant one with the same consumption so far
+	bad, total = burnCurve(60, func(i int) float64 { return float64(i) * 24 / 59 })
+	growing := ForecastBudgetExhaustion(bad, total, 99.9, period)
+	assert.Equal(t, BudgetForecastExhausting, growing.Status)
+	assert.InDelta(t, 0.5, growing.Remaining, 0.01)
+	assert.True(t, growing.ExhaustedAt.Before(f.ExhaustedAt))
+
+	// a low burn rate doesn't exhaust the budget within the period
+	bad, total = burnCurve(60, func(i int) float64 { return 0.5 })
+	f = ForecastBudgetExhaustion(bad, total, 99.9, period)
+	assert.Equal(t, BudgetForecastNotExhausting, f.Status)
+
+	// bursts lower the confidence
+	bad, total = burnCurve(60, func(i int) float64 { return float64(i%2) * 24 })
+	f = ForecastBudgetExhaustion(bad, total, 99.9, period)
+	assert.Equal(t, BudgetForecastExhausting, f.Status)
+	assert.Less(t, f.Confidence, 0.8)
+
+	bad, total = burnCurve(60, func(i int) float64 { return 30 })
+	f = ForecastBudgetExhaustion(bad, total, 99.9, period)
+	assert.Equal(t, BudgetForecastExhausted, f.Status)
+	assert.Equal(t, 0., f.Remaining)
+	assert.Equal(t, last, f.ExhaustedAt)
+}
@@ -26,6 +26,9 @@ type Chart struct {
 	IsColumn    bool         `json:"column"`
 	ColorShift  int          `json:"color_shift"`
 	Annotations []Annotation `json:"annotations"`
+
+	// the series observed a period ago, shifted to the chart's timeline to be overlaid on the current ones
+	Shifted []*Series `json:"shifted,omitempty"`
 }
 
 func NewChart(ctx timeseries.Context, title string) *Chart {
@@ -94,9 +97,10 @@ func (chart *Chart) Feature() *Chart {
 }
 
 type Series struct {
-	Name  string `json:"name"`
-	Color string `json:"color"`
-	Fill  bool   `json:"fill"`
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Fill        bool   `json:"fill"`
+	Approximate bool   `json:"approximate,omitempty"`
 
 	Data timeseries.TimeSeries `json:"data"`
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/coroot/coroot/utils"
 	"github.com/dustin/go-humanize/english"
 	"k8s.io/klog"
+	"math"
 	"reflect"
 	"text/template"
 )
@@ -214,6 +215,11 @@ func init() {
 	}
 }
 
+// GetCheckConfig returns the config of the check with the given id or nil if there's no such check.
+func GetCheckConfig(id CheckId) *CheckConfig {
+	return Checks.index[id]
+}
+
 type CheckContext struct {
 	items *utils.StringSet
 	count int64
@@ -248,6 +254,7 @@ type Check struct {
 	Title                   string    `json:"title"`
 	Status                  Status    `json:"status"`
 	Message                 string    `json:"message"`
+	Error                   string    `json:"error,omitempty"`
 	Threshold               float64   `json:"threshold"`
 	Unit                    CheckUnit `json:"unit"`
 	ConditionFormatTemplate string    `json:"condition_format_template"`
@@ -257,6 +264,7 @@ type Check struct {
 	items           *utils.StringSet
 	count           int64
 	fired           bool
+	burnRate        float64
 }
 
 func (ch *Check) Fire() {
@@ -264,10 +272,46 @@ func (ch *Check) Fire() {
 }
 
 func (ch *Check) SetStatus(status Status, format string, a ...any) {
+	if ch.Error != "" {
+		return
+	}
 	ch.Status = status
 	ch.Message = fmt.Sprintf(format, a...)
 }
 
+// SetError marks the check as failed to evaluate, e.g., due to a malformed config or a failing query.
+// The check gets the UNKNOWN status, which isn't changed by the subsequent evaluation.
+func (ch *Check) SetError(format string, a ...any) {
+	ch.Status = UNKNOWN
+	ch.Message = fmt.Sprintf(format, a...)
+	ch.Error = ch.Message
+}
+
+// SetBurnRate records the error budget burn rate an SLO check is based on.
+func (ch *Check) SetBurnRate(v float64) {
+	ch.burnRate = v
+}
+
+func (ch *Check) BurnRate() float64 {
+	return ch.burnRate
+}
+
+// Value returns the value the status of the check is based on: the number of events for event-based checks,
+// the number of items for item-based checks, and the error budget burn rate for SLO checks. It's NaN otherwise.
+func (ch *Check) Value() float64 {
+	switch ch.typ {
+	case CheckTypeEventBased:
+		return float64(ch.count)
+	case CheckTypeItemBased:
+		return float64(ch.items.Len())
+	}
+	switch ch.Id {
+	case Checks.SLOAvailability.Id, Checks.SLOLatency.Id:
+		return ch.burnRate
+	}
+	return math.NaN()
+}
+
 func (ch *Check) AddItem(format string, a ...any) {
 	if len(a) == 0 {
 		ch.items.Add(format)
@@ -281,6 +325,9 @@ func (ch *Check) Inc(amount int64) {
 }
 
 func (ch *Check) Calc() {
+	if ch.Error != "" {
+		return
+	}
 	switch ch.typ {
 	case CheckTypeEventBased:
 		if ch.count <= int64(ch.Threshold) {
@@ -314,24 +361,60 @@ type CheckConfigSimple struct {
 	Threshold float64 `json:"threshold"`
 }
 
+type AvailabilityQueries struct {
+	TotalRequestsQuery  string `json:"total_requests_query"`
+	FailedRequestsQuery string `json:"failed_requests_query"`
+	// the weight of the pair in a composite availability, see CheckConfigSLOAvailability.IsWeighted
+	Weight float64 `json:"weight,omitempty"`
+}
+
+func (q AvailabilityQueries) Total() string {
+	return fmt.Sprintf(`sum(rate(%s[$RANGE]))`, q.TotalRequestsQuery)
+}
+
+func (q AvailabilityQueries) Failed() string {
+	return fmt.Sprintf(`sum(rate(%s[$RANGE]))`, q.FailedRequestsQuery)
+}
+
 type CheckConfigSLOAvailability struct {
-	TotalRequestsQuery  string  `json:"total_requests_query"`
-	FailedRequestsQuery string  `json:"failed_requests_query"`
-	ObjectivePercentage float64 `json:"objective_percentage"`
+	AvailabilityQueries
+	AdditionalQueries   []AvailabilityQueries `json:"additional_queries,omitempty"`
+	ObjectivePercentage float64               `json:"objective_percentage"`
 }
 
-func (cfg *CheckConfigSLOAvailability) Total() string {
-	return fmt.Sprintf(`sum(rate(%s[$RANGE]))`, cfg.TotalRequestsQuery)
+// Queries returns all the total/failed query pairs; their results are summed up to calculate the SLI
+// unless the config is weighted.
+func (cfg *CheckConfigSLOAvailability) Queries() []AvailabilityQueries {
+	return append([]AvailabilityQueries{cfg.AvailabilityQueries}, cfg.AdditionalQueries...)
 }
 
-func (cfg *CheckConfigSLOAvailability) Failed() string {
-	return fmt.Sprintf(`sum(rate(%s[$RANGE]))`, cfg.FailedRequestsQuery)
+// IsWeighted reports whether the SLI is a composite availability: the weighted average of the availabilities
+// of the query pairs (see CompositeAvailability) rather than the availability of all the requests.
+func (cfg *CheckConfigSLOAvailability) IsWeighted() bool {
+	for _, q := range cfg.Queries() {
+		if q.Weight > 0 {
+			return true
+		}
+	}
+	return false
 }
 
 type CheckConfigSLOLatency struct {
-	HistogramQuery      string  `json:"histogram_query"`
-	ObjectiveBucket     float64 `json:"objective_bucket"`
-	ObjectivePercentage float64 `json:"objective_percentage"`
+	HistogramQuery      string    `json:"histogram_query"`
+	ObjectiveBucket     float64   `json:"objective_bucket"`
+	ObjectivePercentage float64   `json:"objective_percentage"`
+	DisplayBuckets      []float64 `json:"display_buckets,omitempty"`
+	// the unit of the histogram buckets (see utils.FormatByUnit), seconds if not specified
+	Unit string `json:"unit,omitempty"`
+}
+
+// FormatBucket formats a bucket boundary, e.g., the objective bucket, according to the unit of the histogram.
+func (cfg *CheckConfigSLOLatency) FormatBucket(le float64) string {
+	unit := cfg.Unit
+	if unit == "" {
+		unit = utils.UnitSeconds
+	}
+	return utils.FormatByUnit(le, unit)
 }
 
 func (cfg *CheckConfigSLOLatency) Histogram() string {
@@ -341,7 +424,7 @@ func (cfg *CheckConfigSLOLatency) Histogram() string {
 type CheckConfigs map[ApplicationId]map[CheckId]json.RawMessage
 
 func (cc CheckConfigs) getRaw(appId ApplicationId, checkId CheckId) json.RawMessage {
-	for _, i := range []ApplicationId{appId, {}} {
+	for _, i := range []ApplicationId{appId, appId.NamespaceId(), {}} {
 		if appConfigs, ok := cc[i]; ok {
 			if cfg, ok := appConfigs[checkId]; ok {
 				return cfg
@@ -374,7 +457,7 @@ func (cc CheckConfigs) GetSimpleAll(checkId CheckId, appId ApplicationId) []*Che
 	res := []*CheckConfigSimple{{Threshold: Checks.index[checkId].DefaultThreshold}}
 	ids := []ApplicationId{ApplicationIdZero}
 	if !appId.IsZero() {
-		ids = append(ids, appId)
+		ids = append(ids, appId.NamespaceId(), appId)
 	}
 	for _, id := range ids {
 		if appConfigs, ok := cc[id]; ok {
@@ -419,33 +502,48 @@ func (cc CheckConfigs) GetByCheck(id CheckId) map[ApplicationId][]any {
 	return res
 }
 
-func (cc CheckConfigs) GetAvailability(appId ApplicationId) []CheckConfigSLOAvailability {
-	appConfigs := cc[appId]
-	if appConfigs == nil {
-		return nil
-	}
-	raw, ok := appConfigs[Checks.SLOAvailability.Id]
-	if !ok {
-		return nil
+func (cc CheckConfigs) getRawSLO(appId ApplicationId, checkId CheckId) json.RawMessage {
+	if raw := cc.getOwnRaw(appId, checkId); raw != nil {
+		return raw
 	}
-	res, err := unmarshal[[]CheckConfigSLOAvailability](raw)
-	if err != nil {
-		klog.Warningln("failed to unmarshal check config:", err)
-		return nil
+	return cc.getOwnRaw(appId.NamespaceId(), checkId)
+}
+
+// getOwnRaw returns the config defined exactly for the application or namespace, ignoring the inherited ones.
+func (cc CheckConfigs) getOwnRaw(id ApplicationId, checkId CheckId) json.RawMessage {
+	if appConfigs, ok := cc[id]; ok {
+		if cfg, ok := appConfigs[checkId]; ok {
+			return cfg
+		}
 	}
-	return res
+	return nil
 }
 
+// GetAvailability returns the availability SLO configs of the application, or the ones of its namespace if it has none.
+func (cc CheckConfigs) GetAvailability(appId ApplicationId) []CheckConfigSLOAvailability {
+	return unmarshalSLO[CheckConfigSLOAvailability](cc.getRawSLO(appId, Checks.SLOAvailability.Id))
+}
+
+// GetOwnAvailability returns the availability SLO configs defined exactly for the application or namespace.
+func (cc CheckConfigs) GetOwnAvailability(id ApplicationId) []CheckConfigSLOAvailability {
+	return unmarshalSLO[CheckConfigSLOAvailability](cc.getOwnRaw(id, Checks.SLOAvailability.Id))
+}
+
+// GetLatency returns the latency SLO configs of the application, or the ones of its namespace if it has none.
 func (cc CheckConfigs) GetLatency(appId ApplicationId) []CheckConfigSLOLatency {
-	appConfigs := cc[appId]
-	if appConfigs == nil {
-		return nil
-	}
-	raw, ok := appConfigs[Checks.SLOLatency.Id]
-	if !ok {
+	return unmarshalSLO[CheckConfigSLOLatency](cc.getRawSLO(appId, Checks.SLOLatency.Id))
+}
+
+// GetOwnLatency returns the latency SLO configs defined exactly for the application or namespace.
+func (cc CheckConfigs) GetOwnLatency(id ApplicationId) []CheckConfigSLOLatency {
+	return unmarshalSLO[CheckConfigSLOLatency](cc.getOwnRaw(id, Checks.SLOLatency.Id))
+}
+
+func unmarshalSLO[T any](raw json.RawMessage) []T {
+	if raw == nil {
 		return nil
 	}
-	res, err := unmarshal[[]CheckConfigSLOLatency](raw)
+	res, err := unmarshal[[]T](raw)
 	if err != nil {
 		klog.Warningln("failed to unmarshal check config:", err)
 		return nil
@@ -453,6 +551,26 @@ func (cc CheckConfigs) GetLatency(appId ApplicationId) []CheckConfigSLOLatency {
 	return res
 }
 
+// ConfigError returns the error of unmarshalling the config of the check applied to the application, if any.
+func (cc CheckConfigs) ConfigError(appId ApplicationId, checkId CheckId) error {
+	var err error
+	switch checkId {
+	case Checks.SLOAvailability.Id:
+		if raw := cc.getRawSLO(appId, checkId); raw != nil {
+			_, err = unmarshal[[]CheckConfigSLOAvailability](raw)
+		}
+	case Checks.SLOLatency.Id:
+		if raw := cc.getRawSLO(appId, checkId); raw != nil {
+			_, err = unmarshal[[]CheckConfigSLOLatency](raw)
+		}
+	default:
+		if raw := cc.getRaw(appId, checkId); raw != nil {
+			_, err = unmarshal[CheckConfigSimple](raw)
+		}
+	}
+	return err
+}
+
 func unmarshal[T any](raw json.RawMessage) (T, error) {
 	var cfg T
 	if err := json.Unmarshal(raw, &cfg); err != nil {
@@ -0,0 +1,103 @@
+package model
+
+import (
+	"sort"
+)
+
+type CheckConfigScope string
+
+const (
+	CheckConfigScopeGlobal      CheckConfigScope = "global"
+	CheckConfigScopeNamespace   CheckConfigScope = "namespace"
+	CheckConfigScopeApplication CheckConfigScope = "app"
+)
+
+func (s CheckConfigScope) IsValid() bool {
+	switch s {
+	case CheckConfigScopeGlobal, CheckConfigScopeNamespace, CheckConfigScopeApplication:
+		return true
+	}
+	return false
+}
+
+func CheckConfigScopeOf(appId ApplicationId) CheckConfigScope {
+	switch {
+	case appId.IsZero():
+		return CheckConfigScopeGlobal
+	case appId.IsNamespace():
+		return CheckConfigScopeNamespace
+	}
+	return CheckConfigScopeApplication
+}
+
+type CheckConfigEntry struct {
+	Scope         CheckConfigScope `json:"scope"`
+	ApplicationId ApplicationId    `json:"application_id"`
+	CheckId       CheckId          `json:"check_id"`
+	Params        map[string]any   `json:"params"`
+	Error         string           `json:"error,omitempty"`
+}
+
+// ListCheckConfigs flattens the saved check configs of a project into a list with the key parameters of each config.
+// Empty checkId and scope match any check and scope.
+func ListCheckConfigs(cc CheckConfigs, checkId CheckId, scope CheckConfigScope) []CheckConfigEntry {
+	var res []CheckConfigEntry
+	for appId, appConfigs := range cc {
+		s := CheckConfigScopeOf(appId)
+		if scope != "" && s != scope {
+			continue
+		}
+		for id, raw := range appConfigs {
+			if checkId != "" && id != checkId {
+				continue
+			}
+			e := CheckConfigEntry{Scope: s, ApplicationId: appId, CheckId: id, Params: map[string]any{}}
+			if err := e.setParams(raw); err != nil {
+				e.Error = "invalid config: " + err.Error()
+			}
+			res = append(res, e)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].CheckId != res[j].CheckId {
+			return res[i].CheckId < res[j].CheckId
+		}
+		return res[i].ApplicationId.String() < res[j].ApplicationId.String()
+	})
+	return res
+}
+
+func (e *CheckConfigEntry) setParams(raw []byte) error {
+	switch e.CheckId {
+	case Checks.SLOAvailability.Id:
+		cfgs, err := unmarshal[[]CheckConfigSLOAvailability](raw)
+		if err != nil {
+			return err
+		}
+		e.Params["configs"] = len(cfgs)
+		if len(cfgs) > 0 {
+			e.Params["objective_percentage"] = cfgs[0].ObjectivePercentage
+			e.Params["total_requests_query"] = cfgs[0].TotalRequestsQuery
+			e.Params["failed_requests_query"] = cfgs[0].FailedRequestsQuery
+		}
+	case Checks.SLOLatency.Id:
+		cfgs, err := unmarshal[[]CheckConfigSLOLatency](raw)
+		if err != nil {
+			return err
+		}
+		e.Params["configs"] = len(cfgs)
+		if len(cfgs) > 0 {
+			e.Params["objective_percentage"] = cfgs[0].ObjectivePercentage
+			e.Params["objective_bucket"] = cfgs[0].FormatBucket(cfgs[0].ObjectiveBucket)
+			e.Params["histogram_query"] = cfgs[0].HistogramQuery
+		}
+	default:
+		cfg, err := unmarshal[CheckConfigSimple](raw)
+		if err != nil {
+			return err
+		}
+		e.Params["threshold"] = cfg.Threshold
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestListCheckConfigs(t *testing.T) {
+	app := NewApplicationId("default", ApplicationKindDeployment, "app")
+	ns := app.NamespaceId()
+	cc := CheckConfigs{
+		ApplicationIdZero: {
+			Checks.CPUNode.Id: json.RawMessage(`{"threshold":80}`),
+		},
+		ns: {
+			Checks.CPUNode.Id: json.RawMessage(`{"threshold":90}`),
+		},
+		app: {
+			Checks.CPUContainer.Id: json.RawMessage(`{"threshold":"x"}`),
+			Checks.SLOLatency.Id:   json.RawMessage(`[{"histogram_query":"h","objective_bucket":0.5,"objective_percentage":99}]`),
+		},
+	}
+	list := func(checkId CheckId, scope CheckConfigScope) []string {
+		var res []string
+		for _, e := range ListCheckConfigs(cc, checkId, scope) {
+			p, _ := json.Marshal(e.Params)
+			s := string(e.Scope) + " " + e.ApplicationId.String() + " " + string(e.CheckId) + " " + string(p)
+			if e.Error != "" {
+				s += " (" + e.Error + ")"
+			}
+			res = append(res, s)
+		}
+		return res
+	}
+
+	assert.Equal(t, []string{
+		"app default:Deployment:app CPUContainer {} (invalid config: json: cannot unmarshal string into Go struct field CheckConfigSimple.threshold of type float64)",
+		"global :: CPUNode {\"threshold\":80}",
+		"namespace default:: CPUNode {\"threshold\":90}",
+		"app default:Deployment:app SLOLatency {\"configs\":1,\"histogram_query\":\"h\",\"objective_bucket\":\"500 ms\",\"objective_percentage\":99}",
+	}, list("", ""))
+
+	assert.Equal(t, []string{
+		"global :: CPUNode {\"threshold\":80}",
+		"namespace default:: CPUNode {\"threshold\":90}",
+	}, list(Checks.CPUNode.Id, ""))
+
+	assert.Equal(t, []string{
+		"namespace default:: CPUNode {\"threshold\":90}",
+	}, list("", CheckConfigScopeNamespace))
+
+	assert.Nil(t, list(Checks.SLOAvailability.Id, ""))
+}
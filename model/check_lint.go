@@ -0,0 +1,127 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+type LintLevel string
+
+const (
+	LintError   LintLevel = "error"
+	LintWarning LintLevel = "warning"
+)
+
+type LintIssue struct {
+	ApplicationId ApplicationId `json:"application_id"`
+	CheckId       CheckId       `json:"check_id"`
+	Level         LintLevel     `json:"level"`
+	Message       string        `json:"message"`
+}
+
+// LintCheckConfigs validates all the check configs of a project and reports both invalid values
+// and logical issues, such as configs that are never applied or just repeat the inherited ones.
+func LintCheckConfigs(cc CheckConfigs) []LintIssue {
+	var res []LintIssue
+	add := func(appId ApplicationId, checkId CheckId, level LintLevel, format string, a ...any) {
+		res = append(res, LintIssue{ApplicationId: appId, CheckId: checkId, Level: level, Message: fmt.Sprintf(format, a...)})
+	}
+
+	for appId, appConfigs := range cc {
+		for checkId, raw := range appConfigs {
+			if Checks.index[checkId] == nil {
+				add(appId, checkId, LintError, "unknown check")
+				continue
+			}
+			switch checkId {
+			case Checks.SLOAvailability.Id:
+				if appId.IsZero() {
+					add(appId, checkId, LintWarning, "SLO configs can't be defined project-wide, this config is never applied")
+				}
+				cfgs, err := unmarshal[[]CheckConfigSLOAvailability](raw)
+				if err != nil {
+					add(appId, checkId, LintError, "invalid config: %s", err)
+					continue
+				}
+				if len(cfgs) == 0 {
+					add(appId, checkId, LintWarning, "empty config")
+				}
+				for i, cfg := range cfgs {
+					lintObjective(add, appId, checkId, i, cfg.ObjectivePercentage)
+					for _, q := range cfg.Queries() {
+						switch {
+						case q.TotalRequestsQuery == "" || q.FailedRequestsQuery == "":
+							add(appId, checkId, LintError, "config #%d: empty query", i+1)
+						case q.TotalRequestsQuery == q.FailedRequestsQuery:
+							add(appId, checkId, LintWarning, "config #%d: the total and failed requests queries are the same", i+1)
+						}
+					}
+				}
+			case Checks.SLOLatency.Id:
+				if appId.IsZero() {
+					add(appId, checkId, LintWarning, "SLO configs can't be defined project-wide, this config is never applied")
+				}
+				cfgs, err := unmarshal[[]CheckConfigSLOLatency](raw)
+				if err != nil {
+					add(appId, checkId, LintError, "invalid config: %s", err)
+					continue
+				}
+				if len(cfgs) == 0 {
+					add(appId, checkId, LintWarning, "empty config")
+				}
+				for i, cfg := range cfgs {
+					lintObjective(add, appId, checkId, i, cfg.ObjectivePercentage)
+					if cfg.HistogramQuery == "" {
+						add(appId, checkId, LintError, "config #%d: empty query", i+1)
+					}
+					if cfg.ObjectiveBucket <= 0 {
+						add(appId, checkId, LintError, "config #%d: the objective bucket must be greater than 0", i+1)
+					}
+					if len(cfg.DisplayBuckets) > 0 && !sort.Float64sAreSorted(cfg.DisplayBuckets) {
+						add(appId, checkId, LintError, "config #%d: the display buckets must be in ascending order", i+1)
+					}
+				}
+			default:
+				cfg, err := unmarshal[CheckConfigSimple](raw)
+				if err != nil {
+					add(appId, checkId, LintError, "invalid config: %s", err)
+					continue
+				}
+				if cfg.Threshold < 0 {
+					add(appId, checkId, LintError, "the threshold must not be negative")
+				}
+				if appId.IsZero() {
+					continue
+				}
+				parent := ApplicationIdZero
+				if !appId.IsNamespace() {
+					parent = appId.NamespaceId()
+				}
+				if inherited := cc.getRaw(parent, checkId); inherited != nil && bytes.Equal(inherited, raw) {
+					add(appId, checkId, LintWarning, "the config is the same as the inherited one and can be removed")
+				}
+			}
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].ApplicationId != res[j].ApplicationId {
+			return res[i].ApplicationId.String() < res[j].ApplicationId.String()
+		}
+		if res[i].CheckId != res[j].CheckId {
+			return res[i].CheckId < res[j].CheckId
+		}
+		return res[i].Message < res[j].Message
+	})
+	return res
+}
+
+func lintObjective(add func(ApplicationId, CheckId, LintLevel, string, ...any), appId ApplicationId, checkId CheckId, i int, objective float64) {
+	switch {
+	case objective < 0 || objective > 100:
+		add(appId, checkId, LintError, "config #%d: the objective must be greater than 0 and less than or equal to 100", i+1)
+	case objective == 100:
+		add(appId, checkId, LintWarning, "config #%d: the objective of 100%% leaves no error budget", i+1)
+	}
+}
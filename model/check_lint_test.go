@@ -0,0 +1,42 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLintCheckConfigs(t *testing.T) {
+	app := NewApplicationId("default", ApplicationKindDeployment, "app")
+	ns := app.NamespaceId()
+	cc := CheckConfigs{
+		ApplicationIdZero: {
+			Checks.CPUNode.Id:         json.RawMessage(`{"threshold":80}`),
+			Checks.SLOAvailability.Id: json.RawMessage(`[{"total_requests_query":"a","failed_requests_query":"b","objective_percentage":99}]`),
+		},
+		ns: {
+			Checks.CPUNode.Id: json.RawMessage(`{"threshold":80}`),
+		},
+		app: {
+			Checks.CPUContainer.Id:    json.RawMessage(`{"threshold":-1}`),
+			Checks.SLOAvailability.Id: json.RawMessage(`[{"total_requests_query":"a","failed_requests_query":"a","objective_percentage":100}]`),
+			Checks.SLOLatency.Id:      json.RawMessage(`[{"histogram_query":"","objective_bucket":0,"objective_percentage":120}]`),
+			"unknown":                 json.RawMessage(`{}`),
+		},
+	}
+	var issues []string
+	for _, i := range LintCheckConfigs(cc) {
+		issues = append(issues, i.ApplicationId.String()+" "+string(i.CheckId)+" "+string(i.Level)+": "+i.Message)
+	}
+	assert.Equal(t, []string{
+		":: SLOAvailability warning: SLO configs can't be defined project-wide, this config is never applied",
+		"default:: CPUNode warning: the config is the same as the inherited one and can be removed",
+		"default:Deployment:app CPUContainer error: the threshold must not be negative",
+		"default:Deployment:app SLOAvailability warning: config #1: the objective of 100% leaves no error budget",
+		"default:Deployment:app SLOAvailability warning: config #1: the total and failed requests queries are the same",
+		"default:Deployment:app SLOLatency error: config #1: empty query",
+		"default:Deployment:app SLOLatency error: config #1: the objective bucket must be greater than 0",
+		"default:Deployment:app SLOLatency error: config #1: the objective must be greater than 0 and less than or equal to 100",
+		"default:Deployment:app unknown error: unknown check",
+	}, issues)
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMalformedCheckConfig(t *testing.T) {
+	app := NewApplicationId("default", ApplicationKindDeployment, "app")
+	other := NewApplicationId("default", ApplicationKindDeployment, "other")
+	cc := CheckConfigs{
+		app: {
+			Checks.SLOAvailability.Id: json.RawMessage(`{"objective_percentage":99}`),
+			Checks.CPUContainer.Id:    json.RawMessage(`{"threshold":"x"}`),
+		},
+		other: {
+			Checks.CPUContainer.Id: json.RawMessage(`{"threshold":90}`),
+		},
+	}
+	assert.Error(t, cc.ConfigError(app, Checks.SLOAvailability.Id))
+	assert.Error(t, cc.ConfigError(app, Checks.CPUContainer.Id))
+	assert.NoError(t, cc.ConfigError(other, Checks.CPUContainer.Id))
+	assert.NoError(t, cc.ConfigError(other, Checks.SLOAvailability.Id))
+
+	r := NewAuditReport(app, timeseries.Context{}, cc, AuditReportCPU)
+	ch := r.CreateCheck(Checks.CPUContainer)
+	assert.Equal(t, UNKNOWN, ch.Status)
+	assert.NotEmpty(t, ch.Error)
+	ch.SetStatus(WARNING, "high CPU usage")
+	ch.Calc()
+	assert.Equal(t, UNKNOWN, ch.Status)
+	assert.Equal(t, ch.Error, ch.Message)
+
+	r = NewAuditReport(other, timeseries.Context{}, cc, AuditReportCPU)
+	ch = r.CreateCheck(Checks.CPUContainer)
+	assert.Equal(t, OK, ch.Status)
+	assert.Empty(t, ch.Error)
+	assert.Equal(t, float64(90), ch.Threshold)
+}
+
+func TestSLOConfigLevels(t *testing.T) {
+	app := NewApplicationId("default", ApplicationKindDeployment, "app")
+	other := NewApplicationId("default", ApplicationKindDeployment, "other")
+	cc := CheckConfigs{
+		app.NamespaceId(): {
+			Checks.SLOAvailability.Id: json.RawMessage(`[{"total_requests_query":"ns_total","failed_requests_query":"ns_failed","objective_percentage":99}]`),
+		},
+		app: {
+			Checks.SLOLatency.Id: json.RawMessage(`[{"histogram_query":"app_histogram","objective_bucket":0.1,"objective_percentage":99}]`),
+		},
+	}
+	// the namespace config applies to the applications that have none of their own
+	assert.Equal(t, "ns_total", cc.GetAvailability(app)[0].TotalRequestsQuery)
+	assert.Equal(t, "ns_total", cc.GetAvailability(other)[0].TotalRequestsQuery)
+	assert.Nil(t, cc.GetOwnAvailability(app))
+	assert.Equal(t, "ns_total", cc.GetOwnAvailability(app.NamespaceId())[0].TotalRequestsQuery)
+
+	assert.Equal(t, "app_histogram", cc.GetOwnLatency(app)[0].HistogramQuery)
+	assert.Nil(t, cc.GetOwnLatency(app.NamespaceId()))
+	assert.Nil(t, cc.GetLatency(other))
+}
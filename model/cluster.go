@@ -0,0 +1,95 @@
+package model
+
+import (
+	"github.com/coroot/coroot/timeseries"
+	"math"
+)
+
+type ClusterResource struct {
+	Capacity timeseries.TimeSeries `json:"capacity"`
+	Usage    timeseries.TimeSeries `json:"usage"`
+	// the percentage of the capacity in use
+	Utilization timeseries.TimeSeries `json:"utilization"`
+}
+
+type ClusterTotals struct {
+	// the number of nodes reporting both the capacity and the usage of CPU
+	Nodes  timeseries.TimeSeries `json:"nodes"`
+	CPU    ClusterResource       `json:"cpu"`    // cores
+	Memory ClusterResource       `json:"memory"` // bytes
+	Disk   ClusterResource       `json:"disk"`   // bytes of the volumes
+}
+
+// CalcClusterTotals rolls the node series up into cluster-wide totals. A node contributes to a resource at a step only
+// if both its capacity and usage are known at that step, so a node appearing or disappearing mid-window
+// (or missing a scrape) changes the capacity and the usage together, and the utilization doesn't jump artificially.
+func CalcClusterTotals(nodes []*Node) *ClusterTotals {
+	var cpu, memory, disk clusterResourceSum
+	var count timeseries.TimeSeries
+	for _, n := range nodes {
+		cpuUsage := timeseries.Aggregate(func(t timeseries.Time, capacity, percent float64) float64 {
+			return capacity * percent / 100
+		}, n.CpuCapacity, n.CpuUsagePercent)
+		if cpu.add(n.CpuCapacity, cpuUsage) {
+			count = timeseries.Merge(count, timeseries.Map(timeseries.Defined, pairedWith(n.CpuCapacity, cpuUsage)), timeseries.NanSum)
+		}
+		memory.add(n.MemoryTotalBytes, timeseries.Aggregate(timeseries.Sub, n.MemoryTotalBytes, n.MemoryAvailableBytes))
+
+		devices := map[string]bool{}
+		for _, i := range n.Instances {
+			for _, v := range i.Volumes {
+				device := v.Device.Value()
+				if device == "" || devices[device] {
+					continue
+				}
+				devices[device] = true
+				disk.add(v.CapacityBytes, v.UsedBytes)
+			}
+		}
+	}
+	return &ClusterTotals{
+		Nodes:  count,
+		CPU:    cpu.get(),
+		Memory: memory.get(),
+		Disk:   disk.get(),
+	}
+}
+
+type clusterResourceSum struct {
+	capacity timeseries.TimeSeries
+	usage    timeseries.TimeSeries
+}
+
+// add sums up the capacity and the usage at the steps both of them are known.
+// It reports whether the series have been added.
+func (s *clusterResourceSum) add(capacity, usage timeseries.TimeSeries) bool {
+	if timeseries.IsEmpty(capacity) || timeseries.IsEmpty(usage) {
+		return false
+	}
+	s.capacity = timeseries.Merge(s.capacity, pairedWith(capacity, usage), timeseries.NanSum)
+	s.usage = timeseries.Merge(s.usage, pairedWith(usage, capacity), timeseries.NanSum)
+	return true
+}
+
+func (s *clusterResourceSum) get() ClusterResource {
+	r := ClusterResource{Capacity: s.capacity, Usage: s.usage}
+	if !timeseries.IsEmpty(s.capacity) {
+		r.Utilization = timeseries.Aggregate(func(t timeseries.Time, usage, capacity float64) float64 {
+			if capacity <= 0 {
+				return timeseries.NaN
+			}
+			return usage / capacity * 100
+		}, s.usage, s.capacity)
+	}
+	return r
+}
+
+// pairedWith returns the values of ts at the steps other is also known, NaN otherwise.
+func pairedWith(ts, other timeseries.TimeSeries) timeseries.TimeSeries {
+	return timeseries.Aggregate(func(t timeseries.Time, v, o float64) float64 {
+		if math.IsNaN(o) {
+			return timeseries.NaN
+		}
+		return v
+	}, ts, other)
+}
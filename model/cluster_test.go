@@ -0,0 +1,37 @@
+package model
+
+import (
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCalcClusterTotals(t *testing.T) {
+	nan := timeseries.NaN
+	ts := func(vs ...float64) timeseries.TimeSeries {
+		return timeseries.NewWithData(0, timeseries.Minute, vs)
+	}
+
+	n1 := NewNode("n1")
+	n1.CpuCapacity = ts(4, 4, 4, 4)
+	n1.CpuUsagePercent = ts(50, 50, 50, 50)
+	n1.MemoryTotalBytes = ts(100, 100, 100, 100)
+	n1.MemoryAvailableBytes = ts(50, 50, 50, 50)
+
+	// the second node disappears after two steps, its capacity is still reported at the third one
+	n2 := NewNode("n2")
+	n2.CpuCapacity = ts(4, 4, 4, nan)
+	n2.CpuUsagePercent = ts(100, 100, nan, nan)
+	n2.MemoryTotalBytes = ts(100, 100, 100, nan)
+	n2.MemoryAvailableBytes = ts(0, 0, nan, nan)
+
+	totals := CalcClusterTotals([]*Node{n1, n2})
+	assert.Equal(t, "AggregatedTimeseries(2 2 1 1)", totals.Nodes.String())
+	assert.Equal(t, "AggregatedTimeseries(8 8 4 4)", totals.CPU.Capacity.String())
+	assert.Equal(t, "AggregatedTimeseries(6 6 2 2)", totals.CPU.Usage.String())
+	assert.Equal(t, "AggregatedTimeseries(75 75 50 50)", totals.CPU.Utilization.String())
+	assert.Equal(t, "AggregatedTimeseries(200 200 100 100)", totals.Memory.Capacity.String())
+	assert.Equal(t, "AggregatedTimeseries(75 75 50 50)", totals.Memory.Utilization.String())
+	assert.Nil(t, totals.Disk.Capacity)
+	assert.Nil(t, totals.Disk.Utilization)
+}
@@ -21,6 +21,9 @@ type Connection struct {
 	Connects timeseries.TimeSeries
 	Active   timeseries.TimeSeries
 
+	BytesSent     timeseries.TimeSeries
+	BytesReceived timeseries.TimeSeries
+
 	RequestsCount     map[Protocol]map[string]timeseries.TimeSeries // by status
 	RequestsLatency   map[Protocol]timeseries.TimeSeries
 	RequestsHistogram map[Protocol]map[float64]timeseries.TimeSeries // by le
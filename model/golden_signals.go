@@ -0,0 +1,78 @@
+package model
+
+import (
+	"strings"
+)
+
+// GoldenSignal refers to a chart of an audit report that is worth featuring on the application page.
+// Chart is matched as a case-insensitive prefix of the chart or chart group title.
+type GoldenSignal struct {
+	Report AuditReportName `json:"report"`
+	Chart  string          `json:"chart"`
+}
+
+var (
+	goldenSignalsService = []GoldenSignal{
+		{Report: AuditReportSLO, Chart: "Requests to"},
+		{Report: AuditReportSLO, Chart: "Availability"},
+		{Report: AuditReportSLO, Chart: "Latency"},
+		{Report: AuditReportCPU, Chart: "CPU usage of container"},
+		{Report: AuditReportMemory, Chart: "Memory usage (RSS)"},
+	}
+	goldenSignalsStateful = []GoldenSignal{
+		{Report: AuditReportSLO, Chart: "Availability"},
+		{Report: AuditReportSLO, Chart: "Latency"},
+		{Report: AuditReportStorage, Chart: "I/O latency"},
+		{Report: AuditReportStorage, Chart: "Disk space"},
+		{Report: AuditReportMemory, Chart: "Memory usage (RSS)"},
+	}
+	goldenSignalsDatabase = []GoldenSignal{
+		{Report: AuditReportPostgres, Chart: "Postgres query latency"},
+		{Report: AuditReportPostgres, Chart: "Queries per second"},
+		{Report: AuditReportPostgres, Chart: "Errors"},
+		{Report: AuditReportPostgres, Chart: "Replication lag"},
+		{Report: AuditReportRedis, Chart: "Redis latency"},
+	}
+	goldenSignalsBatch = []GoldenSignal{
+		{Report: AuditReportInstances, Chart: "Instances"},
+		{Report: AuditReportCPU, Chart: "CPU usage of container"},
+		{Report: AuditReportMemory, Chart: "Memory usage (RSS)"},
+		{Report: AuditReportMemory, Chart: "Out of memory events"},
+	}
+	goldenSignalsAgent = []GoldenSignal{
+		{Report: AuditReportInstances, Chart: "Instances"},
+		{Report: AuditReportCPU, Chart: "CPU usage of container"},
+		{Report: AuditReportMemory, Chart: "Memory usage (RSS)"},
+		{Report: AuditReportNetwork, Chart: "Network round-trip time"},
+	}
+)
+
+var BuiltinGoldenSignals = map[ApplicationKind][]GoldenSignal{
+	ApplicationKindDeployment:      goldenSignalsService,
+	ApplicationKindPod:             goldenSignalsService,
+	ApplicationKindStaticPods:      goldenSignalsService,
+	ApplicationKindUnknown:         goldenSignalsService,
+	ApplicationKindCustom:          goldenSignalsService,
+	ApplicationKindStatefulSet:     goldenSignalsStateful,
+	ApplicationKindDatabaseCluster: goldenSignalsDatabase,
+	ApplicationKindRds:             goldenSignalsDatabase,
+	ApplicationKindCronJob:         goldenSignalsBatch,
+	ApplicationKindJob:             goldenSignalsBatch,
+	ApplicationKindDaemonSet:       goldenSignalsAgent,
+	ApplicationKindExternalService: {{Report: AuditReportNetwork, Chart: "Network round-trip time"}},
+}
+
+// GetGoldenSignals returns the custom golden signals defined for the kind, falling back to the built-in ones.
+func GetGoldenSignals(kind ApplicationKind, custom map[ApplicationKind][]GoldenSignal) []GoldenSignal {
+	if gs, ok := custom[kind]; ok {
+		return gs
+	}
+	if gs, ok := BuiltinGoldenSignals[kind]; ok {
+		return gs
+	}
+	return goldenSignalsService
+}
+
+func (gs GoldenSignal) Match(report AuditReportName, title string) bool {
+	return gs.Report == report && strings.HasPrefix(strings.ToLower(title), strings.ToLower(gs.Chart))
+}
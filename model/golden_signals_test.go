@@ -0,0 +1,21 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetGoldenSignals(t *testing.T) {
+	custom := map[ApplicationKind][]GoldenSignal{
+		ApplicationKindDeployment: {{Report: AuditReportCPU, Chart: "CPU usage"}},
+	}
+	assert.Equal(t, custom[ApplicationKindDeployment], GetGoldenSignals(ApplicationKindDeployment, custom))
+	assert.Equal(t, BuiltinGoldenSignals[ApplicationKindStatefulSet], GetGoldenSignals(ApplicationKindStatefulSet, custom))
+	assert.Equal(t, goldenSignalsService, GetGoldenSignals("NewKind", nil))
+
+	gs := GoldenSignal{Report: AuditReportMemory, Chart: "Memory usage (RSS)"}
+	assert.True(t, gs.Match(AuditReportMemory, "Memory usage (RSS) <selector>, bytes"))
+	assert.True(t, gs.Match(AuditReportMemory, "memory usage (rss)"))
+	assert.False(t, gs.Match(AuditReportCPU, "Memory usage (RSS)"))
+	assert.False(t, gs.Match(AuditReportMemory, "Out of memory events"))
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"fmt"
+	"math"
+)
+
+// HealthRollupFunction combines the statuses of the checks of an application into its status.
+type HealthRollupFunction string
+
+const (
+	// HealthRollupWorst is the status of the most severe check.
+	HealthRollupWorst HealthRollupFunction = "worst"
+	// HealthRollupMajority is the status most checks have, a tie is resolved in favor of the more severe status.
+	HealthRollupMajority HealthRollupFunction = "majority"
+	// HealthRollupWeighted is the weighted average of the statuses rounded to the nearest one.
+	HealthRollupWeighted HealthRollupFunction = "weighted"
+)
+
+func (f HealthRollupFunction) IsValid() bool {
+	switch f {
+	case HealthRollupWorst, HealthRollupMajority, HealthRollupWeighted:
+		return true
+	}
+	return false
+}
+
+// HealthRollup defines how the statuses of the checks of an application are combined into its status.
+// Only the checks of the reports affecting the status of an application are taken into account
+// (see AuditReportName.AffectsApplicationStatus), the checks with the UNKNOWN status are ignored.
+type HealthRollup struct {
+	Function HealthRollupFunction `json:"function"`
+	// the weights of the checks for HealthRollupWeighted, 1 if not specified
+	Weights map[CheckId]float64 `json:"weights,omitempty"`
+}
+
+func (r *HealthRollup) Validate() error {
+	if r == nil {
+		return nil
+	}
+	if !r.Function.IsValid() {
+		return fmt.Errorf("invalid function: %s", r.Function)
+	}
+	for id, w := range r.Weights {
+		if Checks.index[id] == nil {
+			return fmt.Errorf("unknown check: %s", id)
+		}
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return fmt.Errorf("invalid weight of %s: %v", id, w)
+		}
+	}
+	return nil
+}
+
+// Status returns the status of the audited application. The status calculated by the auditor (the worst one)
+// is returned if the rollup isn't configured.
+func (r *HealthRollup) Status(app *Application) Status {
+	if r == nil || r.Function == HealthRollupWorst || r.Function == "" {
+		return app.Status
+	}
+	var checks []*Check
+	for _, report := range app.Reports {
+		if !report.Name.AffectsApplicationStatus() {
+			continue
+		}
+		for _, ch := range report.Checks {
+			if ch.Status != UNKNOWN {
+				checks = append(checks, ch)
+			}
+		}
+	}
+	if len(checks) == 0 {
+		return app.Status
+	}
+	switch r.Function {
+	case HealthRollupMajority:
+		counts := map[Status]int{}
+		var res Status
+		for _, ch := range checks {
+			counts[ch.Status]++
+			if c := counts[ch.Status]; c > counts[res] || (c == counts[res] && ch.Status > res) {
+				res = ch.Status
+			}
+		}
+		return res
+	case HealthRollupWeighted:
+		var sum, total float64
+		for _, ch := range checks {
+			w, ok := r.Weights[ch.Id]
+			if !ok {
+				w = 1
+			}
+			sum += w * float64(ch.Status)
+			total += w
+		}
+		if total == 0 {
+			return app.Status
+		}
+		return Status(math.Round(sum / total))
+	}
+	return app.Status
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHealthRollup(t *testing.T) {
+	app := NewApplication(NewApplicationId("default", ApplicationKindDeployment, "app"))
+	ctx := timeseries.Context{}
+	slo := NewAuditReport(app.Id, ctx, nil, AuditReportSLO)
+	slo.CreateCheck(Checks.SLOAvailability).SetStatus(CRITICAL, "")
+	slo.CreateCheck(Checks.SLOLatency).SetStatus(UNKNOWN, "not configured")
+	instances := NewAuditReport(app.Id, ctx, nil, AuditReportInstances)
+	instances.CreateCheck(Checks.InstanceAvailability).SetStatus(OK, "")
+	instances.CreateCheck(Checks.InstanceRestarts).SetStatus(OK, "")
+	cpu := NewAuditReport(app.Id, ctx, nil, AuditReportCPU)
+	cpu.CreateCheck(Checks.CPUNode).SetStatus(WARNING, "")
+	app.Reports = []*AuditReport{slo, instances, cpu}
+	app.Status = CRITICAL
+
+	var r *HealthRollup
+	assert.Equal(t, CRITICAL, r.Status(app))
+	assert.Equal(t, CRITICAL, (&HealthRollup{Function: HealthRollupWorst}).Status(app))
+
+	// the CPU report doesn't affect the application status, the unknown latency check is ignored
+	assert.Equal(t, OK, (&HealthRollup{Function: HealthRollupMajority}).Status(app))
+	instances.Checks = instances.Checks[:1]
+	assert.Equal(t, CRITICAL, (&HealthRollup{Function: HealthRollupMajority}).Status(app))
+
+	// (4 + 1) / 2
+	assert.Equal(t, WARNING, (&HealthRollup{Function: HealthRollupWeighted}).Status(app))
+	// (4*3 + 1) / 4
+	assert.Equal(t, WARNING, (&HealthRollup{Function: HealthRollupWeighted, Weights: map[CheckId]float64{Checks.SLOAvailability.Id: 3}}).Status(app))
+	assert.Equal(t, OK, (&HealthRollup{Function: HealthRollupWeighted, Weights: map[CheckId]float64{Checks.SLOAvailability.Id: 0}}).Status(app))
+
+	assert.Error(t, (&HealthRollup{Function: "average"}).Validate())
+	assert.Error(t, (&HealthRollup{Function: HealthRollupWeighted, Weights: map[CheckId]float64{"Unknown": 1}}).Validate())
+	assert.NoError(t, (&HealthRollup{Function: HealthRollupWeighted, Weights: map[CheckId]float64{Checks.SLOLatency.Id: 2}}).Validate())
+}
@@ -20,6 +20,7 @@ const (
 	ApplicationKindDatabaseCluster ApplicationKind = "DatabaseCluster"
 	ApplicationKindRds             ApplicationKind = "RDS"
 	ApplicationKindNode            ApplicationKind = "Node"
+	ApplicationKindCustom          ApplicationKind = "Custom"
 )
 
 type Job struct{}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"fmt"
+	"github.com/coroot/coroot/timeseries"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var logsLinkPlaceholderRe = regexp.MustCompile(`\{[a-z_]+}`)
+
+// LogsLinkPlaceholders are the placeholders a LogsLinkTemplate can contain.
+var LogsLinkPlaceholders = []string{
+	"{app}",       // the application name
+	"{namespace}", // the application namespace
+	"{kind}",      // the application kind, e.g., Deployment
+	"{instance}",  // the instance name
+	"{from}",      // the beginning of the time range, Unix time in milliseconds
+	"{to}",        // the end of the time range, Unix time in milliseconds
+	"{from_iso}",  // the beginning of the time range, RFC 3339
+	"{to_iso}",    // the end of the time range, RFC 3339
+}
+
+// LogsLinkTemplate is a URL of an external log viewer (e.g., Grafana Explore, Kibana) with placeholders
+// (see LogsLinkPlaceholders) substituted with the values of an instance and a time range.
+type LogsLinkTemplate string
+
+func (t LogsLinkTemplate) Validate() error {
+	u, err := url.Parse(logsLinkPlaceholderRe.ReplaceAllString(string(t), "x"))
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("must be an absolute http(s) URL")
+	}
+	known := map[string]bool{}
+	for _, p := range LogsLinkPlaceholders {
+		known[p] = true
+	}
+	for _, p := range logsLinkPlaceholderRe.FindAllString(string(t), -1) {
+		if !known[p] {
+			return fmt.Errorf("unknown placeholder %s", p)
+		}
+	}
+	return nil
+}
+
+// Render returns the link to the logs of the instance within the time range, the values are query-escaped.
+func (t LogsLinkTemplate) Render(appId ApplicationId, instance string, from, to timeseries.Time) string {
+	if t == "" {
+		return ""
+	}
+	ms := func(t timeseries.Time) string {
+		return strconv.FormatInt(int64(t)*1000, 10)
+	}
+	iso := func(t timeseries.Time) string {
+		return t.ToStandard().Format(time.RFC3339)
+	}
+	r := strings.NewReplacer(
+		"{app}", url.QueryEscape(appId.Name),
+		"{namespace}", url.QueryEscape(appId.Namespace),
+		"{kind}", url.QueryEscape(string(appId.Kind)),
+		"{instance}", url.QueryEscape(instance),
+		"{from}", ms(from),
+		"{to}", ms(to),
+		"{from_iso}", url.QueryEscape(iso(from)),
+		"{to_iso}", url.QueryEscape(iso(to)),
+	)
+	return r.Replace(string(t))
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLogsLinkTemplate(t *testing.T) {
+	assert.NoError(t, LogsLinkTemplate("https://grafana/explore?left={from},{to},{app}").Validate())
+	assert.Error(t, LogsLinkTemplate("grafana/explore?app={app}").Validate())
+	assert.Error(t, LogsLinkTemplate("ftp://logs/{app}").Validate())
+	assert.Error(t, LogsLinkTemplate("https://logs/?q={service}").Validate())
+
+	appId := NewApplicationId("prod ns", ApplicationKindDeployment, "api")
+	from, to := timeseries.Time(1668000000), timeseries.Time(1668003600)
+
+	tmpl := LogsLinkTemplate("https://logs/?q=ns:{namespace}+app:{app}+pod:{instance}&kind={kind}&from={from}&to={to}")
+	assert.Equal(t,
+		"https://logs/?q=ns:prod+ns+app:api+pod:api-1&kind=Deployment&from=1668000000000&to=1668003600000",
+		tmpl.Render(appId, "api-1", from, to))
+
+	tmpl = "https://kibana/app/discover#/?_g=(time:(from:'{from_iso}',to:'{to_iso}'))"
+	assert.Equal(t,
+		"https://kibana/app/discover#/?_g=(time:(from:'2022-11-09T13%3A20%3A00Z',to:'2022-11-09T14%3A20%3A00Z'))",
+		tmpl.Render(appId, "api-1", from, to))
+
+	assert.Equal(t, "", LogsLinkTemplate("").Render(appId, "api-1", from, to))
+}
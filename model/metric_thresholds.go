@@ -0,0 +1,48 @@
+package model
+
+import "math"
+
+const (
+	MetricNodeCpuUsage    = "node_cpu_usage"
+	MetricNodeMemoryUsage = "node_memory_usage"
+)
+
+// MetricThreshold defines the values from which a metric is colored as a warning or critical.
+// A zero level is disabled.
+type MetricThreshold struct {
+	Warning  float64 `json:"warning"`
+	Critical float64 `json:"critical"`
+}
+
+// Status returns the level of the value: OK, WARNING, or CRITICAL, or UNKNOWN if the value is NaN.
+func (t MetricThreshold) Status(v float64) Status {
+	switch {
+	case math.IsNaN(v):
+		return UNKNOWN
+	case t.Critical > 0 && v >= t.Critical:
+		return CRITICAL
+	case t.Warning > 0 && v >= t.Warning:
+		return WARNING
+	}
+	return OK
+}
+
+// MetricThresholds maps metric names (e.g., "node_cpu_usage") to their thresholds.
+type MetricThresholds map[string]MetricThreshold
+
+var BuiltinMetricThresholds = MetricThresholds{
+	MetricNodeCpuUsage:    {Warning: 80, Critical: 90},
+	MetricNodeMemoryUsage: {Warning: 80, Critical: 90},
+}
+
+// GetMetricThresholds returns the built-in thresholds overridden by the custom ones.
+func GetMetricThresholds(custom MetricThresholds) MetricThresholds {
+	res := make(MetricThresholds, len(BuiltinMetricThresholds))
+	for m, t := range BuiltinMetricThresholds {
+		if c, ok := custom[m]; ok {
+			t = c
+		}
+		res[m] = t
+	}
+	return res
+}
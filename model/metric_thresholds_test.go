@@ -0,0 +1,26 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestMetricThresholdStatus(t *testing.T) {
+	th := MetricThreshold{Warning: 80, Critical: 90}
+	assert.Equal(t, UNKNOWN, th.Status(math.NaN()))
+	assert.Equal(t, OK, th.Status(79.9))
+	assert.Equal(t, WARNING, th.Status(80))
+	assert.Equal(t, CRITICAL, th.Status(90))
+	assert.Equal(t, CRITICAL, th.Status(100))
+
+	assert.Equal(t, WARNING, MetricThreshold{Warning: 50}.Status(99))
+	assert.Equal(t, OK, MetricThreshold{}.Status(99))
+}
+
+func TestGetMetricThresholds(t *testing.T) {
+	res := GetMetricThresholds(MetricThresholds{MetricNodeCpuUsage: {Warning: 50, Critical: 70}, "unknown": {Warning: 1}})
+	assert.Equal(t, MetricThreshold{Warning: 50, Critical: 70}, res[MetricNodeCpuUsage])
+	assert.Equal(t, BuiltinMetricThresholds[MetricNodeMemoryUsage], res[MetricNodeMemoryUsage])
+	assert.NotContains(t, res, "unknown")
+}
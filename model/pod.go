@@ -13,6 +13,8 @@ type Pod struct {
 
 	ReplicaSet string
 
+	Labels map[string]string
+
 	InitContainers map[string]*Container
 }
 
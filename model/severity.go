@@ -0,0 +1,35 @@
+package model
+
+type SeverityLabel struct {
+	Label string `json:"label"`
+	Color string `json:"color"`
+}
+
+// SeverityLabels maps status names (e.g., "critical") to how they are displayed.
+type SeverityLabels map[string]SeverityLabel
+
+var BuiltinSeverityLabels = SeverityLabels{
+	UNKNOWN.String():  {Label: "unknown", Color: ""},
+	OK.String():       {Label: "ok", Color: "hsl(141, 71%, 48%)"},
+	INFO.String():     {Label: "info", Color: ""},
+	WARNING.String():  {Label: "warning", Color: "hsl(48, 100%, 67%)"},
+	CRITICAL.String(): {Label: "critical", Color: "hsl(4, 90%, 58%)"},
+}
+
+// GetSeverityLabels returns the built-in labels overridden by the custom ones.
+// Empty fields of a custom label fall back to the built-in values.
+func GetSeverityLabels(custom SeverityLabels) SeverityLabels {
+	res := make(SeverityLabels, len(BuiltinSeverityLabels))
+	for s, l := range BuiltinSeverityLabels {
+		if c, ok := custom[s]; ok {
+			if c.Label != "" {
+				l.Label = c.Label
+			}
+			if c.Color != "" {
+				l.Color = c.Color
+			}
+		}
+		res[s] = l
+	}
+	return res
+}
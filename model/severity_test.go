@@ -0,0 +1,22 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetSeverityLabels(t *testing.T) {
+	assert.Equal(t, BuiltinSeverityLabels, GetSeverityLabels(nil))
+
+	res := GetSeverityLabels(SeverityLabels{
+		CRITICAL.String(): {Label: "P1", Color: "#ff0000"},
+		WARNING.String():  {Label: "P2"},
+		"unknown-status":  {Label: "P3"},
+	})
+	assert.Len(t, res, len(BuiltinSeverityLabels))
+	assert.Equal(t, SeverityLabel{Label: "P1", Color: "#ff0000"}, res[CRITICAL.String()])
+	assert.Equal(t, SeverityLabel{Label: "P2", Color: BuiltinSeverityLabels[WARNING.String()].Color}, res[WARNING.String()])
+	assert.Equal(t, BuiltinSeverityLabels[OK.String()], res[OK.String()])
+	// the built-in labels aren't changed
+	assert.Equal(t, "critical", BuiltinSeverityLabels[CRITICAL.String()].Label)
+}
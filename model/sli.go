@@ -3,6 +3,7 @@ package model
 import (
 	"github.com/coroot/coroot/timeseries"
 	"math"
+	"sort"
 )
 
 type AvailabilitySLI struct {
@@ -13,11 +14,89 @@ type AvailabilitySLI struct {
 
 	TotalRequestsRaw  timeseries.TimeSeries
 	FailedRequestsRaw timeseries.TimeSeries
+
+	// the query pairs of a weighted config
+	Components []AvailabilityComponent
+
+	// the error of the SLI queries, if any of them failed
+	Error string
+}
+
+type AvailabilityComponent struct {
+	Queries AvailabilityQueries
+	Weight  float64 // normalized, the weights of all the components sum up to 1
+
+	TotalRequests  timeseries.TimeSeries
+	FailedRequests timeseries.TimeSeries
+}
+
+// CompositeAvailability combines the components into the total and failed requests whose ratio is the weighted
+// average of the components' error ratios. At each point, the weights are normalized among the components
+// having requests, so a component without traffic contributes nothing. The total is the sum of all the requests.
+func CompositeAvailability(components []AvailabilityComponent) (timeseries.TimeSeries, timeseries.TimeSeries) {
+	type point struct {
+		total, errors, weight float64
+	}
+	points := map[timeseries.Time]*point{}
+	var times []timeseries.Time
+	for _, c := range components {
+		if c.Weight <= 0 {
+			continue
+		}
+		failed := map[timeseries.Time]float64{}
+		iter := timeseries.Iter(c.FailedRequests)
+		for iter.Next() {
+			t, v := iter.Value()
+			failed[t] = v
+		}
+		iter = timeseries.Iter(c.TotalRequests)
+		for iter.Next() {
+			t, total := iter.Value()
+			p := points[t]
+			if p == nil {
+				p = &point{}
+				points[t] = p
+				times = append(times, t)
+			}
+			if math.IsNaN(total) || total <= 0 {
+				continue
+			}
+			f := failed[t]
+			if math.IsNaN(f) {
+				f = 0
+			}
+			p.total += total
+			p.errors += c.Weight * math.Min(f/total, 1)
+			p.weight += c.Weight
+		}
+	}
+	if len(times) == 0 {
+		return nil, nil
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	step := timeseries.Second // for a single point
+	for i := 1; i < len(times); i++ {
+		if d := times[i].Sub(times[i-1]); i == 1 || d < step {
+			step = d
+		}
+	}
+	count := int(times[len(times)-1].Sub(times[0])/step) + 1
+	total := timeseries.New(times[0], count, step)
+	failed := timeseries.New(times[0], count, step)
+	for t, p := range points {
+		if p.weight == 0 {
+			continue
+		}
+		total.Set(t, p.total)
+		failed.Set(t, p.total*p.errors/p.weight)
+	}
+	return total, failed
 }
 
 type HistogramBucket struct {
-	Le         float64
-	TimeSeries timeseries.TimeSeries
+	Le           float64
+	TimeSeries   timeseries.TimeSeries
+	Interpolated bool
 }
 
 type LatencySLI struct {
@@ -25,6 +104,9 @@ type LatencySLI struct {
 
 	Histogram    []HistogramBucket
 	HistogramRaw []HistogramBucket
+
+	// the error of the SLI queries, if any of them failed
+	Error string
 }
 
 func (sli *LatencySLI) GetTotalAndFast(raw bool) (timeseries.TimeSeries, timeseries.TimeSeries) {
@@ -43,3 +125,66 @@ func (sli *LatencySLI) GetTotalAndFast(raw bool) (timeseries.TimeSeries, timeser
 	}
 	return total, fast
 }
+
+// DisplayHistogram re-buckets the histogram to the configured display boundaries.
+// Boundaries that don't match any source bucket are linearly interpolated between the neighbouring ones,
+// the result always ends with a +Inf bucket so that no observations are dropped.
+func (sli *LatencySLI) DisplayHistogram() []HistogramBucket {
+	if len(sli.Config.DisplayBuckets) == 0 || len(sli.Histogram) == 0 {
+		return sli.Histogram
+	}
+	var inf *HistogramBucket
+	finite := make([]HistogramBucket, 0, len(sli.Histogram))
+	for i, b := range sli.Histogram {
+		if math.IsInf(b.Le, 1) {
+			inf = &sli.Histogram[i]
+			continue
+		}
+		finite = append(finite, b)
+	}
+	res := make([]HistogramBucket, 0, len(sli.Config.DisplayBuckets)+1)
+	for _, le := range sli.Config.DisplayBuckets {
+		if b := interpolateBucket(finite, le); b != nil {
+			res = append(res, *b)
+		}
+	}
+	// the observations above the largest display boundary are counted in the +Inf bucket,
+	// which is the largest source bucket if the histogram has no +Inf bucket of its own
+	if inf == nil && len(finite) > 0 {
+		inf = &HistogramBucket{Le: math.Inf(1), TimeSeries: finite[len(finite)-1].TimeSeries}
+	}
+	if inf != nil {
+		res = append(res, *inf)
+	}
+	return res
+}
+
+func interpolateBucket(buckets []HistogramBucket, le float64) *HistogramBucket {
+	for i, b := range buckets {
+		if b.Le == le {
+			return &HistogramBucket{Le: le, TimeSeries: b.TimeSeries}
+		}
+		if b.Le < le {
+			continue
+		}
+		if i == 0 {
+			k := le / b.Le
+			return &HistogramBucket{
+				Le:           le,
+				TimeSeries:   timeseries.Map(func(t timeseries.Time, v float64) float64 { return v * k }, b.TimeSeries),
+				Interpolated: true,
+			}
+		}
+		prev := buckets[i-1]
+		k := (le - prev.Le) / (b.Le - prev.Le)
+		return &HistogramBucket{
+			Le: le,
+			TimeSeries: timeseries.Aggregate(
+				func(t timeseries.Time, lower, upper float64) float64 { return lower + (upper-lower)*k },
+				prev.TimeSeries, b.TimeSeries,
+			),
+			Interpolated: true,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestCompositeAvailability(t *testing.T) {
+	ts := func(values ...float64) timeseries.TimeSeries {
+		return timeseries.NewWithData(0, timeseries.Minute, values)
+	}
+	nan := timeseries.NaN
+	components := []AvailabilityComponent{
+		{Weight: 0.75, TotalRequests: ts(100, 100, 0, nan), FailedRequests: ts(10, nan, 0, nan)},
+		{Weight: 0.25, TotalRequests: ts(1000, 0, 10, nan), FailedRequests: ts(0, 0, 5, nan)},
+	}
+	total, failed := CompositeAvailability(components)
+	// 0.75*10% + 0.25*0%; the second component has no traffic; the first one has no traffic; no traffic
+	assert.Equal(t, "InMemoryTimeSeries(0, 4, 60, [1100 100 10 .])", total.String())
+	assert.Equal(t, "InMemoryTimeSeries(0, 4, 60, [82.500000 0 5 .])", failed.String())
+
+	total, failed = CompositeAvailability(nil)
+	assert.Nil(t, total)
+	assert.Nil(t, failed)
+}
+
+func TestDisplayHistogram(t *testing.T) {
+	ts := func(v float64) timeseries.TimeSeries {
+		return timeseries.NewWithData(0, timeseries.Minute, []float64{v})
+	}
+	sli := &LatencySLI{
+		Config:    CheckConfigSLOLatency{DisplayBuckets: []float64{0.05, 0.3, 0.5}},
+		Histogram: []HistogramBucket{{Le: 0.1, TimeSeries: ts(10)}, {Le: 0.5, TimeSeries: ts(50)}, {Le: 1, TimeSeries: ts(80)}},
+	}
+	check := func(expected []HistogramBucket, actual []HistogramBucket) {
+		assert.Len(t, actual, len(expected))
+		for i := range expected {
+			assert.Equal(t, expected[i].Le, actual[i].Le)
+			assert.Equal(t, expected[i].Interpolated, actual[i].Interpolated)
+			assert.InDelta(t, timeseries.Last(expected[i].TimeSeries), timeseries.Last(actual[i].TimeSeries), 1e-9)
+		}
+	}
+
+	// the observations above 0.5 are counted in the +Inf bucket although the histogram has no such bucket
+	check([]HistogramBucket{
+		{Le: 0.05, TimeSeries: ts(5), Interpolated: true},
+		{Le: 0.3, TimeSeries: ts(30), Interpolated: true},
+		{Le: 0.5, TimeSeries: ts(50)},
+		{Le: math.Inf(1), TimeSeries: ts(80)},
+	}, sli.DisplayHistogram())
+
+	sli.Histogram = append(sli.Histogram, HistogramBucket{Le: math.Inf(1), TimeSeries: ts(100)})
+	sli.Config.DisplayBuckets = []float64{2}
+	check([]HistogramBucket{{Le: math.Inf(1), TimeSeries: ts(100)}}, sli.DisplayHistogram())
+
+	sli.Config.DisplayBuckets = nil
+	assert.Equal(t, sli.Histogram, sli.DisplayHistogram())
+}
@@ -31,6 +31,7 @@ type TableRow struct {
 type Progress struct {
 	Percent int    `json:"percent"`
 	Color   string `json:"color"`
+	Status  Status `json:"status"`
 }
 
 type NetInterface struct {
@@ -105,6 +106,14 @@ func (c *TableCell) SetProgress(percent int, color string) *TableCell {
 	return c
 }
 
+// SetProgressStatus sets the level of the progress value, computed with the metric thresholds.
+func (c *TableCell) SetProgressStatus(status Status) *TableCell {
+	if c.Progress != nil {
+		c.Progress.Status = status
+	}
+	return c
+}
+
 func (c *TableCell) SetChart(ts timeseries.TimeSeries) *TableCell {
 	c.Chart = ts
 	return c
@@ -24,6 +24,15 @@ type World struct {
 	Services     []*Service
 
 	IntegrationStatus IntegrationStatus
+
+	// queries whose data is incomplete, e.g., due to a timeout
+	Warnings []string
+
+	// the versions of the Prometheus server the data has been fetched from, more than one if it was upgraded within the time window
+	PrometheusVersions []string
+
+	// the time of the latest cached data if it's older than the project's max staleness, zero otherwise
+	StaleSince timeseries.Time
 }
 
 func NewWorld(from, to timeseries.Time, step timeseries.Duration) *World {
@@ -32,6 +41,20 @@ func NewWorld(from, to timeseries.Time, step timeseries.Duration) *World {
 	}
 }
 
+// FilterApplications keeps only the applications matching the given labels.
+func (w *World) FilterApplications(selector Labels) {
+	if len(selector) == 0 {
+		return
+	}
+	apps := w.Applications[:0]
+	for _, a := range w.Applications {
+		if a.MatchLabels(selector) {
+			apps = append(apps, a)
+		}
+	}
+	w.Applications = apps
+}
+
 func (w *World) GetApplication(id ApplicationId) *Application {
 	for _, a := range w.Applications {
 		if a.Id == id {
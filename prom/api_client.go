@@ -22,9 +22,13 @@ import (
 type ApiClient struct {
 	api    v1.API
 	client api.Client
+
+	queryTimeout time.Duration
 }
 
-func NewApiClient(address, user, password string, skipTlsVerify bool) (*ApiClient, error) {
+// NewApiClient creates a Prometheus API client. If queryTimeout is positive, it's passed to Prometheus
+// as the query evaluation timeout and also limits how long the client waits for each query.
+func NewApiClient(address, user, password string, tlsConfig *tls.Config, queryTimeout time.Duration) (*ApiClient, error) {
 	if user != "" {
 		if u, err := url.Parse(address); err != nil {
 			klog.Errorln("failed to parse url:", err)
@@ -39,14 +43,14 @@ func NewApiClient(address, user, password string, skipTlsVerify bool) (*ApiClien
 			KeepAlive: 30 * time.Second,
 		}).DialContext,
 		TLSHandshakeTimeout: 10 * time.Second,
-		TLSClientConfig:     &tls.Config{InsecureSkipVerify: skipTlsVerify},
+		TLSClientConfig:     tlsConfig,
 	}
 	cfg := api.Config{Address: address, RoundTripper: transport}
 	c, err := api.NewClient(cfg)
 	if err != nil {
 		return nil, err
 	}
-	return &ApiClient{api: v1.NewAPI(c), client: c}, nil
+	return &ApiClient{api: v1.NewAPI(c), client: c, queryTimeout: queryTimeout}, nil
 }
 
 func (c *ApiClient) Ping(ctx context.Context) error {
@@ -54,21 +58,53 @@ func (c *ApiClient) Ping(ctx context.Context) error {
 	return err
 }
 
+// BuildInfo returns the version of the Prometheus server. Prometheus-compatible servers that lack
+// the buildinfo endpoint result in an error.
+func (c *ApiClient) BuildInfo(ctx context.Context) (*BuildInfo, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	res, err := c.api.Buildinfo(ctx)
+	if err != nil {
+		return nil, c.queryError(err)
+	}
+	return &BuildInfo{Version: res.Version, Revision: res.Revision}, nil
+}
+
 func (c *ApiClient) QueryRange(ctx context.Context, query string, from, to timeseries.Time, step timeseries.Duration) ([]model.MetricValues, error) {
+	res, _, err := c.QueryRangeWithWarnings(ctx, query, from, to, step)
+	return res, err
+}
+
+// QueryRangeWithWarnings returns the data along with the warnings Prometheus has responded with.
+func (c *ApiClient) QueryRangeWithWarnings(ctx context.Context, query string, from, to timeseries.Time, step timeseries.Duration) ([]model.MetricValues, []string, error) {
 	query = strings.ReplaceAll(query, "$RANGE", fmt.Sprintf(`%.0fs`, (step*3).ToStandard().Seconds()))
 	from = from.Truncate(step)
 	to = to.Truncate(step)
-	value, _, err := c.api.QueryRange(ctx, query, v1.Range{Start: from.ToStandard(), End: to.ToStandard(), Step: step.ToStandard()})
+	var opts []v1.Option
+	if c.queryTimeout > 0 {
+		opts = append(opts, v1.WithTimeout(c.queryTimeout))
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	t := time.Now()
+	value, warnings, err := c.api.QueryRange(ctx, query, v1.Range{Start: from.ToStandard(), End: to.ToStandard(), Step: step.ToStandard()}, opts...)
+	queryDuration.Observe(time.Since(t).Seconds())
 	if err != nil {
-		return nil, err
+		queriesTotal.WithLabelValues("error").Inc()
+		return nil, nil, c.queryError(err)
+	}
+	if len(warnings) > 0 {
+		queriesTotal.WithLabelValues("partial").Inc()
+	} else {
+		queriesTotal.WithLabelValues("ok").Inc()
 	}
 	if value.Type() != promModel.ValMatrix {
-		return nil, fmt.Errorf("result isn't a Matrix")
+		return nil, nil, fmt.Errorf("result isn't a Matrix")
 	}
 
 	matrix := value.(promModel.Matrix)
 	if len(matrix) == 0 {
-		return nil, nil
+		return nil, warnings, nil
 	}
 
 	res := make([]model.MetricValues, 0, matrix.Len())
@@ -87,26 +123,28 @@ func (c *ApiClient) QueryRange(ctx context.Context, query string, from, to times
 		}
 		res = append(res, mv)
 	}
-	return res, nil
+	return res, warnings, nil
 }
 
-func (c *ApiClient) Proxy(r *http.Request, w http.ResponseWriter) {
-	reStr, err := mux.CurrentRoute(r).GetPathRegexp()
-	if err != nil {
-		klog.Errorln(err)
-		http.Error(w, "", http.StatusInternalServerError)
-		return
+// withTimeout limits the context to the query timeout, if any.
+func (c *ApiClient) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.queryTimeout <= 0 {
+		return ctx, func() {}
 	}
-	re, err := regexp.Compile(reStr)
-	if err != nil {
-		klog.Errorln(err)
-		http.Error(w, "", http.StatusInternalServerError)
-		return
+	return context.WithTimeout(ctx, c.queryTimeout)
+}
+
+// queryError wraps timeouts into QueryTimeoutError, so they can be told apart from the other failures.
+func (c *ApiClient) queryError(err error) error {
+	if c.queryTimeout > 0 && IsTimeout(err) {
+		return &QueryTimeoutError{Timeout: c.queryTimeout, err: err}
 	}
-	path := re.ReplaceAllString(r.URL.Path, "")
-	r.URL = c.client.URL(path, nil)
-	r.RequestURI = ""
-	resp, data, err := c.client.Do(r.Context(), r)
+	return err
+}
+
+// Proxy forwards the request to Prometheus and writes the response.
+func (c *ApiClient) Proxy(r *http.Request, w http.ResponseWriter) {
+	resp, data, err := c.ProxyRequest(r.Context(), r)
 	if err != nil {
 		klog.Errorln(err)
 		http.Error(w, "", http.StatusInternalServerError)
@@ -120,3 +158,20 @@ func (c *ApiClient) Proxy(r *http.Request, w http.ResponseWriter) {
 	w.WriteHeader(resp.StatusCode)
 	_, _ = w.Write(data)
 }
+
+// ProxyRequest forwards the request to Prometheus within ctx: the part of the path after the prefix matched
+// by the route is used as the path of the Prometheus API.
+func (c *ApiClient) ProxyRequest(ctx context.Context, r *http.Request) (*http.Response, []byte, error) {
+	reStr, err := mux.CurrentRoute(r).GetPathRegexp()
+	if err != nil {
+		return nil, nil, err
+	}
+	re, err := regexp.Compile(reStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	path := re.ReplaceAllString(r.URL.Path, "")
+	r.URL = c.client.URL(path, nil)
+	r.RequestURI = ""
+	return c.client.Do(ctx, r)
+}
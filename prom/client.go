@@ -4,9 +4,37 @@ import (
 	"context"
 	"github.com/coroot/coroot/model"
 	"github.com/coroot/coroot/timeseries"
+	"strings"
 )
 
 type Client interface {
 	QueryRange(ctx context.Context, query string, from, to timeseries.Time, step timeseries.Duration) ([]model.MetricValues, error)
 	Ping(ctx context.Context) error
+	BuildInfo(ctx context.Context) (*BuildInfo, error)
+}
+
+// WarningsClient is implemented by the clients reporting that the result of a query may be incomplete,
+// e.g., Prometheus responded with warnings or the data of the range hasn't been fetched completely.
+type WarningsClient interface {
+	QueryRangeWithWarnings(ctx context.Context, query string, from, to timeseries.Time, step timeseries.Duration) ([]model.MetricValues, []string, error)
+}
+
+// QueryRangeWithWarnings runs the query returning the data along with the warnings about it being incomplete,
+// if the client reports them (see WarningsClient).
+func QueryRangeWithWarnings(ctx context.Context, client Client, query string, from, to timeseries.Time, step timeseries.Duration) ([]model.MetricValues, []string, error) {
+	if wc, ok := client.(WarningsClient); ok {
+		return wc.QueryRangeWithWarnings(ctx, query, from, to, step)
+	}
+	res, err := client.QueryRange(ctx, query, from, to, step)
+	return res, nil, err
+}
+
+type BuildInfo struct {
+	Version  string
+	Revision string
+}
+
+// FormatWarnings describes the warnings about the result of a query being incomplete.
+func FormatWarnings(warnings []string) string {
+	return "partial data: " + strings.Join(warnings, "; ")
 }
@@ -0,0 +1,245 @@
+package prom
+
+import (
+	"context"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"math"
+	"sort"
+	"strings"
+)
+
+type dedupClient struct {
+	Client
+	replicaLabel string
+}
+
+// WithReplicaDedup returns a client that merges the series of HA Prometheus replicas, which differ only in the value
+// of replicaLabel (e.g., prometheus_replica), see DedupReplicas. Aggregations are rewritten to keep the label
+// (see KeepLabel), so that the replicas are aggregated separately and deduplicated afterwards instead of being double counted.
+func WithReplicaDedup(client Client, replicaLabel string) Client {
+	if replicaLabel == "" {
+		return client
+	}
+	return &dedupClient{Client: client, replicaLabel: replicaLabel}
+}
+
+func (c *dedupClient) QueryRange(ctx context.Context, query string, from, to timeseries.Time, step timeseries.Duration) ([]model.MetricValues, error) {
+	res, err := c.Client.QueryRange(ctx, KeepLabel(query, c.replicaLabel), from, to, step)
+	return DedupReplicas(res, c.replicaLabel), err
+}
+
+func (c *dedupClient) QueryRangeWithWarnings(ctx context.Context, query string, from, to timeseries.Time, step timeseries.Duration) ([]model.MetricValues, []string, error) {
+	res, warnings, err := QueryRangeWithWarnings(ctx, c.Client, KeepLabel(query, c.replicaLabel), from, to, step)
+	return DedupReplicas(res, c.replicaLabel), warnings, err
+}
+
+// DedupReplicas merges the series differing only in the value of the replica label into one series without the label.
+// The data of the replica having the most points is kept as is, even if the replicas disagree on values,
+// since mixing the counters of different replicas would produce bogus rates; its gaps are filled from the other replicas
+// in the order of their label values. The input series aren't modified.
+func DedupReplicas(mvs []model.MetricValues, replicaLabel string) []model.MetricValues {
+	if replicaLabel == "" || len(mvs) == 0 {
+		return mvs
+	}
+	groups := map[string][]model.MetricValues{}
+	var keys []string
+	for _, mv := range mvs {
+		if _, ok := mv.Labels[replicaLabel]; !ok {
+			return mvs // the query has aggregated the label away
+		}
+		k := labelsKeyWithout(mv.Labels, replicaLabel)
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], mv)
+	}
+	res := make([]model.MetricValues, 0, len(keys))
+	for _, k := range keys {
+		replicas := groups[k]
+		sort.SliceStable(replicas, func(i, j int) bool {
+			pi, pj := definedPoints(replicas[i].Values), definedPoints(replicas[j].Values)
+			if pi != pj {
+				return pi > pj
+			}
+			return replicas[i].Labels[replicaLabel] < replicas[j].Labels[replicaLabel]
+		})
+		primary := replicas[0]
+		merged := model.MetricValues{Labels: model.Labels{}, LabelsHash: primary.LabelsHash}
+		for name, value := range primary.Labels {
+			if name != replicaLabel {
+				merged.Labels[name] = value
+			}
+		}
+		if primary.Values != nil {
+			merged.Values = primary.Values.Clone()
+			data := merged.Values.Data()
+			for _, r := range replicas[1:] {
+				if r.Values == nil {
+					continue
+				}
+				for i, v := range r.Values.Data() {
+					if i < len(data) && math.IsNaN(data[i]) {
+						data[i] = v
+					}
+				}
+			}
+		}
+		res = append(res, merged)
+	}
+	return res
+}
+
+var aggregations = map[string]bool{
+	"sum": true, "min": true, "max": true, "avg": true, "group": true, "stddev": true, "stdvar": true,
+	"count": true, "count_values": true, "bottomk": true, "topk": true, "quantile": true,
+}
+
+// KeepLabel rewrites the aggregations and the on() vector matchings of the query to preserve the label,
+// e.g., `sum(rate(x[1m]))` becomes `sum by (replica) (rate(x[1m]))` and `sum by(le) (x)` becomes `sum by(le, replica) (x)`.
+// Aggregations using without() keep the label anyway.
+func KeepLabel(query, label string) string {
+	if label == "" {
+		return query
+	}
+	var b strings.Builder
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			j := skipString(query, i)
+			b.WriteString(query[i:j])
+			i = j
+		case c == '{':
+			j := closingBrace(query, i)
+			b.WriteString(query[i : j+1])
+			i = j + 1
+		case c == '[':
+			j := strings.IndexByte(query[i:], ']')
+			if j < 0 {
+				j = len(query) - i - 1
+			}
+			b.WriteString(query[i : i+j+1])
+			i += j + 1
+		case isIdentChar(c):
+			j := i + 1
+			for j < len(query) && isIdentChar(query[j]) {
+				j++
+			}
+			ident := query[i:j]
+			b.WriteString(ident)
+			i = j
+			switch lower := strings.ToLower(ident); {
+			case lower == "by" || lower == "on":
+				k := skipSpaces(query, j)
+				if k >= len(query) || query[k] != '(' {
+					break
+				}
+				end := closingParen(query, k)
+				list := query[k+1 : end]
+				b.WriteString(query[j:k+1] + appendLabel(list, label) + ")")
+				i = end + 1
+			case aggregations[lower]:
+				k := skipSpaces(query, j)
+				if k >= len(query) || query[k] != '(' {
+					break // a grouping clause or a metric name
+				}
+				after := skipSpaces(query, closingParen(query, k)+1)
+				if next := strings.ToLower(identAt(query, after)); next != "by" && next != "without" {
+					b.WriteString(" by (" + label + ")")
+				}
+			}
+		case c >= '0' && c <= '9' || c == '.':
+			j := i + 1
+			for j < len(query) && (isIdentChar(query[j]) || query[j] == '.') {
+				j++
+			}
+			b.WriteString(query[i:j])
+			i = j
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+func appendLabel(list, label string) string {
+	trimmed := strings.TrimRight(strings.TrimSpace(list), ",")
+	if trimmed == "" {
+		return label
+	}
+	for _, l := range strings.Split(trimmed, ",") {
+		if strings.TrimSpace(l) == label {
+			return list
+		}
+	}
+	return trimmed + ", " + label
+}
+
+func skipSpaces(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t' || s[i] == '\n') {
+		i++
+	}
+	return i
+}
+
+func identAt(s string, i int) string {
+	j := i
+	for j < len(s) && isIdentChar(s[j]) {
+		j++
+	}
+	return s[i:j]
+}
+
+// closingParen returns the position of the parenthesis closing the one at i, skipping strings, selectors and ranges.
+func closingParen(s string, i int) int {
+	depth := 0
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '"', '\'', '`':
+			j = skipString(s, j) - 1
+		case '{':
+			j = closingBrace(s, j)
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return len(s) - 1
+}
+
+func labelsKeyWithout(labels model.Labels, exclude string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		if name != exclude {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	b := strings.Builder{}
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte(0)
+		b.WriteString(labels[name])
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+func definedPoints(ts *timeseries.InMemoryTimeSeries) int {
+	if ts == nil {
+		return 0
+	}
+	n := 0
+	for _, v := range ts.Data() {
+		if !math.IsNaN(v) {
+			n++
+		}
+	}
+	return n
+}
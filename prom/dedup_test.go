@@ -0,0 +1,84 @@
+package prom
+
+import (
+	"context"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDedupReplicas(t *testing.T) {
+	nan := timeseries.NaN
+	mv := func(replica, pod string, data ...float64) model.MetricValues {
+		labels := model.Labels{"pod": pod}
+		if replica != "" {
+			labels["replica"] = replica
+		}
+		return model.MetricValues{Labels: labels, Values: timeseries.NewWithData(0, 60, data)}
+	}
+	a1 := mv("a", "pod-1", 1, 2, nan, 4, nan)
+	b1 := mv("b", "pod-1", 10, nan, 30, 40, nan)
+	b2 := mv("b", "pod-2", 5, 5, 5, 5, 5)
+	res := DedupReplicas([]model.MetricValues{a1, b1, b2}, "replica")
+	if assert.Len(t, res, 2) {
+		assert.Equal(t, model.Labels{"pod": "pod-1"}, res[0].Labels)
+		assert.Equal(t, "InMemoryTimeSeries(0, 5, 60, [1 2 30 4 .])", res[0].Values.String())
+		assert.Equal(t, model.Labels{"pod": "pod-2"}, res[1].Labels)
+		assert.Equal(t, "InMemoryTimeSeries(0, 5, 60, [5 5 5 5 5])", res[1].Values.String())
+	}
+	// the input isn't modified
+	assert.Equal(t, "InMemoryTimeSeries(0, 5, 60, [1 2 . 4 .])", a1.Values.String())
+	assert.Equal(t, "b", b1.Labels["replica"])
+
+	// the replica with more data is the primary one
+	c1 := mv("c", "pod-1", nan, 20, 30, 40, 50)
+	res = DedupReplicas([]model.MetricValues{a1, c1}, "replica")
+	if assert.Len(t, res, 1) {
+		assert.Equal(t, "InMemoryTimeSeries(0, 5, 60, [1 20 30 40 50])", res[0].Values.String())
+	}
+
+	// aggregated series are returned as is
+	sum := []model.MetricValues{mv("", "pod-1", 1), mv("", "pod-1", 2)}
+	assert.Equal(t, sum, DedupReplicas(sum, "replica"))
+	assert.Equal(t, sum, DedupReplicas(sum, ""))
+}
+
+func TestKeepLabel(t *testing.T) {
+	assert.Equal(t, `sum(x)`, KeepLabel(`sum(x)`, ""))
+	assert.Equal(t, `sum by (replica)(rate(x{a="sum(b)"}[5m]))`, KeepLabel(`sum(rate(x{a="sum(b)"}[5m]))`, "replica"))
+	assert.Equal(t, `histogram_quantile(0.95, sum by(le, replica)(rate(x[5m])))`, KeepLabel(`histogram_quantile(0.95, sum by(le)(rate(x[5m])))`, "replica"))
+	assert.Equal(t, `sum(x) by (pod, replica)`, KeepLabel(`sum(x) by (pod)`, "replica"))
+	assert.Equal(t, `sum(x) without(mode)`, KeepLabel(`sum(x) without(mode)`, "replica"))
+	assert.Equal(t, `max by (replica) (x) / on(pod, replica) group_left y`, KeepLabel(`max (x) / on(pod) group_left y`, "replica"))
+	assert.Equal(t, `sum by (replica) (a) / on(replica) count by (replica)(b)`, KeepLabel(`sum (a) / on() count(b)`, "replica"))
+	assert.Equal(t, `sum by (replica)(x) by_pod`, KeepLabel(`sum(x) by_pod`, "replica"))
+	assert.Equal(t, `topk by (replica)(5, sum_total)`, KeepLabel(`topk(5, sum_total)`, "replica"))
+	assert.Equal(t, `sum by (replica)(x)`, KeepLabel(`sum by (replica)(x)`, "replica"))
+}
+
+type replicasClient struct {
+	Client
+	queries []string
+}
+
+func (c *replicasClient) QueryRange(ctx context.Context, query string, from, to timeseries.Time, step timeseries.Duration) ([]model.MetricValues, error) {
+	c.queries = append(c.queries, query)
+	nan := timeseries.NaN
+	// each replica computes the aggregate on its own data, replica b missed a scrape
+	return []model.MetricValues{
+		{Labels: model.Labels{"replica": "a"}, Values: timeseries.NewWithData(0, 60, []float64{10, 10, 10})},
+		{Labels: model.Labels{"replica": "b"}, Values: timeseries.NewWithData(0, 60, []float64{10, nan, 10})},
+	}, nil
+}
+
+func TestDedupClientAggregatedQuery(t *testing.T) {
+	c := &replicasClient{}
+	res, err := WithReplicaDedup(c, "replica").QueryRange(context.Background(), `sum(rate(http_requests_total{status=~"5.."}[$RANGE]))`, 0, 120, 60)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`sum by (replica)(rate(http_requests_total{status=~"5.."}[$RANGE]))`}, c.queries)
+	if assert.Len(t, res, 1) {
+		assert.Equal(t, model.Labels{}, res[0].Labels)
+		assert.Equal(t, "InMemoryTimeSeries(0, 3, 60, [10 10 10])", res[0].Values.String())
+	}
+}
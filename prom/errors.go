@@ -0,0 +1,32 @@
+package prom
+
+import (
+	"context"
+	"errors"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"time"
+)
+
+// QueryTimeoutError is returned when a query hasn't been completed within the configured timeout.
+type QueryTimeoutError struct {
+	Timeout time.Duration
+	err     error
+}
+
+func (e *QueryTimeoutError) Error() string {
+	return "query timed out after " + e.Timeout.String() + ": " + e.err.Error()
+}
+
+func (e *QueryTimeoutError) Unwrap() error {
+	return e.err
+}
+
+// IsTimeout reports whether the query failed due to a timeout, either on the Prometheus side or the client side.
+func IsTimeout(err error) bool {
+	var qte *QueryTimeoutError
+	if errors.As(err, &qte) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var e *v1.Error
+	return errors.As(err, &e) && (e.Type == v1.ErrTimeout || e.Type == v1.ErrCanceled)
+}
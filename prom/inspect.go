@@ -0,0 +1,122 @@
+package prom
+
+import (
+	"context"
+	"fmt"
+	"github.com/coroot/coroot/timeseries"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	promModel "github.com/prometheus/common/model"
+	"k8s.io/klog"
+	"strings"
+)
+
+const (
+	inspectMaxSeries = 20
+	inspectPoints    = 5
+)
+
+type InspectResult struct {
+	Type        string          `json:"type"`
+	SeriesCount int             `json:"series_count"`
+	Series      []InspectSeries `json:"series"`
+}
+
+type InspectSeries struct {
+	Labels  map[string]string `json:"labels"`
+	Samples []Sample          `json:"samples"`
+}
+
+type Sample struct {
+	Time timeseries.Time `json:"time"`
+	// NaN and ±Inf are encoded as null
+	Value timeseries.Value `json:"value"`
+}
+
+// Inspect evaluates the query at the given time and describes the shape of the result.
+// The samples of scalar and instant vector results are collected over the inspectPoints steps ending at that time.
+// Only the first inspectMaxSeries series are returned.
+func (c *ApiClient) Inspect(ctx context.Context, query string, at timeseries.Time, step timeseries.Duration) (*InspectResult, error) {
+	query = strings.ReplaceAll(query, "$RANGE", fmt.Sprintf(`%.0fs`, (step*3).ToStandard().Seconds()))
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	value, _, err := c.api.Query(ctx, query, at.ToStandard())
+	if err != nil {
+		return nil, c.queryError(err)
+	}
+	res := &InspectResult{Type: value.Type().String(), Series: []InspectSeries{}}
+	sample := func(t promModel.Time, v promModel.SampleValue) Sample {
+		return Sample{Time: timeseries.Time(t.Unix()), Value: timeseries.Value(v)}
+	}
+	switch v := value.(type) {
+	case *promModel.Scalar:
+		res.SeriesCount = 1
+		samples := c.inspectSamples(ctx, query, at, step)
+		is := InspectSeries{Labels: map[string]string{}, Samples: []Sample{sample(v.Timestamp, v.Value)}}
+		if s, ok := samples[promModel.Metric{}.Fingerprint()]; ok {
+			is.Samples = s
+		}
+		res.Series = append(res.Series, is)
+	case promModel.Vector:
+		res.SeriesCount = len(v)
+		if len(v) == 0 {
+			break
+		}
+		samples := c.inspectSamples(ctx, query, at, step)
+		for _, s := range v {
+			if len(res.Series) >= inspectMaxSeries {
+				break
+			}
+			is := InspectSeries{Labels: labels(s.Metric), Samples: []Sample{sample(s.Timestamp, s.Value)}}
+			if ss, ok := samples[s.Metric.Fingerprint()]; ok {
+				is.Samples = ss
+			}
+			res.Series = append(res.Series, is)
+		}
+	case promModel.Matrix: // a range vector selector
+		res.SeriesCount = len(v)
+		for _, s := range v {
+			if len(res.Series) >= inspectMaxSeries {
+				break
+			}
+			is := InspectSeries{Labels: labels(s.Metric), Samples: []Sample{}}
+			for _, p := range s.Values {
+				is.Samples = append(is.Samples, sample(p.Timestamp, p.Value))
+			}
+			res.Series = append(res.Series, is)
+		}
+	}
+	return res, nil
+}
+
+// inspectSamples evaluates the query over the inspectPoints steps ending at the given time and returns the samples by series.
+// If the range query fails, the caller falls back to the sample of the instant query.
+func (c *ApiClient) inspectSamples(ctx context.Context, query string, at timeseries.Time, step timeseries.Duration) map[promModel.Fingerprint][]Sample {
+	to := at.Truncate(step)
+	from := to.Add(-step * (inspectPoints - 1))
+	value, _, err := c.api.QueryRange(ctx, query, v1.Range{Start: from.ToStandard(), End: to.ToStandard(), Step: step.ToStandard()})
+	if err != nil {
+		klog.Warningln("failed to collect the samples of the inspected query:", err)
+		return nil
+	}
+	matrix, ok := value.(promModel.Matrix)
+	if !ok {
+		return nil
+	}
+	res := make(map[promModel.Fingerprint][]Sample, len(matrix))
+	for _, s := range matrix {
+		samples := make([]Sample, 0, len(s.Values))
+		for _, p := range s.Values {
+			samples = append(samples, Sample{Time: timeseries.Time(p.Timestamp.Unix()), Value: timeseries.Value(p.Value)})
+		}
+		res[s.Metric.Fingerprint()] = samples
+	}
+	return res
+}
+
+func labels(m promModel.Metric) map[string]string {
+	res := make(map[string]string, len(m))
+	for k, v := range m {
+		res[string(k)] = string(v)
+	}
+	return res
+}
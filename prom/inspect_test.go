@@ -0,0 +1,66 @@
+package prom
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	var instant, rangeQuery string
+	var start, end string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v1/query_range" {
+			start, end = r.Form.Get("start"), r.Form.Get("end")
+			_, _ = w.Write([]byte(rangeQuery))
+			return
+		}
+		_, _ = w.Write([]byte(instant))
+	}))
+	defer srv.Close()
+
+	c, err := NewApiClient(srv.URL, "", "", nil, 0)
+	require.NoError(t, err)
+	inspect := func(query string) string {
+		res, err := c.Inspect(context.Background(), query, 1680000130, 60)
+		require.NoError(t, err)
+		data, err := json.Marshal(res)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	instant = `{"status":"success","data":{"resultType":"vector","result":[
+		{"metric":{"job":"api"},"value":[1680000130,"3"]},
+		{"metric":{"job":"db"},"value":[1680000130,"4"]}
+	]}}`
+	rangeQuery = `{"status":"success","data":{"resultType":"matrix","result":[
+		{"metric":{"job":"api"},"values":[[1680000000,"1"],[1680000060,"NaN"],[1680000120,"+Inf"]]}
+	]}}`
+	assert.JSONEq(t, `{"type":"vector","series_count":2,"series":[
+		{"labels":{"job":"api"},"samples":[{"time":1680000000000,"value":1},{"time":1680000060000,"value":null},{"time":1680000120000,"value":null}]},
+		{"labels":{"job":"db"},"samples":[{"time":1680000130000,"value":4}]}
+	]}`, inspect(`sum by(job) (rate(x[$RANGE])) / 0`))
+	assert.Equal(t, "1679999880", start)
+	assert.Equal(t, "1680000120", end)
+
+	instant = `{"status":"success","data":{"resultType":"scalar","result":[1680000130,"1"]}}`
+	rangeQuery = `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[[1680000060,"1"],[1680000120,"1"]]}]}}`
+	assert.JSONEq(t, `{"type":"scalar","series_count":1,"series":[
+		{"labels":{},"samples":[{"time":1680000060000,"value":1},{"time":1680000120000,"value":1}]}
+	]}`, inspect(`1`))
+
+	// a range vector selector can't be evaluated over a range, so it's reported as is
+	instant = `{"status":"success","data":{"resultType":"matrix","result":[
+		{"metric":{"__name__":"x"},"values":[[1680000070,"1"],[1680000100,"2"]]}
+	]}}`
+	rangeQuery = `{"status":"error","errorType":"bad_data","error":"invalid expression type \"range vector\""}`
+	assert.JSONEq(t, `{"type":"matrix","series_count":1,"series":[
+		{"labels":{"__name__":"x"},"samples":[{"time":1680000070000,"value":1},{"time":1680000100000,"value":2}]}
+	]}`, inspect(`x[5m]`))
+}
@@ -0,0 +1,24 @@
+package prom
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	queriesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "coroot_prometheus_queries_total",
+		},
+		[]string{"status"},
+	)
+	queryDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "coroot_prometheus_query_duration_seconds",
+			Buckets: []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(queriesTotal, queryDuration)
+}
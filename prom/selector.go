@@ -0,0 +1,219 @@
+package prom
+
+import (
+	"context"
+	"fmt"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	labelNameRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+	// keywords and aggregation operators that can't be metric names
+	keywords = map[string]bool{
+		"and": true, "or": true, "unless": true, "bool": true, "offset": true, "atan2": true, "inf": true, "nan": true,
+		"sum": true, "min": true, "max": true, "avg": true, "group": true, "stddev": true, "stdvar": true,
+		"count": true, "count_values": true, "bottomk": true, "topk": true, "quantile": true,
+	}
+	// keywords followed by a list of label names
+	groupingKeywords = map[string]bool{
+		"by": true, "without": true, "on": true, "ignoring": true, "group_left": true, "group_right": true,
+	}
+)
+
+// NormalizeSelector parses a list of label matchers, e.g. `{tenant="a", env=~"prod|stage"}`,
+// and returns them without the braces.
+func NormalizeSelector(selector string) (string, error) {
+	s := strings.TrimSpace(selector)
+	if strings.HasPrefix(s, "{") != strings.HasSuffix(s, "}") {
+		return "", fmt.Errorf("unbalanced braces: %s", selector)
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")
+	var matchers []string
+	for _, m := range splitMatchers(s) {
+		m = strings.TrimSpace(m)
+		if m == "" {
+			continue
+		}
+		var name, op, value string
+		for _, o := range []string{"=~", "!~", "!=", "="} {
+			if idx := strings.Index(m, o); idx > 0 {
+				name, op, value = strings.TrimSpace(m[:idx]), o, strings.TrimSpace(m[idx+len(o):])
+				break
+			}
+		}
+		if op == "" || !labelNameRe.MatchString(name) {
+			return "", fmt.Errorf("invalid label matcher: %s", m)
+		}
+		v, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid label value: %s", value)
+		}
+		if op == "=~" || op == "!~" {
+			if _, err := regexp.Compile("^(?:" + v + ")$"); err != nil {
+				return "", fmt.Errorf("invalid regexp %s: %s", value, err)
+			}
+		}
+		matchers = append(matchers, name+op+strconv.Quote(v))
+	}
+	return strings.Join(matchers, ", "), nil
+}
+
+// splitMatchers splits the matchers by commas outside quoted strings.
+func splitMatchers(s string) []string {
+	var res []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\'', '`':
+			i = skipString(s, i) - 1
+		case ',':
+			res = append(res, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(res, s[start:])
+}
+
+// InjectSelector adds the label matchers (in the NormalizeSelector format) to every series selector of the query.
+func InjectSelector(query, matchers string) string {
+	if matchers == "" {
+		return query
+	}
+	merge := func(inner string) string {
+		inner = strings.TrimRight(strings.TrimSpace(inner), ",")
+		if inner == "" {
+			return "{" + matchers + "}"
+		}
+		return "{" + inner + ", " + matchers + "}"
+	}
+	var b strings.Builder
+	groupingList := false
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			j := skipString(query, i)
+			b.WriteString(query[i:j])
+			i = j
+		case c == '[':
+			j := strings.IndexByte(query[i:], ']')
+			if j < 0 {
+				j = len(query) - i - 1
+			}
+			b.WriteString(query[i : i+j+1])
+			i += j + 1
+		case c == '{':
+			j := closingBrace(query, i)
+			b.WriteString(merge(query[i+1 : j]))
+			i = j + 1
+		case c == '(' && groupingList:
+			j := strings.IndexByte(query[i:], ')')
+			if j < 0 {
+				j = len(query) - i - 1
+			}
+			b.WriteString(query[i : i+j+1])
+			i += j + 1
+			groupingList = false
+		case c == '$' || c >= '0' && c <= '9' || c == '.':
+			j := i + 1
+			for j < len(query) && isIdentChar(query[j]) || j < len(query) && query[j] == '.' {
+				j++
+			}
+			b.WriteString(query[i:j])
+			i = j
+		case isIdentChar(c):
+			j := i + 1
+			for j < len(query) && isIdentChar(query[j]) {
+				j++
+			}
+			ident := query[i:j]
+			k := j
+			for k < len(query) && (query[k] == ' ' || query[k] == '\t' || query[k] == '\n') {
+				k++
+			}
+			lower := strings.ToLower(ident)
+			switch {
+			case groupingKeywords[lower]:
+				b.WriteString(ident)
+				groupingList = true
+				i = j
+				continue
+			case keywords[lower] || k < len(query) && query[k] == '(': // a function or an aggregation
+				b.WriteString(ident)
+				i = j
+			case k < len(query) && query[k] == '{':
+				end := closingBrace(query, k)
+				b.WriteString(ident + query[j:k] + merge(query[k+1:end]))
+				i = end + 1
+			default:
+				b.WriteString(ident + merge(""))
+				i = j
+			}
+		default:
+			b.WriteByte(c)
+			i++
+		}
+		if c != ' ' && c != '\t' && c != '\n' {
+			groupingList = false
+		}
+	}
+	return b.String()
+}
+
+func isIdentChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == ':'
+}
+
+// skipString returns the position right after the quoted string starting at i.
+func skipString(s string, i int) int {
+	q := s[i]
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			if q != '`' {
+				j++
+			}
+		case q:
+			return j + 1
+		}
+	}
+	return len(s)
+}
+
+func closingBrace(s string, i int) int {
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '"', '\'', '`':
+			j = skipString(s, j) - 1
+		case '}':
+			return j
+		}
+	}
+	return len(s) - 1
+}
+
+type selectorClient struct {
+	Client
+	matchers string
+}
+
+// WithSelector returns a client that injects the label matchers into every query.
+func WithSelector(client Client, matchers string) Client {
+	if matchers == "" {
+		return client
+	}
+	return &selectorClient{Client: client, matchers: matchers}
+}
+
+func (c *selectorClient) QueryRange(ctx context.Context, query string, from, to timeseries.Time, step timeseries.Duration) ([]model.MetricValues, error) {
+	return c.Client.QueryRange(ctx, InjectSelector(query, c.matchers), from, to, step)
+}
+
+func (c *selectorClient) QueryRangeWithWarnings(ctx context.Context, query string, from, to timeseries.Time, step timeseries.Duration) ([]model.MetricValues, []string, error) {
+	return QueryRangeWithWarnings(ctx, c.Client, InjectSelector(query, c.matchers), from, to, step)
+}
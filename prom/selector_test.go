@@ -0,0 +1,71 @@
+package prom
+
+import (
+	"context"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNormalizeSelector(t *testing.T) {
+	s, err := NormalizeSelector(`{tenant="a", env=~"prod|stage"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, `tenant="a", env=~"prod|stage"`, s)
+
+	s, err = NormalizeSelector(` tenant = "a,b" `)
+	assert.NoError(t, err)
+	assert.Equal(t, `tenant="a,b"`, s)
+
+	s, err = NormalizeSelector(``)
+	assert.NoError(t, err)
+	assert.Equal(t, ``, s)
+
+	for _, invalid := range []string{`tenant`, `tenant=a`, `1tenant="a"`, `env=~"("`, `{tenant="a"`} {
+		_, err = NormalizeSelector(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestInjectSelector(t *testing.T) {
+	m := `tenant="a"`
+	assert.Equal(t, `up`, InjectSelector(`up`, ""))
+	assert.Equal(t, `up{tenant="a"}`, InjectSelector(`up`, m))
+	assert.Equal(t, `kube_pod_status_ready{condition="true", tenant="a"}`, InjectSelector(`kube_pod_status_ready{condition="true"}`, m))
+	assert.Equal(t, `kube_pod_status_scheduled{condition="true", tenant="a"} > 0`, InjectSelector(`kube_pod_status_scheduled{condition="true"} > 0`, m))
+	assert.Equal(t,
+		`sum(rate(node_resources_cpu_usage_seconds_total{mode!="idle", tenant="a"}[$RANGE])) without(mode) /sum(rate(node_resources_cpu_usage_seconds_total{tenant="a"}[$RANGE])) without(mode)*100`,
+		InjectSelector(`sum(rate(node_resources_cpu_usage_seconds_total{mode!="idle"}[$RANGE])) without(mode) /sum(rate(node_resources_cpu_usage_seconds_total[$RANGE])) without(mode)*100`, m),
+	)
+	assert.Equal(t,
+		`rate(a{tenant="a"}[5m]) / ignoring(mode) group_left sum by (le) (b{tenant="a"})`,
+		InjectSelector(`rate(a[5m]) / ignoring(mode) group_left sum by (le) (b)`, m),
+	)
+	assert.Equal(t,
+		`label_replace(up{job="x}", tenant="a"}, "dst", "$1", "src", "(.*)")`,
+		InjectSelector(`label_replace(up{job="x}"}, "dst", "$1", "src", "(.*)")`, m),
+	)
+	assert.Equal(t, `histogram_quantile(0.95, x{tenant="a"}) and on() vector(1e3)`, InjectSelector(`histogram_quantile(0.95, x) and on() vector(1e3)`, m))
+	assert.Equal(t, `{__name__="up", tenant="a"}`, InjectSelector(`{__name__="up"}`, m))
+	assert.Equal(t, `a{tenant="a"} offset 5m`, InjectSelector(`a offset 5m`, m))
+}
+
+// warningsClient records the query and returns no data along with a warning.
+type warningsClient struct {
+	Client
+	query string
+}
+
+func (c *warningsClient) QueryRangeWithWarnings(ctx context.Context, query string, from, to timeseries.Time, step timeseries.Duration) ([]model.MetricValues, []string, error) {
+	c.query = query
+	return nil, []string{"some chunks are missing"}, nil
+}
+
+func TestQueryRangeWithWarnings(t *testing.T) {
+	c := &warningsClient{}
+	_, warnings, err := QueryRangeWithWarnings(context.Background(), WithReplicaDedup(WithSelector(c, `tenant="a"`), "replica"), `sum(up)`, 0, 60, 60)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"some chunks are missing"}, warnings)
+	assert.Equal(t, `sum by (replica)(up{tenant="a"})`, c.query)
+	assert.Equal(t, "partial data: some chunks are missing", FormatWarnings(warnings))
+}
@@ -14,34 +14,58 @@ type QueryStats struct {
 	Failed       bool    `json:"failed"`
 }
 
-func ParallelQueryRange(ctx context.Context, client Client, from, to timeseries.Time, step timeseries.Duration, queries map[string]string, stats map[string]QueryStats) (map[string][]model.MetricValues, error) {
+// ParallelQueryRange runs the queries concurrently. The first failure cancels the queries still in flight,
+// as does the cancellation of ctx, in which case the context error is returned.
+// A query with an incomplete result doesn't fail: its data is used as is, or no data is returned if it has timed out.
+// Such queries are returned along with the warnings by query name.
+func ParallelQueryRange(ctx context.Context, client Client, from, to timeseries.Time, step timeseries.Duration, queries map[string]string, stats map[string]QueryStats) (map[string][]model.MetricValues, map[string]string, error) {
 	res := make(map[string][]model.MetricValues, len(queries))
+	warnings := map[string]string{}
 	var lock sync.Mutex
-	var lastErr error
+	var firstErr error
+	queryCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
 	wg := sync.WaitGroup{}
 	now := time.Now()
 	for queryName, query := range queries {
 		wg.Add(1)
 		go func(queryName, query string) {
 			defer wg.Done()
-			metrics, err := client.QueryRange(ctx, query, from, to, step)
+			if queryCtx.Err() != nil {
+				return
+			}
+			metrics, ws, err := QueryRangeWithWarnings(queryCtx, client, query, from, to, step)
+			lock.Lock()
+			defer lock.Unlock()
 			if stats != nil {
 				queryTime := float32(time.Since(now).Seconds())
-				lock.Lock()
 				if queryTime > stats[queryName].QueryTime {
 					stats[queryName] = QueryStats{MetricsCount: len(metrics), QueryTime: queryTime, Failed: err != nil}
 				}
-				lock.Unlock()
+			}
+			if err != nil && queryCtx.Err() == nil && IsTimeout(err) {
+				warnings[queryName] = err.Error()
+				return
 			}
 			if err != nil {
-				lastErr = err
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
 				return
 			}
-			lock.Lock()
+			if len(ws) > 0 {
+				warnings[queryName] = FormatWarnings(ws)
+			}
 			res[queryName] = metrics
-			lock.Unlock()
 		}(queryName, query)
 	}
 	wg.Wait()
-	return res, lastErr
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	if firstErr != nil {
+		return nil, nil, firstErr
+	}
+	return res, warnings, nil
 }
@@ -0,0 +1,130 @@
+package stats
+
+import (
+	"github.com/coroot/coroot/timeseries"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultRequestStatsRetention = time.Hour
+
+	// the number of unique users registered per screen size between two sends, the rest are ignored
+	maxUsersPerScreenSize = 10000
+)
+
+// requestLatencyBuckets are the upper bounds (in seconds) of the histogram buckets of the request durations.
+var requestLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+
+type requestBucket struct {
+	minute    int64
+	requests  uint64
+	errors    uint64
+	durations []uint64 // len(requestLatencyBuckets)+1, the last one is for the longer requests
+}
+
+// requestStats aggregates the handled requests into per-minute buckets kept in a ring buffer,
+// so the memory used doesn't depend on the number of requests and the buckets older than the retention are overwritten.
+type requestStats struct {
+	lock    sync.Mutex
+	buckets []requestBucket
+}
+
+func newRequestStats(retention time.Duration) *requestStats {
+	if retention <= 0 {
+		retention = DefaultRequestStatsRetention
+	}
+	minutes := int(retention / time.Minute)
+	if minutes < 1 {
+		minutes = 1
+	}
+	s := &requestStats{buckets: make([]requestBucket, minutes)}
+	for i := range s.buckets {
+		s.buckets[i].durations = make([]uint64, len(requestLatencyBuckets)+1)
+		s.buckets[i].minute = -1
+	}
+	return s
+}
+
+func (s *requestStats) add(now time.Time, duration time.Duration, code int) {
+	minute := now.Unix() / 60
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	b := &s.buckets[minute%int64(len(s.buckets))]
+	if b.minute != minute {
+		b.minute, b.requests, b.errors = minute, 0, 0
+		for i := range b.durations {
+			b.durations[i] = 0
+		}
+	}
+	b.requests++
+	if code >= 500 {
+		b.errors++
+	}
+	d := duration.Seconds()
+	i := 0
+	for i < len(requestLatencyBuckets) && d > requestLatencyBuckets[i] {
+		i++
+	}
+	b.durations[i]++
+}
+
+// RequestStats are the aggregated stats of the API requests handled within the window.
+type RequestStats struct {
+	Window            timeseries.Duration `json:"window"`
+	Requests          uint64              `json:"requests"`
+	RequestsPerMinute float64             `json:"requests_per_minute"`
+	// the percentage of the requests failed with a 5xx status code
+	ErrorRate float64 `json:"error_rate"`
+	// the 95th percentile of the handler latency in seconds: the upper bound of the histogram bucket it falls into,
+	// or the largest bound if it's above all of them
+	LatencyP95 float64 `json:"latency_p95"`
+}
+
+// summary aggregates the buckets of the last window minutes (including the current one) limited by the retention.
+func (s *requestStats) summary(now time.Time, window time.Duration) RequestStats {
+	minutes := int64(window / time.Minute)
+	if minutes < 1 {
+		minutes = 1
+	}
+	if minutes > int64(len(s.buckets)) {
+		minutes = int64(len(s.buckets))
+	}
+	res := RequestStats{Window: timeseries.Duration(minutes) * timeseries.Minute}
+	current := now.Unix() / 60
+	durations := make([]uint64, len(requestLatencyBuckets)+1)
+	var errors uint64
+
+	s.lock.Lock()
+	for i := range s.buckets {
+		b := &s.buckets[i]
+		if b.minute < 0 || b.minute > current || b.minute <= current-minutes {
+			continue
+		}
+		res.Requests += b.requests
+		errors += b.errors
+		for j, c := range b.durations {
+			durations[j] += c
+		}
+	}
+	s.lock.Unlock()
+
+	if res.Requests == 0 {
+		return res
+	}
+	res.RequestsPerMinute = float64(res.Requests) / float64(minutes)
+	res.ErrorRate = float64(errors) / float64(res.Requests) * 100
+	rank := uint64(float64(res.Requests)*0.95 + 0.5)
+	var cumulative uint64
+	for i, c := range durations {
+		cumulative += c
+		if cumulative >= rank {
+			if i == len(requestLatencyBuckets) {
+				i--
+			}
+			res.LatencyP95 = requestLatencyBuckets[i]
+			break
+		}
+	}
+	return res
+}
@@ -0,0 +1,41 @@
+package stats
+
+import (
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRequestStats(t *testing.T) {
+	now := time.Unix(1668000000, 0)
+	s := newRequestStats(5 * time.Minute)
+
+	assert.Equal(t, RequestStats{Window: 5 * timeseries.Minute}, s.summary(now, time.Hour))
+
+	for i := 0; i < 90; i++ {
+		s.add(now, 20*time.Millisecond, 200)
+	}
+	for i := 0; i < 8; i++ {
+		s.add(now.Add(time.Minute), 200*time.Millisecond, 200)
+	}
+	s.add(now.Add(time.Minute), 3*time.Second, 500)
+	s.add(now.Add(time.Minute), time.Minute, 502)
+
+	res := s.summary(now.Add(time.Minute), 2*time.Minute)
+	assert.Equal(t, uint64(100), res.Requests)
+	assert.Equal(t, 50., res.RequestsPerMinute)
+	assert.Equal(t, 2., res.ErrorRate)
+	assert.Equal(t, .25, res.LatencyP95)
+
+	res = s.summary(now.Add(time.Minute), time.Minute)
+	assert.Equal(t, uint64(10), res.Requests)
+	assert.Equal(t, 20., res.ErrorRate)
+	assert.Equal(t, 30., res.LatencyP95)
+
+	// the bucket of the first minute is overwritten after the retention
+	s.add(now.Add(5*time.Minute), time.Millisecond, 200)
+	res = s.summary(now.Add(5*time.Minute), time.Hour)
+	assert.Equal(t, uint64(11), res.Requests)
+	assert.Equal(t, 5*timeseries.Minute, res.Window)
+}
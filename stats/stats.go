@@ -65,6 +65,7 @@ type Stats struct {
 
 type InspectionOverride struct {
 	ProjectLevel     int `json:"project_level"`
+	NamespaceLevel   int `json:"namespace_level"`
 	ApplicationLevel int `json:"application_level"`
 }
 
@@ -78,9 +79,12 @@ type Collector struct {
 
 	usersByScreenSize map[string]*utils.StringSet
 	lock              sync.Mutex
+
+	requests *requestStats
 }
 
-func NewCollector(dataDir, version string, db *db.DB, cache *cache.Cache) *Collector {
+// NewCollector creates a Collector. The stats of the API requests are kept for requestStatsRetention.
+func NewCollector(dataDir, version string, db *db.DB, cache *cache.Cache, requestStatsRetention time.Duration) *Collector {
 	instanceUuid := ""
 	filePath := path.Join(dataDir, "instance.uuid")
 	data, err := os.ReadFile(filePath)
@@ -106,6 +110,8 @@ func NewCollector(dataDir, version string, db *db.DB, cache *cache.Cache) *Colle
 		instanceVersion: version,
 
 		usersByScreenSize: map[string]*utils.StringSet{},
+
+		requests: newRequestStats(requestStatsRetention),
 	}
 
 	go func() {
@@ -124,17 +130,47 @@ func (c *Collector) RegisterRequest(r *http.Request) {
 		return
 	}
 	userUuid := r.Header.Get("x-device-id")
-	screenSize := r.Header.Get("x-device-size")
+	screenSize := normalizeScreenSize(r.Header.Get("x-device-size"))
 	if userUuid == "" || screenSize == "" {
 		return
 	}
 
 	c.lock.Lock()
 	defer c.lock.Unlock()
-	if c.usersByScreenSize[screenSize] == nil {
-		c.usersByScreenSize[screenSize] = utils.NewStringSet()
+	users := c.usersByScreenSize[screenSize]
+	if users == nil {
+		users = utils.NewStringSet()
+		c.usersByScreenSize[screenSize] = users
 	}
-	c.usersByScreenSize[screenSize].Add(userUuid)
+	if users.Len() < maxUsersPerScreenSize {
+		users.Add(userUuid)
+	}
+}
+
+// normalizeScreenSize maps the value of the x-device-size header to one of the breakpoint names sent by the UI,
+// so that arbitrary header values can't grow the number of registered screen sizes.
+func normalizeScreenSize(size string) string {
+	size = strings.ToLower(strings.TrimSpace(size))
+	switch size {
+	case "":
+		return ""
+	case "xs", "sm", "md", "lg", "xl":
+		return size
+	}
+	return "other"
+}
+
+// ObserveRequest registers a handled API request in the request stats.
+func (c *Collector) ObserveRequest(duration time.Duration, code int) {
+	if c == nil {
+		return
+	}
+	c.requests.add(time.Now(), duration, code)
+}
+
+// RequestStats returns the stats of the API requests handled within the last window, which is limited by the retention.
+func (c *Collector) RequestStats(window time.Duration) RequestStats {
+	return c.requests.summary(time.Now(), window)
 }
 
 func (c *Collector) send() {
@@ -206,9 +242,12 @@ func (c *Collector) collect() Stats {
 		for appId, configs := range checkConfigs {
 			for checkId := range configs {
 				s := stats.Integration.InspectionOverrides[checkId]
-				if appId.IsZero() {
+				switch {
+				case appId.IsZero():
 					s.ProjectLevel++
-				} else {
+				case appId.IsNamespace():
+					s.NamespaceLevel++
+				default:
 					s.ApplicationLevel++
 				}
 				stats.Integration.InspectionOverrides[checkId] = s
@@ -226,7 +265,7 @@ func (c *Collector) collect() Stats {
 		}
 		t := time.Now()
 		step := p.Prometheus.RefreshInterval
-		w, err := constructor.New(cc, step, checkConfigs).LoadWorld(context.Background(), cacheTo.Add(-worldWindow), cacheTo, step, &stats.Performance.Constructor)
+		w, err := constructor.New(cc, step, checkConfigs, p.Prometheus.ExtraSelector, p.Prometheus.ReplicaLabel, p.Settings.ApplicationIdentity, p.Settings.ApplicationExclusions).LoadWorld(context.Background(), cacheTo.Add(-worldWindow), cacheTo, step, &stats.Performance.Constructor)
 		if err != nil {
 			klog.Errorln("failed to load world:", err)
 			continue
@@ -247,7 +286,7 @@ func (c *Collector) collect() Stats {
 		}
 
 		for _, a := range w.Applications {
-			category := model.CalcApplicationCategory(a, p.Settings.ApplicationCategories)
+			category := model.CalcApplicationCategory(a, p.Settings.ApplicationCategories, p.Settings.ApplicationCategoryLabel)
 			if a.IsStandalone() || category == model.ApplicationCategoryControlPlane || category == model.ApplicationCategoryMonitoring {
 				continue
 			}
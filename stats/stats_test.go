@@ -0,0 +1,34 @@
+package stats
+
+import (
+	"github.com/coroot/coroot/utils"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegisterRequestScreenSize(t *testing.T) {
+	c := &Collector{usersByScreenSize: map[string]*utils.StringSet{}}
+	register := func(user, size string) {
+		r := httptest.NewRequest(http.MethodGet, "/api/projects", nil)
+		r.Header.Set("x-device-id", user)
+		r.Header.Set("x-device-size", size)
+		c.RegisterRequest(r)
+	}
+
+	register("u1", "lg")
+	register("u2", " LG ")
+	register("u3", "xs")
+	register("u4", "")
+	for i := 0; i < 100; i++ {
+		register("u5", strings.Repeat("x", i+3))
+	}
+
+	sizes := map[string]int{}
+	for size, users := range c.usersByScreenSize {
+		sizes[size] = users.Len()
+	}
+	assert.Equal(t, map[string]int{"lg": 2, "xs": 1, "other": 1}, sizes)
+}
@@ -0,0 +1,73 @@
+package timeseries
+
+import (
+	"math"
+)
+
+// Band is an envelope of several time series: the minimum, the average and the maximum value at every point.
+type Band struct {
+	Min *InMemoryTimeSeries
+	Avg *InMemoryTimeSeries
+	Max *InMemoryTimeSeries
+}
+
+// NewBand calculates the band in a single pass over the inputs, so the resulting series are aligned.
+// NaN values are ignored unless all the input values at a point are NaN.
+// The result is nil if there are no non-empty inputs.
+func NewBand(tss ...TimeSeries) *Band {
+	var iters []Iterator
+	for _, ts := range tss {
+		if IsEmpty(ts) {
+			continue
+		}
+		iters = append(iters, ts.iter())
+	}
+	if len(iters) == 0 {
+		return nil
+	}
+	var times []Time
+	var min, avg, max []float64
+	for {
+		var t Time
+		done := false
+		mn, mx, sum, count := NaN, NaN, 0., 0.
+		for _, iter := range iters {
+			if !iter.Next() {
+				done = true
+				break
+			}
+			var v float64
+			t, v = iter.Value()
+			if math.IsNaN(v) {
+				continue
+			}
+			mn = Min(t, mn, v)
+			mx = Max(t, mx, v)
+			sum += v
+			count++
+		}
+		if done {
+			break
+		}
+		times = append(times, t)
+		min = append(min, mn)
+		max = append(max, mx)
+		if count > 0 {
+			avg = append(avg, sum/count)
+		} else {
+			avg = append(avg, NaN)
+		}
+	}
+	if len(times) == 0 {
+		return nil
+	}
+	var step Duration
+	if len(times) > 1 {
+		step = times[1].Sub(times[0])
+	}
+	return &Band{
+		Min: NewWithData(times[0], step, min),
+		Avg: NewWithData(times[0], step, avg),
+		Max: NewWithData(times[0], step, max),
+	}
+}
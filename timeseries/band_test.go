@@ -0,0 +1,25 @@
+package timeseries
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewBand(t *testing.T) {
+	assert.Nil(t, NewBand())
+	assert.Nil(t, NewBand(nil, New(0, 0, 30)))
+
+	b := NewBand(
+		NewWithData(60, 30, []float64{1, NaN, NaN, 4}),
+		nil,
+		NewWithData(60, 30, []float64{3, 2, NaN, 2}),
+		NewWithData(60, 30, []float64{2, NaN, NaN, 6}),
+	)
+	assert.Equal(t, "InMemoryTimeSeries(60, 4, 30, [1 2 . 2])", b.Min.String())
+	assert.Equal(t, "InMemoryTimeSeries(60, 4, 30, [2 2 . 4])", b.Avg.String())
+	assert.Equal(t, "InMemoryTimeSeries(60, 4, 30, [3 2 . 6])", b.Max.String())
+
+	b = NewBand(NewWithData(60, 30, []float64{1, 2, 3}), NewWithData(60, 30, []float64{5, 6}))
+	assert.Equal(t, "InMemoryTimeSeries(60, 2, 30, [1 2])", b.Min.String())
+	assert.Equal(t, "InMemoryTimeSeries(60, 2, 30, [5 6])", b.Max.String())
+}
@@ -65,6 +65,10 @@ func (ts *InMemoryTimeSeries) Set(t Time, v float64) Time {
 	return t
 }
 
+func (ts *InMemoryTimeSeries) Clone() *InMemoryTimeSeries {
+	return NewWithData(ts.from, ts.step, append([]float64(nil), ts.data...))
+}
+
 func (ts *InMemoryTimeSeries) CopyFrom(other *InMemoryTimeSeries) {
 	copy(ts.data, other.data)
 }
@@ -0,0 +1,51 @@
+package timeseries
+
+import (
+	"math"
+)
+
+// MovingAvg returns the trailing moving average of the series: the value at each point is the average of the non-NaN
+// points within the window ending at it (inclusive), so the first points are averaged over the available part of the window.
+// A point is NaN only if all the points within its window are NaN. It returns nil if the series is empty.
+// The step of the result is derived from the series, the given step (e.g., the step of the world) is used
+// only if the series has a single point.
+func MovingAvg(ts TimeSeries, window, step Duration) TimeSeries {
+	if IsEmpty(ts) {
+		return nil
+	}
+	type point struct {
+		t Time
+		v float64
+	}
+	var (
+		from, prev Time
+		data       []float64
+		points     []point
+		sum        float64
+	)
+	iter := Iter(ts)
+	for iter.Next() {
+		t, v := iter.Value()
+		if data == nil {
+			from = t
+		} else if len(data) == 1 {
+			step = t.Sub(prev)
+		}
+		prev = t
+		if !math.IsNaN(v) {
+			points = append(points, point{t: t, v: v})
+			sum += v
+		}
+		for len(points) > 0 && !points[0].t.After(t.Add(-window)) {
+			sum -= points[0].v
+			points = points[1:]
+		}
+		if len(points) == 0 {
+			sum = 0 // to not accumulate float errors
+			data = append(data, NaN)
+			continue
+		}
+		data = append(data, sum/float64(len(points)))
+	}
+	return NewWithData(from, step, data)
+}
@@ -0,0 +1,28 @@
+package timeseries
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMovingAvg(t *testing.T) {
+	assert.Nil(t, MovingAvg(nil, 180, 60))
+
+	ts := NewWithData(600, 60, []float64{1, 3, NaN, 8, NaN, NaN, NaN, 4, 6})
+	avg := MovingAvg(ts, 180, 15)
+	assert.Equal(t, "InMemoryTimeSeries(600, 9, 60, [1 2 2 5.500000 8 8 . 4 5])", avg.(*InMemoryTimeSeries).String())
+
+	var times []Time
+	iter := Iter(avg)
+	for iter.Next() {
+		t, _ := iter.Value()
+		times = append(times, t)
+	}
+	assert.Equal(t, []Time{600, 660, 720, 780, 840, 900, 960, 1020, 1080}, times)
+
+	assert.Equal(t, "InMemoryTimeSeries(600, 9, 60, [1 3 . 8 . . . 4 6])", MovingAvg(ts, 60, 15).(*InMemoryTimeSeries).String())
+	assert.Equal(t, "InMemoryTimeSeries(0, 2, 60, [. .])", MovingAvg(NewWithData(0, 60, []float64{NaN, NaN}), 600, 60).(*InMemoryTimeSeries).String())
+
+	// a single point gets the given step
+	assert.Equal(t, "InMemoryTimeSeries(600, 1, 30, [5])", MovingAvg(NewWithData(600, 30, []float64{5}), 600, 30).(*InMemoryTimeSeries).String())
+}
@@ -0,0 +1,26 @@
+package timeseries
+
+// Shift returns a copy of the series with the timestamps moved forward by d (backward if d is negative),
+// e.g., to overlay the last week's data on the current timeline. It returns nil if the series is empty.
+func Shift(ts TimeSeries, d Duration) TimeSeries {
+	if IsEmpty(ts) {
+		return nil
+	}
+	var (
+		from, prev Time
+		step       Duration
+		data       []float64
+	)
+	iter := Iter(ts)
+	for iter.Next() {
+		t, v := iter.Value()
+		if data == nil {
+			from = t
+		} else if step == 0 {
+			step = t.Sub(prev)
+		}
+		prev = t
+		data = append(data, v)
+	}
+	return NewWithData(from.Add(d), step, data)
+}
@@ -0,0 +1,17 @@
+package timeseries
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestShift(t *testing.T) {
+	assert.Nil(t, Shift(nil, 600))
+
+	ts := NewWithData(600, 60, []float64{1, NaN, 3})
+	assert.Equal(t, "InMemoryTimeSeries(1200, 3, 60, [1 . 3])", Shift(ts, 600).(*InMemoryTimeSeries).String())
+	assert.Equal(t, "InMemoryTimeSeries(0, 3, 60, [1 . 3])", Shift(ts, -600).(*InMemoryTimeSeries).String())
+
+	sum := Aggregate(NanSum, ts, NewWithData(600, 60, []float64{1, 1, 1}))
+	assert.Equal(t, "InMemoryTimeSeries(660, 3, 60, [2 1 4])", Shift(sum, 60).(*InMemoryTimeSeries).String())
+}
@@ -0,0 +1,69 @@
+package timeseries
+
+import (
+	"math"
+)
+
+type Trend struct {
+	Slope     float64 // per second
+	Intercept float64 // at the time of the first point
+	R2        float64
+	From      Time
+}
+
+// NewTrend fits a linear trend to the series using the least squares method.
+// It returns nil if the series has less than 3 non-NaN points.
+func NewTrend(ts TimeSeries) *Trend {
+	var n, sx, sy, sxx, sxy, syy float64
+	var from Time
+	iter := Iter(ts)
+	for iter.Next() {
+		t, v := iter.Value()
+		if math.IsNaN(v) {
+			continue
+		}
+		if n == 0 {
+			from = t
+		}
+		x := float64(t.Sub(from))
+		n++
+		sx += x
+		sy += v
+		sxx += x * x
+		sxy += x * v
+		syy += v * v
+	}
+	if n < 3 {
+		return nil
+	}
+	dx := n*sxx - sx*sx
+	if dx == 0 {
+		return nil
+	}
+	tr := &Trend{From: from}
+	tr.Slope = (n*sxy - sx*sy) / dx
+	tr.Intercept = (sy - tr.Slope*sx) / n
+	if dy := n*syy - sy*sy; dy > 0 {
+		r := (n*sxy - sx*sy) / math.Sqrt(dx*dy)
+		tr.R2 = r * r
+	} else {
+		tr.R2 = 1 // constant series
+	}
+	return tr
+}
+
+func (tr *Trend) At(t Time) float64 {
+	return tr.Intercept + tr.Slope*float64(t.Sub(tr.From))
+}
+
+// TimeToReach returns the time when the trend reaches the given value or zero if it never does.
+func (tr *Trend) TimeToReach(v float64) Time {
+	if tr.Slope == 0 {
+		return 0
+	}
+	d := (v - tr.Intercept) / tr.Slope
+	if d < 0 {
+		return 0
+	}
+	return tr.From.Add(Duration(d))
+}
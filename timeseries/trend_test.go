@@ -0,0 +1,33 @@
+package timeseries
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestTrend(t *testing.T) {
+	assert.Nil(t, NewTrend(nil))
+	assert.Nil(t, NewTrend(NewWithData(0, 60, []float64{1, NaN, 2})))
+
+	ts := NewWithData(600, 60, []float64{10, 11, NaN, 13, 14, 15})
+	tr := NewTrend(ts)
+	assert.NotNil(t, tr)
+	assert.InDelta(t, 1./60, tr.Slope, 1e-9)
+	assert.InDelta(t, 10, tr.Intercept, 1e-9)
+	assert.InDelta(t, 1, tr.R2, 1e-9)
+	assert.InDelta(t, 15, tr.At(900), 1e-9)
+	assert.Equal(t, Time(600+90*60), tr.TimeToReach(100))
+	assert.Equal(t, Time(0), tr.TimeToReach(0))
+
+	noisy := NewWithData(0, 60, []float64{10, 30, 5, 25, 10, 30, 5, 25})
+	tr = NewTrend(noisy)
+	assert.NotNil(t, tr)
+	assert.Less(t, tr.R2, 0.1)
+
+	flat := NewWithData(0, 60, []float64{5, 5, 5, 5})
+	tr = NewTrend(flat)
+	assert.Equal(t, 0., tr.Slope)
+	assert.Equal(t, Time(0), tr.TimeToReach(100))
+	assert.False(t, math.IsNaN(tr.R2))
+}
@@ -5,10 +5,71 @@ import (
 	"github.com/dustin/go-humanize"
 	"github.com/hako/durafmt"
 	"math"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// NumberFormat defines the separators used by the format helpers.
+type NumberFormat struct {
+	Decimal  string
+	Grouping string
+}
+
+var numberFormats = map[string]NumberFormat{
+	"":      {Decimal: "."},
+	"en":    {Decimal: ".", Grouping: ","},
+	"de":    {Decimal: ",", Grouping: "."},
+	"de-CH": {Decimal: ".", Grouping: "'"},
+	"es":    {Decimal: ",", Grouping: "."},
+	"it":    {Decimal: ",", Grouping: "."},
+	"fr":    {Decimal: ",", Grouping: "\u202f"},
+	"ru":    {Decimal: ",", Grouping: "\u00a0"},
+}
+
+var numberFormat = numberFormats[""]
+
+// SetNumberLocale sets the separators used by the format helpers.
+// It's not safe for concurrent use and is supposed to be called on startup.
+func SetNumberLocale(locale string) error {
+	f, ok := numberFormats[locale]
+	if !ok {
+		return fmt.Errorf("unsupported number locale: %s", locale)
+	}
+	numberFormat = f
+	return nil
+}
+
+// localizeNumber replaces the separators of a number formatted with a dot decimal separator and no grouping.
+func localizeNumber(s string) string {
+	if numberFormat.Decimal == "." && numberFormat.Grouping == "" {
+		return s
+	}
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	integer, fraction, hasFraction := strings.Cut(s, ".")
+	if numberFormat.Grouping != "" && len(integer) > 3 {
+		var b strings.Builder
+		first := len(integer) % 3
+		if first > 0 {
+			b.WriteString(integer[:first])
+		}
+		for i := first; i < len(integer); i += 3 {
+			if b.Len() > 0 {
+				b.WriteString(numberFormat.Grouping)
+			}
+			b.WriteString(integer[i : i+3])
+		}
+		integer = b.String()
+	}
+	if hasFraction {
+		return sign + integer + numberFormat.Decimal + fraction
+	}
+	return sign + integer
+}
+
 func FormatFloat(v float64) string {
 	switch {
 	case math.IsNaN(v):
@@ -16,13 +77,21 @@ func FormatFloat(v float64) string {
 	case v == 0:
 		return "0"
 	case v >= 1:
-		return fmt.Sprintf("%.0f", v)
+		return localizeNumber(fmt.Sprintf("%.0f", v))
 	case v >= 0.1:
-		return fmt.Sprintf("%.1f", v)
+		return localizeNumber(fmt.Sprintf("%.1f", v))
 	case v >= 0.01:
-		return fmt.Sprintf("%.2f", v)
+		return localizeNumber(fmt.Sprintf("%.2f", v))
+	}
+	return localizeNumber(fmt.Sprintf("%.3f", v))
+}
+
+// FormatPercent formats a percentage rounded to an integer, e.g., 12.3 -> "12%".
+func FormatPercent(v float64) string {
+	if math.IsNaN(v) {
+		return ""
 	}
-	return fmt.Sprintf("%.3f", v)
+	return localizeNumber(fmt.Sprintf("%.0f", v)) + "%"
 }
 
 func FormatDuration(d time.Duration, limitFirstN int) string {
@@ -35,19 +104,69 @@ func FormatBytes(b float64) (string, string) {
 	if len(parts) != 2 {
 		return "", ""
 	}
-	return parts[0], parts[1]
+	return localizeNumber(parts[0]), parts[1]
 }
 
 func HumanBits(v float64) string {
 	if math.IsNaN(v) {
 		return ""
 	}
-	return strings.Replace(humanize.Bytes(uint64(v)), "B", "b", -1) + "ps"
+	s := humanize.Bytes(uint64(v))
+	if number, unit, ok := strings.Cut(s, " "); ok {
+		s = localizeNumber(number) + " " + unit
+	}
+	return strings.Replace(s, "B", "b", -1) + "ps"
 }
 
 func FormatLatency(v float64) string {
-	if v < 1 {
-		return fmt.Sprintf(`%.f ms`, v*1000)
+	return FormatLatencyPrecise(v, 1)
+}
+
+// The units FormatByUnit knows how to scale.
+const (
+	UnitNone         = ""
+	UnitCount        = "count"
+	UnitBytes        = "bytes"
+	UnitSeconds      = "seconds"
+	UnitMilliseconds = "milliseconds"
+	UnitPercent      = "percent"
+)
+
+// FormatByUnit formats a value given in the unit using the helper suitable for it, e.g., 1500 milliseconds -> "1.5 s".
+// Values in an unknown unit are formatted as plain numbers followed by the unit.
+func FormatByUnit(v float64, unit string) string {
+	if math.IsNaN(v) {
+		return ""
+	}
+	switch unit {
+	case UnitNone, UnitCount:
+		return FormatFloat(v)
+	case UnitBytes:
+		value, u := FormatBytes(v)
+		return value + " " + u
+	case UnitSeconds:
+		return FormatLatency(v)
+	case UnitMilliseconds:
+		return FormatLatency(v / 1000)
+	case UnitPercent:
+		return FormatPercent(v)
+	}
+	return FormatFloat(v) + " " + unit
+}
+
+// FormatLatencyPrecise formats a latency given in seconds using µs, ms or s
+// with up to the given number of decimals (trailing zeros are trimmed).
+func FormatLatencyPrecise(v float64, decimals int) string {
+	unit := "s"
+	switch {
+	case v > 0 && v < 0.001:
+		v, unit = v*1e6, "µs"
+	case v < 1:
+		v, unit = v*1e3, "ms"
+	}
+	s := strconv.FormatFloat(v, 'f', decimals, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
 	}
-	return fmt.Sprintf(`%.f s`, v)
+	return localizeNumber(s) + " " + unit
 }
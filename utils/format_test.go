@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestFormatLatency(t *testing.T) {
+	assert.Equal(t, "500 µs", FormatLatency(0.0005))
+	assert.Equal(t, "50 ms", FormatLatency(0.05))
+	assert.Equal(t, "100 ms", FormatLatency(0.1))
+	assert.Equal(t, "1.5 s", FormatLatency(1.5))
+	assert.Equal(t, "90 s", FormatLatency(90))
+	assert.Equal(t, "0 ms", FormatLatency(0))
+
+	assert.Equal(t, "2 s", FormatLatencyPrecise(1.5, 0))
+	assert.Equal(t, "1.25 s", FormatLatencyPrecise(1.25, 2))
+	assert.Equal(t, "12.35 ms", FormatLatencyPrecise(0.012345, 2))
+}
+
+func TestFormatByUnit(t *testing.T) {
+	assert.Equal(t, "1235", FormatByUnit(1234.6, UnitNone))
+	assert.Equal(t, "0.3", FormatByUnit(0.3, UnitCount))
+	assert.Equal(t, "1.2 MB", FormatByUnit(1234567, UnitBytes))
+	assert.Equal(t, "150 ms", FormatByUnit(0.15, UnitSeconds))
+	assert.Equal(t, "1.5 s", FormatByUnit(1500, UnitMilliseconds))
+	assert.Equal(t, "99%", FormatByUnit(99.4, UnitPercent))
+	assert.Equal(t, "42 req", FormatByUnit(42, "req"))
+	assert.Equal(t, "", FormatByUnit(math.NaN(), UnitBytes))
+}
+
+func TestFormatLocale(t *testing.T) {
+	defer SetNumberLocale("")
+
+	assert.Error(t, SetNumberLocale("xx"))
+
+	type formatted struct {
+		float, small, percent, latency, bytes, bits string
+	}
+	format := func() formatted {
+		b, unit := FormatBytes(1234567)
+		return formatted{
+			float:   FormatFloat(1234567.8),
+			small:   FormatFloat(0.25),
+			percent: FormatPercent(1234.5),
+			latency: FormatLatencyPrecise(1.25, 2),
+			bytes:   b + " " + unit,
+			bits:    HumanBits(1500000),
+		}
+	}
+
+	for _, c := range []struct {
+		locale   string
+		expected formatted
+	}{
+		{"", formatted{"1234568", "0.2", "1234%", "1.25 s", "1.2 MB", "1.5 Mbps"}},
+		{"en", formatted{"1,234,568", "0.2", "1,234%", "1.25 s", "1.2 MB", "1.5 Mbps"}},
+		{"de", formatted{"1.234.568", "0,2", "1.234%", "1,25 s", "1,2 MB", "1,5 Mbps"}},
+		{"de-CH", formatted{"1'234'568", "0.2", "1'234%", "1.25 s", "1.2 MB", "1.5 Mbps"}},
+		{"fr", formatted{"1\u202f234\u202f568", "0,2", "1\u202f234%", "1,25 s", "1,2 MB", "1,5 Mbps"}},
+	} {
+		assert.NoError(t, SetNumberLocale(c.locale))
+		assert.Equal(t, c.expected, format(), c.locale)
+	}
+
+	assert.NoError(t, SetNumberLocale("de"))
+	assert.Equal(t, "-1.234,5", localizeNumber("-1234.5"))
+	assert.Equal(t, "123", localizeNumber("123"))
+	assert.Equal(t, "", FormatPercent(math.NaN()))
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strings"
+)
+
+// GzipHandler compresses responses if the client accepts gzip and the response body is at least minSize bytes.
+// WebSocket upgrades, responses that are already encoded and responses of incompressible content types
+// (images, fonts, archives) are passed through as is.
+func GzipHandler(minSize int, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") || r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Accept-Encoding")
+		gw := &gzipResponseWriter{ResponseWriter: w, minSize: minSize}
+		defer gw.Close()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	minSize int
+
+	status  int
+	buf     bytes.Buffer
+	gz      *gzip.Writer
+	started bool // the response headers have been written
+}
+
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	if w.started {
+		if w.gz != nil {
+			return w.gz.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+	w.buf.Write(p)
+	if w.buf.Len() < w.minSize {
+		return len(p), nil
+	}
+	if err := w.start(w.compressible()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *gzipResponseWriter) compressible() bool {
+	h := w.ResponseWriter.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	ct := h.Get("Content-Type")
+	if ct == "" {
+		ct = http.DetectContentType(w.buf.Bytes())
+	}
+	ct = strings.ToLower(strings.TrimSpace(strings.Split(ct, ";")[0]))
+	switch {
+	case strings.HasPrefix(ct, "text/"):
+		return true
+	case ct == "image/svg+xml":
+		return true
+	case strings.HasPrefix(ct, "application/"):
+		sub := strings.TrimPrefix(ct, "application/")
+		return sub == "json" || sub == "javascript" || sub == "xml" || sub == "wasm" ||
+			strings.HasSuffix(sub, "+json") || strings.HasSuffix(sub, "+xml")
+	}
+	return false
+}
+
+func (w *gzipResponseWriter) start(compress bool) error {
+	w.started = true
+	h := w.ResponseWriter.Header()
+	if compress {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+	}
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
+
+func (w *gzipResponseWriter) Flush() {
+	if !w.started {
+		_ = w.start(false)
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *gzipResponseWriter) Close() {
+	if !w.started {
+		_ = w.start(false)
+	}
+	if w.gz != nil {
+		_ = w.gz.Close()
+	}
+}
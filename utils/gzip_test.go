@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"compress/gzip"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGzipHandler(t *testing.T) {
+	big := map[string]string{"data": strings.Repeat("a", 2048)}
+	small := map[string]string{"data": "a"}
+
+	h := GzipHandler(1024, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("size") {
+		case "font":
+			w.Header().Set("Content-Type", "font/woff2")
+			_, _ = w.Write([]byte(strings.Repeat("a", 2048)))
+		case "big":
+			WriteJson(w, big)
+		default:
+			WriteJson(w, small)
+		}
+	}))
+
+	get := func(size, acceptEncoding string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/?size="+size, nil)
+		if acceptEncoding != "" {
+			r.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := get("big", "gzip, deflate")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	gz, err := gzip.NewReader(w.Body)
+	assert.NoError(t, err)
+	body, err := ioutil.ReadAll(gz)
+	assert.NoError(t, err)
+	expected := httptest.NewRecorder()
+	WriteJson(expected, big)
+	assert.Equal(t, expected.Body.String(), string(body))
+
+	w = get("small", "gzip")
+	assert.Equal(t, "", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, `{"data":"a"}`+"\n", w.Body.String())
+
+	w = get("big", "")
+	assert.Equal(t, "", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, expected.Body.String(), w.Body.String())
+
+	w = get("font", "gzip")
+	assert.Equal(t, "", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "font/woff2", w.Header().Get("Content-Type"))
+	assert.Equal(t, 2048, w.Body.Len())
+}
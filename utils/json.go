@@ -18,6 +18,18 @@ func WriteJson(w http.ResponseWriter, v interface{}) {
 	}
 }
 
+func WriteJsonWithStatus(w http.ResponseWriter, status int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		klog.Errorf("failed to encode: %s", err)
+		http.Error(w, "failed to encode", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
 func ReadJson(r *http.Request, dest interface{}) error {
 	if body, err := ioutil.ReadAll(r.Body); err != nil {
 		return fmt.Errorf(`failed to read body: %w`, err)
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"reflect"
+)
+
+// The secret fields of the settings (credentials, tokens, etc.) are marked with the `secret` struct tag.
+// `secret:"url"` is used for URLs so that the placeholder remains a valid URL.
+
+const (
+	SecretPlaceholder    = "<hidden>"
+	SecretUrlPlaceholder = "http://<hidden>"
+)
+
+// MaskSecretFields replaces the non-empty secret fields of v with placeholders.
+func MaskSecretFields(v any) {
+	walkSecretFields(reflect.ValueOf(v), reflect.Value{}, func(field, _ reflect.Value, placeholder string) {
+		if field.String() != "" {
+			field.SetString(placeholder)
+		}
+	})
+}
+
+// RestoreSecretFields replaces the secret fields of v submitted as placeholders with the corresponding values of stored.
+// A placeholder without a stored value becomes empty.
+func RestoreSecretFields(v, stored any) {
+	walkSecretFields(reflect.ValueOf(v), reflect.ValueOf(stored), func(field, storedField reflect.Value, placeholder string) {
+		if field.String() != placeholder {
+			return
+		}
+		if storedField.IsValid() {
+			field.SetString(storedField.String())
+		} else {
+			field.SetString("")
+		}
+	})
+}
+
+// walkSecretFields calls f for every settable secret string field of v along with the same field of stored (if any).
+func walkSecretFields(v, stored reflect.Value, f func(field, storedField reflect.Value, placeholder string)) {
+	if stored.IsValid() && stored.Type() != v.Type() {
+		stored = reflect.Value{}
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		if stored.IsValid() && !stored.IsNil() {
+			stored = stored.Elem()
+		} else {
+			stored = reflect.Value{}
+		}
+		walkSecretFields(v.Elem(), stored, f)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			field := v.Field(i)
+			var storedField reflect.Value
+			if stored.IsValid() {
+				storedField = stored.Field(i)
+			}
+			tag, ok := sf.Tag.Lookup("secret")
+			if !ok {
+				walkSecretFields(field, storedField, f)
+				continue
+			}
+			if field.Kind() != reflect.String || !field.CanSet() {
+				continue
+			}
+			placeholder := SecretPlaceholder
+			if tag == "url" {
+				placeholder = SecretUrlPlaceholder
+			}
+			f(field, storedField, placeholder)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			var storedItem reflect.Value
+			if stored.IsValid() && i < stored.Len() {
+				storedItem = stored.Index(i)
+			}
+			walkSecretFields(v.Index(i), storedItem, f)
+		}
+	}
+}
@@ -7,8 +7,12 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// timestamps greater than this are treated as milliseconds (it's 1973-03-03 in ms and year 5138 in seconds)
+const msTimestampThreshold = 1e11
+
 func ParseTimeFromUrl(now timeseries.Time, query url.Values, key string, def timeseries.Time) timeseries.Time {
 	s := query.Get(key)
 	if s == "" {
@@ -25,10 +29,50 @@ func ParseTimeFromUrl(now timeseries.Time, query url.Values, key string, def tim
 		}
 		return now.Add(timeseries.Duration(d.Seconds()))
 	}
-	ms, err := strconv.ParseInt(s, 10, 64)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return timeseries.Time(t.Unix())
+	}
+	ts, err := strconv.ParseInt(s, 10, 64)
 	if err != nil {
 		klog.Warningf("invalid %s=%s: %s", key, s, err)
 		return def
 	}
-	return timeseries.Time(ms / 1000)
+	if ts > msTimestampThreshold {
+		return timeseries.Time(ts / 1000)
+	}
+	return timeseries.Time(ts)
+}
+
+// ParseDurationFromUrl parses a duration like "5m" or "1h30m". Invalid and negative values fall back to the default.
+func ParseDurationFromUrl(query url.Values, key string, def timeseries.Duration) timeseries.Duration {
+	s := query.Get(key)
+	if s == "" {
+		return def
+	}
+	d, err := str2duration.ParseDuration(s)
+	if err != nil || d < 0 {
+		klog.Warningf("invalid %s=%s", key, s)
+		return def
+	}
+	return timeseries.Duration(d.Seconds())
+}
+
+// ParseLabelsFromUrl parses a comma-separated list of key=value pairs, e.g., "env=prod,team=payments".
+// Malformed pairs are ignored.
+func ParseLabelsFromUrl(query url.Values, key string) map[string]string {
+	s := query.Get(key)
+	if s == "" {
+		return nil
+	}
+	res := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if !ok || k == "" {
+			klog.Warningf("invalid %s=%s: %q is not a key=value pair", key, s, pair)
+			continue
+		}
+		res[k] = v
+	}
+	return res
 }
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+)
+
+func TestParseTimeFromUrl(t *testing.T) {
+	now := timeseries.Time(1668000000)
+	def := timeseries.Time(42)
+
+	parse := func(s string) timeseries.Time {
+		return ParseTimeFromUrl(now, url.Values{"from": []string{s}}, "from", def)
+	}
+
+	assert.Equal(t, def, parse(""))
+	assert.Equal(t, now, parse("now"))
+	assert.Equal(t, now.Add(-timeseries.Hour), parse("now-1h"))
+	assert.Equal(t, def, parse("now-1x"))
+
+	assert.Equal(t, timeseries.Time(1667999000), parse("1667999000123")) // ms
+	assert.Equal(t, timeseries.Time(1667999000), parse("1667999000"))    // s
+	assert.Equal(t, timeseries.Time(1667999000), parse("2022-11-09T13:03:20Z"))
+	assert.Equal(t, timeseries.Time(1667999000), parse("2022-11-09T15:03:20+02:00"))
+
+	assert.Equal(t, timeseries.Time(100000000000), parse("100000000000")) // ambiguous: treated as seconds
+	assert.Equal(t, timeseries.Time(100000000), parse("100000000001"))    // ambiguous: treated as ms
+
+	assert.Equal(t, def, parse("2022-11-09"))
+	assert.Equal(t, def, parse("abc"))
+}
+
+func TestParseDurationFromUrl(t *testing.T) {
+	parse := func(s string) timeseries.Duration {
+		return ParseDurationFromUrl(url.Values{"window": []string{s}}, "window", 42)
+	}
+
+	assert.Equal(t, timeseries.Duration(42), parse(""))
+	assert.Equal(t, 5*timeseries.Minute, parse("5m"))
+	assert.Equal(t, 90*timeseries.Minute, parse("1h30m"))
+	assert.Equal(t, timeseries.Duration(42), parse("-5m"))
+	assert.Equal(t, timeseries.Duration(42), parse("five minutes"))
+}
+
+func TestParseLabelsFromUrl(t *testing.T) {
+	parse := func(s string) map[string]string {
+		return ParseLabelsFromUrl(url.Values{"labels": []string{s}}, "labels")
+	}
+
+	assert.Nil(t, parse(""))
+	assert.Equal(t, map[string]string{"env": "prod"}, parse("env=prod"))
+	assert.Equal(t, map[string]string{"env": "prod", "team": "payments"}, parse("env=prod, team=payments"))
+	assert.Equal(t, map[string]string{"env": ""}, parse("env="))
+	assert.Equal(t, map[string]string{"team": "payments"}, parse("env,=x,team=payments"))
+	assert.Equal(t, map[string]string{}, parse("env"))
+}